@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"online-mall/internal/api/routes"
 	"online-mall/internal/config"
+	"online-mall/internal/service"
 	"online-mall/internal/utils"
 	"os"
 	"os/signal"
@@ -32,6 +33,20 @@ func main() {
 	}
 	defer utils.CloseRedis()
 
+	// 启动后台任务：到期账号注销、过期导出文件清理、过期积分处理、收藏降价/到货提醒、到货提醒补发、浏览量落库、关联推荐计算、秒杀下单队列和库存结算、拼团超时处理和退款、订单自动确认收货
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go service.NewAccountService().RunWorker(jobCtx)
+	go service.NewPointsService().RunWorker(jobCtx)
+	go service.NewFavoriteService().RunWorker(jobCtx)
+	go service.NewRestockService().RunWorker(jobCtx)
+	go service.NewBrowseService().RunWorker(jobCtx)
+	go service.NewRecommendService().RunWorker(jobCtx)
+	go service.NewFlashSaleService().RunWorker(jobCtx)
+	go service.NewFlashSaleService().RunConsumer(jobCtx)
+	go service.NewGroupBuyService().RunWorker(jobCtx)
+	go service.NewOrderService().RunWorker(jobCtx)
+
 	// 设置路由
 	r := routes.SetupRoutes()
 
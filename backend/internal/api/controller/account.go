@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"log"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountService 数据导出和账号注销服务实例
+var accountService = service.NewAccountService()
+
+// AccountDeletionRequest 申请注销账号请求
+type AccountDeletionRequest struct {
+	Password string `json:"password"`                        // 与code二选一
+	Code     string `json:"code" binding:"omitempty,max=10"` // 绑定手机号收到的短信验证码（场景deletion）
+	Reason   string `json:"reason" binding:"omitempty,max=255"`
+}
+
+// accountError 统一处理导出/注销错误
+func accountError(c *gin.Context, err error) {
+	if service.IsAccountError(err) {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	log.Printf("Account operation failed: %v", err)
+	utils.ServerError(c)
+}
+
+// RequestDataExport 申请导出个人数据
+func RequestDataExport(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	export, err := accountService.RequestExport(userID)
+	if err != nil {
+		accountError(c, err)
+		return
+	}
+
+	utils.Created(c, export)
+}
+
+// GetDataExports 获取个人数据导出记录及下载链接
+func GetDataExports(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	exports, err := accountService.GetExports(userID)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, exports)
+}
+
+// RequestAccountDeletion 申请注销账号，进入冷静期
+func RequestAccountDeletion(c *gin.Context) {
+	var req AccountDeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+	if req.Password == "" && req.Code == "" {
+		utils.ParamError(c, "请输入密码或短信验证码")
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	deletion, err := accountService.RequestDeletion(c.Request.Context(), user, req.Password, req.Code, req.Reason)
+	if err != nil {
+		accountError(c, err)
+		return
+	}
+
+	utils.Created(c, deletion)
+}
+
+// GetAccountDeletion 获取冷静期中的注销申请，没有时返回null
+func GetAccountDeletion(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	deletion, err := accountService.GetPendingDeletion(userID)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, deletion)
+}
+
+// CancelAccountDeletion 撤销注销申请
+func CancelAccountDeletion(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	if err := accountService.CancelDeletion(userID); err != nil {
+		accountError(c, err)
+		return
+	}
+
+	utils.Success(c, map[string]string{
+		"message": "已撤销注销申请",
+	})
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"log"
+	"online-mall/internal/api/middleware"
+	"online-mall/internal/models"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditService 审计日志服务实例
+var auditService = service.NewAuditService()
+
+// recordAudit 记录管理操作，before/after 为 service.Snapshot 生成的快照。
+// 审计写入失败只记录日志，不影响已完成的操作
+func recordAudit(c *gin.Context, action, targetType string, targetID uint64, before, after map[string]interface{}) {
+	err := auditService.Record(&service.AuditEntry{
+		ActorID:    c.GetUint64("user_id"),
+		ActorName:  c.GetString("username"),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     before,
+		After:      after,
+		RequestID:  middleware.GetRequestID(c),
+		IP:         c.ClientIP(),
+	})
+	if err != nil {
+		log.Printf("Failed to record audit log (%s %s %d): %v", action, targetType, targetID, err)
+	}
+}
+
+// GetAuditLogs 查询审计日志（管理员）
+func GetAuditLogs(c *gin.Context) {
+	var query models.AuditLogQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	logs, total, err := auditService.GetLogs(&query)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, logs, total, query.Page, query.PageSize)
+}
@@ -1,7 +1,14 @@
 package controller
 
 import (
+	"errors"
+	"log"
+	"net/mail"
+	"online-mall/internal/api/middleware"
 	"online-mall/internal/models"
+	"online-mall/internal/pkg/notify"
+	"online-mall/internal/pkg/password"
+	"online-mall/internal/service"
 	"online-mall/internal/utils"
 	"strings"
 	"time"
@@ -10,19 +17,90 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// AuthService 认证服务实例
+var authService = service.NewAuthService()
+
+// LoginLimitService 登录限制服务实例
+var loginLimitService = service.NewLoginLimitService()
+
+// VerifyCodeService 验证码服务实例
+var verifyCodeService = service.NewVerifyCodeService()
+
+// PasswordService 密码服务实例
+var passwordService = service.NewPasswordService()
+
 // LoginRequest 登录请求
 type LoginRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Password string `json:"password" binding:"required,min=6"`
+	Device   string `json:"device" binding:"omitempty,max=100"` // 设备名称，可选
 }
 
 // RegisterRequest 注册请求
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required,min=3,max=50"`
-	Password string `json:"password" binding:"required,min=6"`
-	Phone    string `json:"phone" binding:"omitempty,e164"`
-	Email    string `json:"email" binding:"omitempty,email"`
-	Nickname string `json:"nickname" binding:"omitempty,max=50"`
+	Username  string `json:"username" binding:"required,min=3,max=50"`
+	Password  string `json:"password" binding:"required"` // 复杂度由密码策略校验
+	Phone     string `json:"phone" binding:"omitempty,e164"`
+	PhoneCode string `json:"phone_code" binding:"required_with=Phone"` // 填写手机号时必填
+	Email     string `json:"email" binding:"omitempty,email"`
+	EmailCode string `json:"email_code" binding:"required_with=Email"` // 填写邮箱时必填
+	Nickname  string `json:"nickname" binding:"omitempty,max=50"`
+	Device    string `json:"device" binding:"omitempty,max=100"`
+}
+
+// SendVerifyCodeRequest 发送验证码请求
+type SendVerifyCodeRequest struct {
+	Target string `json:"target" binding:"required,max=100"` // 手机号或邮箱
+	Scene  string `json:"scene" binding:"required,oneof=login register bind_phone bind_email deletion"`
+}
+
+// CodeLoginRequest 验证码登录请求
+type CodeLoginRequest struct {
+	Phone  string `json:"phone" binding:"required,e164"`
+	Code   string `json:"code" binding:"required"`
+	Device string `json:"device" binding:"omitempty,max=100"`
+}
+
+// RefreshTokenRequest 刷新token请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// clientInfo 获取请求的客户端信息
+func clientInfo(c *gin.Context, device string) *service.ClientInfo {
+	return &service.ClientInfo{
+		DeviceName: device,
+		UserAgent:  c.GetHeader("User-Agent"),
+		IP:         c.ClientIP(),
+	}
+}
+
+// loginLimited 登录受限响应
+func loginLimited(c *gin.Context, message string, status *service.LoginLimitStatus) {
+	utils.ErrorWithData(c, 429, message, map[string]interface{}{
+		"retry_after":      int64(status.RetryAfter.Seconds()),
+		"captcha_required": status.CaptchaRequired,
+	})
+}
+
+// loginFailed 记录登录失败并返回错误
+func loginFailed(c *gin.Context, account string, ip string) {
+	status, err := loginLimitService.RecordFailure(c.Request.Context(), account, ip)
+	if err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+		utils.ParamError(c, "用户名或密码错误")
+		return
+	}
+
+	if status.Locked {
+		loginLimited(c, "登录失败次数过多，账号已被临时锁定", status)
+		return
+	}
+
+	utils.ErrorWithData(c, 400, "用户名或密码错误", map[string]interface{}{
+		"retry_after":      int64(status.RetryAfter.Seconds()),
+		"captcha_required": status.CaptchaRequired,
+	})
 }
 
 // Login 用户登录
@@ -33,31 +111,91 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	ctx := c.Request.Context()
+	ip := c.ClientIP()
+
+	// 检查失败次数限制
+	status, err := loginLimitService.Check(ctx, req.Username, ip)
+	if err != nil {
+		log.Printf("Failed to check login limit: %v", err)
+		status = &service.LoginLimitStatus{}
+	}
+	if status.Locked {
+		loginLimited(c, "登录失败次数过多，请稍后再试", status)
+		return
+	}
+	if status.RetryAfter > 0 {
+		loginLimited(c, "操作过于频繁，请稍后再试", status)
+		return
+	}
+
 	// 查找用户
 	user := &models.User{}
 	if err := models.DB.Where("username = ? OR phone = ? OR email = ?", req.Username, req.Username, req.Username).First(user).Error; err != nil {
 		if err == models.ErrRecordNotFound {
-			utils.ParamError(c, "用户名或密码错误")
+			loginFailed(c, req.Username, ip)
 			return
 		}
 		utils.ServerError(c)
 		return
 	}
 
+	// 验证密码
+	if !user.CheckPassword(req.Password) {
+		loginFailed(c, req.Username, ip)
+		return
+	}
+
 	// 检查用户状态
 	if user.Status != 1 {
 		utils.ParamError(c, "账号已被禁用")
 		return
 	}
 
-	// 验证密码
-	if !user.CheckPassword(req.Password) {
-		utils.ParamError(c, "用户名或密码错误")
+	// 清除失败记录
+	if err := loginLimitService.Reset(ctx, req.Username); err != nil {
+		log.Printf("Failed to reset login limit: %v", err)
+	}
+
+	loginOrChallenge(c, user, req.Device)
+}
+
+// loginOrChallenge 第一步验证通过：需要双因素认证时返回第二步凭证，否则直接登录
+func loginOrChallenge(c *gin.Context, user *models.User, device string) {
+	required, err := twoFactorService.Required(user)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+	if !required {
+		loginSuccess(c, user, device, nil)
+		return
+	}
+
+	challenge, err := twoFactorService.CreateChallenge(c.Request.Context(), user.ID, device)
+	if err != nil {
+		log.Printf("Failed to create two-factor challenge: %v", err)
+		utils.ServerError(c)
+		return
+	}
+
+	status, err := twoFactorService.GetStatus(user)
+	if err != nil {
+		utils.ServerError(c)
 		return
 	}
 
+	utils.Success(c, map[string]interface{}{
+		"two_factor_required": true,
+		"setup_required":      !status.Enabled, // 管理员尚未绑定验证器App，需先获取密钥完成绑定
+		"challenge_token":     challenge,
+	})
+}
+
+// loginSuccess 登录成功：签发token并返回用户信息，extra为附加返回字段
+func loginSuccess(c *gin.Context, user *models.User, device string, extra map[string]interface{}) {
 	// 生成token
-	token, err := utils.GenerateToken(user.ID, user.Username, "user")
+	pair, err := authService.IssueTokenPair(user, clientInfo(c, device))
 	if err != nil {
 		utils.ServerError(c)
 		return
@@ -75,15 +213,186 @@ func Login(c *gin.Context) {
 		"phone":    user.Phone,
 		"email":    user.Email,
 		"avatar":   user.Avatar,
-		"role":     "user",
+		"role":     authService.UserRole(user),
 	}
 
-	utils.Success(c, map[string]interface{}{
-		"token": token,
-		"user":  userInfo,
+	data := map[string]interface{}{
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
+		"user":          userInfo,
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
+	utils.Success(c, data)
+}
+
+// SendVerifyCode 发送验证码
+func SendVerifyCode(c *gin.Context) {
+	var req SendVerifyCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	// 根据场景和号码格式确定发送渠道
+	channel := notify.ChannelSMS
+	if strings.Contains(req.Target, "@") {
+		channel = notify.ChannelEmail
+	}
+	switch {
+	case channel == notify.ChannelSMS && !isE164(req.Target):
+		utils.ParamError(c, "手机号格式错误")
+		return
+	case channel == notify.ChannelEmail && !isEmail(req.Target):
+		utils.ParamError(c, "邮箱格式错误")
+		return
+	case req.Scene == service.VerifySceneLogin && channel != notify.ChannelSMS,
+		req.Scene == service.VerifySceneBindPhone && channel != notify.ChannelSMS,
+		req.Scene == service.VerifySceneDeletion && channel != notify.ChannelSMS:
+		utils.ParamError(c, "请输入手机号")
+		return
+	case req.Scene == service.VerifySceneBindEmail && channel != notify.ChannelEmail:
+		utils.ParamError(c, "请输入邮箱")
+		return
+	}
+
+	if err := verifyCodeService.Send(c.Request.Context(), req.Scene, channel, req.Target); err != nil {
+		if service.IsVerifyCodeError(err) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		log.Printf("Failed to send verify code: %v", err)
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, map[string]string{
+		"message": "验证码已发送",
 	})
 }
 
+// verifyCode 校验验证码，失败时直接写入响应并返回false
+func verifyCode(c *gin.Context, scene string, target string, code string) bool {
+	return verifyCodeResult(c, verifyCodeService.Verify(c.Request.Context(), scene, target, code))
+}
+
+// checkCode 校验验证码但不使其失效，失败时写入响应
+func checkCode(c *gin.Context, scene string, target string, code string) bool {
+	return verifyCodeResult(c, verifyCodeService.Check(c.Request.Context(), scene, target, code))
+}
+
+// verifyCodeResult 处理验证码校验结果
+func verifyCodeResult(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+	if service.IsVerifyCodeError(err) {
+		utils.BadRequest(c, err.Error())
+		return false
+	}
+	utils.ServerError(c)
+	return false
+}
+
+// LoginByCode 手机验证码登录，手机号未注册时自动注册
+func LoginByCode(c *gin.Context) {
+	var req CodeLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	if !verifyCode(c, service.VerifySceneLogin, req.Phone, req.Code) {
+		return
+	}
+
+	now := time.Now()
+	user := &models.User{}
+	err := models.DB.Where("phone = ?", req.Phone).First(user).Error
+	if err != nil && err != models.ErrRecordNotFound {
+		utils.ServerError(c)
+		return
+	}
+
+	if err == models.ErrRecordNotFound {
+		user, err = registerByPhone(req.Phone, now)
+		if err != nil {
+			log.Printf("Failed to register user by phone code: %v", err)
+			utils.ServerError(c)
+			return
+		}
+	} else if user.PhoneVerifiedAt == nil {
+		models.DB.Model(user).Update("phone_verified_at", now)
+	}
+
+	// 检查用户状态
+	if user.Status != 1 {
+		utils.ParamError(c, "账号已被禁用")
+		return
+	}
+
+	loginOrChallenge(c, user, req.Device)
+}
+
+// registerByPhone 验证码登录时自动注册，用户名和初始密码随机生成。
+// 用户名冲突时换一个重试；手机号已被并发的登录请求注册时返回该用户
+func registerByPhone(phone string, now time.Time) (*models.User, error) {
+	initialPassword, err := utils.RandomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		suffix, err := utils.RandomHex(4)
+		if err != nil {
+			return nil, err
+		}
+		user := &models.User{
+			Username:        "u_" + suffix,
+			Password:        initialPassword,
+			Phone:           phone,
+			Status:          1,
+			PhoneVerifiedAt: &now,
+		}
+		err = models.DB.Create(user).Error
+		if err == nil {
+			return user, nil
+		}
+		if !models.IsDuplicateKey(err) || attempt >= 4 {
+			return nil, err
+		}
+
+		existing := &models.User{}
+		if err := models.DB.Where("phone = ?", phone).First(existing).Error; err == nil {
+			return existing, nil
+		} else if err != models.ErrRecordNotFound {
+			return nil, err
+		}
+	}
+}
+
+// isE164 校验E.164格式手机号
+func isE164(phone string) bool {
+	if len(phone) < 3 || len(phone) > 16 || phone[0] != '+' || phone[1] == '0' {
+		return false
+	}
+	for _, ch := range phone[1:] {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isEmail 校验邮箱格式
+func isEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
 // Register 用户注册
 func Register(c *gin.Context) {
 	var req RegisterRequest
@@ -92,6 +401,11 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	if err := password.Validate(req.Password, password.Identities(req.Username, req.Phone, req.Email)...); err != nil {
+		utils.ParamError(c, err.Error())
+		return
+	}
+
 	// 检查用户名是否已存在
 	var count int64
 	if err := models.DB.Model(&models.User{}).Where("username = ?", req.Username).Count(&count).Error; err != nil {
@@ -127,7 +441,8 @@ func Register(c *gin.Context) {
 		}
 	}
 
-	// 创建用户
+	// 校验手机号和邮箱验证码，两者都通过后才使验证码失效，避免一个错误导致另一个需要重新获取
+	now := time.Now()
 	user := &models.User{
 		Username: req.Username,
 		Password: req.Password,
@@ -136,6 +451,29 @@ func Register(c *gin.Context) {
 		Nickname: req.Nickname,
 		Status:   1,
 	}
+	if req.Phone != "" {
+		if !checkCode(c, service.VerifySceneRegister, req.Phone, req.PhoneCode) {
+			return
+		}
+		user.PhoneVerifiedAt = &now
+	}
+	if req.Email != "" {
+		if !checkCode(c, service.VerifySceneRegister, req.Email, req.EmailCode) {
+			return
+		}
+		user.EmailVerifiedAt = &now
+	}
+	for _, target := range []string{req.Phone, req.Email} {
+		if target == "" {
+			continue
+		}
+		if err := verifyCodeService.Consume(c.Request.Context(), service.VerifySceneRegister, target); err != nil {
+			utils.ServerError(c)
+			return
+		}
+	}
+
+	// 创建用户
 
 	if err := models.DB.Create(user).Error; err != nil {
 		utils.ServerError(c)
@@ -143,7 +481,7 @@ func Register(c *gin.Context) {
 	}
 
 	// 生成token
-	token, err := utils.GenerateToken(user.ID, user.Username, "user")
+	pair, err := authService.IssueTokenPair(user, clientInfo(c, req.Device))
 	if err != nil {
 		utils.ServerError(c)
 		return
@@ -157,59 +495,64 @@ func Register(c *gin.Context) {
 		"phone":    user.Phone,
 		"email":    user.Email,
 		"avatar":   user.Avatar,
-		"role":     "user",
+		"role":     authService.UserRole(user),
 	}
 
 	utils.Created(c, map[string]interface{}{
-		"token": token,
-		"user":  userInfo,
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
+		"user":          userInfo,
 	})
 }
 
-// RefreshToken 刷新token
+// RefreshToken 使用刷新令牌换取新的令牌对
 func RefreshToken(c *gin.Context) {
-	tokenString := c.GetHeader("Authorization")
-	if tokenString == "" {
-		utils.Unauthorized(c)
-		return
-	}
-
-	// 解析token
-	claims, err := utils.ParseToken(strings.TrimPrefix(tokenString, "Bearer "))
-	if err != nil {
-		utils.Unauthorized(c)
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
 		return
 	}
 
-	// 生成新token
-	newToken, err := utils.GenerateToken(claims.UserID, claims.Username, claims.Role)
+	pair, err := authService.Refresh(c.Request.Context(), req.RefreshToken, c.ClientIP())
 	if err != nil {
+		if errors.Is(err, service.ErrRefreshTokenInvalid) || errors.Is(err, service.ErrRefreshTokenReused) {
+			utils.Error(c, 401, err.Error())
+			return
+		}
 		utils.ServerError(c)
 		return
 	}
 
-	utils.Success(c, map[string]string{
-		"token": newToken,
-	})
+	utils.Success(c, pair)
 }
 
 // Logout 用户登出
 func Logout(c *gin.Context) {
-	// 获取用户ID
-	_, exists := c.Get("user_id")
-	if !exists {
+	claims, ok := middleware.GetCurrentClaims(c)
+	if !ok {
 		utils.Unauthorized(c)
 		return
 	}
 
-	// 这里可以将token加入黑名单，或者在Redis中标记token为无效
-	// 为了简化，这里只返回成功响应
+	// 将当前token加入黑名单
+	if err := utils.RevokeToken(c.Request.Context(), claims); err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	// 结束本次登录会话
+	if err := authService.TerminateSession(c.Request.Context(), claims.FamilyID); err != nil {
+		utils.ServerError(c)
+		return
+	}
+
 	utils.Success(c, nil)
 }
 
 // GetUserInfo 获取用户信息
 func GetUserInfo(c *gin.Context) {
-	userID := c.GetUint("user_id")
+	userID := c.GetUint64("user_id")
 	if userID == 0 {
 		utils.Unauthorized(c)
 		return
@@ -227,15 +570,17 @@ func GetUserInfo(c *gin.Context) {
 
 	// 返回用户信息（不包含密码）
 	userInfo := map[string]interface{}{
-		"id":            user.ID,
-		"username":      user.Username,
-		"nickname":      user.Nickname,
-		"phone":         user.Phone,
-		"email":         user.Email,
-		"avatar":        user.Avatar,
-		"status":        user.Status,
-		"last_login_at": user.LastLoginAt,
-		"created_at":    user.CreatedAt,
+		"id":                user.ID,
+		"username":          user.Username,
+		"nickname":          user.Nickname,
+		"phone":             user.Phone,
+		"phone_verified_at": user.PhoneVerifiedAt,
+		"email":             user.Email,
+		"email_verified_at": user.EmailVerifiedAt,
+		"avatar":            user.Avatar,
+		"status":            user.Status,
+		"last_login_at":     user.LastLoginAt,
+		"created_at":        user.CreatedAt,
 	}
 
 	utils.Success(c, userInfo)
@@ -243,17 +588,19 @@ func GetUserInfo(c *gin.Context) {
 
 // UpdateUserInfo 更新用户信息
 func UpdateUserInfo(c *gin.Context) {
-	userID := c.GetUint("user_id")
+	userID := c.GetUint64("user_id")
 	if userID == 0 {
 		utils.Unauthorized(c)
 		return
 	}
 
 	var req struct {
-		Nickname string `json:"nickname" binding:"max=50"`
-		Phone    string `json:"phone" binding:"omitempty,e164"`
-		Email    string `json:"email" binding:"omitempty,email"`
-		Avatar   string `json:"avatar"`
+		Nickname  string `json:"nickname" binding:"max=50"`
+		Phone     string `json:"phone" binding:"omitempty,e164"`
+		PhoneCode string `json:"phone_code"` // 修改手机号时必填
+		Email     string `json:"email" binding:"omitempty,email"`
+		EmailCode string `json:"email_code"` // 修改邮箱时必填
+		Avatar    string `json:"avatar"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -261,6 +608,20 @@ func UpdateUserInfo(c *gin.Context) {
 		return
 	}
 
+	user := &models.User{}
+	if err := models.DB.First(user, userID).Error; err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	// 未变化的手机号和邮箱无需重新验证
+	if req.Phone == user.Phone {
+		req.Phone = ""
+	}
+	if req.Email == user.Email {
+		req.Email = ""
+	}
+
 	// 检查手机号是否已被其他用户使用
 	if req.Phone != "" {
 		var count int64
@@ -291,18 +652,27 @@ func UpdateUserInfo(c *gin.Context) {
 		}
 	}
 
+	// 校验新手机号和新邮箱的验证码
+	now := time.Now()
+	if req.Phone != "" && !verifyCode(c, service.VerifySceneBindPhone, req.Phone, req.PhoneCode) {
+		return
+	}
+	if req.Email != "" && !verifyCode(c, service.VerifySceneBindEmail, req.Email, req.EmailCode) {
+		return
+	}
+
 	// 更新用户信息
-	user := &models.User{}
-	user.ID = uint64(userID)
 	updates := map[string]interface{}{}
 	if req.Nickname != "" {
 		updates["nickname"] = req.Nickname
 	}
 	if req.Phone != "" {
 		updates["phone"] = req.Phone
+		updates["phone_verified_at"] = now
 	}
 	if req.Email != "" {
 		updates["email"] = req.Email
+		updates["email_verified_at"] = now
 	}
 	if req.Avatar != "" {
 		updates["avatar"] = req.Avatar
@@ -321,17 +691,24 @@ func UpdateUserInfo(c *gin.Context) {
 		return
 	}
 
+	// 资料完善后完成对应任务，失败不影响资料更新
+	if err := taskService.CheckProfile(userID); err != nil {
+		log.Printf("Failed to check profile task for user %d: %v", userID, err)
+	}
+
 	// 返回更新后的用户信息
 	userInfo := map[string]interface{}{
-		"id":            user.ID,
-		"username":      user.Username,
-		"nickname":      user.Nickname,
-		"phone":         user.Phone,
-		"email":         user.Email,
-		"avatar":        user.Avatar,
-		"status":        user.Status,
-		"last_login_at": user.LastLoginAt,
-		"updated_at":    user.UpdatedAt,
+		"id":                user.ID,
+		"username":          user.Username,
+		"nickname":          user.Nickname,
+		"phone":             user.Phone,
+		"phone_verified_at": user.PhoneVerifiedAt,
+		"email":             user.Email,
+		"email_verified_at": user.EmailVerifiedAt,
+		"avatar":            user.Avatar,
+		"status":            user.Status,
+		"last_login_at":     user.LastLoginAt,
+		"updated_at":        user.UpdatedAt,
 	}
 
 	utils.Updated(c, userInfo)
@@ -339,7 +716,7 @@ func UpdateUserInfo(c *gin.Context) {
 
 // UpdatePassword 更新密码
 func UpdatePassword(c *gin.Context) {
-	userID := c.GetUint("user_id")
+	userID := c.GetUint64("user_id")
 	if userID == 0 {
 		utils.Unauthorized(c)
 		return
@@ -347,7 +724,7 @@ func UpdatePassword(c *gin.Context) {
 
 	var req struct {
 		OldPassword string `json:"old_password" binding:"required,min=6"`
-		NewPassword string `json:"new_password" binding:"required,min=6"`
+		NewPassword string `json:"new_password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -368,6 +745,15 @@ func UpdatePassword(c *gin.Context) {
 		return
 	}
 
+	if err := password.Validate(req.NewPassword, password.Identities(user.Username, user.Phone, user.Email)...); err != nil {
+		utils.ParamError(c, err.Error())
+		return
+	}
+	if req.NewPassword == req.OldPassword {
+		utils.ParamError(c, "新密码不能与旧密码相同")
+		return
+	}
+
 	// 更新密码
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
@@ -380,7 +766,80 @@ func UpdatePassword(c *gin.Context) {
 		return
 	}
 
+	// 吊销该用户已签发的所有token，需要重新登录
+	if err := authService.RevokeAllTokens(c.Request.Context(), user.ID); err != nil {
+		log.Printf("Failed to revoke tokens for user %d: %v", user.ID, err)
+		utils.ServerError(c)
+		return
+	}
+
 	utils.Success(c, map[string]string{
 		"message": "密码更新成功",
 	})
 }
+
+// ForgotPassword 申请密码重置，向绑定的手机号或邮箱发送重置链接
+func ForgotPassword(c *gin.Context) {
+	var req struct {
+		Account string `json:"account" binding:"required,max=100"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	account := strings.TrimSpace(req.Account)
+	if strings.Contains(account, "@") {
+		if !isEmail(account) {
+			utils.ParamError(c, "邮箱格式错误")
+			return
+		}
+	} else if !isE164(account) {
+		utils.ParamError(c, "手机号格式错误")
+		return
+	}
+
+	if err := passwordService.RequestReset(c.Request.Context(), account, c.ClientIP()); err != nil {
+		log.Printf("Failed to request password reset: %v", err)
+		utils.ServerError(c)
+		return
+	}
+
+	// 无论账号是否存在都返回相同结果，避免账号枚举
+	utils.Success(c, map[string]string{
+		"message": "如果该账号已注册，重置链接将发送至对应手机号或邮箱",
+	})
+}
+
+// ResetPassword 使用重置令牌设置新密码
+func ResetPassword(c *gin.Context) {
+	var req struct {
+		Token       string `json:"token" binding:"required,len=64"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	if err := passwordService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		if errors.Is(err, service.ErrResetTokenInvalid) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		var policyErr *password.PolicyError
+		if errors.As(err, &policyErr) {
+			utils.ParamError(c, policyErr.Error())
+			return
+		}
+		log.Printf("Failed to reset password: %v", err)
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, map[string]string{
+		"message": "密码重置成功，请重新登录",
+	})
+}
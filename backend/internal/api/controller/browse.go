@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BrowseService 浏览记录服务实例
+var browseService = service.NewBrowseService()
+
+// GetBrowseHistory 获取最近浏览的商品
+func GetBrowseHistory(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var query struct {
+		Page     int `form:"page"`
+		PageSize int `form:"page_size"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	items, total, err := browseService.GetHistory(c.Request.Context(), userID, query.Page, query.PageSize)
+	if err != nil {
+		log.Printf("Failed to get browse history of user %d: %v", userID, err)
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, items, total, query.Page, query.PageSize)
+}
+
+// DeleteBrowseHistory 删除一条浏览记录
+func DeleteBrowseHistory(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var productID uint64
+	if _, err := fmt.Sscanf(c.Param("product_id"), "%d", &productID); err != nil {
+		utils.ParamError(c, "商品ID格式错误")
+		return
+	}
+
+	if err := browseService.RemoveHistory(c.Request.Context(), userID, productID); err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Deleted(c)
+}
+
+// ClearBrowseHistory 清空浏览记录
+func ClearBrowseHistory(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	if err := browseService.ClearHistory(c.Request.Context(), userID); err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Deleted(c)
+}
@@ -115,6 +115,8 @@ func CreateCategory(c *gin.Context) {
 		utils.BadRequest(c, err.Error())
 		return
 	}
+	recordAudit(c, models.AuditActionCreate, models.AuditTargetCategory, category.ID, nil, service.Snapshot(category))
+	invalidateHomeSection(c, service.HomeSectionCategories)
 
 	utils.Created(c, category)
 }
@@ -144,6 +146,7 @@ func UpdateCategory(c *gin.Context) {
 		utils.NotFound(c, "分类不存在")
 		return
 	}
+	before := service.Snapshot(category)
 
 	if req.Name != nil {
 		category.Name = *req.Name
@@ -162,6 +165,8 @@ func UpdateCategory(c *gin.Context) {
 		utils.BadRequest(c, err.Error())
 		return
 	}
+	recordAudit(c, models.AuditActionUpdate, models.AuditTargetCategory, category.ID, before, service.Snapshot(category))
+	invalidateHomeSection(c, service.HomeSectionCategories)
 
 	utils.Updated(c, category)
 }
@@ -180,10 +185,19 @@ func DeleteCategory(c *gin.Context) {
 		return
 	}
 
+	category, err := categoryService.GetCategory(categoryID)
+	if err != nil {
+		utils.NotFound(c, "分类不存在")
+		return
+	}
+	before := service.Snapshot(category)
+
 	if err := categoryService.DeleteCategory(categoryID); err != nil {
 		utils.BadRequest(c, err.Error())
 		return
 	}
+	recordAudit(c, models.AuditActionDelete, models.AuditTargetCategory, categoryID, before, nil)
+	invalidateHomeSection(c, service.HomeSectionCategories)
 
 	utils.Success(c, map[string]string{
 		"message": "删除成功",
@@ -212,10 +226,20 @@ func UpdateCategoryStatus(c *gin.Context) {
 		return
 	}
 
+	category, err := categoryService.GetCategory(categoryID)
+	if err != nil {
+		utils.NotFound(c, "分类不存在")
+		return
+	}
+	before := service.Snapshot(category)
+
 	if err := categoryService.UpdateCategoryStatus(categoryID, req.Status); err != nil {
 		utils.BadRequest(c, err.Error())
 		return
 	}
+	category.Status = req.Status
+	recordAudit(c, models.AuditActionUpdateStatus, models.AuditTargetCategory, categoryID, before, service.Snapshot(category))
+	invalidateHomeSection(c, service.HomeSectionCategories)
 
 	utils.Success(c, map[string]string{
 		"message": "状态更新成功",
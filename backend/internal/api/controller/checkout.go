@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"log"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CheckoutService 结算服务实例
+var checkoutService = service.NewCheckoutService()
+
+// CheckoutPreviewRequest 结算预览请求
+type CheckoutPreviewRequest struct {
+	Items []struct {
+		SKUID    uint64 `json:"sku_id" binding:"required"`
+		Quantity int    `json:"quantity" binding:"required,min=1,max=999"`
+	} `json:"items" binding:"required,min=1,max=100,dive"`
+	UsePoints    int64   `json:"use_points" binding:"omitempty,min=0"` // 使用积分，不传或0表示不使用
+	UserCouponID *uint64 `json:"user_coupon_id"`                       // 使用的优惠券，不传自动选择最优，0表示不使用
+}
+
+// PreviewCheckout 结算预览：计算会员价、促销和优惠券、积分抵扣、运费和应付金额
+func PreviewCheckout(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var req CheckoutPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	items := make([]*service.CheckoutItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, &service.CheckoutItem{SKUID: item.SKUID, Quantity: item.Quantity})
+	}
+
+	quote, err := checkoutService.Quote(userID, items, req.UsePoints, req.UserCouponID)
+	if err != nil {
+		if service.IsCheckoutError(err) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		log.Printf("Checkout quote failed: %v", err)
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, quote)
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/models"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentService 内容位服务实例
+var contentService = service.NewContentService()
+
+// ContentSlotRequest 创建/更新内容位请求
+type ContentSlotRequest struct {
+	Position   string     `json:"position" binding:"required,oneof=banner promo announcement"`
+	Title      string     `json:"title" binding:"required,max=100"`
+	Image      string     `json:"image" binding:"omitempty,max=255"`
+	Content    string     `json:"content" binding:"omitempty,max=500"`
+	LinkType   string     `json:"link_type" binding:"omitempty,oneof=none product category url"`
+	LinkTarget string     `json:"link_target" binding:"omitempty,max=255"` // 商品ID、分类ID或URL
+	StartAt    *time.Time `json:"start_at"`                                // 不传表示立即生效
+	EndAt      *time.Time `json:"end_at"`                                  // 不传表示长期有效
+	Sort       int        `json:"sort"`
+	Status     *int       `json:"status" binding:"omitempty,oneof=0 1"` // 不传默认启用
+}
+
+// contentError 统一处理内容位错误
+func contentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrContentSlotNotFound):
+		utils.NotFound(c, err.Error())
+	case service.IsContentError(err):
+		utils.BadRequest(c, err.Error())
+	default:
+		log.Printf("Content slot operation failed: %v", err)
+		utils.ServerError(c)
+	}
+}
+
+// parseContentSlotID 解析内容位ID
+func parseContentSlotID(c *gin.Context) (uint64, bool) {
+	var slotID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &slotID); err != nil {
+		utils.ParamError(c, "内容位ID格式错误")
+		return 0, false
+	}
+	return slotID, true
+}
+
+// contentSlotInput 转换内容位请求
+func contentSlotInput(req *ContentSlotRequest) *service.ContentSlotInput {
+	status := 1
+	if req.Status != nil {
+		status = *req.Status
+	}
+	return &service.ContentSlotInput{
+		Position:   req.Position,
+		Title:      req.Title,
+		Image:      req.Image,
+		Content:    req.Content,
+		LinkType:   req.LinkType,
+		LinkTarget: req.LinkTarget,
+		StartAt:    req.StartAt,
+		EndAt:      req.EndAt,
+		Sort:       req.Sort,
+		Status:     status,
+	}
+}
+
+// GetActiveContentSlots 获取某位置当前生效的内容位
+func GetActiveContentSlots(c *gin.Context) {
+	slots, err := contentService.GetActive(c.Request.Context(), c.Query("position"))
+	if err != nil {
+		contentError(c, err)
+		return
+	}
+
+	utils.Success(c, slots)
+}
+
+// GetContentSlots 获取内容位列表（管理员）
+func GetContentSlots(c *gin.Context) {
+	var query struct {
+		Position string `form:"position"`
+		Page     int    `form:"page"`
+		PageSize int    `form:"page_size"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	slots, total, err := contentService.GetSlots(query.Position, query.Page, query.PageSize)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, slots, total, query.Page, query.PageSize)
+}
+
+// CreateContentSlot 创建内容位（管理员）
+func CreateContentSlot(c *gin.Context) {
+	var req ContentSlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	slot, err := contentService.CreateSlot(c.Request.Context(), contentSlotInput(&req))
+	if err != nil {
+		contentError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionCreate, models.AuditTargetContentSlot, slot.ID, nil, service.Snapshot(slot))
+
+	utils.Created(c, slot)
+}
+
+// UpdateContentSlot 更新内容位（管理员）
+func UpdateContentSlot(c *gin.Context) {
+	slotID, ok := parseContentSlotID(c)
+	if !ok {
+		return
+	}
+
+	var req ContentSlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	before, err := contentService.GetSlot(slotID)
+	if err != nil {
+		contentError(c, err)
+		return
+	}
+
+	slot, err := contentService.UpdateSlot(c.Request.Context(), slotID, contentSlotInput(&req))
+	if err != nil {
+		contentError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUpdate, models.AuditTargetContentSlot, slot.ID, service.Snapshot(before), service.Snapshot(slot))
+
+	utils.Updated(c, slot)
+}
+
+// DeleteContentSlot 删除内容位（管理员）
+func DeleteContentSlot(c *gin.Context) {
+	slotID, ok := parseContentSlotID(c)
+	if !ok {
+		return
+	}
+
+	before, err := contentService.GetSlot(slotID)
+	if err != nil {
+		contentError(c, err)
+		return
+	}
+
+	if err := contentService.DeleteSlot(c.Request.Context(), slotID); err != nil {
+		contentError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionDelete, models.AuditTargetContentSlot, slotID, service.Snapshot(before), nil)
+
+	utils.Deleted(c)
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FavoriteService 收藏服务实例
+var favoriteService = service.NewFavoriteService()
+
+// AddFavoriteRequest 添加收藏请求
+type AddFavoriteRequest struct {
+	ProductID uint64 `json:"product_id" binding:"required"`
+	SKUID     uint64 `json:"sku_id"` // 不传表示收藏整个商品
+}
+
+// favoriteError 统一处理收藏错误
+func favoriteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrFavoriteNotFound), errors.Is(err, service.ErrProductNotFound):
+		utils.NotFound(c, err.Error())
+	case service.IsFavoriteError(err):
+		utils.BadRequest(c, err.Error())
+	default:
+		log.Printf("Favorite operation failed: %v", err)
+		utils.ServerError(c)
+	}
+}
+
+// GetFavorites 获取收藏列表
+func GetFavorites(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var query struct {
+		Page     int `form:"page"`
+		PageSize int `form:"page_size"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	favorites, total, err := favoriteService.GetFavorites(userID, query.Page, query.PageSize)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, favorites, total, query.Page, query.PageSize)
+}
+
+// AddFavorite 收藏商品或SKU
+func AddFavorite(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var req AddFavoriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	favorite, err := favoriteService.AddFavorite(userID, req.ProductID, req.SKUID)
+	if err != nil {
+		favoriteError(c, err)
+		return
+	}
+
+	utils.Created(c, favorite)
+}
+
+// RemoveFavorite 取消收藏
+func RemoveFavorite(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var favoriteID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &favoriteID); err != nil {
+		utils.ParamError(c, "收藏ID格式错误")
+		return
+	}
+
+	if err := favoriteService.RemoveFavorite(userID, favoriteID); err != nil {
+		favoriteError(c, err)
+		return
+	}
+
+	utils.Deleted(c)
+}
+
+// GetProductFavorites 获取当前用户对某商品的收藏状态
+func GetProductFavorites(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var productID uint64
+	if _, err := fmt.Sscanf(c.Param("product_id"), "%d", &productID); err != nil {
+		utils.ParamError(c, "商品ID格式错误")
+		return
+	}
+
+	favorites, err := favoriteService.GetProductFavorites(userID, productID)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, favorites)
+}
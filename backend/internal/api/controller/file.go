@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"online-mall/internal/utils"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DownloadFileQuery 私有文件下载请求
+type DownloadFileQuery struct {
+	Path    string `form:"path" binding:"required"`
+	Expires int64  `form:"expires" binding:"required"`
+	UID     string `form:"uid"`
+	Sign    string `form:"sign" binding:"required"`
+}
+
+// DownloadFile 下载私有文件（需携带有效签名）
+func DownloadFile(c *gin.Context) {
+	var query DownloadFileQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	var userID uint64
+	if query.UID != "" {
+		id, err := strconv.ParseUint(query.UID, 10, 64)
+		if err != nil {
+			utils.ParamError(c, "请求参数格式错误")
+			return
+		}
+		userID = id
+	}
+
+	file := &utils.SignedFile{
+		Path:    query.Path,
+		Expires: query.Expires,
+		UserID:  userID,
+		Sign:    query.Sign,
+	}
+
+	// 校验签名
+	if err := utils.VerifyFileSign(file); err != nil {
+		if err == utils.ErrSignatureExpired {
+			utils.Error(c, 403, "下载链接已过期")
+			return
+		}
+		utils.Forbidden(c)
+		return
+	}
+
+	// 绑定用户的链接只能由该用户下载
+	if file.UserID > 0 && c.GetUint64("user_id") != file.UserID {
+		utils.Forbidden(c)
+		return
+	}
+
+	data, err := utils.ReadPrivateFile(file.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			utils.NotFound(c, "文件不存在")
+			return
+		}
+		utils.ServerError(c)
+		return
+	}
+
+	utils.DownloadSuccess(c, data, path.Base(file.Path))
+}
@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/models"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FlashSaleService 秒杀服务实例
+var flashSaleService = service.NewFlashSaleService()
+
+// FlashSaleRequest 创建/更新秒杀活动请求
+type FlashSaleRequest struct {
+	Name         string    `json:"name" binding:"required,max=100"`
+	ProductID    uint64    `json:"product_id" binding:"required"` // 更新时忽略
+	SKUID        uint64    `json:"sku_id" binding:"required"`     // 更新时忽略
+	SalePrice    float64   `json:"sale_price" binding:"required,gt=0"`
+	Quantity     int       `json:"quantity" binding:"required,min=1"`
+	PerUserLimit int       `json:"per_user_limit" binding:"min=0"` // 0表示不限购
+	StartAt      time.Time `json:"start_at" binding:"required"`
+	EndAt        time.Time `json:"end_at" binding:"required"`
+	Status       *int      `json:"status" binding:"omitempty,oneof=0 1"` // 不传默认启用
+}
+
+// FlashSaleBuyRequest 抢购请求
+type FlashSaleBuyRequest struct {
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	AddressID uint64 `json:"address_id" binding:"required"`
+}
+
+// flashSaleError 统一处理秒杀错误
+func flashSaleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrFlashSaleNotFound),
+		errors.Is(err, service.ErrFlashSaleRequestNotFound):
+		utils.NotFound(c, err.Error())
+	case service.IsFlashSaleError(err):
+		utils.BadRequest(c, err.Error())
+	default:
+		log.Printf("Flash sale operation failed: %v", err)
+		utils.ServerError(c)
+	}
+}
+
+// parseFlashSaleID 解析秒杀活动ID
+func parseFlashSaleID(c *gin.Context) (uint64, bool) {
+	var saleID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &saleID); err != nil {
+		utils.ParamError(c, "秒杀活动ID格式错误")
+		return 0, false
+	}
+	return saleID, true
+}
+
+// flashSaleInput 转换秒杀活动请求
+func flashSaleInput(req *FlashSaleRequest) *service.FlashSaleInput {
+	status := 1
+	if req.Status != nil {
+		status = *req.Status
+	}
+	return &service.FlashSaleInput{
+		Name:         req.Name,
+		ProductID:    req.ProductID,
+		SKUID:        req.SKUID,
+		SalePrice:    req.SalePrice,
+		Quantity:     req.Quantity,
+		PerUserLimit: req.PerUserLimit,
+		StartAt:      req.StartAt,
+		EndAt:        req.EndAt,
+		Status:       status,
+	}
+}
+
+// GetFlashSales 获取进行中和即将开始的秒杀活动
+func GetFlashSales(c *gin.Context) {
+	sales, err := flashSaleService.GetVisibleSales(c.Request.Context())
+	if err != nil {
+		flashSaleError(c, err)
+		return
+	}
+
+	utils.Success(c, sales)
+}
+
+// GetFlashSale 获取秒杀活动详情
+func GetFlashSale(c *gin.Context) {
+	saleID, ok := parseFlashSaleID(c)
+	if !ok {
+		return
+	}
+
+	sale, err := flashSaleService.GetSaleView(c.Request.Context(), saleID)
+	if err != nil {
+		flashSaleError(c, err)
+		return
+	}
+
+	utils.Success(c, sale)
+}
+
+// BuyFlashSale 抢购，成功后返回请求ID，通过GetFlashSaleResult查询下单结果
+func BuyFlashSale(c *gin.Context) {
+	saleID, ok := parseFlashSaleID(c)
+	if !ok {
+		return
+	}
+
+	var req FlashSaleBuyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	result, err := flashSaleService.Buy(c.Request.Context(), c.GetUint64("user_id"), saleID, req.Quantity, req.AddressID)
+	if err != nil {
+		flashSaleError(c, err)
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// GetFlashSaleResult 查询抢购结果
+func GetFlashSaleResult(c *gin.Context) {
+	result, err := flashSaleService.GetResult(c.Request.Context(), c.GetUint64("user_id"), c.Param("request_id"))
+	if err != nil {
+		flashSaleError(c, err)
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// GetAdminFlashSales 获取秒杀活动列表（管理员）
+func GetAdminFlashSales(c *gin.Context) {
+	var query struct {
+		Page     int `form:"page"`
+		PageSize int `form:"page_size"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	sales, total, err := flashSaleService.GetSales(query.Page, query.PageSize)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, sales, total, query.Page, query.PageSize)
+}
+
+// CreateFlashSale 创建秒杀活动（管理员）
+func CreateFlashSale(c *gin.Context) {
+	var req FlashSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	sale, err := flashSaleService.CreateSale(c.Request.Context(), flashSaleInput(&req))
+	if err != nil {
+		flashSaleError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionCreate, models.AuditTargetFlashSale, sale.ID, nil, service.Snapshot(sale))
+
+	utils.Created(c, sale)
+}
+
+// UpdateFlashSale 更新未开始的秒杀活动（管理员）
+func UpdateFlashSale(c *gin.Context) {
+	saleID, ok := parseFlashSaleID(c)
+	if !ok {
+		return
+	}
+
+	var req FlashSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	before, err := flashSaleService.GetSale(saleID)
+	if err != nil {
+		flashSaleError(c, err)
+		return
+	}
+
+	sale, err := flashSaleService.UpdateSale(c.Request.Context(), saleID, flashSaleInput(&req))
+	if err != nil {
+		flashSaleError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUpdate, models.AuditTargetFlashSale, sale.ID, service.Snapshot(before), service.Snapshot(sale))
+
+	utils.Updated(c, sale)
+}
+
+// UpdateFlashSaleStatus 启用或停用秒杀活动（管理员）
+func UpdateFlashSaleStatus(c *gin.Context) {
+	saleID, ok := parseFlashSaleID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status *int `json:"status" binding:"required,oneof=0 1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	before, err := flashSaleService.GetSale(saleID)
+	if err != nil {
+		flashSaleError(c, err)
+		return
+	}
+
+	sale, err := flashSaleService.UpdateStatus(c.Request.Context(), saleID, *req.Status)
+	if err != nil {
+		flashSaleError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUpdateStatus, models.AuditTargetFlashSale, sale.ID, service.Snapshot(before), service.Snapshot(sale))
+
+	utils.Updated(c, sale)
+}
+
+// DeleteFlashSale 删除未开始的秒杀活动（管理员）
+func DeleteFlashSale(c *gin.Context) {
+	saleID, ok := parseFlashSaleID(c)
+	if !ok {
+		return
+	}
+
+	before, err := flashSaleService.GetSale(saleID)
+	if err != nil {
+		flashSaleError(c, err)
+		return
+	}
+
+	if err := flashSaleService.DeleteSale(c.Request.Context(), saleID); err != nil {
+		flashSaleError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionDelete, models.AuditTargetFlashSale, saleID, service.Snapshot(before), nil)
+
+	utils.Deleted(c)
+}
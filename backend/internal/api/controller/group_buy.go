@@ -0,0 +1,329 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/models"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GroupBuyService 拼团服务实例
+var groupBuyService = service.NewGroupBuyService()
+
+// GroupBuyRequest 创建/更新拼团活动请求
+type GroupBuyRequest struct {
+	Name          string    `json:"name" binding:"required,max=100"`
+	ProductID     uint64    `json:"product_id" binding:"required"` // 更新时忽略
+	SKUID         uint64    `json:"sku_id" binding:"required"`     // 更新时忽略
+	GroupPrice    float64   `json:"group_price" binding:"required,gt=0"`
+	GroupSize     int       `json:"group_size" binding:"required,min=2,max=100"`
+	DurationHours int       `json:"duration_hours" binding:"required,min=1,max=720"`
+	MaxQuantity   int       `json:"max_quantity" binding:"min=0"` // 不传默认1
+	StartAt       time.Time `json:"start_at" binding:"required"`
+	EndAt         time.Time `json:"end_at" binding:"required"`
+	Status        *int      `json:"status" binding:"omitempty,oneof=0 1"` // 不传默认启用
+}
+
+// GroupOrderRequest 开团/参团请求
+type GroupOrderRequest struct {
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	AddressID uint64 `json:"address_id" binding:"required"`
+}
+
+// groupBuyError 统一处理拼团错误
+func groupBuyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrGroupBuyNotFound),
+		errors.Is(err, service.ErrGroupNotFound),
+		errors.Is(err, service.ErrGroupMemberNotFound):
+		utils.NotFound(c, err.Error())
+	case service.IsGroupBuyError(err):
+		utils.BadRequest(c, err.Error())
+	default:
+		log.Printf("Group buy operation failed: %v", err)
+		utils.ServerError(c)
+	}
+}
+
+// parseGroupBuyID 解析拼团活动ID
+func parseGroupBuyID(c *gin.Context) (uint64, bool) {
+	var groupBuyID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &groupBuyID); err != nil {
+		utils.ParamError(c, "拼团活动ID格式错误")
+		return 0, false
+	}
+	return groupBuyID, true
+}
+
+// groupBuyInput 转换拼团活动请求
+func groupBuyInput(req *GroupBuyRequest) *service.GroupBuyInput {
+	status := 1
+	if req.Status != nil {
+		status = *req.Status
+	}
+	return &service.GroupBuyInput{
+		Name:          req.Name,
+		ProductID:     req.ProductID,
+		SKUID:         req.SKUID,
+		GroupPrice:    req.GroupPrice,
+		GroupSize:     req.GroupSize,
+		DurationHours: req.DurationHours,
+		MaxQuantity:   req.MaxQuantity,
+		StartAt:       req.StartAt,
+		EndAt:         req.EndAt,
+		Status:        status,
+	}
+}
+
+// GetGroupBuys 获取进行中的拼团活动
+func GetGroupBuys(c *gin.Context) {
+	var query struct {
+		Page     int `form:"page"`
+		PageSize int `form:"page_size"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	groupBuys, total, err := groupBuyService.GetActive(query.Page, query.PageSize)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, groupBuys, total, query.Page, query.PageSize)
+}
+
+// GetGroupBuy 获取拼团活动详情及可参与的团
+func GetGroupBuy(c *gin.Context) {
+	groupBuyID, ok := parseGroupBuyID(c)
+	if !ok {
+		return
+	}
+
+	detail, err := groupBuyService.GetDetail(groupBuyID)
+	if err != nil {
+		groupBuyError(c, err)
+		return
+	}
+
+	utils.Success(c, detail)
+}
+
+// GetGroup 通过分享编号获取团详情
+func GetGroup(c *gin.Context) {
+	group, err := groupBuyService.GetGroup(c.Param("code"))
+	if err != nil {
+		groupBuyError(c, err)
+		return
+	}
+
+	utils.Success(c, group)
+}
+
+// OpenGroup 开团，返回待支付订单
+func OpenGroup(c *gin.Context) {
+	groupBuyID, ok := parseGroupBuyID(c)
+	if !ok {
+		return
+	}
+
+	var req GroupOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	result, err := groupBuyService.OpenGroup(c.GetUint64("user_id"), groupBuyID, req.Quantity, req.AddressID)
+	if err != nil {
+		groupBuyError(c, err)
+		return
+	}
+
+	utils.Created(c, result)
+}
+
+// JoinGroup 参团，返回待支付订单
+func JoinGroup(c *gin.Context) {
+	var req GroupOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	result, err := groupBuyService.JoinGroup(c.GetUint64("user_id"), c.Param("code"), req.Quantity, req.AddressID)
+	if err != nil {
+		groupBuyError(c, err)
+		return
+	}
+
+	utils.Created(c, result)
+}
+
+// GetMyGroups 获取我参与的拼团
+func GetMyGroups(c *gin.Context) {
+	var query struct {
+		Page     int `form:"page"`
+		PageSize int `form:"page_size"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	groups, total, err := groupBuyService.GetMyGroups(c.GetUint64("user_id"), query.Page, query.PageSize)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, groups, total, query.Page, query.PageSize)
+}
+
+// GetAdminGroupBuys 获取拼团活动列表（管理员）
+func GetAdminGroupBuys(c *gin.Context) {
+	var query struct {
+		Page     int `form:"page"`
+		PageSize int `form:"page_size"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	groupBuys, total, err := groupBuyService.GetGroupBuys(query.Page, query.PageSize)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, groupBuys, total, query.Page, query.PageSize)
+}
+
+// GetGroupBuyGroups 获取拼团活动的团列表（管理员）
+func GetGroupBuyGroups(c *gin.Context) {
+	groupBuyID, ok := parseGroupBuyID(c)
+	if !ok {
+		return
+	}
+
+	var query struct {
+		Status   string `form:"status"`
+		Page     int    `form:"page"`
+		PageSize int    `form:"page_size"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	groups, total, err := groupBuyService.GetGroups(groupBuyID, query.Status, query.Page, query.PageSize)
+	if err != nil {
+		groupBuyError(c, err)
+		return
+	}
+
+	utils.PageSuccess(c, groups, total, query.Page, query.PageSize)
+}
+
+// CreateGroupBuy 创建拼团活动（管理员）
+func CreateGroupBuy(c *gin.Context) {
+	var req GroupBuyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	groupBuy, err := groupBuyService.CreateGroupBuy(groupBuyInput(&req))
+	if err != nil {
+		groupBuyError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionCreate, models.AuditTargetGroupBuy, groupBuy.ID, nil, service.Snapshot(groupBuy))
+
+	utils.Created(c, groupBuy)
+}
+
+// UpdateGroupBuy 更新拼团活动（管理员）
+func UpdateGroupBuy(c *gin.Context) {
+	groupBuyID, ok := parseGroupBuyID(c)
+	if !ok {
+		return
+	}
+
+	var req GroupBuyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	before, err := groupBuyService.GetGroupBuy(groupBuyID)
+	if err != nil {
+		groupBuyError(c, err)
+		return
+	}
+
+	groupBuy, err := groupBuyService.UpdateGroupBuy(groupBuyID, groupBuyInput(&req))
+	if err != nil {
+		groupBuyError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUpdate, models.AuditTargetGroupBuy, groupBuy.ID, service.Snapshot(before), service.Snapshot(groupBuy))
+
+	utils.Updated(c, groupBuy)
+}
+
+// UpdateGroupBuyStatus 启用或停用拼团活动（管理员）
+func UpdateGroupBuyStatus(c *gin.Context) {
+	groupBuyID, ok := parseGroupBuyID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status *int `json:"status" binding:"required,oneof=0 1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	before, err := groupBuyService.GetGroupBuy(groupBuyID)
+	if err != nil {
+		groupBuyError(c, err)
+		return
+	}
+
+	groupBuy, err := groupBuyService.UpdateStatus(groupBuyID, *req.Status)
+	if err != nil {
+		groupBuyError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUpdateStatus, models.AuditTargetGroupBuy, groupBuy.ID, service.Snapshot(before), service.Snapshot(groupBuy))
+
+	utils.Updated(c, groupBuy)
+}
+
+// RetryGroupRefund 重新发起退款失败的拼团成员退款（管理员）
+func RetryGroupRefund(c *gin.Context) {
+	var memberID uint64
+	if _, err := fmt.Sscanf(c.Param("member_id"), "%d", &memberID); err != nil {
+		utils.ParamError(c, "团成员ID格式错误")
+		return
+	}
+
+	member, err := groupBuyService.RetryRefund(memberID)
+	if err != nil {
+		groupBuyError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionRetryRefund, models.AuditTargetGroupMember, member.ID, nil, service.Snapshot(member))
+
+	utils.Updated(c, member)
+}
@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"log"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HomeService 首页服务实例
+var homeService = service.NewHomeService()
+
+// GetHomeFeed 获取首页数据：轮播图、分类导航、推荐商品、热门和新品。
+// 登录用户的推荐商品根据浏览和购买记录生成，未登录用户为近期人气商品
+func GetHomeFeed(c *gin.Context) {
+	utils.Success(c, homeService.GetFeed(c.Request.Context(), c.GetUint64("user_id")))
+}
+
+// invalidateHomeSection 首页区块数据变更后删除缓存，失败时等待缓存过期
+func invalidateHomeSection(c *gin.Context, name string) {
+	if err := homeService.InvalidateSection(c.Request.Context(), name); err != nil {
+		log.Printf("Failed to invalidate home section %s: %v", name, err)
+	}
+}
+
+// invalidateProductSections 商品上下架或信息变更后删除展示商品的首页区块缓存
+func invalidateProductSections(c *gin.Context) {
+	for _, name := range []string{service.HomeSectionBanners, service.HomeSectionPopular, service.HomeSectionHot, service.HomeSectionNew} {
+		invalidateHomeSection(c, name)
+	}
+}
@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/models"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MemberService 会员服务实例
+var memberService = service.NewMemberService()
+
+// MemberLevelCouponRequest 升级礼包中的优惠券
+type MemberLevelCouponRequest struct {
+	CouponID uint64 `json:"coupon_id" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,min=1,max=10"`
+}
+
+// MemberLevelRequest 创建/更新会员等级请求
+type MemberLevelRequest struct {
+	Name                  string                     `json:"name" binding:"required,max=50"`
+	MinGrowth             *int64                     `json:"min_growth" binding:"required,min=0"`
+	Discount              float64                    `json:"discount" binding:"required,gt=0,lte=1"`            // 如0.95表示95折
+	FreeShippingThreshold *float64                   `json:"free_shipping_threshold" binding:"omitempty,min=0"` // 不传表示使用全站包邮规则
+	Icon                  string                     `json:"icon" binding:"omitempty,max=255"`
+	Description           string                     `json:"description" binding:"omitempty,max=255"`
+	Coupons               []MemberLevelCouponRequest `json:"coupons" binding:"omitempty,dive"`
+}
+
+// SKUMemberPriceRequest 设置SKU会员价请求
+type SKUMemberPriceRequest struct {
+	Prices []struct {
+		LevelID uint64  `json:"level_id" binding:"required"`
+		Price   float64 `json:"price" binding:"min=0"`
+	} `json:"prices" binding:"omitempty,dive"`
+}
+
+// memberError 统一处理会员错误
+func memberError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMemberLevelNotFound):
+		utils.NotFound(c, err.Error())
+	case service.IsMemberError(err):
+		utils.BadRequest(c, err.Error())
+	default:
+		log.Printf("Member operation failed: %v", err)
+		utils.ServerError(c)
+	}
+}
+
+// parseLevelID 解析会员等级ID
+func parseLevelID(c *gin.Context) (uint64, bool) {
+	var levelID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &levelID); err != nil {
+		utils.ParamError(c, "等级ID格式错误")
+		return 0, false
+	}
+	return levelID, true
+}
+
+// levelInput 转换会员等级请求
+func levelInput(req *MemberLevelRequest) *service.MemberLevelInput {
+	coupons := make([]*models.MemberLevelCoupon, 0, len(req.Coupons))
+	for _, item := range req.Coupons {
+		coupons = append(coupons, &models.MemberLevelCoupon{CouponID: item.CouponID, Quantity: item.Quantity})
+	}
+	return &service.MemberLevelInput{
+		Name:                  req.Name,
+		MinGrowth:             *req.MinGrowth,
+		Discount:              req.Discount,
+		FreeShippingThreshold: req.FreeShippingThreshold,
+		Icon:                  req.Icon,
+		Description:           req.Description,
+		Coupons:               coupons,
+	}
+}
+
+// GetMemberLevels 获取会员等级及权益
+func GetMemberLevels(c *gin.Context) {
+	levels, err := memberService.GetLevels()
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, levels)
+}
+
+// GetMyMember 获取当前用户的会员等级和成长值
+func GetMyMember(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	info, err := memberService.GetMemberInfo(userID)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, info)
+}
+
+// GetMyGrowthLogs 获取当前用户的成长值明细
+func GetMyGrowthLogs(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var query struct {
+		Page     int `form:"page"`
+		PageSize int `form:"page_size"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	logs, total, err := memberService.GetGrowthLogs(userID, query.Page, query.PageSize)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, logs, total, query.Page, query.PageSize)
+}
+
+// CreateMemberLevel 创建会员等级（管理员）
+func CreateMemberLevel(c *gin.Context) {
+	var req MemberLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	level, err := memberService.CreateLevel(levelInput(&req))
+	if err != nil {
+		memberError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionCreate, models.AuditTargetMemberLevel, level.ID, nil, service.Snapshot(level))
+
+	utils.Created(c, level)
+}
+
+// UpdateMemberLevel 更新会员等级（管理员）
+func UpdateMemberLevel(c *gin.Context) {
+	levelID, ok := parseLevelID(c)
+	if !ok {
+		return
+	}
+
+	var req MemberLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	before, err := memberService.GetLevel(levelID)
+	if err != nil {
+		memberError(c, err)
+		return
+	}
+
+	level, err := memberService.UpdateLevel(levelID, levelInput(&req))
+	if err != nil {
+		memberError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUpdate, models.AuditTargetMemberLevel, level.ID, service.Snapshot(before), service.Snapshot(level))
+
+	utils.Updated(c, level)
+}
+
+// DeleteMemberLevel 删除会员等级（管理员）
+func DeleteMemberLevel(c *gin.Context) {
+	levelID, ok := parseLevelID(c)
+	if !ok {
+		return
+	}
+
+	before, err := memberService.GetLevel(levelID)
+	if err != nil {
+		memberError(c, err)
+		return
+	}
+
+	if err := memberService.DeleteLevel(levelID); err != nil {
+		memberError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionDelete, models.AuditTargetMemberLevel, levelID, service.Snapshot(before), nil)
+
+	utils.Deleted(c)
+}
+
+// UpdateSKUMemberPrices 设置SKU的会员价（管理员），未设置的等级按等级折扣计价
+func UpdateSKUMemberPrices(c *gin.Context) {
+	var productID, skuID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &productID); err != nil {
+		utils.ParamError(c, "商品ID格式错误")
+		return
+	}
+	if _, err := fmt.Sscanf(c.Param("sku_id"), "%d", &skuID); err != nil {
+		utils.ParamError(c, "SKU ID格式错误")
+		return
+	}
+
+	var req SKUMemberPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	sku, err := productService.GetSKU(productID, skuID)
+	if err != nil {
+		utils.NotFound(c, "商品规格不存在")
+		return
+	}
+
+	prices := make(map[uint64]float64, len(req.Prices))
+	for _, item := range req.Prices {
+		prices[item.LevelID] = item.Price
+	}
+
+	before, err := memberService.GetSKUMemberPrices(sku.ID)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	after, err := memberService.SetSKUMemberPrices(sku, prices)
+	if err != nil {
+		memberError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUpdate, models.AuditTargetSKU, sku.ID,
+		service.Snapshot(map[string]interface{}{"member_prices": before}),
+		service.Snapshot(map[string]interface{}{"member_prices": after}))
+
+	utils.Updated(c, after)
+}
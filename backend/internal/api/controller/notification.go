@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationService 站内通知服务实例
+var notificationService = service.NewNotificationService()
+
+// GetNotifications 获取站内通知列表
+func GetNotifications(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var query struct {
+		Page     int  `form:"page"`
+		PageSize int  `form:"page_size"`
+		Unread   bool `form:"unread"` // 只看未读
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	notifications, total, err := notificationService.GetNotifications(userID, query.Unread, query.Page, query.PageSize)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, notifications, total, query.Page, query.PageSize)
+}
+
+// GetUnreadNotificationCount 获取未读通知数
+func GetUnreadNotificationCount(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	count, err := notificationService.CountUnread(userID)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, map[string]int64{
+		"count": count,
+	})
+}
+
+// ReadNotification 标记通知已读
+func ReadNotification(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var notificationID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &notificationID); err != nil {
+		utils.ParamError(c, "通知ID格式错误")
+		return
+	}
+
+	if err := notificationService.MarkRead(userID, notificationID); err != nil {
+		if errors.Is(err, service.ErrNotificationNotFound) {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, map[string]string{
+		"message": "已标记为已读",
+	})
+}
+
+// ReadAllNotifications 标记全部通知已读
+func ReadAllNotifications(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	if err := notificationService.MarkAllRead(userID); err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, map[string]string{
+		"message": "已全部标记为已读",
+	})
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"online-mall/internal/models"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderService 订单服务实例
+var orderService = service.NewOrderService()
+
+// CreateOrderRequest 结算下单请求，计价规则与结算预览相同
+type CreateOrderRequest struct {
+	CheckoutPreviewRequest
+	AddressID uint64 `json:"address_id" binding:"required"`
+	Remark    string `json:"remark" binding:"max=255"`
+}
+
+// ShipOrderRequest 订单发货请求
+type ShipOrderRequest struct {
+	ShippingCompany string `json:"shipping_company" binding:"required,max=50"`
+	TrackingNo      string `json:"tracking_no" binding:"required,max=50"`
+}
+
+// orderError 统一处理订单错误
+func orderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		utils.NotFound(c, err.Error())
+	case service.IsOrderError(err):
+		utils.BadRequest(c, err.Error())
+	default:
+		log.Printf("Order operation failed: %v", err)
+		utils.ServerError(c)
+	}
+}
+
+// parseOrderID 解析订单ID
+func parseOrderID(c *gin.Context) (uint64, bool) {
+	var orderID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &orderID); err != nil {
+		utils.ParamError(c, "订单ID格式错误")
+		return 0, false
+	}
+	return orderID, true
+}
+
+// GetOrderList 获取当前用户的订单列表
+func GetOrderList(c *gin.Context) {
+	var query struct {
+		Status   *int `form:"status" binding:"omitempty,min=0,max=4"`
+		Page     int  `form:"page"`
+		PageSize int  `form:"page_size"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	orders, total, err := orderService.GetOrders(c.GetUint64("user_id"), query.Status, query.Page, query.PageSize)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, orders, total, query.Page, query.PageSize)
+}
+
+// GetOrderDetail 获取当前用户的订单详情
+func GetOrderDetail(c *gin.Context) {
+	orderID, ok := parseOrderID(c)
+	if !ok {
+		return
+	}
+
+	order, err := orderService.GetOrder(c.GetUint64("user_id"), orderID)
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+
+	utils.Success(c, order)
+}
+
+// CreateOrder 结算下单，返回待支付订单
+func CreateOrder(c *gin.Context) {
+	var req CreateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	items := make([]*service.CheckoutItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, &service.CheckoutItem{SKUID: item.SKUID, Quantity: item.Quantity})
+	}
+
+	order, err := orderService.Create(c.GetUint64("user_id"), &service.OrderInput{
+		Items:        items,
+		UsePoints:    req.UsePoints,
+		UserCouponID: req.UserCouponID,
+		AddressID:    req.AddressID,
+		Remark:       req.Remark,
+	})
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+
+	utils.Created(c, order)
+}
+
+// CancelOrder 取消未支付的订单
+func CancelOrder(c *gin.Context) {
+	orderID, ok := parseOrderID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	order, err := orderService.Cancel(c.GetUint64("user_id"), orderID, req.Reason)
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+
+	utils.Updated(c, order)
+}
+
+// ReceiveOrder 确认收货
+func ReceiveOrder(c *gin.Context) {
+	orderID, ok := parseOrderID(c)
+	if !ok {
+		return
+	}
+
+	order, err := orderService.Receive(c.GetUint64("user_id"), orderID)
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+
+	utils.Updated(c, order)
+}
+
+// ShipOrder 订单发货（管理员）
+func ShipOrder(c *gin.Context) {
+	orderID, ok := parseOrderID(c)
+	if !ok {
+		return
+	}
+
+	var req ShipOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	order, err := orderService.Ship(c.Request.Context(), orderID, req.ShippingCompany, req.TrackingNo)
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionShip, models.AuditTargetOrder, order.ID, nil, service.Snapshot(order))
+
+	utils.Updated(c, order)
+}
+
+// RetryOrderRefund 重新发起退款失败的订单退款（管理员）
+func RetryOrderRefund(c *gin.Context) {
+	orderID, ok := parseOrderID(c)
+	if !ok {
+		return
+	}
+
+	order, err := orderService.RetryRefund(orderID)
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionRetryRefund, models.AuditTargetOrder, order.ID, nil, service.Snapshot(order))
+
+	utils.Updated(c, order)
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"log"
+	"online-mall/internal/pkg/payment"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentService 支付服务实例
+var paymentService = service.NewPaymentService()
+
+// maxNotifyBody 支付通知请求体的最大长度
+const maxNotifyBody = 64 << 10
+
+// PaymentNotify 支付网关的支付成功通知
+func PaymentNotify(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxNotifyBody))
+	if err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	notification, err := payment.ParseNotification(body, c.GetHeader("X-Payment-Signature"))
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidSignature) {
+			utils.Unauthorized(c)
+			return
+		}
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+	amount, err := strconv.ParseFloat(notification.Amount, 64)
+	if err != nil || notification.OrderNo == "" || len(notification.Method) > 20 {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	err = paymentService.ConfirmPaid(notification.OrderNo, notification.TradeNo, amount, notification.Method)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPaymentOrderNotFound):
+			utils.NotFound(c, err.Error())
+		case service.IsPaymentError(err):
+			utils.BadRequest(c, err.Error())
+		default:
+			log.Printf("Failed to confirm payment of order %s: %v", notification.OrderNo, err)
+			utils.ServerError(c)
+		}
+		return
+	}
+
+	utils.Success(c, nil)
+}
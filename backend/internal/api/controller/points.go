@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PointsService 积分服务实例
+var pointsService = service.NewPointsService()
+
+// GetMyPoints 获取当前用户的积分余额和即将过期的积分
+func GetMyPoints(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	summary, err := pointsService.GetSummary(userID)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, summary)
+}
+
+// GetMyPointsLogs 获取当前用户的积分明细
+func GetMyPointsLogs(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var query struct {
+		Page     int    `form:"page"`
+		PageSize int    `form:"page_size"`
+		Type     string `form:"type" binding:"omitempty,oneof=earn spend"` // earn-收入，spend-支出
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	logs, total, err := pointsService.GetLogs(userID, query.Type, query.Page, query.PageSize)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, logs, total, query.Page, query.PageSize)
+}
@@ -1,7 +1,9 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"online-mall/internal/models"
 	"online-mall/internal/service"
 	"online-mall/internal/utils"
@@ -31,18 +33,33 @@ type CreateProductRequest struct {
 
 // UpdateProductRequest 更新商品请求
 type UpdateProductRequest struct {
-	Name          *string  `json:"name" binding:"omitempty,min=1"`
-	CategoryID    *uint64  `json:"category_id" binding:"omitempty,min=1"`
-	Description   *string  `json:"description"`
-	Price         *float64 `json:"price" binding:"omitempty,gte=0"`
-	OriginalPrice *float64 `json:"original_price" binding:"omitempty,gte=0"`
-	Stock         *int     `json:"stock" binding:"omitempty,gte=0"`
-	Images        []string `json:"images"`
-	VideoURL      *string  `json:"video_url"`
-	Status        *int     `json:"status" binding:"omitempty,oneof=0 1"`
-	IsHot         *bool    `json:"is_hot"`
-	IsNew         *bool    `json:"is_new"`
-	Sort          *int     `json:"sort"`
+	Name          *string            `json:"name" binding:"omitempty,min=1"`
+	CategoryID    *uint64            `json:"category_id" binding:"omitempty,min=1"`
+	Description   *string            `json:"description"`
+	Price         *float64           `json:"price" binding:"omitempty,gte=0"`
+	OriginalPrice *float64           `json:"original_price" binding:"omitempty,gte=0"`
+	Stock         *int               `json:"stock" binding:"omitempty,gte=0"`
+	Images        []string           `json:"images"`
+	VideoURL      *string            `json:"video_url"`
+	Status        *int               `json:"status" binding:"omitempty,oneof=0 1"`
+	IsHot         *bool              `json:"is_hot"`
+	IsNew         *bool              `json:"is_new"`
+	Sort          *int               `json:"sort"`
+	Skus          []UpdateSKURequest `json:"skus" binding:"omitempty,dive"` // 按ID更新SKU价格和库存
+}
+
+// UpdateSKURequest 更新SKU请求
+type UpdateSKURequest struct {
+	ID    uint64   `json:"id" binding:"required"`
+	Price *float64 `json:"price" binding:"omitempty,gte=0"`
+	Stock *int     `json:"stock" binding:"omitempty,gte=0"`
+}
+
+// AdjustSKUStockRequest 调整SKU库存请求，stock和delta二选一
+type AdjustSKUStockRequest struct {
+	Stock  *int   `json:"stock" binding:"omitempty,gte=0"` // 设置为指定库存
+	Delta  *int   `json:"delta"`                           // 增减库存，负数为扣减
+	Remark string `json:"remark" binding:"omitempty,max=255"`
 }
 
 // ProductQuery 商品查询请求
@@ -110,6 +127,9 @@ func GetProduct(c *gin.Context) {
 		return
 	}
 
+	// 记录浏览量，登录用户同时记入最近浏览
+	browseService.RecordView(c.Request.Context(), c.GetUint64("user_id"), product.ID)
+
 	utils.Success(c, product)
 }
 
@@ -133,7 +153,14 @@ func GetProductSkus(c *gin.Context) {
 		return
 	}
 
-	utils.Success(c, product.ProductSkus)
+	// 登录用户附带其会员等级可享的会员价
+	skus, err := memberService.PriceSKUs(c.GetUint64("user_id"), product.ProductSkus)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, skus)
 }
 
 // GetHotProducts 获取热门商品
@@ -154,6 +181,24 @@ func GetHotProducts(c *gin.Context) {
 	utils.Success(c, products)
 }
 
+// GetPopularProducts 获取人气商品（按最近浏览量排行）
+func GetPopularProducts(c *gin.Context) {
+	limit := 10
+	if limitParam := c.Query("limit"); limitParam != "" {
+		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	products, err := browseService.GetPopularProducts(limit)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, products)
+}
+
 // GetNewProducts 获取新品商品
 func GetNewProducts(c *gin.Context) {
 	limit := 10
@@ -205,6 +250,8 @@ func CreateProduct(c *gin.Context) {
 		utils.BadRequest(c, err.Error())
 		return
 	}
+	recordAudit(c, models.AuditActionCreate, models.AuditTargetProduct, product.ID, nil, service.Snapshot(product))
+	invalidateProductSections(c)
 
 	utils.Created(c, product)
 }
@@ -235,6 +282,7 @@ func UpdateProduct(c *gin.Context) {
 		utils.NotFound(c, "商品不存在")
 		return
 	}
+	before := service.Snapshot(product)
 
 	// 更新字段
 	if req.Name != nil {
@@ -276,11 +324,31 @@ func UpdateProduct(c *gin.Context) {
 		product.SetImages(req.Images)
 	}
 
-	// 调用服务层
-	if err := productService.UpdateProduct(product); err != nil {
-		utils.BadRequest(c, err.Error())
+	// 商品字段和SKU价格、库存在同一事务中更新，缺货SKU补货后发送到货提醒
+	skuUpdates := make([]*service.SKUUpdate, 0, len(req.Skus))
+	for _, item := range req.Skus {
+		skuUpdates = append(skuUpdates, &service.SKUUpdate{ID: item.ID, Price: item.Price, Stock: item.Stock})
+	}
+	replenished, err := productService.UpdateProduct(product, skuUpdates)
+	if err != nil {
+		if service.IsProductError(err) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		log.Printf("Failed to update product %d: %v", product.ID, err)
+		utils.ServerError(c)
 		return
 	}
+	restockService.Replenished(replenished)
+
+	if len(skuUpdates) > 0 {
+		if product, err = productService.GetProduct(product.ID); err != nil {
+			utils.ServerError(c)
+			return
+		}
+	}
+	recordAudit(c, models.AuditActionUpdate, models.AuditTargetProduct, product.ID, before, service.Snapshot(product))
+	invalidateProductSections(c)
 
 	utils.Updated(c, product)
 }
@@ -299,10 +367,19 @@ func DeleteProduct(c *gin.Context) {
 		return
 	}
 
+	product, err := productService.GetProduct(productID)
+	if err != nil {
+		utils.NotFound(c, "商品不存在")
+		return
+	}
+	before := service.Snapshot(product)
+
 	if err := productService.DeleteProduct(productID); err != nil {
 		utils.BadRequest(c, err.Error())
 		return
 	}
+	recordAudit(c, models.AuditActionDelete, models.AuditTargetProduct, productID, before, nil)
+	invalidateProductSections(c)
 
 	utils.Success(c, map[string]string{
 		"message": "删除成功",
@@ -337,14 +414,70 @@ func UpdateProductStatus(c *gin.Context) {
 		return
 	}
 
+	before := service.Snapshot(product)
 	product.Status = req.Status
 
-	if err := productService.UpdateProduct(product); err != nil {
+	if _, err := productService.UpdateProduct(product, nil); err != nil {
 		utils.ServerError(c)
 		return
 	}
+	recordAudit(c, models.AuditActionUpdateStatus, models.AuditTargetProduct, product.ID, before, service.Snapshot(product))
+	invalidateProductSections(c)
 
 	utils.Success(c, map[string]string{
 		"message": "状态更新成功",
 	})
 }
+
+// AdjustSKUStock 调整SKU库存（管理员），缺货SKU补货后发送到货提醒
+func AdjustSKUStock(c *gin.Context) {
+	var productID, skuID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &productID); err != nil {
+		utils.ParamError(c, "商品ID格式错误")
+		return
+	}
+	if _, err := fmt.Sscanf(c.Param("sku_id"), "%d", &skuID); err != nil {
+		utils.ParamError(c, "SKU ID格式错误")
+		return
+	}
+
+	var req AdjustSKUStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+	if (req.Stock == nil) == (req.Delta == nil) {
+		utils.ParamError(c, "stock和delta需且只能传一个")
+		return
+	}
+
+	delta := 0
+	if req.Delta != nil {
+		delta = *req.Delta
+	}
+	oldStock, newStock, err := productService.AdjustSKUStock(productID, skuID, req.Stock, delta)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrSKUNotFound):
+			utils.NotFound(c, err.Error())
+		case errors.Is(err, service.ErrSKUStockNegative):
+			utils.BadRequest(c, err.Error())
+		default:
+			log.Printf("Failed to adjust stock of sku %d: %v", skuID, err)
+			utils.ServerError(c)
+		}
+		return
+	}
+	recordAudit(c, models.AuditActionUpdate, models.AuditTargetSKU, skuID,
+		service.Snapshot(map[string]interface{}{"stock": oldStock}),
+		service.Snapshot(map[string]interface{}{"stock": newStock, "remark": req.Remark}))
+
+	if oldStock <= 0 && newStock > 0 {
+		restockService.Replenished([]uint64{skuID})
+	}
+
+	utils.Updated(c, map[string]interface{}{
+		"sku_id": skuID,
+		"stock":  newStock,
+	})
+}
@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/models"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromotionService 促销服务实例
+var promotionService = service.NewPromotionService()
+
+// PromotionRequest 创建/更新促销活动请求
+type PromotionRequest struct {
+	Name        string                `json:"name" binding:"required,max=100"`
+	Type        string                `json:"type" binding:"required,oneof=full_reduction n_for_x second_item bundle"`
+	ScopeType   string                `json:"scope_type" binding:"omitempty,oneof=all product category"` // 不传默认全场，组合价忽略
+	ScopeIDs    []uint64              `json:"scope_ids" binding:"max=200"`
+	Rules       models.PromotionRules `json:"rules"`
+	StackCoupon *bool                 `json:"stack_coupon"` // 不传默认可与优惠券同享
+	StartAt     time.Time             `json:"start_at" binding:"required"`
+	EndAt       time.Time             `json:"end_at" binding:"required"`
+	Status      *int                  `json:"status" binding:"omitempty,oneof=0 1"` // 不传默认启用
+}
+
+// promotionError 统一处理促销错误
+func promotionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPromotionNotFound):
+		utils.NotFound(c, err.Error())
+	case service.IsPromotionError(err):
+		utils.BadRequest(c, err.Error())
+	default:
+		log.Printf("Promotion operation failed: %v", err)
+		utils.ServerError(c)
+	}
+}
+
+// parsePromotionID 解析促销活动ID
+func parsePromotionID(c *gin.Context) (uint64, bool) {
+	var promotionID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &promotionID); err != nil {
+		utils.ParamError(c, "促销活动ID格式错误")
+		return 0, false
+	}
+	return promotionID, true
+}
+
+// promotionInput 转换促销活动请求
+func promotionInput(req *PromotionRequest) *service.PromotionInput {
+	scopeType := req.ScopeType
+	if scopeType == "" {
+		scopeType = models.PromotionScopeAll
+	}
+	stackCoupon := true
+	if req.StackCoupon != nil {
+		stackCoupon = *req.StackCoupon
+	}
+	status := 1
+	if req.Status != nil {
+		status = *req.Status
+	}
+	return &service.PromotionInput{
+		Name:        req.Name,
+		Type:        req.Type,
+		ScopeType:   scopeType,
+		ScopeIDs:    req.ScopeIDs,
+		Rules:       req.Rules,
+		StackCoupon: stackCoupon,
+		StartAt:     req.StartAt,
+		EndAt:       req.EndAt,
+		Status:      status,
+	}
+}
+
+// GetPromotions 获取当前生效的促销活动
+func GetPromotions(c *gin.Context) {
+	promotions, err := promotionService.GetActive()
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, promotions)
+}
+
+// GetAdminPromotions 获取促销活动列表（管理员）
+func GetAdminPromotions(c *gin.Context) {
+	var query struct {
+		Page     int `form:"page"`
+		PageSize int `form:"page_size"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	promotions, total, err := promotionService.GetPromotions(query.Page, query.PageSize)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, promotions, total, query.Page, query.PageSize)
+}
+
+// CreatePromotion 创建促销活动（管理员）
+func CreatePromotion(c *gin.Context) {
+	var req PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	promotion, err := promotionService.CreatePromotion(promotionInput(&req))
+	if err != nil {
+		promotionError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionCreate, models.AuditTargetPromotion, promotion.ID, nil, service.Snapshot(promotion))
+
+	utils.Created(c, promotion)
+}
+
+// UpdatePromotion 更新促销活动（管理员）
+func UpdatePromotion(c *gin.Context) {
+	promotionID, ok := parsePromotionID(c)
+	if !ok {
+		return
+	}
+
+	var req PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	before, err := promotionService.GetPromotion(promotionID)
+	if err != nil {
+		promotionError(c, err)
+		return
+	}
+
+	promotion, err := promotionService.UpdatePromotion(promotionID, promotionInput(&req))
+	if err != nil {
+		promotionError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUpdate, models.AuditTargetPromotion, promotion.ID, service.Snapshot(before), service.Snapshot(promotion))
+
+	utils.Updated(c, promotion)
+}
+
+// UpdatePromotionStatus 启用或停用促销活动（管理员）
+func UpdatePromotionStatus(c *gin.Context) {
+	promotionID, ok := parsePromotionID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status *int `json:"status" binding:"required,oneof=0 1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	before, err := promotionService.GetPromotion(promotionID)
+	if err != nil {
+		promotionError(c, err)
+		return
+	}
+
+	promotion, err := promotionService.UpdateStatus(promotionID, *req.Status)
+	if err != nil {
+		promotionError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUpdateStatus, models.AuditTargetPromotion, promotion.ID, service.Snapshot(before), service.Snapshot(promotion))
+
+	utils.Updated(c, promotion)
+}
+
+// DeletePromotion 删除促销活动（管理员）
+func DeletePromotion(c *gin.Context) {
+	promotionID, ok := parsePromotionID(c)
+	if !ok {
+		return
+	}
+
+	before, err := promotionService.GetPromotion(promotionID)
+	if err != nil {
+		promotionError(c, err)
+		return
+	}
+
+	if err := promotionService.DeletePromotion(promotionID); err != nil {
+		promotionError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionDelete, models.AuditTargetPromotion, promotionID, service.Snapshot(before), nil)
+
+	utils.Deleted(c)
+}
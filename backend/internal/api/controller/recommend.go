@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecommendService 商品推荐服务实例
+var recommendService = service.NewRecommendService()
+
+// queryLimit 解析limit参数，未传或不合法时返回默认值
+func queryLimit(c *gin.Context, defaultLimit int) int {
+	if limitParam := c.Query("limit"); limitParam != "" {
+		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 && l <= 50 {
+			return l
+		}
+	}
+	return defaultLimit
+}
+
+// GetRelatedProducts 获取商品详情页的相关推荐（经常一起购买/浏览的商品）
+func GetRelatedProducts(c *gin.Context) {
+	var productID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &productID); err != nil {
+		utils.ParamError(c, "商品ID格式错误")
+		return
+	}
+
+	products, err := recommendService.GetRelated(productID, queryLimit(c, 10))
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, products)
+}
+
+// GetCartRecommendations 获取购物车页的推荐。
+// product_ids为购物车中的商品ID（逗号分隔），不传时使用登录用户购物车中的商品
+func GetCartRecommendations(c *gin.Context) {
+	var productIDs []uint64
+	if param := c.Query("product_ids"); param != "" {
+		for _, part := range strings.Split(param, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				utils.ParamError(c, "商品ID格式错误")
+				return
+			}
+			productIDs = append(productIDs, id)
+		}
+		if len(productIDs) > 100 {
+			utils.ParamError(c, "商品数量不能超过100个")
+			return
+		}
+	}
+
+	products, err := recommendService.GetCartRecommendations(c.GetUint64("user_id"), productIDs, queryLimit(c, 10))
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, products)
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RestockService 到货提醒服务实例
+var restockService = service.NewRestockService()
+
+// SubscribeRestockRequest 订阅到货提醒请求
+type SubscribeRestockRequest struct {
+	ProductID uint64 `json:"product_id" binding:"required"`
+	SKUID     uint64 `json:"sku_id" binding:"required"`
+}
+
+// restockError 统一处理到货提醒错误
+func restockError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrSubscriptionNotFound), errors.Is(err, service.ErrSKUNotFound):
+		utils.NotFound(c, err.Error())
+	case service.IsRestockError(err):
+		utils.BadRequest(c, err.Error())
+	default:
+		log.Printf("Restock subscription failed: %v", err)
+		utils.ServerError(c)
+	}
+}
+
+// GetStockSubscriptions 获取到货提醒订阅列表
+func GetStockSubscriptions(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var query struct {
+		Page     int `form:"page"`
+		PageSize int `form:"page_size"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	subscriptions, total, err := restockService.GetSubscriptions(userID, query.Page, query.PageSize)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, subscriptions, total, query.Page, query.PageSize)
+}
+
+// SubscribeRestock 订阅缺货SKU的到货提醒
+func SubscribeRestock(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var req SubscribeRestockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	subscription, err := restockService.Subscribe(userID, req.ProductID, req.SKUID)
+	if err != nil {
+		restockError(c, err)
+		return
+	}
+
+	utils.Created(c, subscription)
+}
+
+// UnsubscribeRestock 取消到货提醒
+func UnsubscribeRestock(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	var subscriptionID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &subscriptionID); err != nil {
+		utils.ParamError(c, "订阅ID格式错误")
+		return
+	}
+
+	if err := restockService.Unsubscribe(userID, subscriptionID); err != nil {
+		restockError(c, err)
+		return
+	}
+
+	utils.Deleted(c)
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewService 商品评价服务实例
+var reviewService = service.NewReviewService()
+
+// CreateReviewRequest 发表评价请求
+type CreateReviewRequest struct {
+	OrderItemID uint64   `json:"order_item_id" binding:"required"`
+	Rating      int      `json:"rating" binding:"required,min=1,max=5"`
+	Content     string   `json:"content" binding:"max=1000"`
+	Images      []string `json:"images" binding:"max=9,dive,max=255"`
+}
+
+// CreateReview 评价已完成订单中的商品
+func CreateReview(c *gin.Context) {
+	orderID, ok := parseOrderID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	review, err := reviewService.Create(c.GetUint64("user_id"), orderID, &service.ReviewInput{
+		OrderItemID: req.OrderItemID,
+		Rating:      req.Rating,
+		Content:     req.Content,
+		Images:      req.Images,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			utils.NotFound(c, err.Error())
+		case service.IsReviewError(err):
+			utils.BadRequest(c, err.Error())
+		default:
+			log.Printf("Failed to create review: %v", err)
+			utils.ServerError(c)
+		}
+		return
+	}
+
+	utils.Created(c, review)
+}
+
+// GetProductReviews 获取商品评价
+func GetProductReviews(c *gin.Context) {
+	var productID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &productID); err != nil {
+		utils.ParamError(c, "商品ID格式错误")
+		return
+	}
+	var query struct {
+		Page     int `form:"page"`
+		PageSize int `form:"page_size"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	reviews, total, err := reviewService.GetProductReviews(productID, query.Page, query.PageSize)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, reviews, total, query.Page, query.PageSize)
+}
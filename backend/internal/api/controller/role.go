@@ -0,0 +1,260 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/models"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RBACService 角色权限服务实例
+var rbacService = service.NewRBACService()
+
+// CreateRoleRequest 创建角色请求
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,min=2,max=50"` // 小写字母、数字、下划线
+	DisplayName string   `json:"display_name" binding:"required,max=50"`
+	Description string   `json:"description" binding:"omitempty,max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateRoleRequest 更新角色请求
+type UpdateRoleRequest struct {
+	DisplayName *string  `json:"display_name" binding:"omitempty,min=1,max=50"`
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	Permissions []string `json:"permissions"` // 不传时不修改权限
+}
+
+// rbacError 统一处理角色权限错误
+func rbacError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrRoleNotFound):
+		utils.NotFound(c, err.Error())
+	case service.IsRBACError(err):
+		utils.BadRequest(c, err.Error())
+	default:
+		log.Printf("RBAC operation failed: %v", err)
+		utils.ServerError(c)
+	}
+}
+
+// isRoleName 角色名只允许小写字母、数字和下划线
+func isRoleName(name string) bool {
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '_' {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// parseRoleID 解析角色ID
+func parseRoleID(c *gin.Context) (uint64, bool) {
+	var roleID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &roleID); err != nil {
+		utils.ParamError(c, "角色ID格式错误")
+		return 0, false
+	}
+	return roleID, true
+}
+
+// roleNames 提取角色名称，用于审计快照
+func roleNames(roles []*models.Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+// GetPermissions 获取所有权限
+func GetPermissions(c *gin.Context) {
+	permissions, err := rbacService.ListPermissions()
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, permissions)
+}
+
+// GetRoles 获取所有角色
+func GetRoles(c *gin.Context) {
+	roles, err := rbacService.ListRoles()
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, roles)
+}
+
+// GetRole 获取角色详情
+func GetRole(c *gin.Context) {
+	roleID, ok := parseRoleID(c)
+	if !ok {
+		return
+	}
+
+	role, err := rbacService.GetRole(roleID)
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	utils.Success(c, role)
+}
+
+// CreateRole 创建角色
+func CreateRole(c *gin.Context) {
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+	if !isRoleName(req.Name) {
+		utils.ParamError(c, "角色名只能包含小写字母、数字和下划线")
+		return
+	}
+
+	role, err := rbacService.CreateRole(&service.RoleInput{
+		Name:        req.Name,
+		DisplayName: &req.DisplayName,
+		Description: &req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionCreate, models.AuditTargetRole, role.ID, nil, service.Snapshot(role))
+
+	utils.Created(c, role)
+}
+
+// UpdateRole 更新角色
+func UpdateRole(c *gin.Context) {
+	roleID, ok := parseRoleID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	before, err := rbacService.GetRole(roleID)
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	role, err := rbacService.UpdateRole(c.Request.Context(), roleID, &service.RoleInput{
+		DisplayName: req.DisplayName,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUpdate, models.AuditTargetRole, role.ID, service.Snapshot(before), service.Snapshot(role))
+
+	utils.Updated(c, role)
+}
+
+// DeleteRole 删除角色
+func DeleteRole(c *gin.Context) {
+	roleID, ok := parseRoleID(c)
+	if !ok {
+		return
+	}
+
+	before, err := rbacService.GetRole(roleID)
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	if err := rbacService.DeleteRole(c.Request.Context(), roleID); err != nil {
+		rbacError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionDelete, models.AuditTargetRole, roleID, service.Snapshot(before), nil)
+
+	utils.Success(c, map[string]string{
+		"message": "角色删除成功",
+	})
+}
+
+// GetUserRoles 获取用户的角色（管理员）
+func GetUserRoles(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	roles, err := rbacService.GetUserRoles(userID)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, roles)
+}
+
+// UpdateUserRoles 设置用户的角色（管理员）
+func UpdateUserRoles(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Roles []string `json:"roles" binding:"omitempty,dive,required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	user := &models.User{}
+	if err := models.DB.First(user, userID).Error; err != nil {
+		utils.NotFound(c, "用户不存在")
+		return
+	}
+
+	oldRoles, err := rbacService.GetUserRoles(user.ID)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	roles, err := rbacService.SetUserRoles(c.Request.Context(), user.ID, req.Roles)
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionSetRoles, models.AuditTargetUser, user.ID,
+		service.Snapshot(map[string]interface{}{"roles": roleNames(oldRoles)}),
+		service.Snapshot(map[string]interface{}{"roles": roleNames(roles)}))
+
+	utils.Updated(c, roles)
+}
+
+// GetMyPermissions 获取当前用户的角色和权限
+func GetMyPermissions(c *gin.Context) {
+	access, err := rbacService.GetUserAccess(c.Request.Context(), c.GetUint64("user_id"))
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, access)
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"online-mall/internal/api/middleware"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSessions 获取当前用户的登录设备列表
+func GetSessions(c *gin.Context) {
+	claims, ok := middleware.GetCurrentClaims(c)
+	if !ok {
+		utils.Unauthorized(c)
+		return
+	}
+
+	sessions, err := authService.ListSessions(claims.UserID, claims.FamilyID)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, sessions)
+}
+
+// DeleteSession 退出指定登录设备
+func DeleteSession(c *gin.Context) {
+	claims, ok := middleware.GetCurrentClaims(c)
+	if !ok {
+		utils.Unauthorized(c)
+		return
+	}
+
+	id := c.Param("id")
+	var sessionID uint64
+	if _, err := fmt.Sscanf(id, "%d", &sessionID); err != nil {
+		utils.ParamError(c, "设备ID格式错误")
+		return
+	}
+
+	if err := authService.TerminateUserSession(c.Request.Context(), claims.UserID, sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, map[string]string{
+		"message": "已退出该设备",
+	})
+}
+
+// DeleteOtherSessions 退出除当前设备外的所有设备
+func DeleteOtherSessions(c *gin.Context) {
+	claims, ok := middleware.GetCurrentClaims(c)
+	if !ok {
+		utils.Unauthorized(c)
+		return
+	}
+
+	count, err := authService.TerminateOtherSessions(c.Request.Context(), claims.UserID, claims.FamilyID)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, map[string]interface{}{
+		"count": count,
+	})
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/models"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskService 签到和任务服务实例
+var taskService = service.NewTaskService()
+
+// UpdateTaskRequest 更新任务请求
+type UpdateTaskRequest struct {
+	Name           *string `json:"name" binding:"omitempty,min=1,max=50"`
+	Description    *string `json:"description" binding:"omitempty,max=255"`
+	RewardType     *string `json:"reward_type" binding:"omitempty,oneof=points coupon"`
+	RewardPoints   *int64  `json:"reward_points" binding:"omitempty,min=0"`
+	CouponID       *uint64 `json:"coupon_id"`
+	CouponQuantity *int    `json:"coupon_quantity" binding:"omitempty,min=0,max=10"`
+	Sort           *int    `json:"sort"`
+	Status         *int    `json:"status" binding:"omitempty,oneof=0 1"`
+}
+
+// taskError 统一处理签到和任务错误
+func taskError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTaskNotFound):
+		utils.NotFound(c, err.Error())
+	case service.IsTaskError(err):
+		utils.BadRequest(c, err.Error())
+	default:
+		log.Printf("Task operation failed: %v", err)
+		utils.ServerError(c)
+	}
+}
+
+// Checkin 每日签到
+func Checkin(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	result, err := taskService.Checkin(c.Request.Context(), userID)
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// GetCheckinCalendar 获取签到日历
+func GetCheckinCalendar(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	calendar, err := taskService.GetCheckinCalendar(c.Request.Context(), userID, c.Query("month"))
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+
+	utils.Success(c, calendar)
+}
+
+// GetMyTasks 获取任务列表及当前用户的完成情况
+func GetMyTasks(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	tasks, err := taskService.GetTasks(userID)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, tasks)
+}
+
+// GetAllTasks 获取全部任务（管理员）
+func GetAllTasks(c *gin.Context) {
+	tasks, err := taskService.GetAllTasks()
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, tasks)
+}
+
+// UpdateTask 更新任务奖励和状态（管理员）
+func UpdateTask(c *gin.Context) {
+	var taskID uint64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &taskID); err != nil {
+		utils.ParamError(c, "任务ID格式错误")
+		return
+	}
+
+	var req UpdateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	before, err := taskService.GetTask(taskID)
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+
+	task, err := taskService.UpdateTask(taskID, &service.TaskInput{
+		Name:           req.Name,
+		Description:    req.Description,
+		RewardType:     req.RewardType,
+		RewardPoints:   req.RewardPoints,
+		CouponID:       req.CouponID,
+		CouponQuantity: req.CouponQuantity,
+		Sort:           req.Sort,
+		Status:         req.Status,
+	})
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUpdate, models.AuditTargetTask, task.ID, service.Snapshot(before), service.Snapshot(task))
+
+	utils.Updated(c, task)
+}
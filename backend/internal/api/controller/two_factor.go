@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"log"
+	"online-mall/internal/models"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorService 双因素认证服务实例
+var twoFactorService = service.NewTwoFactorService()
+
+// TwoFactorCodeRequest 动态验证码请求
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,max=20"` // 6位动态验证码或恢复码
+}
+
+// TwoFactorChallengeRequest 登录第二步请求
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required,len=64"`
+	Code           string `json:"code" binding:"omitempty,max=20"`
+}
+
+// twoFactorError 统一处理双因素认证错误
+func twoFactorError(c *gin.Context, err error) {
+	if service.IsTwoFactorError(err) {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	log.Printf("Two-factor operation failed: %v", err)
+	utils.ServerError(c)
+}
+
+// currentUser 获取当前登录用户，失败时直接写入响应
+func currentUser(c *gin.Context) (*models.User, bool) {
+	userID := c.GetUint64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c)
+		return nil, false
+	}
+
+	user := &models.User{}
+	if err := models.DB.First(user, userID).Error; err != nil {
+		if err == models.ErrRecordNotFound {
+			utils.NotFound(c, "用户不存在")
+			return nil, false
+		}
+		utils.ServerError(c)
+		return nil, false
+	}
+	return user, true
+}
+
+// GetTwoFactorStatus 获取双因素认证状态
+func GetTwoFactorStatus(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	status, err := twoFactorService.GetStatus(user)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.Success(c, status)
+}
+
+// SetupTwoFactor 生成TOTP密钥和二维码地址
+func SetupTwoFactor(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	setup, err := twoFactorService.BeginSetup(user)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	utils.Success(c, setup)
+}
+
+// EnableTwoFactor 输入验证器App中的动态验证码完成绑定
+func EnableTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	codes, err := twoFactorService.Enable(c.GetUint64("user_id"), req.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	utils.Success(c, map[string]interface{}{
+		"message":        "双因素认证已启用，请妥善保存恢复码",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactor 关闭双因素认证
+func DisableTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if err := twoFactorService.Disable(user, req.Code); err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	utils.Success(c, map[string]string{
+		"message": "双因素认证已关闭",
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	codes, err := twoFactorService.RegenerateRecoveryCodes(c.GetUint64("user_id"), req.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	utils.Success(c, map[string]interface{}{
+		"recovery_codes": codes,
+	})
+}
+
+// TwoFactorLoginSetup 登录过程中强制绑定：获取TOTP密钥
+func TwoFactorLoginSetup(c *gin.Context) {
+	var req TwoFactorChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	setup, err := twoFactorService.ChallengeSetup(c.Request.Context(), req.ChallengeToken)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	utils.Success(c, setup)
+}
+
+// TwoFactorLoginVerify 登录第二步：校验动态验证码或恢复码后签发token
+func TwoFactorLoginVerify(c *gin.Context) {
+	var req TwoFactorChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	result, err := twoFactorService.VerifyChallenge(c.Request.Context(), req.ChallengeToken, req.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	user := &models.User{}
+	if err := models.DB.First(user, result.UserID).Error; err != nil {
+		utils.ServerError(c)
+		return
+	}
+	if user.Status != 1 {
+		utils.ParamError(c, "账号已被禁用")
+		return
+	}
+
+	var extra map[string]interface{}
+	if len(result.RecoveryCodes) > 0 {
+		extra = map[string]interface{}{"recovery_codes": result.RecoveryCodes}
+	}
+	loginSuccess(c, user, result.Device, extra)
+}
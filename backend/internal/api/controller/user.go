@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/models"
+	"online-mall/internal/service"
+	"online-mall/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserService 用户管理服务实例
+var userService = service.NewUserService()
+
+// AdminUpdateUserRequest 管理员更新用户请求
+type AdminUpdateUserRequest struct {
+	Nickname *string `json:"nickname" binding:"omitempty,max=50"`
+	Avatar   *string `json:"avatar" binding:"omitempty,max=255"`
+	Phone    *string `json:"phone" binding:"omitempty"` // 传空字符串表示解绑
+	Email    *string `json:"email" binding:"omitempty"` // 传空字符串表示解绑
+	Status   *int    `json:"status" binding:"omitempty,oneof=0 1"`
+}
+
+// userManageError 统一处理用户管理错误
+func userManageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		utils.NotFound(c, err.Error())
+	case service.IsUserManageError(err):
+		utils.BadRequest(c, err.Error())
+	default:
+		log.Printf("User management failed: %v", err)
+		utils.ServerError(c)
+	}
+}
+
+// parseUserID 解析路径中的用户ID
+func parseUserID(c *gin.Context) (uint64, bool) {
+	id := c.Param("id")
+	if id == "" {
+		utils.ParamError(c, "用户ID不能为空")
+		return 0, false
+	}
+
+	var userID uint64
+	if _, err := fmt.Sscanf(id, "%d", &userID); err != nil {
+		utils.ParamError(c, "用户ID格式错误")
+		return 0, false
+	}
+	return userID, true
+}
+
+// GetUserList 获取用户列表（管理员）
+func GetUserList(c *gin.Context) {
+	var query models.UserQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	users, total, err := userService.GetUsers(&query)
+	if err != nil {
+		utils.ServerError(c)
+		return
+	}
+
+	utils.PageSuccess(c, users, total, query.Page, query.PageSize)
+}
+
+// GetUserDetail 获取用户详情（管理员）
+func GetUserDetail(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := userService.GetUserDetail(userID)
+	if err != nil {
+		userManageError(c, err)
+		return
+	}
+
+	utils.Success(c, user)
+}
+
+// UpdateUser 更新用户资料（管理员）
+func UpdateUser(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req AdminUpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+	if req.Phone != nil && *req.Phone != "" && !isE164(*req.Phone) {
+		utils.ParamError(c, "手机号格式错误")
+		return
+	}
+	if req.Email != nil && *req.Email != "" && !isEmail(*req.Email) {
+		utils.ParamError(c, "邮箱格式错误")
+		return
+	}
+
+	before, err := userService.GetUserDetail(userID)
+	if err != nil {
+		userManageError(c, err)
+		return
+	}
+
+	user, err := userService.UpdateUser(c.Request.Context(), c.GetUint64("user_id"), userID, &service.AdminUserUpdate{
+		Nickname: req.Nickname,
+		Avatar:   req.Avatar,
+		Phone:    req.Phone,
+		Email:    req.Email,
+		Status:   req.Status,
+	})
+	if err != nil {
+		userManageError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUpdate, models.AuditTargetUser, userID, service.Snapshot(before), service.Snapshot(user))
+
+	utils.Updated(c, user)
+}
+
+// UpdateUserStatus 更新用户状态（管理员）
+func UpdateUserStatus(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status *int `json:"status" binding:"required,oneof=0 1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(c, "请求参数格式错误")
+		return
+	}
+
+	before, err := userService.GetUserDetail(userID)
+	if err != nil {
+		userManageError(c, err)
+		return
+	}
+
+	// 禁用账号时吊销其所有token
+	user, err := userService.UpdateStatus(c.Request.Context(), c.GetUint64("user_id"), userID, *req.Status)
+	if err != nil {
+		userManageError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUpdateStatus, models.AuditTargetUser, userID, service.Snapshot(before), service.Snapshot(user))
+
+	utils.Success(c, map[string]string{
+		"message": "状态更新成功",
+	})
+}
+
+// DeleteUser 删除用户（管理员），清除个人信息后软删除
+func DeleteUser(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	before, err := userService.GetUserDetail(userID)
+	if err != nil {
+		userManageError(c, err)
+		return
+	}
+
+	if err := userService.DeleteUser(c.Request.Context(), c.GetUint64("user_id"), userID); err != nil {
+		userManageError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionDelete, models.AuditTargetUser, userID, service.Snapshot(before), nil)
+
+	utils.Deleted(c)
+}
@@ -49,6 +49,7 @@ func JWTAuth() gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("claims", claims)
 
 		// 继续处理请求
 		c.Next()
@@ -71,30 +72,14 @@ func GetCurrentUser(c *gin.Context) (userID uint64, username string, role string
 	return 0, "", "", false
 }
 
-// RequireAdmin 要求管理员权限
-func RequireAdmin() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		_, _, role, ok := GetCurrentUser(c)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    401,
-				"message": "User not authenticated",
-			})
-			c.Abort()
-			return
-		}
-
-		if role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"code":    403,
-				"message": "Admin privileges required",
-			})
-			c.Abort()
-			return
-		}
-
-		c.Next()
+// GetCurrentClaims 从context中获取当前token声明
+func GetCurrentClaims(c *gin.Context) (*utils.JWTClaims, bool) {
+	value, exists := c.Get("claims")
+	if !exists {
+		return nil, false
 	}
+	claims, ok := value.(*utils.JWTClaims)
+	return claims, ok
 }
 
 // OptionalAuth 可选认证（允许未登录用户访问）
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"online-mall/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rbacService 角色权限服务实例
+var rbacService = service.NewRBACService()
+
+// RequirePermission 要求当前用户拥有全部指定权限，需在JWTAuth之后使用
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _, _, ok := GetCurrentUser(c)
+		if !ok || userID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "User not authenticated",
+			})
+			c.Abort()
+			return
+		}
+
+		allowed, err := rbacService.HasPermissions(c.Request.Context(), userID, permissions...)
+		if err != nil {
+			log.Printf("Failed to check permissions: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "Failed to check permissions",
+			})
+			c.Abort()
+			return
+		}
+
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "Permission denied",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
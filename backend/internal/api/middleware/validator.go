@@ -1,8 +1,12 @@
 package middleware
 
 import (
+	"fmt"
+	"log"
 	"net/http"
+	"online-mall/internal/utils"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/locales/zh"
@@ -158,27 +162,6 @@ func ValidateEmail(email string) bool {
 	return strings.Contains(email, "@") && strings.Contains(email, ".")
 }
 
-// ValidatePassword 密码验证
-func ValidatePassword(password string) bool {
-	// 密码长度至少6位，包含字母和数字
-	if len(password) < 6 {
-		return false
-	}
-
-	hasLetter := false
-	hasDigit := false
-
-	for _, char := range password {
-		if (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') {
-			hasLetter = true
-		} else if char >= '0' && char <= '9' {
-			hasDigit = true
-		}
-	}
-
-	return hasLetter && hasDigit
-}
-
 // SanitizeInput 输入清理中间件
 func SanitizeInput() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -202,19 +185,24 @@ func SanitizeInput() gin.HandlerFunc {
 	}
 }
 
-// RateLimiter 限流中间件（简化版本）
-func RateLimiter() gin.HandlerFunc {
-	// 这里可以使用Redis实现限流
-	// 为了简化，使用内存map
-	requests := make(map[string]int)
-
+// RateLimiter 基于Redis的IP限流中间件（固定窗口，窗口结束后自动重置）
+func RateLimiter(limit int64, window time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
-
-		// 简单的计数器实现
-		requests[ip]++
+		ctx := c.Request.Context()
+		key := fmt.Sprintf(utils.RateLimitKey, c.FullPath(), c.ClientIP())
+
+		count, err := utils.Incr(ctx, key)
+		if err != nil {
+			// Redis不可用时不影响正常请求
+			log.Printf("Rate limiter unavailable: %v", err)
+			c.Next()
+			return
+		}
+		if count == 1 {
+			_ = utils.Expire(ctx, key, window)
+		}
 
-		if requests[ip] > 100 { // 每IP每分钟100次请求
+		if count > limit {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"code":    429,
 				"message": "请求过于频繁，请稍后再试",
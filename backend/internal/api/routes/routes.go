@@ -4,6 +4,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"online-mall/internal/api/controller"
 	"online-mall/internal/api/middleware"
+	"online-mall/internal/models"
+	"time"
 )
 
 // SetupRoutes 设置路由
@@ -30,10 +32,17 @@ func SetupRoutes() *gin.Engine {
 	{
 		// 认证相关路由（不需要JWT）
 		auth := api.Group("/auth")
+		auth.Use(middleware.RateLimiter(30, time.Minute))
 		{
 			auth.POST("/login", controller.Login)
+			auth.POST("/login/code", controller.LoginByCode)
+			auth.POST("/2fa/setup", controller.TwoFactorLoginSetup)
+			auth.POST("/2fa/verify", controller.TwoFactorLoginVerify)
+			auth.POST("/verify-code", controller.SendVerifyCode)
 			auth.POST("/register", controller.Register)
 			auth.POST("/refresh-token", controller.RefreshToken)
+			auth.POST("/forgot-password", controller.ForgotPassword)
+			auth.POST("/reset-password", controller.ResetPassword)
 			auth.POST("/logout", middleware.JWTAuth(), controller.Logout)
 		}
 
@@ -46,18 +55,70 @@ func SetupRoutes() *gin.Engine {
 			user.PUT("/profile", controller.UpdateUserInfo)
 			user.PUT("/password", controller.UpdatePassword)
 
-			// 管理员路由 - 待实现
-			/*
-				admin := user.Group("")
-				admin.Use(middleware.RequireAdmin())
-				{
-					admin.GET("", controller.GetUserList)
-					admin.GET("/:id", controller.GetUserDetail)
-					admin.PUT("/:id", controller.UpdateUser)
-					admin.PUT("/:id/status", controller.UpdateUserStatus)
-					admin.DELETE("/:id", controller.DeleteUser)
-				}
-			*/
+			// 登录设备管理
+			user.GET("/sessions", controller.GetSessions)
+			user.DELETE("/sessions/others", controller.DeleteOtherSessions)
+			user.DELETE("/sessions/:id", controller.DeleteSession)
+
+			// 双因素认证
+			user.GET("/2fa", controller.GetTwoFactorStatus)
+			user.POST("/2fa/setup", controller.SetupTwoFactor)
+			user.POST("/2fa/enable", controller.EnableTwoFactor)
+			user.POST("/2fa/disable", controller.DisableTwoFactor)
+			user.POST("/2fa/recovery-codes", controller.RegenerateRecoveryCodes)
+
+			// 当前用户的角色和权限
+			user.GET("/permissions", controller.GetMyPermissions)
+
+			// 个人数据导出和账号注销
+			user.GET("/data-export", controller.GetDataExports)
+			user.POST("/data-export", controller.RequestDataExport)
+			user.GET("/deletion", controller.GetAccountDeletion)
+			user.POST("/deletion", controller.RequestAccountDeletion)
+			user.DELETE("/deletion", controller.CancelAccountDeletion)
+
+			// 管理员路由
+			adminRead := user.Group("")
+			adminRead.Use(middleware.RequirePermission(models.PermUserRead))
+			{
+				adminRead.GET("", controller.GetUserList)
+				adminRead.GET("/:id", controller.GetUserDetail)
+			}
+
+			admin := user.Group("")
+			admin.Use(middleware.RequirePermission(models.PermUserWrite))
+			{
+				admin.PUT("/:id", controller.UpdateUser)
+				admin.PUT("/:id/status", controller.UpdateUserStatus)
+				admin.DELETE("/:id", controller.DeleteUser)
+			}
+
+			// 用户角色分配
+			userRoles := user.Group("")
+			userRoles.Use(middleware.RequirePermission(models.PermRoleManage))
+			{
+				userRoles.GET("/:id/roles", controller.GetUserRoles)
+				userRoles.PUT("/:id/roles", controller.UpdateUserRoles)
+			}
+		}
+
+		// 角色权限管理路由
+		roles := api.Group("/roles")
+		roles.Use(middleware.JWTAuth(), middleware.RequirePermission(models.PermRoleManage))
+		{
+			roles.GET("", controller.GetRoles)
+			roles.POST("", controller.CreateRole)
+			roles.GET("/permissions", controller.GetPermissions)
+			roles.GET("/:id", controller.GetRole)
+			roles.PUT("/:id", controller.UpdateRole)
+			roles.DELETE("/:id", controller.DeleteRole)
+		}
+
+		// 审计日志路由
+		auditLogs := api.Group("/audit-logs")
+		auditLogs.Use(middleware.JWTAuth(), middleware.RequirePermission(models.PermAuditRead))
+		{
+			auditLogs.GET("", controller.GetAuditLogs)
 		}
 
 		// 地址管理路由 - 待实现
@@ -78,19 +139,24 @@ func SetupRoutes() *gin.Engine {
 		products := api.Group("/products")
 		{
 			products.GET("", controller.GetProducts)
-			products.GET("/:id", controller.GetProduct)
-			products.GET("/:id/skus", controller.GetProductSkus)
+			products.GET("/:id", middleware.OptionalAuth(), controller.GetProduct)
+			products.GET("/:id/skus", middleware.OptionalAuth(), controller.GetProductSkus)
+			products.GET("/:id/related", controller.GetRelatedProducts)
+			products.GET("/:id/reviews", controller.GetProductReviews)
 			products.GET("/hot", controller.GetHotProducts)
 			products.GET("/new", controller.GetNewProducts)
+			products.GET("/popular", controller.GetPopularProducts)
 
 			// 管理员路由
 			adminProducts := products.Group("")
-			adminProducts.Use(middleware.RequireAdmin())
+			adminProducts.Use(middleware.JWTAuth(), middleware.RequirePermission(models.PermProductWrite))
 			{
 				adminProducts.POST("", controller.CreateProduct)
 				adminProducts.PUT("/:id", controller.UpdateProduct)
 				adminProducts.DELETE("/:id", controller.DeleteProduct)
 				adminProducts.PUT("/:id/status", controller.UpdateProductStatus)
+				adminProducts.PUT("/:id/skus/:sku_id/member-prices", controller.UpdateSKUMemberPrices)
+				adminProducts.PUT("/:id/skus/:sku_id/stock", controller.AdjustSKUStock)
 			}
 		}
 
@@ -104,7 +170,7 @@ func SetupRoutes() *gin.Engine {
 
 			// 管理员路由
 			adminCategories := categories.Group("")
-			adminCategories.Use(middleware.RequireAdmin())
+			adminCategories.Use(middleware.JWTAuth(), middleware.RequirePermission(models.PermCategoryWrite))
 			{
 				adminCategories.POST("", controller.CreateCategory)
 				adminCategories.PUT("/:id", controller.UpdateCategory)
@@ -113,6 +179,210 @@ func SetupRoutes() *gin.Engine {
 			}
 		}
 
+		// 会员路由
+		members := api.Group("/members")
+		{
+			members.GET("/levels", controller.GetMemberLevels)
+
+			me := members.Group("/me")
+			me.Use(middleware.JWTAuth())
+			{
+				me.GET("", controller.GetMyMember)
+				me.GET("/growth-logs", controller.GetMyGrowthLogs)
+			}
+
+			// 管理员路由
+			adminLevels := members.Group("/levels")
+			adminLevels.Use(middleware.JWTAuth(), middleware.RequirePermission(models.PermMemberManage))
+			{
+				adminLevels.POST("", controller.CreateMemberLevel)
+				adminLevels.PUT("/:id", controller.UpdateMemberLevel)
+				adminLevels.DELETE("/:id", controller.DeleteMemberLevel)
+			}
+		}
+
+		// 积分路由
+		points := api.Group("/points")
+		points.Use(middleware.JWTAuth())
+		{
+			points.GET("", controller.GetMyPoints)
+			points.GET("/logs", controller.GetMyPointsLogs)
+		}
+
+		// 收藏路由
+		favorites := api.Group("/favorites")
+		favorites.Use(middleware.JWTAuth())
+		{
+			favorites.GET("", controller.GetFavorites)
+			favorites.POST("", controller.AddFavorite)
+			favorites.DELETE("/:id", controller.RemoveFavorite)
+			favorites.GET("/products/:product_id", controller.GetProductFavorites)
+		}
+
+		// 首页
+		api.GET("/home", middleware.OptionalAuth(), controller.GetHomeFeed)
+
+		// 首页内容位路由
+		contentSlots := api.Group("/content-slots")
+		{
+			contentSlots.GET("/active", controller.GetActiveContentSlots)
+
+			// 管理员路由
+			adminContentSlots := contentSlots.Group("")
+			adminContentSlots.Use(middleware.JWTAuth(), middleware.RequirePermission(models.PermMarketing))
+			{
+				adminContentSlots.GET("", controller.GetContentSlots)
+				adminContentSlots.POST("", controller.CreateContentSlot)
+				adminContentSlots.PUT("/:id", controller.UpdateContentSlot)
+				adminContentSlots.DELETE("/:id", controller.DeleteContentSlot)
+			}
+		}
+
+		// 秒杀路由
+		flashSales := api.Group("/flash-sales")
+		{
+			flashSales.GET("", controller.GetFlashSales)
+			flashSales.GET("/:id", controller.GetFlashSale)
+			flashSales.POST("/:id/buy", middleware.JWTAuth(), middleware.RateLimiter(20, time.Minute), controller.BuyFlashSale)
+			flashSales.GET("/requests/:request_id", middleware.JWTAuth(), controller.GetFlashSaleResult)
+
+			// 管理员路由
+			adminFlashSales := flashSales.Group("")
+			adminFlashSales.Use(middleware.JWTAuth(), middleware.RequirePermission(models.PermMarketing))
+			{
+				adminFlashSales.GET("/all", controller.GetAdminFlashSales)
+				adminFlashSales.POST("", controller.CreateFlashSale)
+				adminFlashSales.PUT("/:id", controller.UpdateFlashSale)
+				adminFlashSales.PUT("/:id/status", controller.UpdateFlashSaleStatus)
+				adminFlashSales.DELETE("/:id", controller.DeleteFlashSale)
+			}
+		}
+
+		// 拼团路由
+		groupBuys := api.Group("/group-buys")
+		{
+			groupBuys.GET("", controller.GetGroupBuys)
+			groupBuys.GET("/:id", controller.GetGroupBuy)
+			groupBuys.GET("/groups/:code", controller.GetGroup)
+			groupBuys.POST("/:id/groups", middleware.JWTAuth(), controller.OpenGroup)
+			groupBuys.POST("/groups/:code/join", middleware.JWTAuth(), controller.JoinGroup)
+			groupBuys.GET("/my", middleware.JWTAuth(), controller.GetMyGroups)
+
+			// 管理员路由
+			adminGroupBuys := groupBuys.Group("")
+			adminGroupBuys.Use(middleware.JWTAuth(), middleware.RequirePermission(models.PermMarketing))
+			{
+				adminGroupBuys.GET("/all", controller.GetAdminGroupBuys)
+				adminGroupBuys.GET("/:id/groups", controller.GetGroupBuyGroups)
+				adminGroupBuys.POST("", controller.CreateGroupBuy)
+				adminGroupBuys.PUT("/:id", controller.UpdateGroupBuy)
+				adminGroupBuys.PUT("/:id/status", controller.UpdateGroupBuyStatus)
+				adminGroupBuys.POST("/members/:member_id/refund", controller.RetryGroupRefund)
+			}
+		}
+
+		// 促销路由
+		promotions := api.Group("/promotions")
+		{
+			promotions.GET("", controller.GetPromotions)
+
+			// 管理员路由
+			adminPromotions := promotions.Group("")
+			adminPromotions.Use(middleware.JWTAuth(), middleware.RequirePermission(models.PermMarketing))
+			{
+				adminPromotions.GET("/all", controller.GetAdminPromotions)
+				adminPromotions.POST("", controller.CreatePromotion)
+				adminPromotions.PUT("/:id", controller.UpdatePromotion)
+				adminPromotions.PUT("/:id/status", controller.UpdatePromotionStatus)
+				adminPromotions.DELETE("/:id", controller.DeletePromotion)
+			}
+		}
+
+		// 推荐路由
+		recommendations := api.Group("/recommendations")
+		{
+			recommendations.GET("/cart", middleware.OptionalAuth(), controller.GetCartRecommendations)
+		}
+
+		// 浏览记录路由
+		browseHistory := api.Group("/browse-history")
+		browseHistory.Use(middleware.JWTAuth())
+		{
+			browseHistory.GET("", controller.GetBrowseHistory)
+			browseHistory.DELETE("", controller.ClearBrowseHistory)
+			browseHistory.DELETE("/:product_id", controller.DeleteBrowseHistory)
+		}
+
+		// 到货提醒路由
+		stockSubscriptions := api.Group("/stock-subscriptions")
+		stockSubscriptions.Use(middleware.JWTAuth())
+		{
+			stockSubscriptions.GET("", controller.GetStockSubscriptions)
+			stockSubscriptions.POST("", controller.SubscribeRestock)
+			stockSubscriptions.DELETE("/:id", controller.UnsubscribeRestock)
+		}
+
+		// 站内通知路由
+		notifications := api.Group("/notifications")
+		notifications.Use(middleware.JWTAuth())
+		{
+			notifications.GET("", controller.GetNotifications)
+			notifications.GET("/unread-count", controller.GetUnreadNotificationCount)
+			notifications.PUT("/read-all", controller.ReadAllNotifications)
+			notifications.PUT("/:id/read", controller.ReadNotification)
+		}
+
+		// 签到路由
+		checkin := api.Group("/checkin")
+		checkin.Use(middleware.JWTAuth())
+		{
+			checkin.POST("", controller.Checkin)
+			checkin.GET("/calendar", controller.GetCheckinCalendar)
+		}
+
+		// 任务路由
+		tasks := api.Group("/tasks")
+		tasks.Use(middleware.JWTAuth())
+		{
+			tasks.GET("", controller.GetMyTasks)
+
+			// 管理员路由
+			adminTasks := tasks.Group("")
+			adminTasks.Use(middleware.RequirePermission(models.PermMarketing))
+			{
+				adminTasks.GET("/all", controller.GetAllTasks)
+				adminTasks.PUT("/:id", controller.UpdateTask)
+			}
+		}
+
+		// 支付通知路由（由支付网关调用，通过签名认证）
+		payments := api.Group("/payments")
+		{
+			payments.POST("/notify", controller.PaymentNotify)
+		}
+
+		// 订单路由
+		orders := api.Group("/orders")
+		orders.Use(middleware.JWTAuth())
+		{
+			orders.GET("", controller.GetOrderList)
+			orders.GET("/:id", controller.GetOrderDetail)
+			orders.POST("", controller.CreateOrder)
+			orders.PUT("/:id/cancel", controller.CancelOrder)
+			orders.PUT("/:id/receive", controller.ReceiveOrder)
+			orders.POST("/:id/reviews", controller.CreateReview)
+
+			// 管理员路由
+			orders.PUT("/:id/ship", middleware.RequirePermission(models.PermOrderShip), controller.ShipOrder)
+			orders.POST("/:id/refund", middleware.RequirePermission(models.PermOrderWrite), controller.RetryOrderRefund)
+		}
+
+		checkout := api.Group("/checkout")
+		checkout.Use(middleware.JWTAuth())
+		{
+			checkout.POST("/preview", controller.PreviewCheckout)
+		}
+
 		// 购物车路由 - 待实现
 		/*
 			cart := api.Group("/cart")
@@ -130,25 +400,9 @@ func SetupRoutes() *gin.Engine {
 
 		// 订单路由 - 待实现
 		/*
-			orders := api.Group("/orders")
-			orders.Use(middleware.JWTAuth())
-			{
-				orders.GET("", controller.GetOrderList)
-				orders.GET("/:id", controller.GetOrderDetail)
-				orders.POST("", controller.CreateOrder)
-				orders.PUT("/:id/cancel", controller.CancelOrder)
-				orders.PUT("/:id/receive", controller.ReceiveOrder)
-				orders.DELETE("/:id", controller.DeleteOrder)
-
-				// 管理员路由
-				adminOrders := orders.Group("")
-				adminOrders.Use(middleware.RequireAdmin())
-				{
-					adminOrders.PUT("/:id/status", controller.UpdateOrderStatus)
-					adminOrders.PUT("/:id/ship", controller.ShipOrder)
-					adminOrders.GET("/statistics", controller.GetOrderStatistics)
-				}
-			}
+			orders.DELETE("/:id", controller.DeleteOrder)
+			orders.PUT("/:id/status", middleware.RequirePermission(models.PermOrderWrite), controller.UpdateOrderStatus)
+			orders.GET("/statistics", middleware.RequirePermission(models.PermOrderRead), controller.GetOrderStatistics)
 		*/
 
 		// 优惠券路由 - 待实现
@@ -168,7 +422,7 @@ func SetupRoutes() *gin.Engine {
 
 				// 管理员路由
 				adminCoupons := coupons.Group("")
-				adminCoupons.Use(middleware.RequireAdmin())
+				adminCoupons.Use(middleware.JWTAuth(), middleware.RequirePermission(models.PermCouponWrite))
 				{
 					adminCoupons.POST("", controller.CreateCoupon)
 					adminCoupons.PUT("/:id", controller.UpdateCoupon)
@@ -183,6 +437,9 @@ func SetupRoutes() *gin.Engine {
 
 		// 上传路由 - 待实现
 		// api.POST("/upload", middleware.JWTAuth(), controller.UploadFile)
+
+		// 私有文件下载（签名链接，绑定用户的链接需登录）
+		api.GET("/files/download", middleware.OptionalAuth(), controller.DownloadFile)
 	}
 
 	// 静态文件服务
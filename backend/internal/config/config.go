@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"github.com/spf13/viper"
 	"log"
 	"os"
@@ -9,12 +10,32 @@ import (
 
 // Config 应用配置结构体
 type Config struct {
-	App      AppConfig      `mapstructure:"app"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Upload   UploadConfig   `mapstructure:"upload"`
-	Log      LogConfig      `mapstructure:"log"`
+	App          AppConfig          `mapstructure:"app"`
+	Database     DatabaseConfig     `mapstructure:"database"`
+	Redis        RedisConfig        `mapstructure:"redis"`
+	JWT          JWTConfig          `mapstructure:"jwt"`
+	Upload       UploadConfig       `mapstructure:"upload"`
+	Log          LogConfig          `mapstructure:"log"`
+	Login        LoginConfig        `mapstructure:"login"`
+	Notify       NotifyConfig       `mapstructure:"notify"`
+	Verify       VerifyConfig       `mapstructure:"verify"`
+	Password     PasswordConfig     `mapstructure:"password"`
+	TwoFactor    TwoFactorConfig    `mapstructure:"two_factor"`
+	Account      AccountConfig      `mapstructure:"account"`
+	Member       MemberConfig       `mapstructure:"member"`
+	Checkout     CheckoutConfig     `mapstructure:"checkout"`
+	Points       PointsConfig       `mapstructure:"points"`
+	Checkin      CheckinConfig      `mapstructure:"checkin"`
+	Notification NotificationConfig `mapstructure:"notification"`
+	Favorite     FavoriteConfig     `mapstructure:"favorite"`
+	Restock      RestockConfig      `mapstructure:"restock"`
+	Browse       BrowseConfig       `mapstructure:"browse"`
+	Recommend    RecommendConfig    `mapstructure:"recommend"`
+	Home         HomeConfig         `mapstructure:"home"`
+	FlashSale    FlashSaleConfig    `mapstructure:"flash_sale"`
+	Payment      PaymentConfig      `mapstructure:"payment"`
+	GroupBuy     GroupBuyConfig     `mapstructure:"group_buy"`
+	Order        OrderConfig        `mapstructure:"order"`
 }
 
 // AppConfig 应用配置
@@ -49,16 +70,20 @@ type RedisConfig struct {
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	Secret      string `mapstructure:"secret"`
-	ExpireHours int    `mapstructure:"expire_hours"`
-	Issuer      string `mapstructure:"issuer"`
+	Secret              string `mapstructure:"secret"`
+	AccessExpireMinutes int    `mapstructure:"access_expire_minutes"` // 访问令牌有效期（分钟）
+	RefreshExpireDays   int    `mapstructure:"refresh_expire_days"`   // 刷新令牌有效期（天）
+	Issuer              string `mapstructure:"issuer"`
 }
 
 // UploadConfig 文件上传配置
 type UploadConfig struct {
-	Path         string   `mapstructure:"path"`
-	MaxSize      int      `mapstructure:"max_size"`
-	AllowedTypes []string `mapstructure:"allowed_types"`
+	Path              string   `mapstructure:"path"`
+	MaxSize           int      `mapstructure:"max_size"`
+	AllowedTypes      []string `mapstructure:"allowed_types"`
+	PrivatePath       string   `mapstructure:"private_path"`        // 私有文件目录（不通过/static暴露）
+	SignSecret        string   `mapstructure:"sign_secret"`         // 下载链接签名密钥，必须配置
+	SignExpireMinutes int      `mapstructure:"sign_expire_minutes"` // 下载链接默认有效期（分钟）
 }
 
 // LogConfig 日志配置
@@ -71,6 +96,196 @@ type LogConfig struct {
 	Compress   bool   `mapstructure:"compress"`
 }
 
+// LoginConfig 登录防护配置
+type LoginConfig struct {
+	FailureWindowMinutes int `mapstructure:"failure_window_minutes"` // 失败次数统计窗口（分钟）
+	MaxAccountFailures   int `mapstructure:"max_account_failures"`   // 单账号失败多少次后锁定
+	MaxIPFailures        int `mapstructure:"max_ip_failures"`        // 单IP失败多少次后锁定
+	LockMinutes          int `mapstructure:"lock_minutes"`           // 锁定时长（分钟）
+	DelayAfterFailures   int `mapstructure:"delay_after_failures"`   // 失败多少次后开始递增延迟
+	BaseDelaySeconds     int `mapstructure:"base_delay_seconds"`     // 初始延迟（秒），之后每次翻倍
+	MaxDelaySeconds      int `mapstructure:"max_delay_seconds"`      // 最大延迟（秒）
+	CaptchaThreshold     int `mapstructure:"captcha_threshold"`      // 失败多少次后要求验证码，0表示不启用
+}
+
+// NotifyConfig 消息通知配置
+type NotifyConfig struct {
+	Driver   string     `mapstructure:"driver"`    // log-打印日志，file-写入文件，live-真实发送
+	FilePath string     `mapstructure:"file_path"` // file驱动的输出文件
+	SMS      SMSConfig  `mapstructure:"sms"`
+	SMTP     SMTPConfig `mapstructure:"smtp"`
+}
+
+// SMSConfig 短信网关配置
+type SMSConfig struct {
+	Endpoint string `mapstructure:"endpoint"`  // 短信网关地址
+	APIKey   string `mapstructure:"api_key"`   // 网关访问密钥
+	SignName string `mapstructure:"sign_name"` // 短信签名
+	Timeout  int    `mapstructure:"timeout"`   // 请求超时（秒）
+}
+
+// SMTPConfig 邮件发送配置
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
+// VerifyConfig 验证码配置
+type VerifyConfig struct {
+	CodeLength    int `mapstructure:"code_length"`    // 验证码位数
+	ExpireMinutes int `mapstructure:"expire_minutes"` // 有效期（分钟）
+	ResendSeconds int `mapstructure:"resend_seconds"` // 重发间隔（秒）
+	MaxAttempts   int `mapstructure:"max_attempts"`   // 单个验证码最多校验次数
+	DailyLimit    int `mapstructure:"daily_limit"`    // 每个号码每天最多发送次数
+
+	ResetExpireMinutes int    `mapstructure:"reset_expire_minutes"` // 密码重置链接有效期（分钟）
+	ResetURL           string `mapstructure:"reset_url"`            // 密码重置页面地址，token作为查询参数附加
+}
+
+// PasswordConfig 密码策略配置
+type PasswordConfig struct {
+	MinLength      int  `mapstructure:"min_length"`      // 最小长度
+	MaxLength      int  `mapstructure:"max_length"`      // 最大长度
+	RequireLetter  bool `mapstructure:"require_letter"`  // 必须包含字母
+	RequireUpper   bool `mapstructure:"require_upper"`   // 必须包含大写字母
+	RequireLower   bool `mapstructure:"require_lower"`   // 必须包含小写字母
+	RequireDigit   bool `mapstructure:"require_digit"`   // 必须包含数字
+	RequireSymbol  bool `mapstructure:"require_symbol"`  // 必须包含特殊字符
+	ForbidIdentity bool `mapstructure:"forbid_identity"` // 禁止包含用户名、手机号、邮箱
+	CheckCommon    bool `mapstructure:"check_common"`    // 检查常见泄露密码
+}
+
+// TwoFactorConfig 双因素认证配置
+type TwoFactorConfig struct {
+	Issuer            string `mapstructure:"issuer"`              // 验证器App中显示的发行方
+	EncryptKey        string `mapstructure:"encrypt_key"`         // TOTP密钥加密存储使用的密钥
+	Skew              int    `mapstructure:"skew"`                // 允许的时钟偏差（时间步，每步30秒）
+	ChallengeMinutes  int    `mapstructure:"challenge_minutes"`   // 登录第二步的有效期（分钟）
+	MaxAttempts       int    `mapstructure:"max_attempts"`        // 登录第二步最多尝试次数
+	RecoveryCodeCount int    `mapstructure:"recovery_code_count"` // 恢复码数量
+}
+
+// AccountConfig 数据导出和账号注销配置
+type AccountConfig struct {
+	DeletionCoolingDays   int `mapstructure:"deletion_cooling_days"`   // 注销冷静期（天），期间可撤销
+	ExportExpireHours     int `mapstructure:"export_expire_hours"`     // 导出文件保留时长（小时）
+	ExportCooldownMinutes int `mapstructure:"export_cooldown_minutes"` // 两次导出的最小间隔（分钟）
+	WorkerIntervalMinutes int `mapstructure:"worker_interval_minutes"` // 后台任务执行间隔（分钟）
+}
+
+// MemberConfig 会员成长值配置
+type MemberConfig struct {
+	GrowthPerYuan float64 `mapstructure:"growth_per_yuan"` // 订单实付每1元获得的成长值
+}
+
+// CheckoutConfig 结算配置
+type CheckoutConfig struct {
+	Freight               float64 `mapstructure:"freight"`                 // 默认运费
+	FreeShippingThreshold float64 `mapstructure:"free_shipping_threshold"` // 全站满额包邮门槛，0表示不包邮
+}
+
+// PointsConfig 积分配置
+type PointsConfig struct {
+	EarnPerYuan           float64 `mapstructure:"earn_per_yuan"`           // 订单实付每1元获得的积分
+	ReviewPoints          int64   `mapstructure:"review_points"`           // 评价奖励积分
+	ExpireDays            int     `mapstructure:"expire_days"`             // 积分有效期（天），按获得批次分别计算
+	PointsPerYuan         int64   `mapstructure:"points_per_yuan"`         // 抵扣比例，多少积分抵1元
+	MaxRedeemRatio        float64 `mapstructure:"max_redeem_ratio"`        // 单笔订单最多抵扣商品金额的比例
+	MinRedeemPoints       int64   `mapstructure:"min_redeem_points"`       // 单笔最少使用积分
+	WorkerIntervalMinutes int     `mapstructure:"worker_interval_minutes"` // 过期处理执行间隔（分钟）
+}
+
+// CheckinConfig 签到配置
+type CheckinConfig struct {
+	Rewards []int64 `mapstructure:"rewards"` // 连续签到第N天获得的积分，超过后按最后一档发放
+}
+
+// NotificationConfig 站内通知配置
+type NotificationConfig struct {
+	Email bool `mapstructure:"email"` // 是否同时发送邮件（仅发送给已验证邮箱的用户）
+}
+
+// FavoriteConfig 收藏配置
+type FavoriteConfig struct {
+	MaxItems              int `mapstructure:"max_items"`               // 每个用户最多收藏数量
+	WorkerIntervalMinutes int `mapstructure:"worker_interval_minutes"` // 降价/到货检查间隔（分钟）
+}
+
+// RestockConfig 到货提醒配置
+type RestockConfig struct {
+	BatchSize             int `mapstructure:"batch_size"`              // 每批发送的通知数
+	MaxPerUserHour        int `mapstructure:"max_per_user_hour"`       // 每个用户每小时最多收到的到货提醒，超出的延后发送
+	WorkerIntervalMinutes int `mapstructure:"worker_interval_minutes"` // 补发延后和失败提醒的检查间隔（分钟）
+}
+
+// BrowseConfig 浏览记录配置
+type BrowseConfig struct {
+	HistorySize          int `mapstructure:"history_size"`           // 每个用户保留的最近浏览商品数
+	HistoryDays          int `mapstructure:"history_days"`           // 最近浏览记录的保留天数（自最后一次浏览起）
+	FlushIntervalMinutes int `mapstructure:"flush_interval_minutes"` // 每日浏览量写入数据库的间隔（分钟）
+	PopularDays          int `mapstructure:"popular_days"`           // 人气排行统计的天数
+}
+
+// RecommendConfig 关联推荐配置
+type RecommendConfig struct {
+	TopN              int     `mapstructure:"top_n"`                // 每个商品保留的关联商品数
+	LookbackDays      int     `mapstructure:"lookback_days"`        // 统计最近多少天的订单
+	MinSupport        int     `mapstructure:"min_support"`          // 共同购买订单数或共同浏览次数的最小值，低于此值视为偶然
+	MaxBasketSize     int     `mapstructure:"max_basket_size"`      // 单个订单参与计算的最多商品数，浏览时最多与多少条最近浏览记录组成共同浏览
+	CoViewWindowHours int     `mapstructure:"co_view_window_hours"` // 同一用户在此时间内浏览的商品视为共同浏览
+	PurchaseWeight    float64 `mapstructure:"purchase_weight"`      // 共同购买相似度的权重
+	ViewWeight        float64 `mapstructure:"view_weight"`          // 共同浏览相似度的权重
+	IntervalHours     int     `mapstructure:"interval_hours"`       // 计算任务执行间隔（小时）
+}
+
+// HomeConfig 首页配置
+type HomeConfig struct {
+	BannerSize           int `mapstructure:"banner_size"`            // 轮播图数量
+	CategorySize         int `mapstructure:"category_size"`          // 分类导航数量
+	RecommendSize        int `mapstructure:"recommend_size"`         // 推荐商品数量
+	ListSize             int `mapstructure:"list_size"`              // 热门、新品商品数量
+	CacheSeconds         int `mapstructure:"cache_seconds"`          // 公共区块缓存时间（秒）
+	PersonalCacheSeconds int `mapstructure:"personal_cache_seconds"` // 个性化推荐缓存时间（秒）
+}
+
+// FlashSaleConfig 秒杀配置
+type FlashSaleConfig struct {
+	QueueWorkers          int `mapstructure:"queue_workers"`           // 每个实例消费下单队列的协程数
+	MaxAttempts           int `mapstructure:"max_attempts"`            // 下单失败（数据库异常等）的最多尝试次数
+	PayTimeoutMinutes     int `mapstructure:"pay_timeout_minutes"`     // 秒杀订单未支付自动取消的时间（分钟）
+	ResultTTLMinutes      int `mapstructure:"result_ttl_minutes"`      // 抢购结果在Redis中的保留时间（分钟）
+	InfoCacheSeconds      int `mapstructure:"info_cache_seconds"`      // 活动信息缓存时间（秒）
+	WorkerIntervalSeconds int `mapstructure:"worker_interval_seconds"` // 库存预热、超时取消和结算任务的间隔（秒）
+}
+
+// PaymentConfig 支付渠道配置
+type PaymentConfig struct {
+	Driver       string `mapstructure:"driver"`        // log-打印日志（退款总是成功），live-调用支付网关
+	Endpoint     string `mapstructure:"endpoint"`      // 支付网关地址
+	APIKey       string `mapstructure:"api_key"`       // 网关访问密钥
+	NotifySecret string `mapstructure:"notify_secret"` // 支付成功通知的签名密钥，为空时拒绝所有通知
+	Timeout      int    `mapstructure:"timeout"`       // 请求超时（秒）
+}
+
+// GroupBuyConfig 拼团配置
+type GroupBuyConfig struct {
+	PayTimeoutMinutes     int `mapstructure:"pay_timeout_minutes"`     // 拼团订单未支付自动取消的时间（分钟）
+	OpenGroupLimit        int `mapstructure:"open_group_limit"`        // 活动详情展示的可参与团数量
+	MaxRefundAttempts     int `mapstructure:"max_refund_attempts"`     // 拼团失败自动退款的最多尝试次数，超过后需管理员重试
+	WorkerIntervalSeconds int `mapstructure:"worker_interval_seconds"` // 超时取消、成团检查和退款任务的间隔（秒）
+}
+
+// OrderConfig 订单配置
+type OrderConfig struct {
+	PayTimeoutMinutes     int `mapstructure:"pay_timeout_minutes"`     // 普通订单未支付自动取消的时间（分钟）
+	AutoConfirmDays       int `mapstructure:"auto_confirm_days"`       // 发货后多少天未确认收货自动确认
+	MaxRefundAttempts     int `mapstructure:"max_refund_attempts"`     // 取消后才收到支付的订单自动退款的最多尝试次数，超过后需管理员重试
+	WorkerIntervalSeconds int `mapstructure:"worker_interval_seconds"` // 超时取消、自动确认收货和退款任务的间隔（秒）
+}
+
 // GlobalConfig 全局配置变量
 var GlobalConfig *Config
 
@@ -103,14 +318,17 @@ func InitConfig() error {
 			PoolSize: 100,
 		},
 		JWT: JWTConfig{
-			Secret:      "online-mall-jwt-secret-key-2024",
-			ExpireHours: 24,
-			Issuer:      "online-mall",
+			Secret:              "online-mall-jwt-secret-key-2024",
+			AccessExpireMinutes: 30,
+			RefreshExpireDays:   30,
+			Issuer:              "online-mall",
 		},
 		Upload: UploadConfig{
-			Path:         "./uploads",
-			MaxSize:      10,
-			AllowedTypes: []string{"jpg", "jpeg", "png", "gif", "mp4"},
+			Path:              "./uploads",
+			MaxSize:           10,
+			AllowedTypes:      []string{"jpg", "jpeg", "png", "gif", "mp4"},
+			PrivatePath:       "./storage/private",
+			SignExpireMinutes: 30,
 		},
 		Log: LogConfig{
 			Level:      "info",
@@ -120,6 +338,134 @@ func InitConfig() error {
 			MaxAge:     30,
 			Compress:   true,
 		},
+		Notify: NotifyConfig{
+			Driver:   "log",
+			FilePath: "./logs/notify.log",
+			SMS: SMSConfig{
+				Timeout: 5,
+			},
+			SMTP: SMTPConfig{
+				Port: 465,
+			},
+		},
+		Verify: VerifyConfig{
+			CodeLength:    6,
+			ExpireMinutes: 5,
+			ResendSeconds: 60,
+			MaxAttempts:   5,
+			DailyLimit:    10,
+
+			ResetExpireMinutes: 30,
+			ResetURL:           "http://localhost:3000/reset-password",
+		},
+		Password: PasswordConfig{
+			MinLength:      8,
+			MaxLength:      64,
+			RequireLetter:  true,
+			RequireDigit:   true,
+			ForbidIdentity: true,
+			CheckCommon:    true,
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:            "在线商城",
+			EncryptKey:        "online-mall-totp-key-2024",
+			Skew:              1,
+			ChallengeMinutes:  5,
+			MaxAttempts:       5,
+			RecoveryCodeCount: 10,
+		},
+		Account: AccountConfig{
+			DeletionCoolingDays:   15,
+			ExportExpireHours:     72,
+			ExportCooldownMinutes: 60,
+			WorkerIntervalMinutes: 10,
+		},
+		Member: MemberConfig{
+			GrowthPerYuan: 1,
+		},
+		Checkout: CheckoutConfig{
+			Freight:               10,
+			FreeShippingThreshold: 99,
+		},
+		Points: PointsConfig{
+			EarnPerYuan:           1,
+			ReviewPoints:          10,
+			ExpireDays:            365,
+			PointsPerYuan:         100,
+			MaxRedeemRatio:        0.5,
+			MinRedeemPoints:       100,
+			WorkerIntervalMinutes: 60,
+		},
+		Checkin: CheckinConfig{
+			Rewards: []int64{5, 5, 10, 10, 15, 15, 20},
+		},
+		Favorite: FavoriteConfig{
+			MaxItems:              500,
+			WorkerIntervalMinutes: 30,
+		},
+		Restock: RestockConfig{
+			BatchSize:             200,
+			MaxPerUserHour:        5,
+			WorkerIntervalMinutes: 10,
+		},
+		Browse: BrowseConfig{
+			HistorySize:          100,
+			HistoryDays:          90,
+			FlushIntervalMinutes: 10,
+			PopularDays:          7,
+		},
+		Recommend: RecommendConfig{
+			TopN:              20,
+			LookbackDays:      180,
+			MinSupport:        2,
+			MaxBasketSize:     50,
+			CoViewWindowHours: 24,
+			PurchaseWeight:    1,
+			ViewWeight:        0.5,
+			IntervalHours:     24,
+		},
+		Home: HomeConfig{
+			BannerSize:           5,
+			CategorySize:         9,
+			RecommendSize:        20,
+			ListSize:             10,
+			CacheSeconds:         300,
+			PersonalCacheSeconds: 600,
+		},
+		FlashSale: FlashSaleConfig{
+			QueueWorkers:          4,
+			MaxAttempts:           3,
+			PayTimeoutMinutes:     15,
+			ResultTTLMinutes:      30,
+			InfoCacheSeconds:      10,
+			WorkerIntervalSeconds: 30,
+		},
+		Payment: PaymentConfig{
+			Driver:  "log",
+			Timeout: 10,
+		},
+		GroupBuy: GroupBuyConfig{
+			PayTimeoutMinutes:     15,
+			OpenGroupLimit:        10,
+			MaxRefundAttempts:     5,
+			WorkerIntervalSeconds: 60,
+		},
+		Order: OrderConfig{
+			PayTimeoutMinutes:     30,
+			AutoConfirmDays:       10,
+			MaxRefundAttempts:     5,
+			WorkerIntervalSeconds: 60,
+		},
+		Login: LoginConfig{
+			FailureWindowMinutes: 15,
+			MaxAccountFailures:   5,
+			MaxIPFailures:        20,
+			LockMinutes:          15,
+			DelayAfterFailures:   2,
+			BaseDelaySeconds:     1,
+			MaxDelaySeconds:      30,
+			CaptchaThreshold:     3,
+		},
 	}
 
 	// 加载配置文件
@@ -150,6 +496,11 @@ func InitConfig() error {
 		return err
 	}
 
+	// 下载链接签名密钥没有默认值，未配置时拒绝启动，避免使用公开的密钥签发可伪造的链接
+	if config.Upload.SignSecret == "" {
+		return errors.New("upload.sign_secret must be configured")
+	}
+
 	// 确保日志目录存在
 	if err := os.MkdirAll(filepath.Dir(config.Log.Filename), 0755); err != nil {
 		return err
@@ -160,6 +511,11 @@ func InitConfig() error {
 		return err
 	}
 
+	// 确保私有文件目录存在
+	if err := os.MkdirAll(config.Upload.PrivatePath, 0700); err != nil {
+		return err
+	}
+
 	GlobalConfig = config
 	return nil
 }
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// 数据导出状态
+const (
+	DataExportPending    = 0 // 等待处理
+	DataExportProcessing = 1 // 生成中
+	DataExportCompleted  = 2 // 可下载
+	DataExportFailed     = 3 // 生成失败
+	DataExportExpired    = 4 // 已过期，文件已删除
+)
+
+// DataExport 个人数据导出任务
+type DataExport struct {
+	BaseModel
+	UserID      uint64     `gorm:"not null;index" json:"user_id"`
+	Status      int        `gorm:"type:tinyint;default:0" json:"status"`
+	FilePath    string     `gorm:"type:varchar(255)" json:"-"` // 私有目录下的相对路径
+	FileSize    int64      `gorm:"default:0" json:"file_size"`
+	Error       string     `gorm:"type:varchar(255)" json:"-"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at"` // 文件保留截止时间
+}
+
+// TableName 表名
+func (DataExport) TableName() string {
+	return "data_exports"
+}
+
+// 注销申请状态
+const (
+	AccountDeletionPending   = 0 // 冷静期中
+	AccountDeletionCancelled = 1 // 已撤销
+	AccountDeletionCompleted = 2 // 已注销
+)
+
+// AccountDeletion 账号注销申请，冷静期结束后清除个人信息
+type AccountDeletion struct {
+	BaseModel
+	UserID      uint64     `gorm:"not null;index" json:"user_id"`
+	Status      int        `gorm:"type:tinyint;default:0;index" json:"status"`
+	Reason      string     `gorm:"type:varchar(255)" json:"reason"`
+	ScheduledAt time.Time  `gorm:"not null;index" json:"scheduled_at"` // 冷静期结束时间
+	CancelledAt *time.Time `json:"cancelled_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// TableName 表名
+func (AccountDeletion) TableName() string {
+	return "account_deletions"
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 审计操作类型
+const (
+	AuditActionCreate       = "create"
+	AuditActionUpdate       = "update"
+	AuditActionDelete       = "delete"
+	AuditActionUpdateStatus = "update_status"
+	AuditActionSetRoles     = "set_roles"
+	AuditActionShip         = "ship"
+	AuditActionRetryRefund  = "retry_refund"
+)
+
+// 审计对象类型
+const (
+	AuditTargetProduct     = "product"
+	AuditTargetCategory    = "category"
+	AuditTargetCoupon      = "coupon"
+	AuditTargetOrder       = "order"
+	AuditTargetUser        = "user"
+	AuditTargetRole        = "role"
+	AuditTargetMemberLevel = "member_level"
+	AuditTargetSKU         = "product_sku"
+	AuditTargetTask        = "task"
+	AuditTargetContentSlot = "content_slot"
+	AuditTargetFlashSale   = "flash_sale"
+	AuditTargetGroupBuy    = "group_buy"
+	AuditTargetGroupMember = "group_member"
+	AuditTargetPromotion   = "promotion"
+)
+
+// AuditLog 管理操作审计日志，只追加不修改
+type AuditLog struct {
+	ID         uint64          `gorm:"primarykey" json:"id"`
+	ActorID    uint64          `gorm:"not null;index" json:"actor_id"`
+	ActorName  string          `gorm:"type:varchar(50)" json:"actor_name"`
+	Action     string          `gorm:"type:varchar(50);not null;index" json:"action"`
+	TargetType string          `gorm:"type:varchar(50);not null;index:idx_audit_target" json:"target_type"`
+	TargetID   uint64          `gorm:"index:idx_audit_target" json:"target_id"`
+	Before     json.RawMessage `gorm:"type:text" json:"before"` // 修改前快照，创建时为null
+	After      json.RawMessage `gorm:"type:text" json:"after"`  // 修改后快照，删除时为null
+	Diff       json.RawMessage `gorm:"type:text" json:"diff"`   // 变化字段 {"字段": {"before": 旧值, "after": 新值}}
+	RequestID  string          `gorm:"type:varchar(64);index" json:"request_id"`
+	IP         string          `gorm:"type:varchar(45)" json:"ip"`
+	CreatedAt  time.Time       `gorm:"index" json:"created_at"`
+}
+
+// TableName 表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// AuditLogQuery 审计日志查询结构体
+type AuditLogQuery struct {
+	Page       int       `form:"page" json:"page"`
+	PageSize   int       `form:"page_size" json:"page_size"`
+	ActorID    uint64    `form:"actor_id" json:"actor_id"`
+	Action     string    `form:"action" json:"action"`
+	TargetType string    `form:"target_type" json:"target_type"`
+	TargetID   uint64    `form:"target_id" json:"target_id"`
+	RequestID  string    `form:"request_id" json:"request_id"`
+	StartDate  time.Time `form:"start_date" time_format:"2006-01-02" json:"start_date"` // 含当天
+	EndDate    time.Time `form:"end_date" time_format:"2006-01-02" json:"end_date"`     // 含当天
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// 内容位位置
+const (
+	ContentPositionBanner       = "banner"       // 首页轮播图
+	ContentPositionPromo        = "promo"        // 首页促销位
+	ContentPositionAnnouncement = "announcement" // 公告
+)
+
+// 内容位跳转类型
+const (
+	ContentLinkNone     = "none"     // 不跳转
+	ContentLinkProduct  = "product"  // 商品详情，目标为商品ID
+	ContentLinkCategory = "category" // 分类商品列表，目标为分类ID
+	ContentLinkURL      = "url"      // 站内路径或外部链接
+)
+
+// ContentSlot 首页内容位（轮播图、促销位、公告），在排期时间内且启用时展示
+type ContentSlot struct {
+	BaseModel
+	Position   string     `gorm:"type:varchar(20);not null;index:idx_content_slot_position" json:"position"`
+	Title      string     `gorm:"type:varchar(100);not null" json:"title"`
+	Image      string     `gorm:"type:varchar(255)" json:"image"`
+	Content    string     `gorm:"type:varchar(500)" json:"content"` // 公告正文
+	LinkType   string     `gorm:"type:varchar(20);not null;default:'none'" json:"link_type"`
+	LinkTarget string     `gorm:"type:varchar(255)" json:"link_target"`
+	StartAt    *time.Time `json:"start_at"` // 为空表示立即生效
+	EndAt      *time.Time `json:"end_at"`   // 为空表示长期有效
+	Sort       int        `gorm:"default:0;index:idx_content_slot_position" json:"sort"`
+	Status     int        `gorm:"type:tinyint;default:1" json:"status"` // 1-启用，0-停用
+}
+
+// TableName 表名
+func (ContentSlot) TableName() string {
+	return "content_slots"
+}
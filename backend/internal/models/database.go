@@ -61,6 +61,21 @@ func InitDatabase() error {
 		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
+	// 初始化内置角色和权限
+	if err := seedRBAC(db); err != nil {
+		return fmt.Errorf("failed to seed roles: %v", err)
+	}
+
+	// 初始化会员等级
+	if err := seedMemberLevels(db); err != nil {
+		return fmt.Errorf("failed to seed member levels: %v", err)
+	}
+
+	// 初始化任务
+	if err := seedTasks(db); err != nil {
+		return fmt.Errorf("failed to seed tasks: %v", err)
+	}
+
 	DB = db
 	return nil
 }
@@ -78,6 +93,44 @@ func autoMigrate(db *gorm.DB) error {
 		&CartItem{},
 		&Coupon{},
 		&UserCoupon{},
+		&RefreshToken{},
+		&UserSession{},
+		&PasswordReset{},
+		&UserTOTP{},
+		&UserRecoveryCode{},
+		&Permission{},
+		&Role{},
+		&UserRole{},
+		&AuditLog{},
+		&DataExport{},
+		&AccountDeletion{},
+		&MemberLevel{},
+		&MemberLevelCoupon{},
+		&UserMember{},
+		&MemberLevelReward{},
+		&GrowthLog{},
+		&SKUMemberPrice{},
+		&UserPoints{},
+		&PointsLedger{},
+		&PointsBatch{},
+		&PointsRedemption{},
+		&PointsRedemptionItem{},
+		&Task{},
+		&UserTask{},
+		&CheckinRecord{},
+		&Notification{},
+		&Favorite{},
+		&StockSubscription{},
+		&ProductViewDaily{},
+		&ProductRelation{},
+		&ContentSlot{},
+		&FlashSale{},
+		&FlashSaleOrder{},
+		&GroupBuy{},
+		&GroupBuyGroup{},
+		&GroupBuyMember{},
+		&Promotion{},
+		&ProductReview{},
 	)
 }
 
@@ -1,10 +1,14 @@
 package models
 
-import "errors"
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
 
 var (
-	// ErrRecordNotFound 记录未找到
-	ErrRecordNotFound = errors.New("record not found")
+	// ErrRecordNotFound 记录未找到（与GORM返回的错误一致，便于直接比较）
+	ErrRecordNotFound = gorm.ErrRecordNotFound
 
 	// ErrDuplicateKey 重复键错误
 	ErrDuplicateKey = errors.New("duplicate key")
@@ -30,7 +34,13 @@ func IsRecordNotFound(err error) bool {
 	return errors.Is(err, ErrRecordNotFound)
 }
 
-// IsDuplicateKey 检查是否是重复键错误
+// IsDuplicateKey 检查是否是重复键错误（包括数据库唯一索引冲突）
 func IsDuplicateKey(err error) bool {
-	return errors.Is(err, ErrDuplicateKey)
+	if errors.Is(err, ErrDuplicateKey) || errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	if translator, ok := DB.Dialector.(gorm.ErrorTranslator); ok {
+		return errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey)
+	}
+	return false
 }
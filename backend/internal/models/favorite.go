@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// Favorite 商品收藏，SKUID为0表示收藏整个商品
+type Favorite struct {
+	ID            uint64    `gorm:"primarykey" json:"id"`
+	UserID        uint64    `gorm:"not null;uniqueIndex:idx_user_favorite" json:"user_id"`
+	ProductID     uint64    `gorm:"not null;uniqueIndex:idx_user_favorite;index" json:"product_id"`
+	SKUID         uint64    `gorm:"column:sku_id;not null;default:0;uniqueIndex:idx_user_favorite" json:"sku_id"`
+	Price         float64   `gorm:"type:decimal(10,2);not null" json:"price"` // 收藏时的价格
+	NotifiedPrice *float64  `gorm:"type:decimal(10,2)" json:"-"`              // 最近一次降价提醒的价格
+	InStock       bool      `gorm:"not null;default:true" json:"-"`           // 最近一次检查时是否有货
+	CreatedAt     time.Time `json:"created_at"`
+	Product       Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+// TableName 表名
+func (Favorite) TableName() string {
+	return "favorites"
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// 秒杀下单记录状态
+const (
+	FlashSaleOrderCreated  = "created"  // 已创建订单
+	FlashSaleOrderFailed   = "failed"   // 创建订单失败，库存已退回
+	FlashSaleOrderReleased = "released" // 订单已取消，待退回秒杀库存
+	FlashSaleOrderReturned = "returned" // 订单已取消，库存已退回
+)
+
+// FlashSale 秒杀活动，创建时从SKU库存中划出秒杀库存，活动结束后未售出的部分退回SKU
+type FlashSale struct {
+	BaseModel
+	Name          string    `gorm:"type:varchar(100);not null" json:"name"`
+	ProductID     uint64    `gorm:"not null;index" json:"product_id"`
+	SKUID         uint64    `gorm:"column:sku_id;not null;index" json:"sku_id"`
+	ProductName   string    `gorm:"type:varchar(255);not null" json:"product_name"` // 创建时的商品名称
+	SKUName       string    `gorm:"column:sku_name;type:varchar(100)" json:"sku_name"`
+	Image         string    `gorm:"type:varchar(255)" json:"image"`
+	OriginalPrice float64   `gorm:"type:decimal(10,2);not null" json:"original_price"` // 创建时的SKU价格
+	SalePrice     float64   `gorm:"type:decimal(10,2);not null" json:"sale_price"`
+	Quantity      int       `gorm:"not null" json:"quantity"`                 // 秒杀库存
+	Sold          int       `gorm:"not null;default:0" json:"sold"`           // 已创建订单的数量（不含已取消）
+	PerUserLimit  int       `gorm:"not null;default:1" json:"per_user_limit"` // 每人限购
+	StartAt       time.Time `gorm:"not null;index" json:"start_at"`           // 开始时间
+	EndAt         time.Time `gorm:"not null;index" json:"end_at"`             // 结束时间
+	Status        int       `gorm:"type:tinyint;default:1" json:"status"`     // 1-启用，0-停用
+	Settled       bool      `gorm:"not null;default:false" json:"settled"`    // 结束后剩余库存是否已退回SKU
+}
+
+// TableName 表名
+func (FlashSale) TableName() string {
+	return "flash_sales"
+}
+
+// FlashSaleOrder 秒杀下单记录，由下单队列消费时写入；同一抢购请求只处理一次
+type FlashSaleOrder struct {
+	ID          uint64    `gorm:"primarykey" json:"id"`
+	RequestID   string    `gorm:"type:varchar(32);not null;uniqueIndex" json:"request_id"`
+	FlashSaleID uint64    `gorm:"not null;index:idx_flash_sale_user" json:"flash_sale_id"`
+	UserID      uint64    `gorm:"not null;index:idx_flash_sale_user" json:"user_id"`
+	Quantity    int       `gorm:"not null" json:"quantity"`
+	OrderID     uint64    `gorm:"not null;default:0;index" json:"order_id"`
+	Status      string    `gorm:"type:varchar(20);not null;index" json:"status"`
+	Reason      string    `gorm:"type:varchar(255)" json:"reason"` // 失败原因
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName 表名
+func (FlashSaleOrder) TableName() string {
+	return "flash_sale_orders"
+}
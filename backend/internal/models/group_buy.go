@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+)
+
+// 团状态
+const (
+	GroupStatusPending   = "pending"   // 待团长支付，支付后开团
+	GroupStatusOpen      = "open"      // 拼团中
+	GroupStatusSucceeded = "succeeded" // 拼团成功
+	GroupStatusFailed    = "failed"    // 拼团失败（超时未成团或团长未支付）
+)
+
+// 团成员状态
+const (
+	GroupMemberPending   = "pending"   // 已下单待支付，占用名额
+	GroupMemberPaid      = "paid"      // 已支付
+	GroupMemberCancelled = "cancelled" // 订单未支付已取消
+	GroupMemberRefunding = "refunding" // 拼团失败，等待退款
+	GroupMemberRefunded  = "refunded"  // 已退款
+)
+
+// GroupBuy 拼团活动，开团和参团时按拼团价下单并扣减SKU库存
+type GroupBuy struct {
+	BaseModel
+	Name          string    `gorm:"type:varchar(100);not null" json:"name"`
+	ProductID     uint64    `gorm:"not null;index" json:"product_id"`
+	SKUID         uint64    `gorm:"column:sku_id;not null;index" json:"sku_id"`
+	ProductName   string    `gorm:"type:varchar(255);not null" json:"product_name"` // 创建时的商品名称
+	SKUName       string    `gorm:"column:sku_name;type:varchar(100)" json:"sku_name"`
+	Image         string    `gorm:"type:varchar(255)" json:"image"`
+	OriginalPrice float64   `gorm:"type:decimal(10,2);not null" json:"original_price"` // 创建时的SKU价格
+	GroupPrice    float64   `gorm:"type:decimal(10,2);not null" json:"group_price"`
+	GroupSize     int       `gorm:"not null" json:"group_size"`              // 成团人数（含团长）
+	DurationHours int       `gorm:"not null" json:"duration_hours"`          // 开团后的成团时限（小时）
+	MaxQuantity   int       `gorm:"not null;default:1" json:"max_quantity"`  // 每人每团最多购买数量
+	StartAt       time.Time `gorm:"not null;index" json:"start_at"`          // 可开团的开始时间
+	EndAt         time.Time `gorm:"not null;index" json:"end_at"`            // 可开团的结束时间，已开的团可在时限内继续参团
+	Status        int       `gorm:"type:tinyint;default:1" json:"status"`    // 1-启用，0-停用
+	GroupCount    int       `gorm:"not null;default:0" json:"group_count"`   // 已开团数
+	SuccessCount  int       `gorm:"not null;default:0" json:"success_count"` // 成团数
+}
+
+// TableName 表名
+func (GroupBuy) TableName() string {
+	return "group_buys"
+}
+
+// GroupBuyGroup 团，团长支付后开团，在时限内支付人数达到成团人数即拼团成功
+type GroupBuyGroup struct {
+	ID           uint64     `gorm:"primarykey" json:"id"`
+	GroupBuyID   uint64     `gorm:"not null;index" json:"group_buy_id"`
+	LeaderID     uint64     `gorm:"not null;index" json:"leader_id"`
+	ShareCode    string     `gorm:"type:varchar(16);not null;uniqueIndex" json:"share_code"` // 分享链接中的团编号
+	GroupPrice   float64    `gorm:"type:decimal(10,2);not null" json:"group_price"`          // 开团时的拼团价
+	RequiredSize int        `gorm:"not null" json:"required_size"`                           // 开团时的成团人数
+	PaidCount    int        `gorm:"not null;default:0" json:"paid_count"`                    // 已支付人数
+	Status       string     `gorm:"type:varchar(20);not null;index:idx_group_status_expire" json:"status"`
+	ExpireAt     *time.Time `gorm:"index:idx_group_status_expire" json:"expire_at"` // 成团截止时间，团长支付后设置
+	FinishedAt   *time.Time `json:"finished_at"`                                    // 成团或失败时间
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName 表名
+func (GroupBuyGroup) TableName() string {
+	return "group_buy_groups"
+}
+
+// GroupBuyMember 团成员，每个成员对应一个订单
+type GroupBuyMember struct {
+	ID             uint64     `gorm:"primarykey" json:"id"`
+	GroupID        uint64     `gorm:"not null;index" json:"group_id"`
+	GroupBuyID     uint64     `gorm:"not null;index" json:"group_buy_id"`
+	UserID         uint64     `gorm:"not null;index" json:"user_id"`
+	OrderID        uint64     `gorm:"not null;uniqueIndex" json:"order_id"`
+	Quantity       int        `gorm:"not null" json:"quantity"`
+	IsLeader       bool       `gorm:"not null;default:false" json:"is_leader"`
+	Status         string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	PaidAt         *time.Time `json:"paid_at"`
+	RefundNo       string     `gorm:"type:varchar(40)" json:"refund_no,omitempty"`     // 退款单号
+	RefundID       string     `gorm:"type:varchar(64)" json:"refund_id,omitempty"`     // 支付渠道退款流水号
+	RefundAttempts int        `gorm:"not null;default:0" json:"refund_attempts"`       // 退款尝试次数
+	RefundError    string     `gorm:"type:varchar(255)" json:"refund_error,omitempty"` // 最近一次退款失败原因
+	RefundedAt     *time.Time `json:"refunded_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName 表名
+func (GroupBuyMember) TableName() string {
+	return "group_buy_members"
+}
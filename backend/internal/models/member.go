@@ -0,0 +1,121 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 成长值来源
+const (
+	GrowthSourceOrderComplete = "order_complete" // 订单完成
+	GrowthSourceOrderRefund   = "order_refund"   // 订单退款扣回
+)
+
+// MemberLevel 会员等级，成长值达到 MinGrowth 即升至该等级
+type MemberLevel struct {
+	BaseModel
+	Name                  string               `gorm:"type:varchar(50);not null" json:"name"`
+	MinGrowth             int64                `gorm:"not null;uniqueIndex" json:"min_growth"`                  // 所需成长值
+	Discount              float64              `gorm:"type:decimal(4,2);not null;default:1.00" json:"discount"` // 未单独设置会员价的SKU按此折扣计价，1表示不打折
+	FreeShippingThreshold *float64             `gorm:"type:decimal(10,2)" json:"free_shipping_threshold"`       // 满额包邮门槛，为空时使用全站规则
+	Icon                  string               `gorm:"type:varchar(255)" json:"icon"`
+	Description           string               `gorm:"type:varchar(255)" json:"description"`
+	Coupons               []*MemberLevelCoupon `gorm:"foreignKey:LevelID" json:"coupons,omitempty"`
+}
+
+// TableName 表名
+func (MemberLevel) TableName() string {
+	return "member_levels"
+}
+
+// MemberLevelCoupon 升级到该等级时发放的优惠券
+type MemberLevelCoupon struct {
+	BaseModel
+	LevelID  uint64 `gorm:"not null;index" json:"level_id"`
+	CouponID uint64 `gorm:"not null" json:"coupon_id"`
+	Quantity int    `gorm:"not null;default:1" json:"quantity"`
+}
+
+// TableName 表名
+func (MemberLevelCoupon) TableName() string {
+	return "member_level_coupons"
+}
+
+// UserMember 用户会员信息
+type UserMember struct {
+	BaseModel
+	UserID         uint64       `gorm:"not null;uniqueIndex" json:"user_id"`
+	LevelID        uint64       `gorm:"default:0;index" json:"level_id"` // 0表示未达到任何等级
+	GrowthValue    int64        `gorm:"default:0" json:"growth_value"`
+	LevelChangedAt *time.Time   `json:"level_changed_at"`
+	Level          *MemberLevel `gorm:"foreignKey:LevelID" json:"level,omitempty"`
+}
+
+// TableName 表名
+func (UserMember) TableName() string {
+	return "user_members"
+}
+
+// MemberLevelReward 已发放的升级礼包，每个等级只发放一次
+type MemberLevelReward struct {
+	BaseModel
+	UserID  uint64 `gorm:"not null;uniqueIndex:idx_member_level_reward" json:"user_id"`
+	LevelID uint64 `gorm:"not null;uniqueIndex:idx_member_level_reward" json:"level_id"`
+}
+
+// TableName 表名
+func (MemberLevelReward) TableName() string {
+	return "member_level_rewards"
+}
+
+// GrowthLog 成长值流水，同一来源只记录一次
+type GrowthLog struct {
+	BaseModel
+	UserID   uint64 `gorm:"not null;uniqueIndex:idx_growth_source" json:"user_id"`
+	Source   string `gorm:"type:varchar(30);not null;uniqueIndex:idx_growth_source" json:"source"`
+	SourceID uint64 `gorm:"not null;uniqueIndex:idx_growth_source" json:"source_id"`
+	Change   int64  `gorm:"not null" json:"change"`
+	Balance  int64  `gorm:"not null" json:"balance"` // 变动后的成长值
+	Remark   string `gorm:"type:varchar(255)" json:"remark"`
+}
+
+// TableName 表名
+func (GrowthLog) TableName() string {
+	return "growth_logs"
+}
+
+// SKUMemberPrice SKU的会员价，优先于等级折扣
+type SKUMemberPrice struct {
+	BaseModel
+	SKUID   uint64  `gorm:"column:sku_id;not null;uniqueIndex:idx_sku_member_price" json:"sku_id"`
+	LevelID uint64  `gorm:"not null;uniqueIndex:idx_sku_member_price" json:"level_id"`
+	Price   float64 `gorm:"type:decimal(10,2);not null" json:"price"`
+}
+
+// TableName 表名
+func (SKUMemberPrice) TableName() string {
+	return "sku_member_prices"
+}
+
+// defaultMemberLevels 内置会员等级，仅在没有任何等级时创建
+var defaultMemberLevels = []MemberLevel{
+	{Name: "普通会员", MinGrowth: 0, Discount: 1},
+	{Name: "白银会员", MinGrowth: 1000, Discount: 0.98},
+	{Name: "黄金会员", MinGrowth: 5000, Discount: 0.95},
+	{Name: "钻石会员", MinGrowth: 20000, Discount: 0.9},
+}
+
+// seedMemberLevels 初始化会员等级
+func seedMemberLevels(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&MemberLevel{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	levels := make([]MemberLevel, len(defaultMemberLevels))
+	copy(levels, defaultMemberLevels)
+	return db.Create(&levels).Error
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// 站内通知类型
+const (
+	NotificationPriceDrop = "price_drop" // 收藏商品降价
+	NotificationRestock   = "restock"    // 商品到货
+	NotificationGroupBuy  = "group_buy"  // 拼团结果
+	NotificationOrder     = "order"      // 订单发货
+)
+
+// Notification 站内通知
+type Notification struct {
+	ID        uint64     `gorm:"primarykey" json:"id"`
+	UserID    uint64     `gorm:"not null;index:idx_notification_user" json:"user_id"`
+	Type      string     `gorm:"type:varchar(30);not null" json:"type"`
+	Title     string     `gorm:"type:varchar(100);not null" json:"title"`
+	Content   string     `gorm:"type:varchar(500)" json:"content"`
+	Link      string     `gorm:"type:varchar(255)" json:"link"` // 前端跳转地址，如 /products/1
+	ReadAt    *time.Time `gorm:"index:idx_notification_user" json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName 表名
+func (Notification) TableName() string {
+	return "notifications"
+}
@@ -11,25 +11,73 @@ import (
 // Order 订单模型
 type Order struct {
 	BaseModel
-	OrderNo        string      `gorm:"type:varchar(32);uniqueIndex;not null" json:"order_no"`
-	UserID         uint64      `gorm:"not null;index" json:"user_id"`
-	AddressID      uint64      `gorm:"not null" json:"address_id"`
-	TotalAmount    float64     `gorm:"type:decimal(10,2);not null" json:"total_amount" validate:"required,gte=0"`
-	Freight        float64     `gorm:"type:decimal(10,2);default:0.00" json:"freight" validate:"gte=0"`
-	DiscountAmount float64     `gorm:"type:decimal(10,2);default:0.00" json:"discount_amount" validate:"gte=0"`
-	PayAmount      float64     `gorm:"type:decimal(10,2);not null" json:"pay_amount" validate:"required,gte=0"`
-	PayStatus      int         `gorm:"type:tinyint;default:0" json:"pay_status"` // 0-未支付，1-已支付
-	PayTime        *time.Time  `json:"pay_time"`
-	PaymentMethod  string      `gorm:"type:varchar(20)" json:"payment_method"`
-	OrderStatus    int         `gorm:"type:tinyint;default:0" json:"order_status"` // 0-待付款，1-待发货，2-待收货，3-已完成，4-已取消
-	CancelReason   string      `gorm:"type:varchar(255)" json:"cancel_reason"`
-	CancelTime     *time.Time  `json:"cancel_time"`
-	Remark         string      `gorm:"type:varchar(255)" json:"remark"`
-	User           User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Address        Address     `gorm:"foreignKey:AddressID" json:"address,omitempty"`
-	OrderItems     []OrderItem `gorm:"foreignKey:OrderID" json:"order_items,omitempty"`
+	OrderNo         string      `gorm:"type:varchar(32);uniqueIndex;not null" json:"order_no"`
+	UserID          uint64      `gorm:"not null;index" json:"user_id"`
+	OrderType       string      `gorm:"type:varchar(20);default:'normal';index" json:"order_type"` // normal-普通订单，flash_sale-秒杀，group_buy-拼团
+	AddressID       uint64      `gorm:"not null" json:"address_id"`
+	ReceiverName    string      `gorm:"type:varchar(50)" json:"receiver_name"`     // 下单时的收货人快照，地址修改或删除后不变
+	ReceiverPhone   string      `gorm:"type:varchar(20)" json:"receiver_phone"`    // 下单时的收货电话快照
+	ReceiverAddress string      `gorm:"type:varchar(500)" json:"receiver_address"` // 下单时的省市区和详细地址快照
+	TotalAmount     float64     `gorm:"type:decimal(10,2);not null" json:"total_amount" validate:"required,gte=0"`
+	Freight         float64     `gorm:"type:decimal(10,2);default:0.00" json:"freight" validate:"gte=0"`
+	DiscountAmount  float64     `gorm:"type:decimal(10,2);default:0.00" json:"discount_amount" validate:"gte=0"`
+	PromotionAmount float64     `gorm:"type:decimal(10,2);default:0.00" json:"promotion_amount"` // 促销优惠金额
+	CouponAmount    float64     `gorm:"type:decimal(10,2);default:0.00" json:"coupon_amount"`    // 优惠券抵扣金额
+	UserCouponID    *uint64     `json:"user_coupon_id"`                                          // 使用的用户优惠券
+	PointsUsed      int64       `gorm:"default:0" json:"points_used"`                            // 使用积分
+	PointsAmount    float64     `gorm:"type:decimal(10,2);default:0.00" json:"points_amount"`    // 积分抵扣金额
+	PayAmount       float64     `gorm:"type:decimal(10,2);not null" json:"pay_amount" validate:"required,gte=0"`
+	PayStatus       int         `gorm:"type:tinyint;default:0" json:"pay_status"` // 0-未支付，1-已支付
+	PayTime         *time.Time  `json:"pay_time"`
+	PaymentMethod   string      `gorm:"type:varchar(20)" json:"payment_method"`
+	TradeNo         string      `gorm:"type:varchar(64)" json:"trade_no"`           // 支付渠道交易号
+	OrderStatus     int         `gorm:"type:tinyint;default:0" json:"order_status"` // 0-待付款，1-待发货，2-待收货，3-已完成，4-已取消
+	CancelReason    string      `gorm:"type:varchar(255)" json:"cancel_reason"`
+	CancelTime      *time.Time  `json:"cancel_time"`
+	ShippingCompany string      `gorm:"type:varchar(50)" json:"shipping_company"` // 物流公司
+	TrackingNo      string      `gorm:"type:varchar(50)" json:"tracking_no"`      // 物流单号
+	ShipTime        *time.Time  `gorm:"index" json:"ship_time"`
+	CompleteTime    *time.Time  `json:"complete_time"`
+	RefundStatus    string      `gorm:"type:varchar(20);index" json:"refund_status,omitempty"` // 取消后才收到支付的订单退款状态：refunding-等待退款，refunded-已退款
+	RefundNo        string      `gorm:"type:varchar(40)" json:"refund_no,omitempty"`           // 退款单号
+	RefundID        string      `gorm:"type:varchar(64)" json:"refund_id,omitempty"`           // 支付渠道退款流水号
+	RefundAttempts  int         `gorm:"not null;default:0" json:"refund_attempts"`             // 退款尝试次数
+	RefundError     string      `gorm:"type:varchar(255)" json:"refund_error,omitempty"`       // 最近一次退款失败原因
+	RefundedAt      *time.Time  `json:"refunded_at"`
+	Remark          string      `gorm:"type:varchar(255)" json:"remark"`
+	User            User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Address         Address     `gorm:"foreignKey:AddressID" json:"address,omitempty"`
+	OrderItems      []OrderItem `gorm:"foreignKey:OrderID" json:"order_items,omitempty"`
 }
 
+// 订单状态
+const (
+	OrderStatusPending   = 0 // 待付款
+	OrderStatusToShip    = 1 // 待发货
+	OrderStatusShipped   = 2 // 待收货
+	OrderStatusCompleted = 3 // 已完成
+	OrderStatusCancelled = 4 // 已取消
+)
+
+// 订单类型
+const (
+	OrderTypeNormal    = "normal"     // 普通订单（结算下单）
+	OrderTypeFlashSale = "flash_sale" // 秒杀订单
+	OrderTypeGroupBuy  = "group_buy"  // 拼团订单
+)
+
+// 订单退款状态
+const (
+	OrderRefunding = "refunding" // 等待退款
+	OrderRefunded  = "refunded"  // 已退款
+)
+
+// 支付状态
+const (
+	PayStatusUnpaid = 0 // 未支付
+	PayStatusPaid   = 1 // 已支付
+)
+
 // TableName 表名
 func (Order) TableName() string {
 	return "orders"
@@ -44,6 +92,14 @@ func (o *Order) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// SetReceiver 记录收货地址快照
+func (o *Order) SetReceiver(address *Address) {
+	o.AddressID = address.ID
+	o.ReceiverName = address.Name
+	o.ReceiverPhone = address.Phone
+	o.ReceiverAddress = address.Province + address.City + address.District + " " + address.Detail
+}
+
 // generateOrderNo 生成订单号
 func (o *Order) generateOrderNo() string {
 	timestamp := time.Now().Format("20060102150405")
@@ -63,6 +119,9 @@ type OrderItem struct {
 	Price          float64 `gorm:"type:decimal(10,2);not null" json:"price" validate:"required,gte=0"`
 	Quantity       int     `gorm:"not null" json:"quantity" validate:"required,gte=1"`
 	TotalAmount    float64 `gorm:"type:decimal(10,2);not null" json:"total_amount" validate:"required,gte=0"`
+	DiscountAmount float64 `gorm:"type:decimal(10,2);default:0.00" json:"discount_amount"` // 分摊到该商品的促销和优惠券优惠
+	DiscountDetail string  `gorm:"type:text" json:"discount_detail"`                       // JSON格式存储优惠分摊明细
+	PayAmount      float64 `gorm:"type:decimal(10,2);default:0.00" json:"pay_amount"`      // 优惠后金额
 	Order          Order   `gorm:"foreignKey:OrderID" json:"order,omitempty"`
 	Product        Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
 }
@@ -87,6 +146,29 @@ func (oi *OrderItem) SetSpecifications(specs map[string]string) {
 	oi.Specifications = string(data)
 }
 
+// ItemDiscount 订单商品的优惠分摊明细
+type ItemDiscount struct {
+	Source   string  `json:"source"`    // promotion 或 coupon
+	SourceID uint64  `json:"source_id"` // 促销ID或用户优惠券ID
+	Name     string  `json:"name"`
+	Amount   float64 `json:"amount"`
+}
+
+// GetDiscountDetail 获取优惠分摊明细
+func (oi *OrderItem) GetDiscountDetail() []*ItemDiscount {
+	var discounts []*ItemDiscount
+	if oi.DiscountDetail != "" {
+		_ = json.Unmarshal([]byte(oi.DiscountDetail), &discounts)
+	}
+	return discounts
+}
+
+// SetDiscountDetail 设置优惠分摊明细
+func (oi *OrderItem) SetDiscountDetail(discounts []*ItemDiscount) {
+	data, _ := json.Marshal(discounts)
+	oi.DiscountDetail = string(data)
+}
+
 // CartItem 购物车模型
 type CartItem struct {
 	BaseModel
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+)
+
+// 积分来源
+const (
+	PointsSourceOrderComplete  = "order_complete"  // 订单完成奖励
+	PointsSourceReview         = "review"          // 商品评价奖励
+	PointsSourceOrderRedeem    = "order_redeem"    // 下单抵扣
+	PointsSourceRedeemRollback = "redeem_rollback" // 订单取消/退款退回抵扣的积分
+	PointsSourceOrderRefund    = "order_refund"    // 订单退款扣回奖励
+	PointsSourceExpire         = "expire"          // 积分过期，来源ID为批次ID
+	PointsSourceCheckin        = "checkin"         // 签到奖励，来源ID为签到记录ID
+	PointsSourceTask           = "task"            // 任务奖励，来源ID为任务ID
+)
+
+// UserPoints 用户积分余额
+type UserPoints struct {
+	BaseModel
+	UserID  uint64 `gorm:"not null;uniqueIndex" json:"user_id"`
+	Balance int64  `gorm:"not null;default:0" json:"balance"`
+}
+
+// TableName 表名
+func (UserPoints) TableName() string {
+	return "user_points"
+}
+
+// PointsLedger 积分流水，只追加不修改；同一来源只记录一次
+type PointsLedger struct {
+	ID        uint64    `gorm:"primarykey" json:"id"`
+	UserID    uint64    `gorm:"not null;uniqueIndex:idx_points_source" json:"user_id"`
+	Source    string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_points_source" json:"source"`
+	SourceID  uint64    `gorm:"not null;uniqueIndex:idx_points_source" json:"source_id"`
+	Change    int64     `gorm:"not null" json:"change"`  // 正数为获得，负数为消耗
+	Balance   int64     `gorm:"not null" json:"balance"` // 变动后余额
+	Remark    string    `gorm:"type:varchar(255)" json:"remark"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName 表名
+func (PointsLedger) TableName() string {
+	return "points_ledgers"
+}
+
+// PointsBatch 积分批次，每次获得积分生成一个批次，按过期时间先后消耗
+type PointsBatch struct {
+	BaseModel
+	UserID    uint64    `gorm:"not null;index:idx_points_batch_user" json:"user_id"`
+	LedgerID  uint64    `gorm:"not null" json:"ledger_id"`
+	Amount    int64     `gorm:"not null" json:"amount"`
+	Remaining int64     `gorm:"not null" json:"remaining"`
+	ExpiresAt time.Time `gorm:"not null;index;index:idx_points_batch_user" json:"expires_at"`
+}
+
+// TableName 表名
+func (PointsBatch) TableName() string {
+	return "points_batches"
+}
+
+// PointsRedemption 订单积分抵扣记录
+type PointsRedemption struct {
+	BaseModel
+	UserID       uint64     `gorm:"not null;index" json:"user_id"`
+	OrderID      uint64     `gorm:"not null;uniqueIndex" json:"order_id"`
+	Points       int64      `gorm:"not null" json:"points"`
+	Amount       float64    `gorm:"type:decimal(10,2);not null" json:"amount"` // 抵扣金额
+	RolledBackAt *time.Time `json:"rolled_back_at"`                            // 退回时间
+}
+
+// TableName 表名
+func (PointsRedemption) TableName() string {
+	return "points_redemptions"
+}
+
+// PointsRedemptionItem 抵扣消耗的批次明细，退回时恢复到原批次
+type PointsRedemptionItem struct {
+	ID           uint64 `gorm:"primarykey" json:"id"`
+	RedemptionID uint64 `gorm:"not null;index" json:"redemption_id"`
+	BatchID      uint64 `gorm:"not null" json:"batch_id"`
+	Points       int64  `gorm:"not null" json:"points"`
+}
+
+// TableName 表名
+func (PointsRedemptionItem) TableName() string {
+	return "points_redemption_items"
+}
@@ -70,6 +70,8 @@ type ProductSKU struct {
 	Sales          int     `gorm:"default:0" json:"sales"` // SKU销量
 	Image          string  `gorm:"type:varchar(255)" json:"image"`
 	Product        Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+
+	MemberPrices []SKUMemberPrice `gorm:"foreignKey:SKUID" json:"member_prices,omitempty"` // 各会员等级的价格
 }
 
 // TableName 表名
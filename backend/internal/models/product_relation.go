@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// ProductRelation 商品关联推荐，由离线任务根据共同购买和共同浏览计算，每个商品保留得分最高的若干个
+type ProductRelation struct {
+	ID        uint64    `gorm:"primarykey" json:"id"`
+	ProductID uint64    `gorm:"not null;uniqueIndex:idx_product_relation" json:"product_id"`
+	RelatedID uint64    `gorm:"not null;uniqueIndex:idx_product_relation" json:"related_id"`
+	Score     float64   `gorm:"not null" json:"score"`               // 相似度得分，越高越相关
+	CoBought  int       `gorm:"not null;default:0" json:"co_bought"` // 共同购买的订单数
+	CoViewed  int       `gorm:"not null;default:0" json:"co_viewed"` // 共同浏览的用户数
+	UpdatedAt time.Time `gorm:"index" json:"updated_at"`
+}
+
+// TableName 表名
+func (ProductRelation) TableName() string {
+	return "product_relations"
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// ProductViewDaily 商品每日浏览量，由Redis中的实时计数定期写入，用于人气排行
+type ProductViewDaily struct {
+	ID        uint64    `gorm:"primarykey" json:"id"`
+	ProductID uint64    `gorm:"not null;uniqueIndex:idx_product_view_date" json:"product_id"`
+	Date      string    `gorm:"type:char(10);not null;uniqueIndex:idx_product_view_date;index" json:"date"` // 2006-01-02
+	Views     int64     `gorm:"not null;default:0" json:"views"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 表名
+func (ProductViewDaily) TableName() string {
+	return "product_view_daily"
+}
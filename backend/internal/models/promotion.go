@@ -0,0 +1,85 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 促销类型
+const (
+	PromotionFullReduction = "full_reduction" // 满减，按适用商品金额达到的最高档位减免
+	PromotionNForX         = "n_for_x"        // N件X元，适用商品任选N件按X元计
+	PromotionSecondItem    = "second_item"    // 第二件折扣（如第二件半价），同一SKU每两件中一件打折
+	PromotionBundle        = "bundle"         // 组合价，指定商品各一件按组合价计
+)
+
+// 促销适用范围
+const (
+	PromotionScopeAll      = "all"      // 全场
+	PromotionScopeProduct  = "product"  // 指定商品
+	PromotionScopeCategory = "category" // 指定分类（含子分类）
+)
+
+// PromotionTier 满减档位
+type PromotionTier struct {
+	Threshold float64 `json:"threshold"` // 门槛金额
+	Reduction float64 `json:"reduction"` // 减免金额
+}
+
+// PromotionRules 促销规则，按促销类型使用对应字段
+type PromotionRules struct {
+	Tiers      []PromotionTier `json:"tiers,omitempty"`       // 满减档位
+	Count      int             `json:"count,omitempty"`       // N件X元的件数N
+	Price      float64         `json:"price,omitempty"`       // N件X元的总价X，或组合价
+	Rate       float64         `json:"rate,omitempty"`        // 第二件的折扣率，0.5为半价
+	ProductIDs []uint64        `json:"product_ids,omitempty"` // 组合商品
+}
+
+// Promotion 全场促销活动，结算时由促销引擎与优惠券一起计算最优组合
+type Promotion struct {
+	BaseModel
+	Name        string    `gorm:"type:varchar(100);not null" json:"name"`
+	Type        string    `gorm:"type:varchar(20);not null" json:"type"`
+	ScopeType   string    `gorm:"type:varchar(20);not null;default:'all'" json:"scope_type"`
+	ScopeIDs    string    `gorm:"column:scope_ids;type:text" json:"scope_ids"` // JSON格式存储商品或分类ID
+	Rules       string    `gorm:"type:text;not null" json:"rules"`             // JSON格式存储促销规则
+	StackCoupon bool      `gorm:"default:true" json:"stack_coupon"`            // 是否可与优惠券同享
+	StartAt     time.Time `gorm:"not null;index" json:"start_at"`
+	EndAt       time.Time `gorm:"not null;index" json:"end_at"`
+	Status      int       `gorm:"type:tinyint;default:1" json:"status"` // 1-启用，0-停用
+}
+
+// TableName 表名
+func (Promotion) TableName() string {
+	return "promotions"
+}
+
+// GetScopeIDs 获取适用的商品或分类ID
+func (p *Promotion) GetScopeIDs() []uint64 {
+	var ids []uint64
+	if p.ScopeIDs != "" {
+		_ = json.Unmarshal([]byte(p.ScopeIDs), &ids)
+	}
+	return ids
+}
+
+// SetScopeIDs 设置适用的商品或分类ID
+func (p *Promotion) SetScopeIDs(ids []uint64) {
+	data, _ := json.Marshal(ids)
+	p.ScopeIDs = string(data)
+}
+
+// GetRules 获取促销规则
+func (p *Promotion) GetRules() *PromotionRules {
+	var rules PromotionRules
+	if p.Rules != "" {
+		_ = json.Unmarshal([]byte(p.Rules), &rules)
+	}
+	return &rules
+}
+
+// SetRules 设置促销规则
+func (p *Promotion) SetRules(rules *PromotionRules) {
+	data, _ := json.Marshal(rules)
+	p.Rules = string(data)
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 系统内置角色
+const (
+	RoleSuperAdmin = "super_admin" // 超级管理员，拥有全部权限
+	RoleAdmin      = "admin"       // 运营管理员
+)
+
+// 权限编码，格式为 资源:操作
+const (
+	PermUserRead      = "user:read"
+	PermUserWrite     = "user:write"
+	PermRoleManage    = "role:manage"
+	PermProductWrite  = "product:write"
+	PermCategoryWrite = "category:write"
+	PermOrderRead     = "order:read"
+	PermOrderWrite    = "order:write"
+	PermOrderShip     = "order:ship"
+	PermCouponWrite   = "coupon:write"
+	PermAuditRead     = "audit:read"
+	PermMemberManage  = "member:manage"
+	PermMarketing     = "marketing:manage"
+)
+
+// Permission 权限模型
+type Permission struct {
+	BaseModel
+	Code        string `gorm:"type:varchar(100);uniqueIndex;not null" json:"code"`
+	Name        string `gorm:"type:varchar(50);not null" json:"name"`
+	Description string `gorm:"type:varchar(255)" json:"description"`
+}
+
+// TableName 表名
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// Role 角色模型
+type Role struct {
+	BaseModel
+	Name        string        `gorm:"type:varchar(50);uniqueIndex;not null" json:"name"`
+	DisplayName string        `gorm:"type:varchar(50);not null" json:"display_name"`
+	Description string        `gorm:"type:varchar(255)" json:"description"`
+	IsSystem    bool          `gorm:"default:false" json:"is_system"` // 系统内置角色不可删除
+	Permissions []*Permission `gorm:"many2many:role_permissions" json:"permissions,omitempty"`
+}
+
+// TableName 表名
+func (Role) TableName() string {
+	return "roles"
+}
+
+// UserRole 用户角色关联
+type UserRole struct {
+	UserID uint64 `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	RoleID uint64 `gorm:"primaryKey;autoIncrement:false;index" json:"role_id"`
+}
+
+// TableName 表名
+func (UserRole) TableName() string {
+	return "user_roles"
+}
+
+// DefaultPermissions 系统内置权限
+var DefaultPermissions = []Permission{
+	{Code: PermUserRead, Name: "查看用户"},
+	{Code: PermUserWrite, Name: "管理用户", Description: "修改用户资料、启用禁用、删除用户"},
+	{Code: PermRoleManage, Name: "管理角色", Description: "维护角色权限并为用户分配角色"},
+	{Code: PermProductWrite, Name: "管理商品", Description: "创建、修改、删除、上下架商品"},
+	{Code: PermCategoryWrite, Name: "管理分类"},
+	{Code: PermOrderRead, Name: "查看订单", Description: "查看全部订单和订单统计"},
+	{Code: PermOrderWrite, Name: "管理订单", Description: "修改订单状态"},
+	{Code: PermOrderShip, Name: "订单发货"},
+	{Code: PermCouponWrite, Name: "管理优惠券"},
+	{Code: PermAuditRead, Name: "查看审计日志", Description: "查看后台管理操作记录"},
+	{Code: PermMemberManage, Name: "管理会员等级", Description: "维护会员等级、折扣、包邮门槛和升级礼包"},
+	{Code: PermMarketing, Name: "管理营销活动", Description: "维护任务奖励、首页内容位、秒杀、拼团、促销等营销活动"},
+}
+
+// seedRBAC 初始化内置权限和角色，已存在时只补充缺失的权限
+func seedRBAC(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i := range DefaultPermissions {
+			perm := DefaultPermissions[i]
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "code"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "description"}),
+			}).Create(&perm).Error; err != nil {
+				return err
+			}
+		}
+
+		var permissions []*Permission
+		if err := tx.Find(&permissions).Error; err != nil {
+			return err
+		}
+
+		// 超级管理员始终拥有全部权限
+		superAdmin := &Role{}
+		if err := tx.Where(Role{Name: RoleSuperAdmin}).
+			Attrs(Role{DisplayName: "超级管理员", Description: "拥有全部权限", IsSystem: true}).
+			FirstOrCreate(superAdmin).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(superAdmin).Association("Permissions").Replace(permissions); err != nil {
+			return err
+		}
+
+		// 运营管理员首次创建时授予除角色管理外的权限，之后可自行调整
+		admin := &Role{}
+		result := tx.Where(Role{Name: RoleAdmin}).
+			Attrs(Role{DisplayName: "管理员", Description: "日常运营管理", IsSystem: true}).
+			FirstOrCreate(admin)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			var adminPermissions []*Permission
+			for _, perm := range permissions {
+				if perm.Code != PermRoleManage {
+					adminPermissions = append(adminPermissions, perm)
+				}
+			}
+			if err := tx.Model(admin).Association("Permissions").Replace(adminPermissions); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// ProductReview 商品评价，订单完成后每件订单商品可评价一次
+type ProductReview struct {
+	BaseModel
+	UserID      uint64 `gorm:"not null;index" json:"user_id"`
+	OrderID     uint64 `gorm:"not null;index" json:"order_id"`
+	OrderItemID uint64 `gorm:"not null;uniqueIndex" json:"order_item_id"`
+	ProductID   uint64 `gorm:"not null;index" json:"product_id"`
+	SKUID       uint64 `gorm:"column:sku_id;not null" json:"sku_id"`
+	Rating      int    `gorm:"type:tinyint;not null" json:"rating"` // 1-5星
+	Content     string `gorm:"type:varchar(1000)" json:"content"`
+	Images      string `gorm:"type:text" json:"images"` // JSON格式存储图片数组
+}
+
+// TableName 表名
+func (ProductReview) TableName() string {
+	return "product_reviews"
+}
+
+// GetImages 获取评价图片数组
+func (r *ProductReview) GetImages() []string {
+	var images []string
+	if r.Images != "" {
+		_ = json.Unmarshal([]byte(r.Images), &images)
+	}
+	return images
+}
+
+// SetImages 设置评价图片数组
+func (r *ProductReview) SetImages(images []string) {
+	data, _ := json.Marshal(images)
+	r.Images = string(data)
+}
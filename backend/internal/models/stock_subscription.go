@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// StockSubscription 缺货SKU的到货提醒订阅，提醒一次后失效，可重新订阅
+type StockSubscription struct {
+	ID         uint64     `gorm:"primarykey" json:"id"`
+	UserID     uint64     `gorm:"not null;uniqueIndex:idx_user_stock_subscription" json:"user_id"`
+	SKUID      uint64     `gorm:"column:sku_id;not null;uniqueIndex:idx_user_stock_subscription;index:idx_stock_subscription_pending" json:"sku_id"`
+	ProductID  uint64     `gorm:"not null" json:"product_id"`
+	NotifiedAt *time.Time `gorm:"index:idx_stock_subscription_pending" json:"notified_at"` // 为空表示等待到货
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName 表名
+func (StockSubscription) TableName() string {
+	return "stock_subscriptions"
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 任务编码
+const (
+	TaskCompleteProfile = "complete_profile" // 完善资料
+	TaskFirstOrder      = "first_order"      // 首次完成订单
+	TaskFirstReview     = "first_review"     // 首次评价
+)
+
+// 任务奖励类型
+const (
+	TaskRewardPoints = "points" // 积分
+	TaskRewardCoupon = "coupon" // 优惠券
+)
+
+// Task 一次性任务，完成后自动发放奖励
+type Task struct {
+	BaseModel
+	Code           string `gorm:"type:varchar(50);uniqueIndex;not null" json:"code"`
+	Name           string `gorm:"type:varchar(50);not null" json:"name"`
+	Description    string `gorm:"type:varchar(255)" json:"description"`
+	RewardType     string `gorm:"type:varchar(20);not null" json:"reward_type"`
+	RewardPoints   int64  `gorm:"default:0" json:"reward_points"`
+	CouponID       uint64 `gorm:"default:0" json:"coupon_id"`
+	CouponQuantity int    `gorm:"default:0" json:"coupon_quantity"`
+	Sort           int    `gorm:"default:0" json:"sort"`
+	Status         int    `gorm:"type:tinyint;default:1" json:"status"` // 1-启用，0-停用
+}
+
+// TableName 表名
+func (Task) TableName() string {
+	return "tasks"
+}
+
+// UserTask 用户已完成的任务，每个任务只完成一次
+type UserTask struct {
+	ID          uint64    `gorm:"primarykey" json:"id"`
+	UserID      uint64    `gorm:"not null;uniqueIndex:idx_user_task" json:"user_id"`
+	TaskID      uint64    `gorm:"not null;uniqueIndex:idx_user_task" json:"task_id"`
+	CompletedAt time.Time `gorm:"not null" json:"completed_at"`
+}
+
+// TableName 表名
+func (UserTask) TableName() string {
+	return "user_tasks"
+}
+
+// CheckinRecord 签到记录，签到日历同时写入Redis位图
+type CheckinRecord struct {
+	ID        uint64    `gorm:"primarykey" json:"id"`
+	UserID    uint64    `gorm:"not null;uniqueIndex:idx_checkin_user_date" json:"user_id"`
+	Date      string    `gorm:"type:char(10);not null;uniqueIndex:idx_checkin_user_date" json:"date"` // 2006-01-02
+	Streak    int       `gorm:"not null" json:"streak"`                                               // 连续签到天数
+	Points    int64     `gorm:"not null" json:"points"`                                               // 获得积分
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (CheckinRecord) TableName() string {
+	return "checkin_records"
+}
+
+// defaultTasks 内置任务，按编码补充缺失的任务
+var defaultTasks = []Task{
+	{Code: TaskCompleteProfile, Name: "完善个人资料", Description: "设置昵称、头像并绑定手机号或邮箱", RewardType: TaskRewardPoints, RewardPoints: 50, Sort: 1},
+	{Code: TaskFirstOrder, Name: "完成首笔订单", Description: "首次确认收货", RewardType: TaskRewardPoints, RewardPoints: 100, Sort: 2},
+	{Code: TaskFirstReview, Name: "发表首条评价", Description: "首次评价已购商品", RewardType: TaskRewardPoints, RewardPoints: 50, Sort: 3},
+}
+
+// seedTasks 初始化内置任务，已存在的任务不覆盖
+func seedTasks(db *gorm.DB) error {
+	tasks := make([]Task, len(defaultTasks))
+	copy(tasks, defaultTasks)
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tasks).Error
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken 刷新令牌模型（只保存令牌哈希）
+type RefreshToken struct {
+	BaseModel
+	UserID     uint64     `gorm:"not null;index" json:"user_id"`
+	FamilyID   string     `gorm:"type:varchar(32);not null;index" json:"family_id"` // 令牌族ID，同一次登录轮换出的令牌属于同一族
+	TokenHash  string     `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at"`    // 已轮换时间，非空表示该令牌已被使用
+	RevokedAt  *time.Time `json:"revoked_at"` // 吊销时间
+	ReplacedBy uint64     `gorm:"default:0" json:"replaced_by"`
+}
+
+// TableName 表名
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// IsExpired 检查是否过期
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// IsActive 检查是否可用
+func (t *RefreshToken) IsActive() bool {
+	return t.UsedAt == nil && t.RevokedAt == nil && !t.IsExpired()
+}
+
+// UserSession 用户登录会话（一次登录对应一个刷新令牌族）
+type UserSession struct {
+	BaseModel
+	UserID     uint64     `gorm:"not null;index" json:"user_id"`
+	FamilyID   string     `gorm:"type:varchar(32);uniqueIndex;not null" json:"-"`
+	DeviceName string     `gorm:"type:varchar(100)" json:"device_name"`
+	UserAgent  string     `gorm:"type:varchar(255)" json:"user_agent"`
+	IP         string     `gorm:"type:varchar(64)" json:"ip"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"` // 退出登录时间
+}
+
+// TableName 表名
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+// IsActive 检查会话是否有效
+func (s *UserSession) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// PasswordReset 密码重置令牌（只保存令牌哈希）
+type PasswordReset struct {
+	BaseModel
+	UserID    uint64     `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	Channel   string     `gorm:"type:varchar(10)" json:"channel"` // 发送渠道：sms、email
+	IP        string     `gorm:"type:varchar(64)" json:"ip"`      // 申请IP
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// TableName 表名
+func (PasswordReset) TableName() string {
+	return "password_resets"
+}
+
+// IsActive 检查是否可用
+func (r *PasswordReset) IsActive() bool {
+	return r.UsedAt == nil && time.Now().Before(r.ExpiresAt)
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// UserTOTP 用户TOTP双因素认证配置
+type UserTOTP struct {
+	BaseModel
+	UserID       uint64     `gorm:"not null;uniqueIndex" json:"user_id"`
+	Secret       string     `gorm:"type:varchar(255);not null" json:"-"` // 加密后的Base32密钥
+	EnabledAt    *time.Time `json:"enabled_at"`                          // 启用时间，为空表示尚未完成绑定
+	LastUsedStep int64      `gorm:"default:0" json:"-"`                  // 最近一次使用的时间步，防止验证码重放
+}
+
+// TableName 表名
+func (UserTOTP) TableName() string {
+	return "user_totps"
+}
+
+// IsEnabled 是否已启用
+func (t *UserTOTP) IsEnabled() bool {
+	return t.EnabledAt != nil
+}
+
+// UserRecoveryCode 双因素认证恢复码（只保存哈希）
+type UserRecoveryCode struct {
+	BaseModel
+	UserID   uint64     `gorm:"not null;index" json:"user_id"`
+	CodeHash string     `gorm:"type:char(64);not null;index" json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+// TableName 表名
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
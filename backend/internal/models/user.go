@@ -10,14 +10,16 @@ import (
 // User 用户模型
 type User struct {
 	BaseModel
-	Username    string     `gorm:"type:varchar(50);uniqueIndex;not null" json:"username" validate:"required,min=3,max=50"`
-	Password    string     `gorm:"type:varchar(255);not null" json:"-" validate:"required,min=6"`
-	Phone       string     `gorm:"type:varchar(20);uniqueIndex" json:"phone" validate:"e164"`
-	Email       string     `gorm:"type:varchar(100);uniqueIndex" json:"email" validate:"email"`
-	Nickname    string     `gorm:"type:varchar(50)" json:"nickname"`
-	Avatar      string     `gorm:"type:varchar(255)" json:"avatar"`
-	Status      int        `gorm:"type:tinyint;default:1" json:"status"` // 1-正常，0-禁用
-	LastLoginAt *time.Time `json:"last_login_at"`
+	Username        string     `gorm:"type:varchar(50);uniqueIndex;not null" json:"username" validate:"required,min=3,max=50"`
+	Password        string     `gorm:"type:varchar(255);not null" json:"-" validate:"required,min=6"`
+	Phone           string     `gorm:"type:varchar(20);uniqueIndex;default:null" json:"phone" validate:"e164"` // 未填写时存NULL，避免唯一索引冲突
+	Email           string     `gorm:"type:varchar(100);uniqueIndex;default:null" json:"email" validate:"email"`
+	Nickname        string     `gorm:"type:varchar(50)" json:"nickname"`
+	Avatar          string     `gorm:"type:varchar(255)" json:"avatar"`
+	Status          int        `gorm:"type:tinyint;default:1" json:"status"` // 1-正常，0-禁用
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`                    // 手机号验证时间
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`                    // 邮箱验证时间
+	LastLoginAt     *time.Time `json:"last_login_at"`
 }
 
 // TableName 表名
@@ -64,5 +66,7 @@ type UserQuery struct {
 	Username string `form:"username" json:"username"`
 	Phone    string `form:"phone" json:"phone"`
 	Email    string `form:"email" json:"email"`
-	Status   int    `form:"status" json:"status"`
+	Status   *int   `form:"status" json:"status"`   // 不传时查询全部状态
+	Keyword  string `form:"keyword" json:"keyword"` // 用户名、昵称、手机号、邮箱模糊搜索
+	Sort     string `form:"sort" json:"sort"`       // created_desc（默认）、created_asc、last_login_desc
 }
@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"online-mall/internal/config"
+	"sync"
+)
+
+// 通知渠道
+const (
+	ChannelSMS   = "sms"
+	ChannelEmail = "email"
+)
+
+// 发送驱动
+const (
+	DriverLog  = "log"
+	DriverFile = "file"
+	DriverLive = "live"
+)
+
+// Message 通知消息
+type Message struct {
+	Channel string // 渠道：sms、email
+	To      string // 手机号或邮箱
+	Subject string // 标题（邮件使用）
+	Content string // 内容
+}
+
+// Sender 消息发送接口
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// router 按渠道分发消息
+type router struct {
+	senders map[string]Sender
+}
+
+// Send 发送消息
+func (r *router) Send(ctx context.Context, msg *Message) error {
+	sender, ok := r.senders[msg.Channel]
+	if !ok {
+		return fmt.Errorf("unsupported notify channel: %s", msg.Channel)
+	}
+	return sender.Send(ctx, msg)
+}
+
+// NewSender 根据配置创建发送器
+func NewSender(cfg config.NotifyConfig) (Sender, error) {
+	switch cfg.Driver {
+	case DriverLive:
+		return &router{senders: map[string]Sender{
+			ChannelSMS:   NewSMSSender(cfg.SMS),
+			ChannelEmail: NewSMTPSender(cfg.SMTP),
+		}}, nil
+	case DriverFile:
+		return NewFileSender(cfg.FilePath), nil
+	case DriverLog, "":
+		return NewLogSender(), nil
+	default:
+		return nil, fmt.Errorf("unknown notify driver: %s", cfg.Driver)
+	}
+}
+
+var (
+	defaultSender Sender
+	defaultOnce   sync.Once
+)
+
+// Default 获取全局发送器（首次使用时按配置创建）
+func Default() Sender {
+	defaultOnce.Do(func() {
+		sender, err := NewSender(config.GlobalConfig.Notify)
+		if err != nil {
+			log.Printf("Failed to create notify sender: %v, fallback to log sender", err)
+			sender = NewLogSender()
+		}
+		defaultSender = sender
+	})
+	return defaultSender
+}
+
+// Send 使用全局发送器发送消息
+func Send(ctx context.Context, msg *Message) error {
+	return Default().Send(ctx, msg)
+}
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"online-mall/internal/config"
+	"time"
+)
+
+// SMSSender 通过HTTP短信网关发送短信
+type SMSSender struct {
+	cfg    config.SMSConfig
+	client *http.Client
+}
+
+// NewSMSSender 创建短信发送器
+func NewSMSSender(cfg config.SMSConfig) *SMSSender {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &SMSSender{
+		cfg:    cfg,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// smsRequest 短信网关请求体
+type smsRequest struct {
+	Phone    string `json:"phone"`
+	SignName string `json:"sign_name"`
+	Content  string `json:"content"`
+}
+
+// Send 发送短信
+func (s *SMSSender) Send(ctx context.Context, msg *Message) error {
+	if s.cfg.Endpoint == "" {
+		return errors.New("sms endpoint is not configured")
+	}
+
+	body, err := json.Marshal(smsRequest{
+		Phone:    msg.To,
+		SignName: s.cfg.SignName,
+		Content:  msg.Content,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.cfg.APIKey)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms gateway returned %d: %s", resp.StatusCode, string(data))
+	}
+
+	return nil
+}
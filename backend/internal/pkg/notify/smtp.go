@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"online-mall/internal/config"
+	"strings"
+	"time"
+)
+
+// SMTPSender 通过SMTP发送邮件
+type SMTPSender struct {
+	cfg config.SMTPConfig
+}
+
+// NewSMTPSender 创建邮件发送器
+func NewSMTPSender(cfg config.SMTPConfig) *SMTPSender {
+	return &SMTPSender{cfg: cfg}
+}
+
+// buildMail 构建邮件内容
+func (s *SMTPSender) buildMail(msg *Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + s.cfg.From + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Content)
+	return []byte(b.String())
+}
+
+// Send 发送邮件
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	if s.cfg.Host == "" {
+		return errors.New("smtp host is not configured")
+	}
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return errors.New("invalid email address")
+	}
+
+	addr := fmt.Sprintf("%s:%d", s.cfg.Host, s.cfg.Port)
+	auth := smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	body := s.buildMail(msg)
+
+	// 非465端口使用STARTTLS
+	if s.cfg.Port != 465 {
+		return smtp.SendMail(addr, auth, s.cfg.From, []string{msg.To}, body)
+	}
+
+	// 465端口使用隐式TLS
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: s.cfg.Host})
+	if err != nil {
+		return fmt.Errorf("failed to connect smtp server: %v", err)
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Auth(auth); err != nil {
+		return err
+	}
+	if err := client.Mail(s.cfg.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
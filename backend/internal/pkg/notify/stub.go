@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LogSender 本地开发用发送器，只打印日志
+type LogSender struct{}
+
+// NewLogSender 创建日志发送器
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+// Send 发送消息
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	log.Printf("[NOTIFY] channel=%s to=%s subject=%q content=%q", msg.Channel, msg.To, msg.Subject, msg.Content)
+	return nil
+}
+
+// FileSender 本地开发用发送器，将消息追加写入文件
+type FileSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSender 创建文件发送器
+func NewFileSender(path string) *FileSender {
+	return &FileSender{path: path}
+}
+
+// Send 发送消息
+func (s *FileSender) Send(ctx context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\t%s\t%s\n",
+		time.Now().Format("2006-01-02 15:04:05"), msg.Channel, msg.To, msg.Subject, msg.Content)
+	return err
+}
@@ -0,0 +1,140 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"online-mall/internal/config"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsData string
+
+var (
+	commonPasswords     map[string]struct{}
+	commonPasswordsOnce sync.Once
+)
+
+// maxBytes bcrypt支持的最大密码字节数，超出时bcrypt直接报错
+const maxBytes = 72
+
+// PolicyError 密码不符合策略
+type PolicyError struct {
+	Message string
+}
+
+// Error 实现error接口
+func (e *PolicyError) Error() string {
+	return e.Message
+}
+
+// newPolicyError 创建策略错误
+func newPolicyError(format string, args ...interface{}) *PolicyError {
+	return &PolicyError{Message: fmt.Sprintf(format, args...)}
+}
+
+// loadCommonPasswords 解析内置的常见密码列表
+func loadCommonPasswords() {
+	commonPasswords = make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswordsData))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		commonPasswords[strings.ToLower(line)] = struct{}{}
+	}
+}
+
+// IsCommon 判断是否为常见泄露密码（忽略大小写）
+func IsCommon(password string) bool {
+	commonPasswordsOnce.Do(loadCommonPasswords)
+	_, ok := commonPasswords[strings.ToLower(password)]
+	return ok
+}
+
+// Validate 按配置的密码策略校验密码
+// identities 为用户名、手机号等账号标识，密码中不允许包含这些内容
+func Validate(password string, identities ...string) error {
+	cfg := config.GlobalConfig.Password
+
+	length := utf8.RuneCountInString(password)
+	if length < cfg.MinLength {
+		return newPolicyError("密码长度不能少于%d位", cfg.MinLength)
+	}
+	if cfg.MaxLength > 0 && length > cfg.MaxLength {
+		return newPolicyError("密码长度不能超过%d位", cfg.MaxLength)
+	}
+	// 中文等多字节字符按UTF-8编码后可能超出bcrypt的字节上限
+	if len(password) > maxBytes {
+		return newPolicyError("密码过长，不能超过%d字节（中文等字符每个占3字节）", maxBytes)
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsSpace(r) || unicode.IsControl(r):
+			return newPolicyError("密码不能包含空白或控制字符")
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+
+	if cfg.RequireLetter && !hasLower && !hasUpper {
+		return newPolicyError("密码必须包含字母")
+	}
+	if cfg.RequireUpper && !hasUpper {
+		return newPolicyError("密码必须包含大写字母")
+	}
+	if cfg.RequireLower && !hasLower {
+		return newPolicyError("密码必须包含小写字母")
+	}
+	if cfg.RequireDigit && !hasDigit {
+		return newPolicyError("密码必须包含数字")
+	}
+	if cfg.RequireSymbol && !hasSymbol {
+		return newPolicyError("密码必须包含特殊字符")
+	}
+
+	if cfg.ForbidIdentity {
+		lower := strings.ToLower(password)
+		for _, identity := range identities {
+			identity = strings.ToLower(strings.TrimSpace(identity))
+			// 过短的标识容易误伤，不做限制
+			if utf8.RuneCountInString(identity) < 3 {
+				continue
+			}
+			if strings.Contains(lower, identity) {
+				return newPolicyError("密码不能包含用户名、手机号或邮箱")
+			}
+		}
+	}
+
+	if cfg.CheckCommon && IsCommon(password) {
+		return newPolicyError("密码过于常见，存在泄露风险，请更换")
+	}
+
+	return nil
+}
+
+// Identities 从用户名、手机号、邮箱中提取用于比对的账号标识
+func Identities(username, phone, email string) []string {
+	identities := []string{username}
+	if phone = strings.TrimPrefix(phone, "+"); phone != "" {
+		identities = append(identities, phone)
+	}
+	if local, _, ok := strings.Cut(email, "@"); ok {
+		identities = append(identities, local)
+	}
+	return identities
+}
@@ -0,0 +1,89 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"online-mall/internal/config"
+	"strings"
+	"time"
+)
+
+// GatewayProvider 通过HTTP支付网关退款
+type GatewayProvider struct {
+	cfg    config.PaymentConfig
+	client *http.Client
+}
+
+// NewGatewayProvider 创建支付网关渠道
+func NewGatewayProvider(cfg config.PaymentConfig) *GatewayProvider {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &GatewayProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// refundRequest 网关退款请求体
+type refundRequest struct {
+	OrderNo  string `json:"order_no"`
+	RefundNo string `json:"refund_no"`
+	Amount   string `json:"amount"`
+	Reason   string `json:"reason"`
+}
+
+// refundResponse 网关退款响应体
+type refundResponse struct {
+	RefundID string `json:"refund_id"`
+}
+
+// Refund 退款
+func (p *GatewayProvider) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	if p.cfg.Endpoint == "" {
+		return nil, errors.New("payment endpoint is not configured")
+	}
+
+	body, err := json.Marshal(refundRequest{
+		OrderNo:  req.OrderNo,
+		RefundNo: req.RefundNo,
+		Amount:   fmt.Sprintf("%.2f", req.Amount),
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimRight(p.cfg.Endpoint, "/") + "/refunds"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.cfg.APIKey)
+	// 网关按退款单号去重，超时重试不会重复退款
+	httpReq.Header.Set("Idempotency-Key", req.RefundNo)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request refund: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("payment gateway returned %d: %s", resp.StatusCode, string(data))
+	}
+
+	var result refundResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid payment gateway response: %v", err)
+	}
+	return &RefundResult{RefundNo: req.RefundNo, ProviderID: result.RefundID}, nil
+}
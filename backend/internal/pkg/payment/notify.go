@@ -0,0 +1,42 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"online-mall/internal/config"
+)
+
+// ErrInvalidSignature 支付通知签名无效
+var ErrInvalidSignature = errors.New("invalid payment notification signature")
+
+// Notification 支付网关的支付成功通知
+type Notification struct {
+	OrderNo string `json:"order_no"` // 商户订单号
+	TradeNo string `json:"trade_no"` // 支付渠道的交易流水号
+	Amount  string `json:"amount"`   // 支付金额（元）
+	Method  string `json:"method"`   // 支付方式
+}
+
+// Sign 计算通知签名：请求体的HMAC-SHA256（十六进制）
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseNotification 校验签名并解析支付成功通知，未配置签名密钥时拒绝所有通知
+func ParseNotification(body []byte, signature string) (*Notification, error) {
+	secret := config.GlobalConfig.Payment.NotifySecret
+	if secret == "" || !hmac.Equal([]byte(Sign(secret, body)), []byte(signature)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var notification Notification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
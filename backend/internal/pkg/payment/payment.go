@@ -0,0 +1,69 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"online-mall/internal/config"
+	"sync"
+)
+
+// 支付驱动
+const (
+	DriverLog  = "log"
+	DriverLive = "live"
+)
+
+// RefundRequest 退款请求
+type RefundRequest struct {
+	OrderNo  string  // 商户订单号
+	RefundNo string  // 商户退款单号，同一退款单号重复提交不会重复退款
+	Amount   float64 // 退款金额（元）
+	Reason   string  // 退款原因
+}
+
+// RefundResult 退款结果
+type RefundResult struct {
+	RefundNo   string // 商户退款单号
+	ProviderID string // 支付渠道的退款流水号
+}
+
+// Provider 支付渠道接口
+type Provider interface {
+	Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error)
+}
+
+// NewProvider 根据配置创建支付渠道
+func NewProvider(cfg config.PaymentConfig) (Provider, error) {
+	switch cfg.Driver {
+	case DriverLive:
+		return NewGatewayProvider(cfg), nil
+	case DriverLog, "":
+		return NewLogProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment driver: %s", cfg.Driver)
+	}
+}
+
+var (
+	defaultProvider Provider
+	defaultOnce     sync.Once
+)
+
+// Default 获取全局支付渠道（首次使用时按配置创建）
+func Default() Provider {
+	defaultOnce.Do(func() {
+		provider, err := NewProvider(config.GlobalConfig.Payment)
+		if err != nil {
+			log.Printf("Failed to create payment provider: %v, fallback to log provider", err)
+			provider = NewLogProvider()
+		}
+		defaultProvider = provider
+	})
+	return defaultProvider
+}
+
+// Refund 使用全局支付渠道退款
+func Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	return Default().Refund(ctx, req)
+}
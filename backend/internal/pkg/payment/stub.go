@@ -0,0 +1,20 @@
+package payment
+
+import (
+	"context"
+	"log"
+)
+
+// LogProvider 本地开发用支付渠道，只打印日志，退款总是成功
+type LogProvider struct{}
+
+// NewLogProvider 创建日志支付渠道
+func NewLogProvider() *LogProvider {
+	return &LogProvider{}
+}
+
+// Refund 退款
+func (p *LogProvider) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	log.Printf("[PAYMENT] refund order=%s refund_no=%s amount=%.2f reason=%q", req.OrderNo, req.RefundNo, req.Amount, req.Reason)
+	return &RefundResult{RefundNo: req.RefundNo, ProviderID: "log-" + req.RefundNo}, nil
+}
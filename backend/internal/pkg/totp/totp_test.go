@@ -0,0 +1,130 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 4226/6238 测试向量使用的SHA1密钥 "12345678901234567890"（Base32编码）
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeAtRFC4226(t *testing.T) {
+	key := []byte("12345678901234567890")
+	// RFC 4226 附录D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		if got := codeAt(key, int64(counter)); got != code {
+			t.Errorf("codeAt(counter=%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	// RFC 6238 附录B的SHA1向量，取8位结果的后6位
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("Code(%d) error: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeAcceptsLowercaseAndSpaces(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	want, _ := Code(rfcSecret, now)
+	secret := strings.ToLower(rfcSecret[:16]) + " " + rfcSecret[16:]
+	got, err := Code(secret, now)
+	if err != nil {
+		t.Fatalf("Code error: %v", err)
+	}
+	if got != want {
+		t.Errorf("Code(%q) = %s, want %s", secret, got, want)
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+	codeFor := func(offset int64) string {
+		code, err := Code(rfcSecret, now.Add(time.Duration(offset*Period)*time.Second))
+		if err != nil {
+			t.Fatalf("Code error: %v", err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		offset   int64
+		skew     int
+		wantOK   bool
+		wantStep int64
+	}{
+		{"current step", 0, 0, true, current},
+		{"previous step without skew", -1, 0, false, 0},
+		{"previous step within skew", -1, 1, true, current - 1},
+		{"next step within skew", 1, 1, true, current + 1},
+		{"two steps behind outside skew", -2, 1, false, 0},
+		{"two steps ahead outside skew", 2, 1, false, 0},
+		{"two steps behind within wider skew", -2, 2, true, current - 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, codeFor(tt.offset), now, tt.skew)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Validate() = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsMalformedInput(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := Code(rfcSecret, now)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+	}{
+		{"short code", rfcSecret, code[:5]},
+		{"long code", rfcSecret, code + "0"},
+		{"invalid secret", "not-base32!", code},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Validate(tt.secret, tt.code, now, 1); ok {
+				t.Errorf("Validate(%q, %q) accepted", tt.secret, tt.code)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret error: %v", err)
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		t.Fatalf("decodeSecret(%q) error: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret length = %d bytes, want 20", len(key))
+	}
+}
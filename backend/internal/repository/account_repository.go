@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AccountRepository 数据导出和账号注销数据访问层
+type AccountRepository struct{}
+
+// NewAccountRepository 创建账号Repository实例
+func NewAccountRepository() *AccountRepository {
+	return &AccountRepository{}
+}
+
+// CreateExport 创建导出任务
+func (r *AccountRepository) CreateExport(export *models.DataExport) error {
+	return models.DB.Create(export).Error
+}
+
+// UpdateExport 更新导出任务
+func (r *AccountRepository) UpdateExport(id uint64, updates map[string]interface{}) error {
+	return models.DB.Model(&models.DataExport{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// GetLatestExport 获取用户最近一次导出任务
+func (r *AccountRepository) GetLatestExport(userID uint64) (*models.DataExport, error) {
+	var export models.DataExport
+	err := models.DB.Where("user_id = ?", userID).Order("id DESC").First(&export).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// GetExports 获取用户最近的导出任务
+func (r *AccountRepository) GetExports(userID uint64, limit int) ([]*models.DataExport, error) {
+	var exports []*models.DataExport
+	err := models.DB.Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&exports).Error
+	return exports, err
+}
+
+// GetExpiredExports 获取已过保留期但文件未删除的导出任务
+func (r *AccountRepository) GetExpiredExports(now time.Time) ([]*models.DataExport, error) {
+	var exports []*models.DataExport
+	err := models.DB.Where("status = ? AND expires_at <= ?", models.DataExportCompleted, now).Find(&exports).Error
+	return exports, err
+}
+
+// FailStaleExports 将长时间未完成的导出任务标记为失败（如生成过程中服务重启）
+func (r *AccountRepository) FailStaleExports(before time.Time) error {
+	return models.DB.Model(&models.DataExport{}).
+		Where("status IN ? AND created_at < ?", []int{models.DataExportPending, models.DataExportProcessing}, before).
+		Updates(map[string]interface{}{
+			"status": models.DataExportFailed,
+			"error":  "timeout",
+		}).Error
+}
+
+// GetExportsWithFile 获取用户所有保存了文件的导出任务
+func (r *AccountRepository) GetExportsWithFile(userID uint64) ([]*models.DataExport, error) {
+	var exports []*models.DataExport
+	err := models.DB.Where("user_id = ? AND file_path <> ''", userID).Find(&exports).Error
+	return exports, err
+}
+
+// GetAddresses 获取用户的收货地址
+func (r *AccountRepository) GetAddresses(userID uint64) ([]*models.Address, error) {
+	var addresses []*models.Address
+	err := models.DB.Where("user_id = ?", userID).Order("id ASC").Find(&addresses).Error
+	return addresses, err
+}
+
+// GetOrders 获取用户的订单（含订单商品）
+func (r *AccountRepository) GetOrders(userID uint64) ([]*models.Order, error) {
+	var orders []*models.Order
+	err := models.DB.Preload("OrderItems").Where("user_id = ?", userID).Order("id ASC").Find(&orders).Error
+	return orders, err
+}
+
+// GetUserCoupons 获取用户领取的优惠券（含优惠券信息）
+func (r *AccountRepository) GetUserCoupons(userID uint64) ([]*models.UserCoupon, error) {
+	var coupons []*models.UserCoupon
+	err := models.DB.Preload("Coupon").Where("user_id = ?", userID).Order("id ASC").Find(&coupons).Error
+	return coupons, err
+}
+
+// GetReviews 获取用户发表的商品评价
+func (r *AccountRepository) GetReviews(userID uint64) ([]*models.ProductReview, error) {
+	var reviews []*models.ProductReview
+	err := models.DB.Where("user_id = ?", userID).Order("id ASC").Find(&reviews).Error
+	return reviews, err
+}
+
+// CountOpenOrders 统计用户未完成的订单数（待付款、待发货、待收货）
+func (r *AccountRepository) CountOpenOrders(userID uint64) (int64, error) {
+	var count int64
+	err := models.DB.Model(&models.Order{}).
+		Where("user_id = ? AND order_status IN ?", userID, []int{
+			models.OrderStatusPending,
+			models.OrderStatusToShip,
+			models.OrderStatusShipped,
+		}).
+		Count(&count).Error
+	return count, err
+}
+
+// CreateDeletion 创建注销申请
+func (r *AccountRepository) CreateDeletion(deletion *models.AccountDeletion) error {
+	return models.DB.Create(deletion).Error
+}
+
+// GetPendingDeletion 获取用户冷静期中的注销申请
+func (r *AccountRepository) GetPendingDeletion(userID uint64) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := models.DB.Where("user_id = ? AND status = ?", userID, models.AccountDeletionPending).First(&deletion).Error
+	if err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// GetDueDeletions 获取冷静期已结束的注销申请
+func (r *AccountRepository) GetDueDeletions(now time.Time, limit int) ([]*models.AccountDeletion, error) {
+	var deletions []*models.AccountDeletion
+	err := models.DB.Where("status = ? AND scheduled_at <= ?", models.AccountDeletionPending, now).
+		Order("scheduled_at ASC").
+		Limit(limit).
+		Find(&deletions).Error
+	return deletions, err
+}
+
+// UpdateDeletionStatus 更新仍处于冷静期的注销申请，返回是否更新成功
+func (r *AccountRepository) UpdateDeletionStatus(tx *gorm.DB, id uint64, updates map[string]interface{}) (bool, error) {
+	result := tx.Model(&models.AccountDeletion{}).
+		Where("id = ? AND status = ?", id, models.AccountDeletionPending).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
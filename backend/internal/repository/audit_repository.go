@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+)
+
+// AuditRepository 审计日志数据访问层
+type AuditRepository struct{}
+
+// NewAuditRepository 创建审计日志Repository实例
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+// Create 写入审计日志
+func (r *AuditRepository) Create(log *models.AuditLog) error {
+	return models.DB.Create(log).Error
+}
+
+// GetLogs 分页查询审计日志，按时间倒序
+func (r *AuditRepository) GetLogs(query *models.AuditLogQuery) ([]*models.AuditLog, int64, error) {
+	var logs []*models.AuditLog
+	var total int64
+
+	db := models.DB.Model(&models.AuditLog{})
+
+	if query.ActorID > 0 {
+		db = db.Where("actor_id = ?", query.ActorID)
+	}
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if query.TargetType != "" {
+		db = db.Where("target_type = ?", query.TargetType)
+	}
+	if query.TargetID > 0 {
+		db = db.Where("target_id = ?", query.TargetID)
+	}
+	if query.RequestID != "" {
+		db = db.Where("request_id = ?", query.RequestID)
+	}
+	if !query.StartDate.IsZero() {
+		db = db.Where("created_at >= ?", query.StartDate)
+	}
+	if !query.EndDate.IsZero() {
+		db = db.Where("created_at < ?", query.EndDate.AddDate(0, 0, 1))
+	}
+
+	// 获取总数
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (query.Page - 1) * query.PageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(query.PageSize).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
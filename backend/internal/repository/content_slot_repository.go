@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+	"time"
+)
+
+// ContentSlotRepository 内容位数据访问层
+type ContentSlotRepository struct{}
+
+// NewContentSlotRepository 创建内容位Repository实例
+func NewContentSlotRepository() *ContentSlotRepository {
+	return &ContentSlotRepository{}
+}
+
+// Create 创建内容位
+func (r *ContentSlotRepository) Create(slot *models.ContentSlot) error {
+	return models.DB.Create(slot).Error
+}
+
+// Update 更新内容位
+func (r *ContentSlotRepository) Update(id uint64, updates map[string]interface{}) error {
+	return models.DB.Model(&models.ContentSlot{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Delete 删除内容位
+func (r *ContentSlotRepository) Delete(id uint64) error {
+	return models.DB.Delete(&models.ContentSlot{}, id).Error
+}
+
+// GetByID 根据ID获取内容位
+func (r *ContentSlotRepository) GetByID(id uint64) (*models.ContentSlot, error) {
+	var slot models.ContentSlot
+	if err := models.DB.First(&slot, id).Error; err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// GetSlots 分页获取内容位，position为空时获取全部位置
+func (r *ContentSlotRepository) GetSlots(position string, page, pageSize int) ([]*models.ContentSlot, int64, error) {
+	var slots []*models.ContentSlot
+	var total int64
+
+	db := models.DB.Model(&models.ContentSlot{})
+	if position != "" {
+		db = db.Where("position = ?", position)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := db.Order("position ASC, sort ASC, id DESC").Offset(offset).Limit(pageSize).Find(&slots).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return slots, total, nil
+}
+
+// GetActive 获取某位置当前生效的内容位
+func (r *ContentSlotRepository) GetActive(position string, now time.Time, limit int) ([]*models.ContentSlot, error) {
+	var slots []*models.ContentSlot
+	err := models.DB.Where("position = ? AND status = ?", position, 1).
+		Where("start_at IS NULL OR start_at <= ?", now).
+		Where("end_at IS NULL OR end_at > ?", now).
+		Order("sort ASC, id DESC").
+		Limit(limit).
+		Find(&slots).Error
+	return slots, err
+}
+
+// GetNextChange 获取某位置启用的内容位中，now之后最早的开始或结束时间，没有时返回nil
+func (r *ContentSlotRepository) GetNextChange(position string, now time.Time) (*time.Time, error) {
+	var next *time.Time
+	for _, column := range []string{"start_at", "end_at"} {
+		var result struct {
+			At *time.Time
+		}
+		err := models.DB.Model(&models.ContentSlot{}).
+			Select("MIN("+column+") AS at").
+			Where("position = ? AND status = ? AND "+column+" > ?", position, 1, now).
+			Scan(&result).Error
+		if err != nil {
+			return nil, err
+		}
+		if result.At != nil && (next == nil || result.At.Before(*next)) {
+			next = result.At
+		}
+	}
+	return next, nil
+}
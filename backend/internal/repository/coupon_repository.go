@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"errors"
+	"online-mall/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CouponRepository 优惠券数据访问层
+type CouponRepository struct{}
+
+// NewCouponRepository 创建优惠券Repository实例
+func NewCouponRepository() *CouponRepository {
+	return &CouponRepository{}
+}
+
+// GetByIDs 批量获取优惠券
+func (r *CouponRepository) GetByIDs(ids []uint64) ([]*models.Coupon, error) {
+	var coupons []*models.Coupon
+	err := models.DB.Where("id IN ?", ids).Find(&coupons).Error
+	return coupons, err
+}
+
+// CreateUserCoupons 向用户发放优惠券
+func (r *CouponRepository) CreateUserCoupons(tx *gorm.DB, coupons []*models.UserCoupon) error {
+	if len(coupons) == 0 {
+		return nil
+	}
+	return tx.Omit("User", "Coupon").Create(&coupons).Error
+}
+
+// GetUserUnused 获取用户未使用的优惠券，按ID升序
+func (r *CouponRepository) GetUserUnused(userID uint64) ([]*models.UserCoupon, error) {
+	var coupons []*models.UserCoupon
+	err := models.DB.Preload("Coupon").
+		Where("user_id = ? AND status = ?", userID, 0).
+		Order("id ASC").
+		Find(&coupons).Error
+	return coupons, err
+}
+
+// GetUserCoupon 获取用户的某张优惠券
+func (r *CouponRepository) GetUserCoupon(userID uint64, id uint64) (*models.UserCoupon, error) {
+	var coupon models.UserCoupon
+	if err := models.DB.Preload("Coupon").Where("id = ? AND user_id = ?", id, userID).First(&coupon).Error; err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// UseCoupon 将未使用的用户优惠券标记为已使用，优惠券已被使用时返回false
+func (r *CouponRepository) UseCoupon(tx *gorm.DB, id uint64, orderID uint64, usedTime time.Time) (bool, error) {
+	result := tx.Model(&models.UserCoupon{}).
+		Where("id = ? AND status = ?", id, 0).
+		Updates(map[string]interface{}{
+			"status":    1,
+			"order_id":  orderID,
+			"used_time": usedTime,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	var coupon models.UserCoupon
+	if err := tx.Select("coupon_id").First(&coupon, id).Error; err != nil {
+		return false, err
+	}
+	err := tx.Model(&models.Coupon{}).Where("id = ?", coupon.CouponID).
+		UpdateColumn("used_count", gorm.Expr("used_count + 1")).Error
+	return err == nil, err
+}
+
+// ReturnOrderCoupon 退回订单使用的优惠券，订单未使用优惠券时返回false
+func (r *CouponRepository) ReturnOrderCoupon(tx *gorm.DB, orderID uint64) (bool, error) {
+	var coupon models.UserCoupon
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND status = ?", orderID, 1).
+		First(&coupon).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	err = tx.Model(&models.UserCoupon{}).Where("id = ?", coupon.ID).
+		Updates(map[string]interface{}{
+			"status":    0,
+			"order_id":  nil,
+			"used_time": nil,
+		}).Error
+	if err != nil {
+		return false, err
+	}
+	err = tx.Model(&models.Coupon{}).Where("id = ? AND used_count > 0", coupon.CouponID).
+		UpdateColumn("used_count", gorm.Expr("used_count - 1")).Error
+	return err == nil, err
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// FavoriteRepository 收藏数据访问层
+type FavoriteRepository struct{}
+
+// NewFavoriteRepository 创建收藏Repository实例
+func NewFavoriteRepository() *FavoriteRepository {
+	return &FavoriteRepository{}
+}
+
+// Create 添加收藏，已收藏时返回false
+func (r *FavoriteRepository) Create(favorite *models.Favorite) (bool, error) {
+	result := models.DB.Omit("Product").Clauses(clause.OnConflict{DoNothing: true}).Create(favorite)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountByUser 统计用户收藏数
+func (r *FavoriteRepository) CountByUser(userID uint64) (int64, error) {
+	var count int64
+	err := models.DB.Model(&models.Favorite{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// Delete 删除用户的收藏，返回是否删除成功
+func (r *FavoriteRepository) Delete(userID uint64, id uint64) (bool, error) {
+	result := models.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Favorite{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetByUserProduct 获取用户对某商品的收藏（含整个商品和各SKU）
+func (r *FavoriteRepository) GetByUserProduct(userID uint64, productID uint64) ([]*models.Favorite, error) {
+	var favorites []*models.Favorite
+	err := models.DB.Where("user_id = ? AND product_id = ?", userID, productID).Find(&favorites).Error
+	return favorites, err
+}
+
+// GetFavorites 分页获取用户收藏（含商品）
+func (r *FavoriteRepository) GetFavorites(userID uint64, page, pageSize int) ([]*models.Favorite, int64, error) {
+	var favorites []*models.Favorite
+	var total int64
+
+	db := models.DB.Model(&models.Favorite{}).Where("user_id = ?", userID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := db.Preload("Product").
+		Order("id DESC").Offset(offset).Limit(pageSize).
+		Find(&favorites).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return favorites, total, nil
+}
+
+// GetBatchAfter 按ID顺序获取一批收藏，用于后台扫描
+func (r *FavoriteRepository) GetBatchAfter(lastID uint64, limit int) ([]*models.Favorite, error) {
+	var favorites []*models.Favorite
+	err := models.DB.Where("id > ?", lastID).Order("id ASC").Limit(limit).Find(&favorites).Error
+	return favorites, err
+}
+
+// Update 更新收藏的提醒状态
+func (r *FavoriteRepository) Update(id uint64, updates map[string]interface{}) error {
+	return models.DB.Model(&models.Favorite{}).Where("id = ?", id).Updates(updates).Error
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FlashSaleRepository 秒杀数据访问层
+type FlashSaleRepository struct{}
+
+// NewFlashSaleRepository 创建秒杀Repository实例
+func NewFlashSaleRepository() *FlashSaleRepository {
+	return &FlashSaleRepository{}
+}
+
+// UserQuantity 用户已抢购数量
+type UserQuantity struct {
+	UserID   uint64
+	Quantity int
+}
+
+// Create 创建秒杀活动
+func (r *FlashSaleRepository) Create(tx *gorm.DB, sale *models.FlashSale) error {
+	return tx.Create(sale).Error
+}
+
+// Update 更新秒杀活动
+func (r *FlashSaleRepository) Update(tx *gorm.DB, id uint64, updates map[string]interface{}) error {
+	return tx.Model(&models.FlashSale{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Delete 删除秒杀活动
+func (r *FlashSaleRepository) Delete(tx *gorm.DB, id uint64) error {
+	return tx.Delete(&models.FlashSale{}, id).Error
+}
+
+// GetByID 根据ID获取秒杀活动
+func (r *FlashSaleRepository) GetByID(id uint64) (*models.FlashSale, error) {
+	var sale models.FlashSale
+	if err := models.DB.First(&sale, id).Error; err != nil {
+		return nil, err
+	}
+	return &sale, nil
+}
+
+// GetForUpdate 锁定并获取秒杀活动
+func (r *FlashSaleRepository) GetForUpdate(tx *gorm.DB, id uint64) (*models.FlashSale, error) {
+	var sale models.FlashSale
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sale, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sale, nil
+}
+
+// GetSales 分页获取秒杀活动（管理员）
+func (r *FlashSaleRepository) GetSales(page, pageSize int) ([]*models.FlashSale, int64, error) {
+	var sales []*models.FlashSale
+	var total int64
+
+	db := models.DB.Model(&models.FlashSale{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("start_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&sales).Error; err != nil {
+		return nil, 0, err
+	}
+	return sales, total, nil
+}
+
+// GetVisible 获取启用且未结束的秒杀活动，按开始时间排序
+func (r *FlashSaleRepository) GetVisible(now time.Time, limit int) ([]*models.FlashSale, error) {
+	var sales []*models.FlashSale
+	err := models.DB.Where("status = ? AND end_at > ?", 1, now).
+		Order("start_at ASC, id ASC").
+		Limit(limit).
+		Find(&sales).Error
+	return sales, err
+}
+
+// ExistsOverlap 检查SKU在时间段内是否已有其他秒杀活动
+func (r *FlashSaleRepository) ExistsOverlap(tx *gorm.DB, skuID uint64, startAt, endAt time.Time, excludeID uint64) (bool, error) {
+	var count int64
+	err := tx.Model(&models.FlashSale{}).
+		Where("sku_id = ? AND id <> ? AND start_at < ? AND end_at > ?", skuID, excludeID, endAt, startAt).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// IncrSold 增加已售数量，超过秒杀库存或活动已结算时返回false
+func (r *FlashSaleRepository) IncrSold(tx *gorm.DB, id uint64, quantity int) (bool, error) {
+	result := tx.Model(&models.FlashSale{}).
+		Where("id = ? AND settled = ? AND sold + ? <= quantity", id, false, quantity).
+		UpdateColumn("sold", gorm.Expr("sold + ?", quantity))
+	return result.RowsAffected > 0, result.Error
+}
+
+// DecrSold 减少已售数量
+func (r *FlashSaleRepository) DecrSold(tx *gorm.DB, id uint64, quantity int) error {
+	return tx.Model(&models.FlashSale{}).
+		Where("id = ?", id).
+		UpdateColumn("sold", gorm.Expr("sold - ?", quantity)).Error
+}
+
+// GetUnsettled 获取已结束但剩余库存未退回SKU的秒杀活动
+func (r *FlashSaleRepository) GetUnsettled(now time.Time, limit int) ([]*models.FlashSale, error) {
+	var sales []*models.FlashSale
+	err := models.DB.Where("end_at <= ? AND settled = ?", now, false).
+		Order("end_at ASC").Limit(limit).
+		Find(&sales).Error
+	return sales, err
+}
+
+// GetOrderByRequestID 根据抢购请求ID获取下单记录
+func (r *FlashSaleRepository) GetOrderByRequestID(requestID string) (*models.FlashSaleOrder, error) {
+	var order models.FlashSaleOrder
+	if err := models.DB.Where("request_id = ?", requestID).First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetOrderByOrderIDForUpdate 锁定并获取订单对应的下单记录
+func (r *FlashSaleRepository) GetOrderByOrderIDForUpdate(tx *gorm.DB, orderID uint64) (*models.FlashSaleOrder, error) {
+	var order models.FlashSaleOrder
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", orderID).First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// CreateOrder 写入下单记录，同一请求已有记录时返回false
+func (r *FlashSaleRepository) CreateOrder(tx *gorm.DB, order *models.FlashSaleOrder) (bool, error) {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(order)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateOrderStatus 将下单记录从from状态改为to状态，状态不符时返回false
+func (r *FlashSaleRepository) UpdateOrderStatus(tx *gorm.DB, id uint64, from, to string) (bool, error) {
+	result := tx.Model(&models.FlashSaleOrder{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkSaleReleasedReturned 将活动中待退回库存的记录标记为已退回（重建Redis库存时按数据库已售数量计算）
+func (r *FlashSaleRepository) MarkSaleReleasedReturned(saleID uint64) error {
+	return models.DB.Model(&models.FlashSaleOrder{}).
+		Where("flash_sale_id = ? AND status = ?", saleID, models.FlashSaleOrderReleased).
+		Update("status", models.FlashSaleOrderReturned).Error
+}
+
+// GetUserQuantities 获取活动中各用户已创建订单的数量
+func (r *FlashSaleRepository) GetUserQuantities(saleID uint64) ([]*UserQuantity, error) {
+	var rows []*UserQuantity
+	err := models.DB.Model(&models.FlashSaleOrder{}).
+		Select("user_id, SUM(quantity) AS quantity").
+		Where("flash_sale_id = ? AND status = ?", saleID, models.FlashSaleOrderCreated).
+		Group("user_id").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GetExpiredUnpaid 获取超时未支付的秒杀订单记录
+func (r *FlashSaleRepository) GetExpiredUnpaid(before time.Time, limit int) ([]*models.FlashSaleOrder, error) {
+	var orders []*models.FlashSaleOrder
+	err := models.DB.Model(&models.FlashSaleOrder{}).
+		Select("flash_sale_orders.*").
+		Joins("JOIN orders ON orders.id = flash_sale_orders.order_id").
+		Where("flash_sale_orders.status = ? AND orders.pay_status = ? AND orders.order_status = ? AND orders.created_at < ?",
+			models.FlashSaleOrderCreated, models.PayStatusUnpaid, models.OrderStatusPending, before).
+		Order("flash_sale_orders.id ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
+// GetReleased 获取待退回库存的下单记录
+func (r *FlashSaleRepository) GetReleased(limit int) ([]*models.FlashSaleOrder, error) {
+	var orders []*models.FlashSaleOrder
+	err := models.DB.Where("status = ?", models.FlashSaleOrderReleased).
+		Order("id ASC").Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
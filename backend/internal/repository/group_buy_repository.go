@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GroupBuyRepository 拼团数据访问层
+type GroupBuyRepository struct{}
+
+// NewGroupBuyRepository 创建拼团Repository实例
+func NewGroupBuyRepository() *GroupBuyRepository {
+	return &GroupBuyRepository{}
+}
+
+// activeMemberStatuses 占用名额的成员状态
+var activeMemberStatuses = []string{models.GroupMemberPending, models.GroupMemberPaid}
+
+// Create 创建拼团活动
+func (r *GroupBuyRepository) Create(groupBuy *models.GroupBuy) error {
+	return models.DB.Create(groupBuy).Error
+}
+
+// Update 更新拼团活动
+func (r *GroupBuyRepository) Update(tx *gorm.DB, id uint64, updates map[string]interface{}) error {
+	return tx.Model(&models.GroupBuy{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// GetByID 根据ID获取拼团活动
+func (r *GroupBuyRepository) GetByID(id uint64) (*models.GroupBuy, error) {
+	var groupBuy models.GroupBuy
+	if err := models.DB.First(&groupBuy, id).Error; err != nil {
+		return nil, err
+	}
+	return &groupBuy, nil
+}
+
+// GetGroupBuys 分页获取拼团活动（管理员）
+func (r *GroupBuyRepository) GetGroupBuys(page, pageSize int) ([]*models.GroupBuy, int64, error) {
+	var groupBuys []*models.GroupBuy
+	var total int64
+
+	db := models.DB.Model(&models.GroupBuy{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&groupBuys).Error; err != nil {
+		return nil, 0, err
+	}
+	return groupBuys, total, nil
+}
+
+// GetActive 分页获取当前可开团的拼团活动
+func (r *GroupBuyRepository) GetActive(now time.Time, page, pageSize int) ([]*models.GroupBuy, int64, error) {
+	var groupBuys []*models.GroupBuy
+	var total int64
+
+	db := models.DB.Model(&models.GroupBuy{}).Where("status = ? AND start_at <= ? AND end_at > ?", 1, now, now)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("start_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&groupBuys).Error; err != nil {
+		return nil, 0, err
+	}
+	return groupBuys, total, nil
+}
+
+// IncrCount 累加活动的开团数或成团数
+func (r *GroupBuyRepository) IncrCount(tx *gorm.DB, id uint64, column string) error {
+	return tx.Model(&models.GroupBuy{}).
+		Where("id = ?", id).
+		UpdateColumn(column, gorm.Expr(column+" + 1")).Error
+}
+
+// CreateGroup 创建团
+func (r *GroupBuyRepository) CreateGroup(tx *gorm.DB, group *models.GroupBuyGroup) error {
+	return tx.Create(group).Error
+}
+
+// GetGroupByCode 根据分享编号获取团
+func (r *GroupBuyRepository) GetGroupByCode(code string) (*models.GroupBuyGroup, error) {
+	var group models.GroupBuyGroup
+	if err := models.DB.Where("share_code = ?", code).First(&group).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// GetGroupForUpdate 锁定并获取团
+func (r *GroupBuyRepository) GetGroupForUpdate(tx *gorm.DB, id uint64) (*models.GroupBuyGroup, error) {
+	var group models.GroupBuyGroup
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// UpdateGroup 更新团
+func (r *GroupBuyRepository) UpdateGroup(tx *gorm.DB, id uint64, updates map[string]interface{}) error {
+	return tx.Model(&models.GroupBuyGroup{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// GetGroupsByIDs 批量获取团
+func (r *GroupBuyRepository) GetGroupsByIDs(ids []uint64) ([]*models.GroupBuyGroup, error) {
+	var groups []*models.GroupBuyGroup
+	err := models.DB.Where("id IN ?", ids).Find(&groups).Error
+	return groups, err
+}
+
+// GetOpenGroups 获取活动中未过期的拼团中的团，差人数少的在前
+func (r *GroupBuyRepository) GetOpenGroups(groupBuyID uint64, now time.Time, limit int) ([]*models.GroupBuyGroup, error) {
+	var groups []*models.GroupBuyGroup
+	err := models.DB.Where("group_buy_id = ? AND status = ? AND expire_at > ?", groupBuyID, models.GroupStatusOpen, now).
+		Order("required_size - paid_count ASC, expire_at ASC").
+		Limit(limit).
+		Find(&groups).Error
+	return groups, err
+}
+
+// GetGroups 分页获取活动的团（管理员）
+func (r *GroupBuyRepository) GetGroups(groupBuyID uint64, status string, page, pageSize int) ([]*models.GroupBuyGroup, int64, error) {
+	var groups []*models.GroupBuyGroup
+	var total int64
+
+	db := models.DB.Model(&models.GroupBuyGroup{}).Where("group_buy_id = ?", groupBuyID)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&groups).Error; err != nil {
+		return nil, 0, err
+	}
+	return groups, total, nil
+}
+
+// GetExpiredGroupIDs 获取已过成团截止时间仍在拼团中的团
+func (r *GroupBuyRepository) GetExpiredGroupIDs(now time.Time, limit int) ([]uint64, error) {
+	var ids []uint64
+	err := models.DB.Model(&models.GroupBuyGroup{}).
+		Where("status = ? AND expire_at <= ?", models.GroupStatusOpen, now).
+		Order("expire_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// CreateMember 创建团成员
+func (r *GroupBuyRepository) CreateMember(tx *gorm.DB, member *models.GroupBuyMember) error {
+	return tx.Create(member).Error
+}
+
+// GetMemberByID 根据ID获取团成员
+func (r *GroupBuyRepository) GetMemberByID(id uint64) (*models.GroupBuyMember, error) {
+	var member models.GroupBuyMember
+	if err := models.DB.First(&member, id).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetMemberByOrderForUpdate 锁定并获取订单对应的团成员
+func (r *GroupBuyRepository) GetMemberByOrderForUpdate(tx *gorm.DB, orderID uint64) (*models.GroupBuyMember, error) {
+	var member models.GroupBuyMember
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", orderID).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// UpdateMember 将团成员从from状态更新，状态不符时返回false
+func (r *GroupBuyRepository) UpdateMember(tx *gorm.DB, id uint64, from string, updates map[string]interface{}) (bool, error) {
+	result := tx.Model(&models.GroupBuyMember{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountActiveMembers 统计团中占用名额的成员数
+func (r *GroupBuyRepository) CountActiveMembers(tx *gorm.DB, groupID uint64) (int64, error) {
+	var count int64
+	err := tx.Model(&models.GroupBuyMember{}).
+		Where("group_id = ? AND status IN ?", groupID, activeMemberStatuses).
+		Count(&count).Error
+	return count, err
+}
+
+// ExistsActiveMember 检查用户是否已在团中
+func (r *GroupBuyRepository) ExistsActiveMember(tx *gorm.DB, groupID uint64, userID uint64) (bool, error) {
+	var count int64
+	err := tx.Model(&models.GroupBuyMember{}).
+		Where("group_id = ? AND user_id = ? AND status IN ?", groupID, userID, activeMemberStatuses).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetMembers 获取团中占用名额的成员，团长在前
+func (r *GroupBuyRepository) GetMembers(groupID uint64) ([]*models.GroupBuyMember, error) {
+	var members []*models.GroupBuyMember
+	err := models.DB.Where("group_id = ? AND status IN ?", groupID, activeMemberStatuses).
+		Order("is_leader DESC, id ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// GetMembersByStatus 获取团中某状态的成员
+func (r *GroupBuyRepository) GetMembersByStatus(tx *gorm.DB, groupID uint64, status string) ([]*models.GroupBuyMember, error) {
+	var members []*models.GroupBuyMember
+	err := tx.Where("group_id = ? AND status = ?", groupID, status).Find(&members).Error
+	return members, err
+}
+
+// GetUserMembers 分页获取用户参与的拼团
+func (r *GroupBuyRepository) GetUserMembers(userID uint64, page, pageSize int) ([]*models.GroupBuyMember, int64, error) {
+	var members []*models.GroupBuyMember
+	var total int64
+
+	db := models.DB.Model(&models.GroupBuyMember{}).Where("user_id = ?", userID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&members).Error; err != nil {
+		return nil, 0, err
+	}
+	return members, total, nil
+}
+
+// GetExpiredUnpaid 获取订单超时未支付的待支付成员
+func (r *GroupBuyRepository) GetExpiredUnpaid(before time.Time, limit int) ([]*models.GroupBuyMember, error) {
+	var members []*models.GroupBuyMember
+	err := models.DB.Model(&models.GroupBuyMember{}).
+		Select("group_buy_members.*").
+		Joins("JOIN orders ON orders.id = group_buy_members.order_id").
+		Where("group_buy_members.status = ? AND orders.pay_status = ? AND orders.order_status = ? AND orders.created_at < ?",
+			models.GroupMemberPending, models.PayStatusUnpaid, models.OrderStatusPending, before).
+		Order("group_buy_members.id ASC").
+		Limit(limit).
+		Find(&members).Error
+	return members, err
+}
+
+// GetRefunding 获取等待退款且未超过尝试次数的成员
+func (r *GroupBuyRepository) GetRefunding(maxAttempts int, limit int) ([]*models.GroupBuyMember, error) {
+	var members []*models.GroupBuyMember
+	err := models.DB.Where("status = ? AND refund_attempts < ?", models.GroupMemberRefunding, maxAttempts).
+		Order("id ASC").
+		Limit(limit).
+		Find(&members).Error
+	return members, err
+}
+
+// RecordRefundFailure 记录一次退款失败
+func (r *GroupBuyRepository) RecordRefundFailure(id uint64, reason string) error {
+	return models.DB.Model(&models.GroupBuyMember{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"refund_attempts": gorm.Expr("refund_attempts + 1"),
+			"refund_error":    reason,
+		}).Error
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MemberRepository 会员数据访问层
+type MemberRepository struct{}
+
+// NewMemberRepository 创建会员Repository实例
+func NewMemberRepository() *MemberRepository {
+	return &MemberRepository{}
+}
+
+// GetLevels 获取所有等级，按所需成长值升序
+func (r *MemberRepository) GetLevels() ([]*models.MemberLevel, error) {
+	var levels []*models.MemberLevel
+	err := models.DB.Preload("Coupons").Order("min_growth ASC").Find(&levels).Error
+	return levels, err
+}
+
+// GetLevelByID 根据ID获取等级
+func (r *MemberRepository) GetLevelByID(id uint64) (*models.MemberLevel, error) {
+	var level models.MemberLevel
+	err := models.DB.Preload("Coupons").Where("id = ?", id).First(&level).Error
+	if err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
+
+// ExistsLevelByMinGrowth 检查是否已有相同成长值门槛的等级
+func (r *MemberRepository) ExistsLevelByMinGrowth(minGrowth int64, excludeID uint64) (bool, error) {
+	var count int64
+	err := models.DB.Model(&models.MemberLevel{}).
+		Where("min_growth = ? AND id <> ?", minGrowth, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateLevel 创建等级
+func (r *MemberRepository) CreateLevel(tx *gorm.DB, level *models.MemberLevel) error {
+	return tx.Omit("Coupons").Create(level).Error
+}
+
+// UpdateLevel 更新等级
+func (r *MemberRepository) UpdateLevel(tx *gorm.DB, id uint64, updates map[string]interface{}) error {
+	return tx.Model(&models.MemberLevel{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DeleteLevel 删除等级及其升级礼包和会员价（物理删除，避免成长值门槛的唯一索引冲突）
+func (r *MemberRepository) DeleteLevel(tx *gorm.DB, id uint64) error {
+	if err := tx.Unscoped().Where("level_id = ?", id).Delete(&models.MemberLevelCoupon{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("level_id = ?", id).Delete(&models.SKUMemberPrice{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&models.MemberLevel{}, id).Error
+}
+
+// ReplaceLevelCoupons 替换等级的升级礼包
+func (r *MemberRepository) ReplaceLevelCoupons(tx *gorm.DB, levelID uint64, coupons []*models.MemberLevelCoupon) error {
+	if err := tx.Unscoped().Where("level_id = ?", levelID).Delete(&models.MemberLevelCoupon{}).Error; err != nil {
+		return err
+	}
+	if len(coupons) == 0 {
+		return nil
+	}
+	for _, coupon := range coupons {
+		coupon.LevelID = levelID
+	}
+	return tx.Create(&coupons).Error
+}
+
+// CountMembersByLevel 统计处于该等级的用户数
+func (r *MemberRepository) CountMembersByLevel(levelID uint64) (int64, error) {
+	var count int64
+	err := models.DB.Model(&models.UserMember{}).Where("level_id = ?", levelID).Count(&count).Error
+	return count, err
+}
+
+// GetMember 获取用户会员信息（含等级）
+func (r *MemberRepository) GetMember(userID uint64) (*models.UserMember, error) {
+	var member models.UserMember
+	err := models.DB.Preload("Level").Where("user_id = ?", userID).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetOrCreateMemberForUpdate 获取用户会员信息并加锁，不存在时创建
+func (r *MemberRepository) GetOrCreateMemberForUpdate(tx *gorm.DB, userID uint64) (*models.UserMember, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.UserMember{UserID: userID}).Error; err != nil {
+		return nil, err
+	}
+
+	var member models.UserMember
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// UpdateMember 更新用户会员信息
+func (r *MemberRepository) UpdateMember(tx *gorm.DB, id uint64, updates map[string]interface{}) error {
+	return tx.Model(&models.UserMember{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ExistsGrowthLog 检查该来源是否已记录成长值
+func (r *MemberRepository) ExistsGrowthLog(tx *gorm.DB, userID uint64, source string, sourceID uint64) (bool, error) {
+	var count int64
+	err := tx.Model(&models.GrowthLog{}).
+		Where("user_id = ? AND source = ? AND source_id = ?", userID, source, sourceID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetGrowthLog 获取某来源的成长值流水
+func (r *MemberRepository) GetGrowthLog(tx *gorm.DB, userID uint64, source string, sourceID uint64) (*models.GrowthLog, error) {
+	var log models.GrowthLog
+	err := tx.Where("user_id = ? AND source = ? AND source_id = ?", userID, source, sourceID).First(&log).Error
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// CreateGrowthLog 记录成长值流水
+func (r *MemberRepository) CreateGrowthLog(tx *gorm.DB, log *models.GrowthLog) error {
+	return tx.Create(log).Error
+}
+
+// GetGrowthLogs 分页获取用户成长值流水
+func (r *MemberRepository) GetGrowthLogs(userID uint64, page, pageSize int) ([]*models.GrowthLog, int64, error) {
+	var logs []*models.GrowthLog
+	var total int64
+
+	db := models.DB.Model(&models.GrowthLog{}).Where("user_id = ?", userID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// CreateLevelReward 记录已发放的升级礼包，已发放过时返回false
+func (r *MemberRepository) CreateLevelReward(tx *gorm.DB, userID uint64, levelID uint64) (bool, error) {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.MemberLevelReward{UserID: userID, LevelID: levelID})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecalculateLevels 按当前等级门槛重新计算所有用户的等级（levels 需按成长值升序）
+func (r *MemberRepository) RecalculateLevels(tx *gorm.DB, levels []*models.MemberLevel) error {
+	if err := tx.Model(&models.UserMember{}).Where("1 = 1").Update("level_id", 0).Error; err != nil {
+		return err
+	}
+	for _, level := range levels {
+		if err := tx.Model(&models.UserMember{}).
+			Where("growth_value >= ?", level.MinGrowth).
+			Update("level_id", level.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSKUMemberPrices 获取SKU在某等级的会员价
+func (r *MemberRepository) GetSKUMemberPrices(skuIDs []uint64, levelID uint64) (map[uint64]float64, error) {
+	prices := make(map[uint64]float64, len(skuIDs))
+	if len(skuIDs) == 0 || levelID == 0 {
+		return prices, nil
+	}
+
+	var rows []*models.SKUMemberPrice
+	if err := models.DB.Where("sku_id IN ? AND level_id = ?", skuIDs, levelID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		prices[row.SKUID] = row.Price
+	}
+	return prices, nil
+}
+
+// GetSKUMemberPriceList 获取SKU在各等级的会员价
+func (r *MemberRepository) GetSKUMemberPriceList(skuID uint64) ([]*models.SKUMemberPrice, error) {
+	var prices []*models.SKUMemberPrice
+	err := models.DB.Where("sku_id = ?", skuID).Order("level_id ASC").Find(&prices).Error
+	return prices, err
+}
+
+// ReplaceSKUMemberPrices 替换SKU的会员价
+func (r *MemberRepository) ReplaceSKUMemberPrices(tx *gorm.DB, skuID uint64, prices []*models.SKUMemberPrice) error {
+	if err := tx.Unscoped().Where("sku_id = ?", skuID).Delete(&models.SKUMemberPrice{}).Error; err != nil {
+		return err
+	}
+	if len(prices) == 0 {
+		return nil
+	}
+	for _, price := range prices {
+		price.SKUID = skuID
+	}
+	return tx.Create(&prices).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+	"time"
+)
+
+// NotificationRepository 站内通知数据访问层
+type NotificationRepository struct{}
+
+// NewNotificationRepository 创建站内通知Repository实例
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{}
+}
+
+// CreateBatch 批量创建通知
+func (r *NotificationRepository) CreateBatch(notifications []*models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return models.DB.CreateInBatches(notifications, 200).Error
+}
+
+// GetNotifications 分页获取用户通知，unreadOnly为true时只查未读
+func (r *NotificationRepository) GetNotifications(userID uint64, unreadOnly bool, page, pageSize int) ([]*models.Notification, int64, error) {
+	var notifications []*models.Notification
+	var total int64
+
+	db := models.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		db = db.Where("read_at IS NULL")
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+// CountUnread 统计用户未读通知数
+func (r *NotificationRepository) CountUnread(userID uint64) (int64, error) {
+	var count int64
+	err := models.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead 将用户的通知标记为已读，id为0时标记全部，返回是否有记录被更新
+func (r *NotificationRepository) MarkRead(userID uint64, id uint64) (bool, error) {
+	db := models.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if id > 0 {
+		db = db.Where("id = ?", id)
+	}
+	result := db.Update("read_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// Exists 检查通知是否属于该用户
+func (r *NotificationRepository) Exists(userID uint64, id uint64) (bool, error) {
+	var count int64
+	err := models.DB.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Count(&count).Error
+	return count > 0, err
+}
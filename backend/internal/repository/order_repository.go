@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrderRepository 订单数据访问层
+type OrderRepository struct{}
+
+// NewOrderRepository 创建订单Repository实例
+func NewOrderRepository() *OrderRepository {
+	return &OrderRepository{}
+}
+
+// GetAddress 获取用户的收货地址
+func (r *OrderRepository) GetAddress(tx *gorm.DB, userID uint64, addressID uint64) (*models.Address, error) {
+	var address models.Address
+	if err := tx.Where("id = ? AND user_id = ?", addressID, userID).First(&address).Error; err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// CreateWithItems 创建订单及订单商品
+func (r *OrderRepository) CreateWithItems(tx *gorm.DB, order *models.Order, items []*models.OrderItem) error {
+	if err := tx.Omit(clause.Associations).Create(order).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		item.OrderID = order.ID
+	}
+	return tx.Omit(clause.Associations).Create(&items).Error
+}
+
+// GetByID 获取订单
+func (r *OrderRepository) GetByID(tx *gorm.DB, id uint64) (*models.Order, error) {
+	var order models.Order
+	if err := tx.First(&order, id).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetItems 获取订单商品
+func (r *OrderRepository) GetItems(tx *gorm.DB, orderID uint64) ([]*models.OrderItem, error) {
+	var items []*models.OrderItem
+	err := tx.Where("order_id = ?", orderID).Find(&items).Error
+	return items, err
+}
+
+// GetItemsByIDs 批量获取订单商品
+func (r *OrderRepository) GetItemsByIDs(ids []uint64) ([]*models.OrderItem, error) {
+	var items []*models.OrderItem
+	err := models.DB.Where("id IN ?", ids).Find(&items).Error
+	return items, err
+}
+
+// GetByOrderNoForUpdate 锁定并获取订单
+func (r *OrderRepository) GetByOrderNoForUpdate(tx *gorm.DB, orderNo string) (*models.Order, error) {
+	var order models.Order
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_no = ?", orderNo).First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// MarkPaid 将未支付的订单标记为已支付并记录支付渠道交易号，待付款的订单转为待发货，订单已支付时返回false
+func (r *OrderRepository) MarkPaid(tx *gorm.DB, id uint64, method string, tradeNo string, payTime time.Time) (bool, error) {
+	result := tx.Model(&models.Order{}).
+		Where("id = ? AND pay_status = ?", id, models.PayStatusUnpaid).
+		Updates(map[string]interface{}{
+			"pay_status":     models.PayStatusPaid,
+			"pay_time":       payTime,
+			"payment_method": method,
+			"trade_no":       tradeNo,
+			"order_status": gorm.Expr("CASE WHEN order_status = ? THEN ? ELSE order_status END",
+				models.OrderStatusPending, models.OrderStatusToShip),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetByIDs 批量获取订单
+func (r *OrderRepository) GetByIDs(ids []uint64) ([]*models.Order, error) {
+	var orders []*models.Order
+	err := models.DB.Where("id IN ?", ids).Find(&orders).Error
+	return orders, err
+}
+
+// CancelUnpaid 取消未支付的待付款订单，订单状态已变化时返回false
+func (r *OrderRepository) CancelUnpaid(tx *gorm.DB, id uint64, reason string) (bool, error) {
+	result := tx.Model(&models.Order{}).
+		Where("id = ? AND order_status = ? AND pay_status = ?", id, models.OrderStatusPending, models.PayStatusUnpaid).
+		Updates(map[string]interface{}{
+			"order_status":  models.OrderStatusCancelled,
+			"cancel_reason": reason,
+			"cancel_time":   time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkRefunding 将已取消且已支付的订单标记为等待退款
+func (r *OrderRepository) MarkRefunding(tx *gorm.DB, id uint64, refundNo string) error {
+	return tx.Model(&models.Order{}).
+		Where("id = ? AND order_status = ? AND pay_status = ? AND refund_status = ''",
+			id, models.OrderStatusCancelled, models.PayStatusPaid).
+		Updates(map[string]interface{}{
+			"refund_status": models.OrderRefunding,
+			"refund_no":     refundNo,
+		}).Error
+}
+
+// GetRefunding 获取等待退款且未超过尝试次数的订单
+func (r *OrderRepository) GetRefunding(maxAttempts int, limit int) ([]*models.Order, error) {
+	var orders []*models.Order
+	err := models.DB.Where("refund_status = ? AND refund_attempts < ?", models.OrderRefunding, maxAttempts).
+		Order("id ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
+// RecordRefundFailure 记录一次退款失败
+func (r *OrderRepository) RecordRefundFailure(id uint64, reason string) error {
+	return models.DB.Model(&models.Order{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"refund_attempts": gorm.Expr("refund_attempts + 1"),
+			"refund_error":    reason,
+		}).Error
+}
+
+// MarkRefunded 将等待退款的订单标记为已退款，订单不在等待退款时返回false
+func (r *OrderRepository) MarkRefunded(tx *gorm.DB, id uint64, refundID string, refundedAt time.Time) (bool, error) {
+	result := tx.Model(&models.Order{}).
+		Where("id = ? AND refund_status = ?", id, models.OrderRefunding).
+		Updates(map[string]interface{}{
+			"refund_status": models.OrderRefunded,
+			"refund_id":     refundID,
+			"refund_error":  "",
+			"refunded_at":   refundedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ResetRefundAttempts 重置退款失败订单的尝试次数，订单不是退款失败状态时返回false
+func (r *OrderRepository) ResetRefundAttempts(id uint64) (bool, error) {
+	result := models.DB.Model(&models.Order{}).
+		Where("id = ? AND refund_status = ? AND refund_attempts > 0", id, models.OrderRefunding).
+		Update("refund_attempts", 0)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CancelRefunded 取消已全额退款的订单，订单已取消时返回false
+func (r *OrderRepository) CancelRefunded(tx *gorm.DB, id uint64, reason string) (bool, error) {
+	result := tx.Model(&models.Order{}).
+		Where("id = ? AND order_status <> ?", id, models.OrderStatusCancelled).
+		Updates(map[string]interface{}{
+			"order_status":  models.OrderStatusCancelled,
+			"cancel_reason": reason,
+			"cancel_time":   time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetUserOrders 分页获取用户的订单及订单商品，status为空时不过滤
+func (r *OrderRepository) GetUserOrders(userID uint64, status *int, page, pageSize int) ([]*models.Order, int64, error) {
+	var orders []*models.Order
+	var total int64
+
+	db := models.DB.Model(&models.Order{}).Where("user_id = ?", userID)
+	if status != nil {
+		db = db.Where("order_status = ?", *status)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := db.Preload("OrderItems").Order("id DESC").Offset(offset).Limit(pageSize).Find(&orders).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+// GetUserOrder 获取用户的订单及订单商品
+func (r *OrderRepository) GetUserOrder(userID uint64, id uint64) (*models.Order, error) {
+	var order models.Order
+	if err := models.DB.Preload("OrderItems").Where("id = ? AND user_id = ?", id, userID).First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// MarkShipped 将已支付的待发货订单标记为已发货，订单状态已变化时返回false
+func (r *OrderRepository) MarkShipped(tx *gorm.DB, id uint64, company, trackingNo string, shipTime time.Time) (bool, error) {
+	result := tx.Model(&models.Order{}).
+		Where("id = ? AND order_status = ? AND pay_status = ?", id, models.OrderStatusToShip, models.PayStatusPaid).
+		Updates(map[string]interface{}{
+			"order_status":     models.OrderStatusShipped,
+			"shipping_company": company,
+			"tracking_no":      trackingNo,
+			"ship_time":        shipTime,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkCompleted 将已发货的订单标记为已完成，订单状态已变化时返回false
+func (r *OrderRepository) MarkCompleted(tx *gorm.DB, id uint64, completeTime time.Time) (bool, error) {
+	result := tx.Model(&models.Order{}).
+		Where("id = ? AND order_status = ?", id, models.OrderStatusShipped).
+		Updates(map[string]interface{}{
+			"order_status":  models.OrderStatusCompleted,
+			"complete_time": completeTime,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetShippedBefore 获取发货时间早于指定时间的待收货订单ID
+func (r *OrderRepository) GetShippedBefore(before time.Time, limit int) ([]uint64, error) {
+	var ids []uint64
+	err := models.DB.Model(&models.Order{}).
+		Where("order_status = ? AND ship_time < ?", models.OrderStatusShipped, before).
+		Order("id").Limit(limit).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// GetExpiredUnpaid 获取创建时间早于指定时间的未支付订单ID
+func (r *OrderRepository) GetExpiredUnpaid(orderType string, before time.Time, limit int) ([]uint64, error) {
+	var ids []uint64
+	err := models.DB.Model(&models.Order{}).
+		Where("order_type = ? AND order_status = ? AND pay_status = ? AND created_at < ?",
+			orderType, models.OrderStatusPending, models.PayStatusUnpaid, before).
+		Order("id").Limit(limit).Pluck("id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PointsRepository 积分数据访问层
+type PointsRepository struct{}
+
+// NewPointsRepository 创建积分Repository实例
+func NewPointsRepository() *PointsRepository {
+	return &PointsRepository{}
+}
+
+// GetAccount 获取用户积分账户，不存在时返回余额为0的账户
+func (r *PointsRepository) GetAccount(userID uint64) (*models.UserPoints, error) {
+	var account models.UserPoints
+	err := models.DB.Where("user_id = ?", userID).Limit(1).Find(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	account.UserID = userID
+	return &account, nil
+}
+
+// GetOrCreateAccountForUpdate 获取用户积分账户并加锁，不存在时创建
+func (r *PointsRepository) GetOrCreateAccountForUpdate(tx *gorm.DB, userID uint64) (*models.UserPoints, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.UserPoints{UserID: userID}).Error; err != nil {
+		return nil, err
+	}
+
+	var account models.UserPoints
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// UpdateBalance 更新积分余额
+func (r *PointsRepository) UpdateBalance(tx *gorm.DB, id uint64, balance int64) error {
+	return tx.Model(&models.UserPoints{}).Where("id = ?", id).Update("balance", balance).Error
+}
+
+// ExistsLedger 检查该来源是否已记录积分流水
+func (r *PointsRepository) ExistsLedger(tx *gorm.DB, userID uint64, source string, sourceID uint64) (bool, error) {
+	var count int64
+	err := tx.Model(&models.PointsLedger{}).
+		Where("user_id = ? AND source = ? AND source_id = ?", userID, source, sourceID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetLedger 获取某来源的积分流水
+func (r *PointsRepository) GetLedger(tx *gorm.DB, userID uint64, source string, sourceID uint64) (*models.PointsLedger, error) {
+	var ledger models.PointsLedger
+	err := tx.Where("user_id = ? AND source = ? AND source_id = ?", userID, source, sourceID).First(&ledger).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ledger, nil
+}
+
+// CreateLedger 追加积分流水
+func (r *PointsRepository) CreateLedger(tx *gorm.DB, ledger *models.PointsLedger) error {
+	return tx.Create(ledger).Error
+}
+
+// GetLedgers 分页获取用户积分流水，direction为earn只查收入，spend只查支出
+func (r *PointsRepository) GetLedgers(userID uint64, direction string, page, pageSize int) ([]*models.PointsLedger, int64, error) {
+	var ledgers []*models.PointsLedger
+	var total int64
+
+	db := models.DB.Model(&models.PointsLedger{}).Where("user_id = ?", userID)
+	switch direction {
+	case "earn":
+		db = db.Where("`change` > 0")
+	case "spend":
+		db = db.Where("`change` < 0")
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&ledgers).Error; err != nil {
+		return nil, 0, err
+	}
+	return ledgers, total, nil
+}
+
+// CreateBatch 创建积分批次
+func (r *PointsRepository) CreateBatch(tx *gorm.DB, batch *models.PointsBatch) error {
+	return tx.Create(batch).Error
+}
+
+// GetAvailableBatchesForUpdate 获取用户未过期且有剩余的批次并加锁，按过期时间先后排序；
+// preferBatchID不为0时该批次排在最前
+func (r *PointsRepository) GetAvailableBatchesForUpdate(tx *gorm.DB, userID uint64, preferBatchID uint64) ([]*models.PointsBatch, error) {
+	var batches []*models.PointsBatch
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND remaining > 0 AND expires_at > ?", userID, time.Now()).
+		Order(clause.Expr{SQL: "id = ? DESC, expires_at ASC, id ASC", Vars: []interface{}{preferBatchID}}).
+		Find(&batches).Error
+	return batches, err
+}
+
+// GetBatchByLedger 根据流水获取积分批次
+func (r *PointsRepository) GetBatchByLedger(tx *gorm.DB, ledgerID uint64) (*models.PointsBatch, error) {
+	var batch models.PointsBatch
+	err := tx.Where("ledger_id = ?", ledgerID).First(&batch).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// AdjustBatchRemaining 增减批次剩余积分
+func (r *PointsRepository) AdjustBatchRemaining(tx *gorm.DB, id uint64, delta int64) error {
+	return tx.Model(&models.PointsBatch{}).Where("id = ?", id).
+		Update("remaining", gorm.Expr("remaining + ?", delta)).Error
+}
+
+// SumExpiring 统计用户在指定时间前到期的剩余积分
+func (r *PointsRepository) SumExpiring(userID uint64, before time.Time) (int64, error) {
+	var sum int64
+	err := models.DB.Model(&models.PointsBatch{}).
+		Select("COALESCE(SUM(remaining), 0)").
+		Where("user_id = ? AND remaining > 0 AND expires_at > ? AND expires_at <= ?", userID, time.Now(), before).
+		Scan(&sum).Error
+	return sum, err
+}
+
+// SumAvailable 统计用户未过期的剩余积分
+func (r *PointsRepository) SumAvailable(userID uint64) (int64, error) {
+	var sum int64
+	err := models.DB.Model(&models.PointsBatch{}).
+		Select("COALESCE(SUM(remaining), 0)").
+		Where("user_id = ? AND remaining > 0 AND expires_at > ?", userID, time.Now()).
+		Scan(&sum).Error
+	return sum, err
+}
+
+// GetExpiredBatches 获取已过期但仍有剩余的批次
+func (r *PointsRepository) GetExpiredBatches(limit int) ([]*models.PointsBatch, error) {
+	var batches []*models.PointsBatch
+	err := models.DB.Where("remaining > 0 AND expires_at <= ?", time.Now()).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&batches).Error
+	return batches, err
+}
+
+// GetBatchForUpdate 获取积分批次并加锁
+func (r *PointsRepository) GetBatchForUpdate(tx *gorm.DB, id uint64) (*models.PointsBatch, error) {
+	var batch models.PointsBatch
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&batch).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// CreateRedemption 创建抵扣记录及批次明细
+func (r *PointsRepository) CreateRedemption(tx *gorm.DB, redemption *models.PointsRedemption, items []*models.PointsRedemptionItem) error {
+	if err := tx.Create(redemption).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		item.RedemptionID = redemption.ID
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return tx.Create(&items).Error
+}
+
+// GetRedemptionForUpdate 获取订单的抵扣记录并加锁
+func (r *PointsRepository) GetRedemptionForUpdate(tx *gorm.DB, orderID uint64) (*models.PointsRedemption, error) {
+	var redemption models.PointsRedemption
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", orderID).First(&redemption).Error
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+// GetRedemptionItems 获取抵扣消耗的批次明细
+func (r *PointsRepository) GetRedemptionItems(tx *gorm.DB, redemptionID uint64) ([]*models.PointsRedemptionItem, error) {
+	var items []*models.PointsRedemptionItem
+	err := tx.Where("redemption_id = ?", redemptionID).Order("id ASC").Find(&items).Error
+	return items, err
+}
+
+// MarkRedemptionRolledBack 标记抵扣已退回
+func (r *PointsRepository) MarkRedemptionRolledBack(tx *gorm.DB, id uint64) error {
+	return tx.Model(&models.PointsRedemption{}).Where("id = ?", id).Update("rolled_back_at", time.Now()).Error
+}
@@ -4,6 +4,7 @@ import (
 	"online-mall/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ProductRepository 商品数据访问层
@@ -111,8 +112,8 @@ func (r *ProductRepository) Create(product *models.Product) error {
 }
 
 // Update 更新商品
-func (r *ProductRepository) Update(product *models.Product) error {
-	return models.DB.Save(product).Error
+func (r *ProductRepository) Update(tx *gorm.DB, product *models.Product) error {
+	return tx.Save(product).Error
 }
 
 // Delete 删除商品
@@ -159,3 +160,62 @@ func (r *ProductRepository) UpdateStock(productID uint64, quantity int) error {
 		Where("id = ?", productID).
 		UpdateColumn("stock", gorm.Expr("stock + ?", quantity)).Error
 }
+
+// GetByIDs 批量获取商品
+func (r *ProductRepository) GetByIDs(ids []uint64) ([]*models.Product, error) {
+	var products []*models.Product
+	err := models.DB.Where("id IN ?", ids).Find(&products).Error
+	return products, err
+}
+
+// GetSKUsByIDs 批量获取SKU（含所属商品）
+func (r *ProductRepository) GetSKUsByIDs(ids []uint64) ([]*models.ProductSKU, error) {
+	var skus []*models.ProductSKU
+	err := models.DB.Preload("Product").Where("id IN ?", ids).Find(&skus).Error
+	return skus, err
+}
+
+// GetSKU 获取商品下的SKU
+func (r *ProductRepository) GetSKU(productID uint64, skuID uint64) (*models.ProductSKU, error) {
+	var sku models.ProductSKU
+	err := models.DB.Where("id = ? AND product_id = ?", skuID, productID).First(&sku).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sku, nil
+}
+
+// GetSKUsForUpdate 获取商品下的SKU并加锁
+func (r *ProductRepository) GetSKUsForUpdate(tx *gorm.DB, productID uint64, ids []uint64) ([]*models.ProductSKU, error) {
+	var skus []*models.ProductSKU
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND id IN ?", productID, ids).
+		Find(&skus).Error
+	return skus, err
+}
+
+// GetSKUsByIDsForUpdate 按ID顺序锁定并获取SKU，多个订单同时下单时加锁顺序一致
+func (r *ProductRepository) GetSKUsByIDsForUpdate(tx *gorm.DB, ids []uint64) ([]*models.ProductSKU, error) {
+	var skus []*models.ProductSKU
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&skus).Error
+	return skus, err
+}
+
+// UpdateSKU 更新SKU
+func (r *ProductRepository) UpdateSKU(tx *gorm.DB, id uint64, updates map[string]interface{}) error {
+	return tx.Model(&models.ProductSKU{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// GetTopSellingInCategories 获取指定分类下销量最高的在售商品，排除指定商品
+func (r *ProductRepository) GetTopSellingInCategories(categoryIDs []uint64, excludeIDs []uint64, limit int) ([]*models.Product, error) {
+	var products []*models.Product
+	db := models.DB.Where("category_id IN ? AND status = ?", categoryIDs, 1)
+	if len(excludeIDs) > 0 {
+		db = db.Where("id NOT IN ?", excludeIDs)
+	}
+	err := db.Order("sales DESC, id DESC").Limit(limit).Find(&products).Error
+	return products, err
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// ProductViewRepository 商品浏览量数据访问层
+type ProductViewRepository struct{}
+
+// NewProductViewRepository 创建商品浏览量Repository实例
+func NewProductViewRepository() *ProductViewRepository {
+	return &ProductViewRepository{}
+}
+
+// ProductViewCount 商品在统计期内的浏览量
+type ProductViewCount struct {
+	ProductID uint64 `json:"product_id"`
+	Views     int64  `json:"views"`
+}
+
+// SaveDaily 写入商品每日浏览量，已存在时覆盖为最新的累计值
+func (r *ProductViewRepository) SaveDaily(views []*models.ProductViewDaily) error {
+	if len(views) == 0 {
+		return nil
+	}
+	return models.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"views", "updated_at"}),
+	}).CreateInBatches(views, 500).Error
+}
+
+// GetTopViewed 获取自某日起浏览量最高的在售商品
+func (r *ProductViewRepository) GetTopViewed(since string, limit int) ([]*ProductViewCount, error) {
+	var counts []*ProductViewCount
+	err := models.DB.Model(&models.ProductViewDaily{}).
+		Select("product_view_daily.product_id, SUM(product_view_daily.views) AS views").
+		Joins("JOIN products ON products.id = product_view_daily.product_id AND products.deleted_at IS NULL AND products.status = ?", 1).
+		Where("product_view_daily.date >= ?", since).
+		Group("product_view_daily.product_id").
+		Order("views DESC").
+		Limit(limit).
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+	"time"
+)
+
+// PromotionRepository 促销活动数据访问层
+type PromotionRepository struct{}
+
+// NewPromotionRepository 创建促销活动Repository实例
+func NewPromotionRepository() *PromotionRepository {
+	return &PromotionRepository{}
+}
+
+// Create 创建促销活动
+func (r *PromotionRepository) Create(promotion *models.Promotion) error {
+	return models.DB.Create(promotion).Error
+}
+
+// Update 更新促销活动
+func (r *PromotionRepository) Update(id uint64, updates map[string]interface{}) error {
+	return models.DB.Model(&models.Promotion{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Delete 删除促销活动
+func (r *PromotionRepository) Delete(id uint64) error {
+	return models.DB.Delete(&models.Promotion{}, id).Error
+}
+
+// GetByID 根据ID获取促销活动
+func (r *PromotionRepository) GetByID(id uint64) (*models.Promotion, error) {
+	var promotion models.Promotion
+	if err := models.DB.First(&promotion, id).Error; err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// GetPromotions 分页获取促销活动（管理员）
+func (r *PromotionRepository) GetPromotions(page, pageSize int) ([]*models.Promotion, int64, error) {
+	var promotions []*models.Promotion
+	var total int64
+
+	db := models.DB.Model(&models.Promotion{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&promotions).Error; err != nil {
+		return nil, 0, err
+	}
+	return promotions, total, nil
+}
+
+// GetActive 获取当前生效的促销活动，按ID升序
+func (r *PromotionRepository) GetActive(now time.Time) ([]*models.Promotion, error) {
+	var promotions []*models.Promotion
+	err := models.DB.Where("status = ? AND start_at <= ? AND end_at > ?", 1, now, now).
+		Order("id ASC").
+		Find(&promotions).Error
+	return promotions, err
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// RecommendRepository 商品推荐数据访问层
+type RecommendRepository struct{}
+
+// NewRecommendRepository 创建商品推荐Repository实例
+func NewRecommendRepository() *RecommendRepository {
+	return &RecommendRepository{}
+}
+
+// OrderProduct 订单包含的商品
+type OrderProduct struct {
+	OrderID   uint64
+	ProductID uint64
+}
+
+// GetPaidOrderIDs 按ID顺序获取自某时间起已支付且未取消的一批订单ID
+func (r *RecommendRepository) GetPaidOrderIDs(since time.Time, lastID uint64, limit int) ([]uint64, error) {
+	var ids []uint64
+	err := models.DB.Model(&models.Order{}).
+		Where("id > ? AND created_at >= ? AND pay_status = ? AND order_status <> ?",
+			lastID, since, models.PayStatusPaid, models.OrderStatusCancelled).
+		Order("id ASC").Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// GetOrderProducts 获取订单包含的商品（同一订单内去重）
+func (r *RecommendRepository) GetOrderProducts(orderIDs []uint64) ([]*OrderProduct, error) {
+	var rows []*OrderProduct
+	err := models.DB.Model(&models.OrderItem{}).
+		Distinct("order_id", "product_id").
+		Where("order_id IN ?", orderIDs).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// SaveRelations 写入商品关联，已存在时更新得分
+func (r *RecommendRepository) SaveRelations(relations []*models.ProductRelation) error {
+	if len(relations) == 0 {
+		return nil
+	}
+	return models.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "related_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score", "co_bought", "co_viewed", "updated_at"}),
+	}).CreateInBatches(relations, 500).Error
+}
+
+// DeleteStaleRelations 删除本轮计算未更新的商品关联
+func (r *RecommendRepository) DeleteStaleRelations(before time.Time) (int64, error) {
+	result := models.DB.Where("updated_at < ?", before).Delete(&models.ProductRelation{})
+	return result.RowsAffected, result.Error
+}
+
+// GetRelations 获取商品的关联商品（关联商品须在售），按得分倒序
+func (r *RecommendRepository) GetRelations(productIDs []uint64, limit int) ([]*models.ProductRelation, error) {
+	var relations []*models.ProductRelation
+	err := models.DB.Model(&models.ProductRelation{}).
+		Select("product_relations.*").
+		Joins("JOIN products ON products.id = product_relations.related_id AND products.deleted_at IS NULL AND products.status = ?", 1).
+		Where("product_relations.product_id IN ?", productIDs).
+		Order("product_relations.score DESC, product_relations.related_id ASC").
+		Limit(limit).
+		Find(&relations).Error
+	return relations, err
+}
+
+// GetCartProductIDs 获取用户购物车中的商品ID
+func (r *RecommendRepository) GetCartProductIDs(userID uint64) ([]uint64, error) {
+	var ids []uint64
+	err := models.DB.Model(&models.CartItem{}).
+		Where("user_id = ?", userID).
+		Distinct().Pluck("product_id", &ids).Error
+	return ids, err
+}
+
+// GetPurchasedProductIDs 获取用户最近购买的商品ID，按最近购买时间倒序
+func (r *RecommendRepository) GetPurchasedProductIDs(userID uint64, limit int) ([]uint64, error) {
+	var ids []uint64
+	err := models.DB.Model(&models.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("orders.user_id = ? AND orders.pay_status = ?", userID, models.PayStatusPaid).
+		Group("order_items.product_id").
+		Order("MAX(order_items.id) DESC").
+		Limit(limit).
+		Pluck("order_items.product_id", &ids).Error
+	return ids, err
+}
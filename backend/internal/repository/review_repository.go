@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ReviewRepository 商品评价数据访问层
+type ReviewRepository struct{}
+
+// NewReviewRepository 创建商品评价Repository实例
+func NewReviewRepository() *ReviewRepository {
+	return &ReviewRepository{}
+}
+
+// Create 创建评价
+func (r *ReviewRepository) Create(tx *gorm.DB, review *models.ProductReview) error {
+	return tx.Create(review).Error
+}
+
+// GetProductReviews 分页获取商品的评价，按时间倒序
+func (r *ReviewRepository) GetProductReviews(productID uint64, page, pageSize int) ([]*models.ProductReview, int64, error) {
+	var reviews []*models.ProductReview
+	var total int64
+
+	db := models.DB.Model(&models.ProductReview{}).Where("product_id = ?", productID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&reviews).Error; err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RoleRepository 角色权限数据访问层
+type RoleRepository struct{}
+
+// NewRoleRepository 创建角色Repository实例
+func NewRoleRepository() *RoleRepository {
+	return &RoleRepository{}
+}
+
+// GetAll 获取所有角色（含权限）
+func (r *RoleRepository) GetAll() ([]*models.Role, error) {
+	var roles []*models.Role
+	err := models.DB.Preload("Permissions").Order("id ASC").Find(&roles).Error
+	return roles, err
+}
+
+// GetByID 根据ID获取角色（含权限）
+func (r *RoleRepository) GetByID(id uint64) (*models.Role, error) {
+	var role models.Role
+	err := models.DB.Preload("Permissions").Where("id = ?", id).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetByNames 根据名称批量获取角色
+func (r *RoleRepository) GetByNames(names []string) ([]*models.Role, error) {
+	var roles []*models.Role
+	err := models.DB.Where("name IN ?", names).Find(&roles).Error
+	return roles, err
+}
+
+// ExistsByName 检查角色名是否存在
+func (r *RoleRepository) ExistsByName(name string) (bool, error) {
+	var count int64
+	err := models.DB.Model(&models.Role{}).Where("name = ?", name).Count(&count).Error
+	return count > 0, err
+}
+
+// Create 创建角色
+func (r *RoleRepository) Create(tx *gorm.DB, role *models.Role) error {
+	return tx.Omit("Permissions").Create(role).Error
+}
+
+// Update 更新角色基本信息
+func (r *RoleRepository) Update(tx *gorm.DB, id uint64, updates map[string]interface{}) error {
+	return tx.Model(&models.Role{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Delete 删除角色及其权限、用户关联
+func (r *RoleRepository) Delete(tx *gorm.DB, role *models.Role) error {
+	if err := tx.Model(role).Association("Permissions").Clear(); err != nil {
+		return err
+	}
+	if err := tx.Where("role_id = ?", role.ID).Delete(&models.UserRole{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(role).Error
+}
+
+// ReplacePermissions 替换角色的权限
+func (r *RoleRepository) ReplacePermissions(tx *gorm.DB, role *models.Role, permissions []*models.Permission) error {
+	return tx.Model(role).Association("Permissions").Replace(permissions)
+}
+
+// GetAllPermissions 获取所有权限
+func (r *RoleRepository) GetAllPermissions() ([]*models.Permission, error) {
+	var permissions []*models.Permission
+	err := models.DB.Order("id ASC").Find(&permissions).Error
+	return permissions, err
+}
+
+// GetPermissionsByCodes 根据编码批量获取权限
+func (r *RoleRepository) GetPermissionsByCodes(codes []string) ([]*models.Permission, error) {
+	var permissions []*models.Permission
+	err := models.DB.Where("code IN ?", codes).Find(&permissions).Error
+	return permissions, err
+}
+
+// GetUserRoles 获取用户的角色
+func (r *RoleRepository) GetUserRoles(userID uint64) ([]*models.Role, error) {
+	var roles []*models.Role
+	err := models.DB.Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.id ASC").
+		Find(&roles).Error
+	return roles, err
+}
+
+// UserRoleName 用户的角色名
+type UserRoleName struct {
+	UserID uint64
+	Name   string
+}
+
+// GetRoleNamesByUsers 批量获取用户的角色名，按角色ID升序
+func (r *RoleRepository) GetRoleNamesByUsers(userIDs []uint64) ([]*UserRoleName, error) {
+	var rows []*UserRoleName
+	if len(userIDs) == 0 {
+		return rows, nil
+	}
+	err := models.DB.Model(&models.Role{}).
+		Select("user_roles.user_id, roles.name").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id IN ?", userIDs).
+		Order("roles.id ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GetUserPermissionCodes 获取用户通过角色获得的全部权限编码
+func (r *RoleRepository) GetUserPermissionCodes(userID uint64) ([]string, error) {
+	var codes []string
+	err := models.DB.Model(&models.Permission{}).
+		Distinct("permissions.code").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Pluck("permissions.code", &codes).Error
+	return codes, err
+}
+
+// SetUserRoles 设置用户的角色（覆盖原有角色）
+func (r *RoleRepository) SetUserRoles(tx *gorm.DB, userID uint64, roleIDs []uint64) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.UserRole{}).Error; err != nil {
+		return err
+	}
+	if len(roleIDs) == 0 {
+		return nil
+	}
+	userRoles := make([]*models.UserRole, 0, len(roleIDs))
+	for _, roleID := range roleIDs {
+		userRoles = append(userRoles, &models.UserRole{UserID: userID, RoleID: roleID})
+	}
+	return tx.Create(&userRoles).Error
+}
+
+// AddUserRole 为用户添加角色，已存在时忽略
+func (r *RoleRepository) AddUserRole(tx *gorm.DB, userID uint64, roleID uint64) error {
+	return tx.Where(models.UserRole{UserID: userID, RoleID: roleID}).
+		FirstOrCreate(&models.UserRole{}).Error
+}
+
+// GetUserIDsByRole 获取拥有该角色的用户ID
+func (r *RoleRepository) GetUserIDsByRole(roleID uint64) ([]uint64, error) {
+	var userIDs []uint64
+	err := models.DB.Model(&models.UserRole{}).Where("role_id = ?", roleID).Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// CountUsersByRoleName 统计拥有该角色的正常状态用户数
+func (r *RoleRepository) CountUsersByRoleName(tx *gorm.DB, roleName string) (int64, error) {
+	var count int64
+	err := tx.Model(&models.UserRole{}).
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Joins("JOIN users ON users.id = user_roles.user_id AND users.deleted_at IS NULL AND users.status = 1").
+		Where("roles.name = ?", roleName).
+		Count(&count).Error
+	return count, err
+}
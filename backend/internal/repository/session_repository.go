@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository 登录会话数据访问层
+type SessionRepository struct{}
+
+// NewSessionRepository 创建会话Repository实例
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{}
+}
+
+// Create 创建会话
+func (r *SessionRepository) Create(tx *gorm.DB, session *models.UserSession) error {
+	return tx.Create(session).Error
+}
+
+// GetByID 根据ID获取用户的会话
+func (r *SessionRepository) GetByID(userID uint64, id uint64) (*models.UserSession, error) {
+	var session models.UserSession
+	err := models.DB.Where("id = ? AND user_id = ?", id, userID).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetActiveByUser 获取用户的有效会话
+func (r *SessionRepository) GetActiveByUser(userID uint64) ([]*models.UserSession, error) {
+	var sessions []*models.UserSession
+	err := models.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Touch 更新会话最后活跃信息
+func (r *SessionRepository) Touch(tx *gorm.DB, familyID string, ip string, expiresAt time.Time) error {
+	return tx.Model(&models.UserSession{}).
+		Where("family_id = ?", familyID).
+		Updates(map[string]interface{}{
+			"ip":           ip,
+			"last_seen_at": time.Now(),
+			"expires_at":   expiresAt,
+		}).Error
+}
+
+// Revoke 标记会话已退出
+func (r *SessionRepository) Revoke(tx *gorm.DB, familyID string) error {
+	return tx.Model(&models.UserSession{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeByUser 标记用户所有会话已退出
+func (r *SessionRepository) RevokeByUser(tx *gorm.DB, userID uint64) error {
+	return tx.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// StockSubscriptionRepository 到货提醒订阅数据访问层
+type StockSubscriptionRepository struct{}
+
+// NewStockSubscriptionRepository 创建到货提醒订阅Repository实例
+func NewStockSubscriptionRepository() *StockSubscriptionRepository {
+	return &StockSubscriptionRepository{}
+}
+
+// Upsert 创建订阅，已订阅过时重新置为等待到货
+func (r *StockSubscriptionRepository) Upsert(subscription *models.StockSubscription) error {
+	return models.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "sku_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"product_id", "notified_at", "updated_at"}),
+	}).Create(subscription).Error
+}
+
+// GetByUserSKU 获取用户对某SKU的订阅
+func (r *StockSubscriptionRepository) GetByUserSKU(userID uint64, skuID uint64) (*models.StockSubscription, error) {
+	var subscription models.StockSubscription
+	err := models.DB.Where("user_id = ? AND sku_id = ?", userID, skuID).First(&subscription).Error
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// Delete 删除用户的订阅，返回是否删除成功
+func (r *StockSubscriptionRepository) Delete(userID uint64, id uint64) (bool, error) {
+	result := models.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.StockSubscription{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetSubscriptions 分页获取用户的订阅
+func (r *StockSubscriptionRepository) GetSubscriptions(userID uint64, page, pageSize int) ([]*models.StockSubscription, int64, error) {
+	var subscriptions []*models.StockSubscription
+	var total int64
+
+	db := models.DB.Model(&models.StockSubscription{}).Where("user_id = ?", userID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&subscriptions).Error; err != nil {
+		return nil, 0, err
+	}
+	return subscriptions, total, nil
+}
+
+// GetPendingBatch 按ID顺序获取某SKU等待到货的一批订阅
+func (r *StockSubscriptionRepository) GetPendingBatch(skuID uint64, lastID uint64, limit int) ([]*models.StockSubscription, error) {
+	var subscriptions []*models.StockSubscription
+	err := models.DB.Where("sku_id = ? AND notified_at IS NULL AND id > ?", skuID, lastID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// MarkNotified 标记订阅已提醒
+func (r *StockSubscriptionRepository) MarkNotified(ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return models.DB.Model(&models.StockSubscription{}).
+		Where("id IN ? AND notified_at IS NULL", ids).
+		Update("notified_at", time.Now()).Error
+}
+
+// GetPendingInStockSKUIDs 获取已有货、商品在售但仍有等待提醒订阅的SKU
+func (r *StockSubscriptionRepository) GetPendingInStockSKUIDs(limit int) ([]uint64, error) {
+	var skuIDs []uint64
+	err := models.DB.Model(&models.StockSubscription{}).
+		Distinct("stock_subscriptions.sku_id").
+		Joins("JOIN product_skus ON product_skus.id = stock_subscriptions.sku_id AND product_skus.stock > 0 AND product_skus.deleted_at IS NULL").
+		Joins("JOIN products ON products.id = product_skus.product_id AND products.status = 1 AND products.deleted_at IS NULL").
+		Where("stock_subscriptions.notified_at IS NULL").
+		Limit(limit).
+		Pluck("stock_subscriptions.sku_id", &skuIDs).Error
+	return skuIDs, err
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TaskRepository 任务和签到数据访问层
+type TaskRepository struct{}
+
+// NewTaskRepository 创建任务Repository实例
+func NewTaskRepository() *TaskRepository {
+	return &TaskRepository{}
+}
+
+// GetTasks 获取任务列表，onlyEnabled为true时只返回启用的任务
+func (r *TaskRepository) GetTasks(onlyEnabled bool) ([]*models.Task, error) {
+	var tasks []*models.Task
+	db := models.DB.Model(&models.Task{})
+	if onlyEnabled {
+		db = db.Where("status = ?", 1)
+	}
+	err := db.Order("sort ASC, id ASC").Find(&tasks).Error
+	return tasks, err
+}
+
+// GetTaskByID 根据ID获取任务
+func (r *TaskRepository) GetTaskByID(id uint64) (*models.Task, error) {
+	var task models.Task
+	err := models.DB.Where("id = ?", id).First(&task).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetTaskByCode 根据编码获取任务
+func (r *TaskRepository) GetTaskByCode(tx *gorm.DB, code string) (*models.Task, error) {
+	var task models.Task
+	err := tx.Where("code = ?", code).First(&task).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// UpdateTask 更新任务
+func (r *TaskRepository) UpdateTask(id uint64, updates map[string]interface{}) error {
+	return models.DB.Model(&models.Task{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// CreateUserTask 记录用户完成任务，已完成过时返回false
+func (r *TaskRepository) CreateUserTask(tx *gorm.DB, userTask *models.UserTask) (bool, error) {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(userTask)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetUserTasks 获取用户已完成的任务，按任务ID索引
+func (r *TaskRepository) GetUserTasks(userID uint64) (map[uint64]*models.UserTask, error) {
+	var userTasks []*models.UserTask
+	if err := models.DB.Where("user_id = ?", userID).Find(&userTasks).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint64]*models.UserTask, len(userTasks))
+	for _, userTask := range userTasks {
+		result[userTask.TaskID] = userTask
+	}
+	return result, nil
+}
+
+// GetCheckin 获取用户某天的签到记录
+func (r *TaskRepository) GetCheckin(tx *gorm.DB, userID uint64, date string) (*models.CheckinRecord, error) {
+	var record models.CheckinRecord
+	err := tx.Where("user_id = ? AND date = ?", userID, date).First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// CreateCheckin 创建签到记录，当天已签到时返回false
+func (r *TaskRepository) CreateCheckin(tx *gorm.DB, record *models.CheckinRecord) (bool, error) {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetCheckinDates 获取用户在日期范围内的签到日期
+func (r *TaskRepository) GetCheckinDates(userID uint64, startDate, endDate string) ([]string, error) {
+	var dates []string
+	err := models.DB.Model(&models.CheckinRecord{}).
+		Where("user_id = ? AND date BETWEEN ? AND ?", userID, startDate, endDate).
+		Pluck("date", &dates).Error
+	return dates, err
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TokenRepository 令牌数据访问层
+type TokenRepository struct{}
+
+// NewTokenRepository 创建令牌Repository实例
+func NewTokenRepository() *TokenRepository {
+	return &TokenRepository{}
+}
+
+// Create 创建刷新令牌
+func (r *TokenRepository) Create(tx *gorm.DB, token *models.RefreshToken) error {
+	return tx.Create(token).Error
+}
+
+// GetByHashForUpdate 根据哈希获取刷新令牌并加锁
+func (r *TokenRepository) GetByHashForUpdate(tx *gorm.DB, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed 标记令牌已轮换
+func (r *TokenRepository) MarkUsed(tx *gorm.DB, id uint64, replacedBy uint64) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"used_at":     time.Now(),
+			"replaced_by": replacedBy,
+		}).Error
+}
+
+// RevokeFamily 吊销整个令牌族
+func (r *TokenRepository) RevokeFamily(tx *gorm.DB, familyID string) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeByUser 吊销用户的所有刷新令牌
+func (r *TokenRepository) RevokeByUser(tx *gorm.DB, userID uint64) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// CreatePasswordReset 创建密码重置令牌
+func (r *TokenRepository) CreatePasswordReset(tx *gorm.DB, reset *models.PasswordReset) error {
+	return tx.Create(reset).Error
+}
+
+// GetPasswordResetForUpdate 根据哈希获取密码重置令牌并加锁
+func (r *TokenRepository) GetPasswordResetForUpdate(tx *gorm.DB, tokenHash string) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&reset).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+// InvalidatePasswordResets 作废用户所有未使用的密码重置令牌
+func (r *TokenRepository) InvalidatePasswordResets(tx *gorm.DB, userID uint64) error {
+	return tx.Model(&models.PasswordReset{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"online-mall/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TwoFactorRepository 双因素认证数据访问层
+type TwoFactorRepository struct{}
+
+// NewTwoFactorRepository 创建双因素认证Repository实例
+func NewTwoFactorRepository() *TwoFactorRepository {
+	return &TwoFactorRepository{}
+}
+
+// GetByUserID 获取用户的TOTP配置
+func (r *TwoFactorRepository) GetByUserID(userID uint64) (*models.UserTOTP, error) {
+	var totp models.UserTOTP
+	err := models.DB.Where("user_id = ?", userID).First(&totp).Error
+	if err != nil {
+		return nil, err
+	}
+	return &totp, nil
+}
+
+// GetByUserIDForUpdate 加锁获取用户的TOTP配置
+func (r *TwoFactorRepository) GetByUserIDForUpdate(tx *gorm.DB, userID uint64) (*models.UserTOTP, error) {
+	var totp models.UserTOTP
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		First(&totp).Error
+	if err != nil {
+		return nil, err
+	}
+	return &totp, nil
+}
+
+// Save 创建或重置用户的TOTP配置（未启用状态）
+func (r *TwoFactorRepository) Save(tx *gorm.DB, userID uint64, secret string) error {
+	totp := &models.UserTOTP{UserID: userID, Secret: secret}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"secret": secret, "enabled_at": nil, "last_used_step": 0}),
+	}).Create(totp).Error
+}
+
+// Enable 启用TOTP
+func (r *TwoFactorRepository) Enable(tx *gorm.DB, id uint64, step int64) error {
+	return tx.Model(&models.UserTOTP{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"enabled_at": time.Now(), "last_used_step": step}).Error
+}
+
+// UpdateLastUsedStep 记录已使用的时间步
+func (r *TwoFactorRepository) UpdateLastUsedStep(tx *gorm.DB, id uint64, step int64) error {
+	return tx.Model(&models.UserTOTP{}).Where("id = ?", id).Update("last_used_step", step).Error
+}
+
+// Delete 删除用户的TOTP配置和恢复码
+func (r *TwoFactorRepository) Delete(tx *gorm.DB, userID uint64) error {
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.UserTOTP{}).Error; err != nil {
+		return err
+	}
+	return r.DeleteRecoveryCodes(tx, userID)
+}
+
+// ReplaceRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(tx *gorm.DB, userID uint64, codeHashes []string) error {
+	if err := r.DeleteRecoveryCodes(tx, userID); err != nil {
+		return err
+	}
+	codes := make([]*models.UserRecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, &models.UserRecoveryCode{UserID: userID, CodeHash: hash})
+	}
+	return tx.Create(&codes).Error
+}
+
+// DeleteRecoveryCodes 删除用户的恢复码
+func (r *TwoFactorRepository) DeleteRecoveryCodes(tx *gorm.DB, userID uint64) error {
+	return tx.Unscoped().Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error
+}
+
+// UseRecoveryCode 使用恢复码，返回是否成功
+func (r *TwoFactorRepository) UseRecoveryCode(tx *gorm.DB, userID uint64, codeHash string) (bool, error) {
+	result := tx.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Limit(1).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountUnusedRecoveryCodes 统计剩余可用恢复码数量
+func (r *TwoFactorRepository) CountUnusedRecoveryCodes(userID uint64) (int64, error) {
+	var count int64
+	err := models.DB.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"fmt"
+	"online-mall/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// UserOrderStat 用户订单统计
+type UserOrderStat struct {
+	UserID     uint64  `json:"user_id"`
+	OrderCount int64   `json:"order_count"` // 订单数（不含已取消）
+	TotalSpend float64 `json:"total_spend"` // 累计实付金额（已支付且未取消）
+}
+
+// UserRepository 用户数据访问层
+type UserRepository struct{}
+
+// NewUserRepository 创建用户Repository实例
+func NewUserRepository() *UserRepository {
+	return &UserRepository{}
+}
+
+// GetByID 根据ID获取用户
+func (r *UserRepository) GetByID(id uint64) (*models.User, error) {
+	var user models.User
+	err := models.DB.Where("id = ?", id).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByIDs 批量获取用户
+func (r *UserRepository) GetByIDs(ids []uint64) ([]*models.User, error) {
+	var users []*models.User
+	err := models.DB.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+// GetUsers 分页获取用户列表
+func (r *UserRepository) GetUsers(query *models.UserQuery) ([]*models.User, int64, error) {
+	var users []*models.User
+	var total int64
+
+	db := models.DB.Model(&models.User{})
+
+	if query.Username != "" {
+		db = db.Where("username LIKE ?", "%"+query.Username+"%")
+	}
+	if query.Phone != "" {
+		db = db.Where("phone LIKE ?", "%"+query.Phone+"%")
+	}
+	if query.Email != "" {
+		db = db.Where("email LIKE ?", "%"+query.Email+"%")
+	}
+	if query.Keyword != "" {
+		keyword := "%" + query.Keyword + "%"
+		db = db.Where("username LIKE ? OR nickname LIKE ? OR phone LIKE ? OR email LIKE ?", keyword, keyword, keyword, keyword)
+	}
+	if query.Status != nil {
+		db = db.Where("status = ?", *query.Status)
+	}
+
+	// 获取总数
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 排序
+	switch query.Sort {
+	case "created_asc":
+		db = db.Order("id ASC")
+	case "last_login_desc":
+		db = db.Order("last_login_at DESC, id DESC")
+	default:
+		db = db.Order("id DESC")
+	}
+
+	offset := (query.Page - 1) * query.PageSize
+	if err := db.Offset(offset).Limit(query.PageSize).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// GetOrderStats 批量统计用户的订单数和消费金额
+func (r *UserRepository) GetOrderStats(userIDs []uint64) (map[uint64]*UserOrderStat, error) {
+	stats := make(map[uint64]*UserOrderStat, len(userIDs))
+	if len(userIDs) == 0 {
+		return stats, nil
+	}
+
+	var rows []*UserOrderStat
+	err := models.DB.Model(&models.Order{}).
+		Select("user_id, COUNT(*) AS order_count, COALESCE(SUM(CASE WHEN pay_status = ? THEN pay_amount ELSE 0 END), 0) AS total_spend", models.PayStatusPaid).
+		Where("user_id IN ? AND order_status <> ?", userIDs, models.OrderStatusCancelled).
+		Group("user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		stats[row.UserID] = row
+	}
+	for _, userID := range userIDs {
+		if _, ok := stats[userID]; !ok {
+			stats[userID] = &UserOrderStat{UserID: userID}
+		}
+	}
+	return stats, nil
+}
+
+// ExistsByField 检查字段值是否已被其他用户使用
+func (r *UserRepository) ExistsByField(field string, value string, excludeID uint64) (bool, error) {
+	var count int64
+	err := models.DB.Model(&models.User{}).
+		Where(field+" = ? AND id <> ?", value, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Update 更新用户字段
+func (r *UserRepository) Update(tx *gorm.DB, id uint64, updates map[string]interface{}) error {
+	return tx.Model(&models.User{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Anonymize 清除用户个人信息并软删除，保留ID以便订单等历史数据关联
+func (r *UserRepository) Anonymize(tx *gorm.DB, user *models.User, passwordHash string) error {
+	err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"username":          fmt.Sprintf("deleted_%d", user.ID),
+		"password":          passwordHash,
+		"phone":             nil,
+		"email":             nil,
+		"nickname":          "已注销用户",
+		"avatar":            "",
+		"status":            0,
+		"phone_verified_at": nil,
+		"email_verified_at": nil,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	// 早于收货地址快照的订单只通过address_id关联地址，清除前先将地址快照写入订单，保留发货记录
+	var addresses []*models.Address
+	if err := tx.Where("user_id = ?", user.ID).Find(&addresses).Error; err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		snapshot := &models.Order{}
+		snapshot.SetReceiver(address)
+		err := tx.Model(&models.Order{}).
+			Where("user_id = ? AND address_id = ? AND (receiver_name = '' OR receiver_name IS NULL)", user.ID, address.ID).
+			Updates(map[string]interface{}{
+				"receiver_name":    snapshot.ReceiverName,
+				"receiver_phone":   snapshot.ReceiverPhone,
+				"receiver_address": snapshot.ReceiverAddress,
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// 收货地址包含姓名、电话和详细地址，一并清除
+	err = tx.Model(&models.Address{}).Where("user_id = ?", user.ID).Updates(map[string]interface{}{
+		"name":     "",
+		"phone":    "",
+		"province": "",
+		"city":     "",
+		"district": "",
+		"detail":   "",
+		"postcode": "",
+	}).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.Address{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Delete(&models.User{}, user.ID).Error
+}
@@ -0,0 +1,429 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// exportTimeout 导出任务超过该时长仍未完成视为失败
+const exportTimeout = 30 * time.Minute
+
+// deletionBatchSize 每轮处理的到期注销申请数量
+const deletionBatchSize = 100
+
+var (
+	// ErrExportInProgress 已有导出任务在处理
+	ErrExportInProgress = errors.New("数据导出正在进行中，请稍后查看")
+
+	// ErrExportTooFrequent 导出过于频繁
+	ErrExportTooFrequent = errors.New("导出过于频繁，请稍后再试")
+
+	// ErrDeletionPending 已提交注销申请
+	ErrDeletionPending = errors.New("已提交注销申请，冷静期内可撤销")
+
+	// ErrDeletionNotFound 没有待处理的注销申请
+	ErrDeletionNotFound = errors.New("没有待处理的注销申请")
+
+	// ErrDeletionOpenOrders 存在未完成订单
+	ErrDeletionOpenOrders = errors.New("存在未完成的订单，请完成或取消后再注销")
+
+	// ErrPasswordIncorrect 密码错误
+	ErrPasswordIncorrect = errors.New("密码错误")
+
+	// ErrDeletionPhoneUnbound 未绑定手机号，不能使用短信验证码
+	ErrDeletionPhoneUnbound = errors.New("未绑定手机号，请使用密码确认")
+)
+
+// DataExportView 导出任务及下载链接
+type DataExportView struct {
+	*models.DataExport
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// AccountService 数据导出和账号注销业务逻辑层
+type AccountService struct {
+	accountRepo       *repository.AccountRepository
+	userService       *UserService
+	verifyCodeService *VerifyCodeService
+}
+
+// NewAccountService 创建账号Service实例
+func NewAccountService() *AccountService {
+	return &AccountService{
+		accountRepo:       repository.NewAccountRepository(),
+		userService:       NewUserService(),
+		verifyCodeService: NewVerifyCodeService(),
+	}
+}
+
+// RequestExport 创建数据导出任务，后台生成ZIP文件
+func (s *AccountService) RequestExport(userID uint64) (*DataExportView, error) {
+	latest, err := s.accountRepo.GetLatestExport(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if latest != nil {
+		running := latest.Status == models.DataExportPending || latest.Status == models.DataExportProcessing
+		if running && time.Since(latest.CreatedAt) < exportTimeout {
+			return nil, ErrExportInProgress
+		}
+		cooldown := time.Duration(config.GlobalConfig.Account.ExportCooldownMinutes) * time.Minute
+		if latest.Status == models.DataExportCompleted && time.Since(latest.CreatedAt) < cooldown {
+			return nil, ErrExportTooFrequent
+		}
+	}
+
+	export := &models.DataExport{UserID: userID, Status: models.DataExportPending}
+	if err := s.accountRepo.CreateExport(export); err != nil {
+		return nil, err
+	}
+
+	go s.runExport(export)
+
+	return &DataExportView{DataExport: export}, nil
+}
+
+// runExport 生成导出文件并更新任务状态，在后台协程中执行，panic时将任务标记为失败
+func (s *AccountService) runExport(export *models.DataExport) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.failExport(export.ID, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	if err := s.accountRepo.UpdateExport(export.ID, map[string]interface{}{"status": models.DataExportProcessing}); err != nil {
+		log.Printf("Failed to start data export %d: %v", export.ID, err)
+		return
+	}
+
+	data, err := s.buildArchive(export.UserID)
+	var name string
+	if err == nil {
+		name, err = utils.SavePrivateFile(utils.PrivateDirExport,
+			fmt.Sprintf("%d/%d-%s.zip", export.UserID, export.ID, time.Now().Format("20060102150405")), data)
+	}
+	if err != nil {
+		s.failExport(export.ID, err)
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(config.GlobalConfig.Account.ExportExpireHours) * time.Hour)
+	if err := s.accountRepo.UpdateExport(export.ID, map[string]interface{}{
+		"status":       models.DataExportCompleted,
+		"file_path":    name,
+		"file_size":    len(data),
+		"completed_at": now,
+		"expires_at":   expiresAt,
+	}); err != nil {
+		log.Printf("Failed to update data export %d: %v", export.ID, err)
+		_ = utils.DeletePrivateFile(name)
+	}
+}
+
+// failExport 将导出任务标记为失败
+func (s *AccountService) failExport(exportID uint64, err error) {
+	log.Printf("Data export %d failed: %v", exportID, err)
+	message := err.Error()
+	if len(message) > 255 {
+		message = message[:255]
+	}
+	if err := s.accountRepo.UpdateExport(exportID, map[string]interface{}{
+		"status": models.DataExportFailed,
+		"error":  message,
+	}); err != nil {
+		log.Printf("Failed to update data export %d: %v", exportID, err)
+	}
+}
+
+// buildArchive 打包用户的个人数据，每类数据一个JSON文件
+func (s *AccountService) buildArchive(userID uint64) ([]byte, error) {
+	user, err := s.userService.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := s.accountRepo.GetAddresses(userID)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.accountRepo.GetOrders(userID)
+	if err != nil {
+		return nil, err
+	}
+	coupons, err := s.accountRepo.GetUserCoupons(userID)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := s.accountRepo.GetReviews(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user},
+		{"addresses.json", addresses},
+		{"orders.json", orders},
+		{"coupons.json", coupons},
+		{"reviews.json", reviews},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GetExports 获取最近的导出任务，可下载的任务附带签名链接（仅本人可下载）
+func (s *AccountService) GetExports(userID uint64) ([]*DataExportView, error) {
+	exports, err := s.accountRepo.GetExports(userID, 5)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	views := make([]*DataExportView, 0, len(exports))
+	for _, export := range exports {
+		view := &DataExportView{DataExport: export}
+		if export.Status == models.DataExportCompleted && export.ExpiresAt != nil && now.Before(*export.ExpiresAt) {
+			// 链接有效期不超过文件保留期
+			expire := time.Duration(config.GlobalConfig.Upload.SignExpireMinutes) * time.Minute
+			if remaining := export.ExpiresAt.Sub(now); remaining < expire {
+				expire = remaining
+			}
+			url, err := utils.SignFileURL(export.FilePath, userID, expire)
+			if err != nil {
+				return nil, err
+			}
+			view.DownloadURL = url
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// CleanupExports 删除过期的导出文件，并结束超时的导出任务
+func (s *AccountService) CleanupExports() error {
+	if err := s.accountRepo.FailStaleExports(time.Now().Add(-exportTimeout)); err != nil {
+		return err
+	}
+
+	exports, err := s.accountRepo.GetExpiredExports(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if err := utils.DeletePrivateFile(export.FilePath); err != nil {
+			log.Printf("Failed to delete export file %s: %v", export.FilePath, err)
+			continue
+		}
+		if err := s.accountRepo.UpdateExport(export.ID, map[string]interface{}{
+			"status":    models.DataExportExpired,
+			"file_path": "",
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteExportFiles 删除用户所有导出文件
+func (s *AccountService) deleteExportFiles(userID uint64) error {
+	exports, err := s.accountRepo.GetExportsWithFile(userID)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if err := utils.DeletePrivateFile(export.FilePath); err != nil {
+			return err
+		}
+		if err := s.accountRepo.UpdateExport(export.ID, map[string]interface{}{
+			"status":    models.DataExportExpired,
+			"file_path": "",
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RequestDeletion 申请注销账号，冷静期结束后执行。
+// 通过密码或绑定手机号收到的短信验证码确认（验证码登录自动注册的用户没有设置过密码），code非空时使用验证码
+func (s *AccountService) RequestDeletion(ctx context.Context, user *models.User, password string, code string, reason string) (*models.AccountDeletion, error) {
+	if code != "" {
+		if user.Phone == "" {
+			return nil, ErrDeletionPhoneUnbound
+		}
+		if err := s.verifyCodeService.Check(ctx, VerifySceneDeletion, user.Phone, code); err != nil {
+			return nil, err
+		}
+	} else if !user.CheckPassword(password) {
+		return nil, ErrPasswordIncorrect
+	}
+
+	if _, err := s.accountRepo.GetPendingDeletion(user.ID); err == nil {
+		return nil, ErrDeletionPending
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	count, err := s.accountRepo.CountOpenOrders(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrDeletionOpenOrders
+	}
+
+	deletion := &models.AccountDeletion{
+		UserID:      user.ID,
+		Status:      models.AccountDeletionPending,
+		Reason:      reason,
+		ScheduledAt: time.Now().AddDate(0, 0, config.GlobalConfig.Account.DeletionCoolingDays),
+	}
+	if err := s.accountRepo.CreateDeletion(deletion); err != nil {
+		return nil, err
+	}
+	if code != "" {
+		if err := s.verifyCodeService.Consume(ctx, VerifySceneDeletion, user.Phone); err != nil {
+			log.Printf("Failed to consume deletion code of user %d: %v", user.ID, err)
+		}
+	}
+	return deletion, nil
+}
+
+// GetPendingDeletion 获取冷静期中的注销申请，没有时返回nil
+func (s *AccountService) GetPendingDeletion(userID uint64) (*models.AccountDeletion, error) {
+	deletion, err := s.accountRepo.GetPendingDeletion(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return deletion, nil
+}
+
+// CancelDeletion 冷静期内撤销注销申请
+func (s *AccountService) CancelDeletion(userID uint64) error {
+	deletion, err := s.GetPendingDeletion(userID)
+	if err != nil {
+		return err
+	}
+	if deletion == nil {
+		return ErrDeletionNotFound
+	}
+
+	ok, err := s.accountRepo.UpdateDeletionStatus(models.DB, deletion.ID, map[string]interface{}{
+		"status":       models.AccountDeletionCancelled,
+		"cancelled_at": time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrDeletionNotFound
+	}
+	return nil
+}
+
+// ProcessDueDeletions 执行冷静期已结束的注销申请，返回完成的数量。
+// 个人信息和收货地址被清除，订单记录保留用于对账
+func (s *AccountService) ProcessDueDeletions(ctx context.Context) (int, error) {
+	deletions, err := s.accountRepo.GetDueDeletions(time.Now(), deletionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	for _, deletion := range deletions {
+		// 冷静期内产生的订单未完成时暂缓注销，下一轮重试
+		count, err := s.accountRepo.CountOpenOrders(deletion.UserID)
+		if err != nil {
+			return completed, err
+		}
+		if count > 0 {
+			continue
+		}
+
+		// operatorID 为0表示系统执行
+		if err := s.userService.DeleteUser(ctx, 0, deletion.UserID); err != nil && !errors.Is(err, ErrUserNotFound) {
+			log.Printf("Failed to delete account %d: %v", deletion.UserID, err)
+			continue
+		}
+		if err := s.deleteExportFiles(deletion.UserID); err != nil {
+			log.Printf("Failed to delete export files of user %d: %v", deletion.UserID, err)
+		}
+
+		if _, err := s.accountRepo.UpdateDeletionStatus(models.DB, deletion.ID, map[string]interface{}{
+			"status":       models.AccountDeletionCompleted,
+			"completed_at": time.Now(),
+		}); err != nil {
+			return completed, err
+		}
+		completed++
+	}
+	return completed, nil
+}
+
+// RunWorker 定期执行到期注销和过期导出文件清理
+func (s *AccountService) RunWorker(ctx context.Context) {
+	interval := time.Duration(config.GlobalConfig.Account.WorkerIntervalMinutes) * time.Minute
+	runPeriodic(ctx, utils.AccountWorkerLockKey, interval, s.runWorkerOnce)
+}
+
+// runWorkerOnce 执行一轮后台任务
+func (s *AccountService) runWorkerOnce(ctx context.Context) {
+	count, err := s.ProcessDueDeletions(ctx)
+	if err != nil {
+		log.Printf("Failed to process account deletions: %v", err)
+	}
+	if count > 0 {
+		log.Printf("Deleted %d accounts after cooling-off period", count)
+	}
+
+	if err := s.CleanupExports(); err != nil {
+		log.Printf("Failed to clean up data exports: %v", err)
+	}
+}
+
+// IsAccountError 判断是否为导出/注销业务错误（可直接返回给用户）
+func IsAccountError(err error) bool {
+	return errors.Is(err, ErrExportInProgress) ||
+		errors.Is(err, ErrExportTooFrequent) ||
+		errors.Is(err, ErrDeletionPending) ||
+		errors.Is(err, ErrDeletionNotFound) ||
+		errors.Is(err, ErrDeletionOpenOrders) ||
+		errors.Is(err, ErrPasswordIncorrect) ||
+		errors.Is(err, ErrDeletionPhoneUnbound) ||
+		IsVerifyCodeError(err)
+}
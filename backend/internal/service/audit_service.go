@@ -0,0 +1,117 @@
+package service
+
+import (
+	"encoding/json"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"reflect"
+)
+
+// auditIgnoredFields 不参与差异比较的字段
+var auditIgnoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+// AuditEntry 一次管理操作的审计信息
+type AuditEntry struct {
+	ActorID    uint64
+	ActorName  string
+	Action     string
+	TargetType string
+	TargetID   uint64
+	Before     map[string]interface{} // 修改前快照，创建时为nil
+	After      map[string]interface{} // 修改后快照，删除时为nil
+	RequestID  string
+	IP         string
+}
+
+// AuditService 审计日志业务逻辑层
+type AuditService struct {
+	auditRepo *repository.AuditRepository
+}
+
+// NewAuditService 创建审计日志Service实例
+func NewAuditService() *AuditService {
+	return &AuditService{
+		auditRepo: repository.NewAuditRepository(),
+	}
+}
+
+// Snapshot 将对象按其JSON表示转换为快照，需在修改对象前调用
+func Snapshot(v interface{}) map[string]interface{} {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil
+	}
+	return snapshot
+}
+
+// diffSnapshots 比较前后快照，返回发生变化的字段
+func diffSnapshots(before, after map[string]interface{}) map[string]map[string]interface{} {
+	diff := make(map[string]map[string]interface{})
+	for field, value := range after {
+		if auditIgnoredFields[field] {
+			continue
+		}
+		old, ok := before[field]
+		if !ok || !reflect.DeepEqual(old, value) {
+			diff[field] = map[string]interface{}{"before": old, "after": value}
+		}
+	}
+	for field, old := range before {
+		if _, ok := after[field]; !ok && !auditIgnoredFields[field] {
+			diff[field] = map[string]interface{}{"before": old, "after": nil}
+		}
+	}
+	return diff
+}
+
+// Record 写入审计日志
+func (s *AuditService) Record(entry *AuditEntry) error {
+	before, err := json.Marshal(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := json.Marshal(entry.After)
+	if err != nil {
+		return err
+	}
+	diff, err := json.Marshal(diffSnapshots(entry.Before, entry.After))
+	if err != nil {
+		return err
+	}
+
+	return s.auditRepo.Create(&models.AuditLog{
+		ActorID:    entry.ActorID,
+		ActorName:  entry.ActorName,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     before,
+		After:      after,
+		Diff:       diff,
+		RequestID:  entry.RequestID,
+		IP:         entry.IP,
+	})
+}
+
+// GetLogs 分页查询审计日志
+func (s *AuditService) GetLogs(query *models.AuditLogQuery) ([]*models.AuditLog, int64, error) {
+	// 设置默认值
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 || query.PageSize > 100 {
+		query.PageSize = 20
+	}
+
+	return s.auditRepo.GetLogs(query)
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRefreshTokenInvalid 刷新令牌无效或已过期
+	ErrRefreshTokenInvalid = errors.New("刷新令牌无效或已过期")
+
+	// ErrRefreshTokenReused 刷新令牌被重复使用
+	ErrRefreshTokenReused = errors.New("刷新令牌已被使用，请重新登录")
+
+	// ErrSessionNotFound 会话不存在
+	ErrSessionNotFound = errors.New("登录设备不存在")
+)
+
+// ClientInfo 登录客户端信息
+type ClientInfo struct {
+	DeviceName string
+	UserAgent  string
+	IP         string
+}
+
+// SessionView 登录设备信息
+type SessionView struct {
+	*models.UserSession
+	Current bool `json:"current"` // 是否为当前设备
+}
+
+// TokenPair 访问令牌与刷新令牌
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // 访问令牌有效期（秒）
+	FamilyID     string `json:"-"`
+}
+
+// AuthService 认证业务逻辑层
+type AuthService struct {
+	tokenRepo   *repository.TokenRepository
+	sessionRepo *repository.SessionRepository
+	roleRepo    *repository.RoleRepository
+}
+
+// NewAuthService 创建认证Service实例
+func NewAuthService() *AuthService {
+	return &AuthService{
+		tokenRepo:   repository.NewTokenRepository(),
+		sessionRepo: repository.NewSessionRepository(),
+		roleRepo:    repository.NewRoleRepository(),
+	}
+}
+
+// UserRole 获取用户签发token时使用的角色
+// 分配了任意后台角色的用户为admin，具体能做什么由权限中间件按角色权限判断
+func (s *AuthService) UserRole(user *models.User) string {
+	roles, err := s.roleRepo.GetUserRoles(user.ID)
+	if err != nil {
+		log.Printf("Failed to load user roles: %v", err)
+		return "user"
+	}
+	if len(roles) > 0 {
+		return "admin"
+	}
+	return "user"
+}
+
+// hashRefreshToken 计算刷新令牌哈希
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// refreshTokenTTL 刷新令牌有效期
+func refreshTokenTTL() time.Duration {
+	return time.Duration(config.GlobalConfig.JWT.RefreshExpireDays) * 24 * time.Hour
+}
+
+// parseDeviceName 根据User-Agent粗略识别设备
+func parseDeviceName(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(ua, "iphone"):
+		return "iPhone"
+	case strings.Contains(ua, "ipad"):
+		return "iPad"
+	case strings.Contains(ua, "android"):
+		return "Android"
+	case strings.Contains(ua, "windows"):
+		return "Windows"
+	case strings.Contains(ua, "macintosh") || strings.Contains(ua, "mac os"):
+		return "Mac"
+	case strings.Contains(ua, "linux"):
+		return "Linux"
+	default:
+		return "未知设备"
+	}
+}
+
+// IssueTokenPair 登录成功后签发新的令牌对，并记录登录会话
+func (s *AuthService) IssueTokenPair(user *models.User, client *ClientInfo) (*TokenPair, error) {
+	familyID, err := utils.RandomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceName := client.DeviceName
+	if deviceName == "" {
+		deviceName = parseDeviceName(client.UserAgent)
+	}
+	userAgent := client.UserAgent
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	var pair *TokenPair
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		var refreshToken *models.RefreshToken
+		pair, refreshToken, err = s.issue(tx, user, familyID)
+		if err != nil {
+			return err
+		}
+
+		return s.sessionRepo.Create(tx, &models.UserSession{
+			UserID:     user.ID,
+			FamilyID:   familyID,
+			DeviceName: deviceName,
+			UserAgent:  userAgent,
+			IP:         client.IP,
+			LastSeenAt: time.Now(),
+			ExpiresAt:  refreshToken.ExpiresAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// issue 在指定令牌族中签发令牌对
+func (s *AuthService) issue(tx *gorm.DB, user *models.User, familyID string) (*TokenPair, *models.RefreshToken, error) {
+	accessToken, err := utils.GenerateAccessToken(user.ID, user.Username, s.UserRole(user), familyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawRefresh, err := utils.RandomHex(32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashRefreshToken(rawRefresh),
+		ExpiresAt: time.Now().Add(refreshTokenTTL()),
+	}
+	if err := s.tokenRepo.Create(tx, refreshToken); err != nil {
+		return nil, nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawRefresh,
+		ExpiresIn:    int64(utils.AccessTokenTTL().Seconds()),
+		FamilyID:     familyID,
+	}, refreshToken, nil
+}
+
+// Refresh 使用刷新令牌换取新的令牌对，旧刷新令牌随即失效
+// 已轮换过的刷新令牌再次出现视为泄露，整个令牌族被吊销
+func (s *AuthService) Refresh(ctx context.Context, rawRefresh string, ip string) (*TokenPair, error) {
+	var pair *TokenPair
+	var reusedFamily string
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		current, err := s.tokenRepo.GetByHashForUpdate(tx, hashRefreshToken(rawRefresh))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+
+		// 重复使用检测
+		if current.UsedAt != nil && current.RevokedAt == nil {
+			reusedFamily = current.FamilyID
+			if err := s.tokenRepo.RevokeFamily(tx, current.FamilyID); err != nil {
+				return err
+			}
+			return s.sessionRepo.Revoke(tx, current.FamilyID)
+		}
+
+		if !current.IsActive() {
+			return ErrRefreshTokenInvalid
+		}
+
+		user := &models.User{}
+		if err := tx.First(user, current.UserID).Error; err != nil {
+			return ErrRefreshTokenInvalid
+		}
+		if user.Status != 1 {
+			return ErrRefreshTokenInvalid
+		}
+
+		next, nextToken, err := s.issue(tx, user, current.FamilyID)
+		if err != nil {
+			return err
+		}
+		if err := s.tokenRepo.MarkUsed(tx, current.ID, nextToken.ID); err != nil {
+			return err
+		}
+		if err := s.sessionRepo.Touch(tx, current.FamilyID, ip, nextToken.ExpiresAt); err != nil {
+			return err
+		}
+
+		pair = next
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reusedFamily != "" {
+		if err := utils.RevokeSessionTokens(ctx, reusedFamily); err != nil {
+			log.Printf("Failed to revoke session %s: %v", reusedFamily, err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	return pair, nil
+}
+
+// TerminateSession 退出指定登录会话：吊销刷新令牌并使已签发的访问令牌失效
+func (s *AuthService) TerminateSession(ctx context.Context, familyID string) error {
+	if familyID == "" {
+		return nil
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.tokenRepo.RevokeFamily(tx, familyID); err != nil {
+			return err
+		}
+		return s.sessionRepo.Revoke(tx, familyID)
+	})
+	if err != nil {
+		return err
+	}
+
+	return utils.RevokeSessionTokens(ctx, familyID)
+}
+
+// ListSessions 获取用户的登录设备列表
+func (s *AuthService) ListSessions(userID uint64, currentFamilyID string) ([]*SessionView, error) {
+	sessions, err := s.sessionRepo.GetActiveByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]*SessionView, 0, len(sessions))
+	for _, session := range sessions {
+		views = append(views, &SessionView{
+			UserSession: session,
+			Current:     session.FamilyID == currentFamilyID,
+		})
+	}
+	return views, nil
+}
+
+// TerminateUserSession 用户退出指定设备
+func (s *AuthService) TerminateUserSession(ctx context.Context, userID uint64, sessionID uint64) error {
+	session, err := s.sessionRepo.GetByID(userID, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	return s.TerminateSession(ctx, session.FamilyID)
+}
+
+// TerminateOtherSessions 退出除当前设备外的所有设备，返回退出的设备数
+func (s *AuthService) TerminateOtherSessions(ctx context.Context, userID uint64, currentFamilyID string) (int, error) {
+	sessions, err := s.sessionRepo.GetActiveByUser(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, session := range sessions {
+		if session.FamilyID == currentFamilyID {
+			continue
+		}
+		if err := s.TerminateSession(ctx, session.FamilyID); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// RevokeAllTokens 吊销用户的全部访问令牌和刷新令牌（修改密码、禁用账号），任一吊销失败时返回错误
+func (s *AuthService) RevokeAllTokens(ctx context.Context, userID uint64) error {
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.tokenRepo.RevokeByUser(tx, userID); err != nil {
+			return err
+		}
+		return s.sessionRepo.RevokeByUser(tx, userID)
+	})
+	// 刷新令牌吊销失败时仍吊销访问令牌
+	if revokeErr := utils.RevokeUserTokens(ctx, userID); revokeErr != nil {
+		return revokeErr
+	}
+	return err
+}
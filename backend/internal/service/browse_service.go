@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// productViewsTTL 每日浏览量计数在Redis中的保留时间，落库任务会在此之前写入数据库
+const productViewsTTL = 3 * 24 * time.Hour
+
+// coViewScript 在写入最近浏览前记录本次浏览带来的共同浏览：商品在时间窗口内首次被浏览时计一次浏览，
+// 并与用户在窗口内浏览过的其他商品各计一次共同浏览；窗口内重复浏览同一商品不重复计数。
+// KEYS[1] 用户最近浏览，KEYS[2] 当天共同浏览次数，KEYS[3] 当天浏览次数；
+// ARGV[1] 商品ID，ARGV[2] 浏览时间，ARGV[3] 时间窗口（秒），ARGV[4] 最多比较的浏览记录数，ARGV[5] 计数保留时间（秒）
+var coViewScript = redis.NewScript(`
+local now = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local last = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if last and now - tonumber(last) <= window then
+	return 0
+end
+redis.call('HINCRBY', KEYS[3], ARGV[1], 1)
+local recent = redis.call('ZREVRANGEBYSCORE', KEYS[1], '+inf', now - window, 'LIMIT', 0, tonumber(ARGV[4]))
+for _, other in ipairs(recent) do
+	if other ~= ARGV[1] then
+		local field = ARGV[1] .. ':' .. other
+		if tonumber(other) < tonumber(ARGV[1]) then
+			field = other .. ':' .. ARGV[1]
+		end
+		redis.call('HINCRBY', KEYS[2], field, 1)
+	end
+end
+redis.call('EXPIRE', KEYS[2], ARGV[5])
+redis.call('EXPIRE', KEYS[3], ARGV[5])
+return #recent
+`)
+
+// BrowseHistoryItem 最近浏览记录
+type BrowseHistoryItem struct {
+	ProductID uint64          `json:"product_id"`
+	ViewedAt  time.Time       `json:"viewed_at"`
+	Product   *models.Product `json:"product"`
+}
+
+// BrowseService 浏览记录业务逻辑层。
+// 用户最近浏览保存在Redis有序集合中（按商品去重、限制条数），商品浏览量按天计数并定期写入数据库用于人气排行；
+// 登录用户浏览时同时按天累计共同浏览次数，供关联推荐计算使用
+type BrowseService struct {
+	viewRepo    *repository.ProductViewRepository
+	productRepo *repository.ProductRepository
+}
+
+// NewBrowseService 创建浏览记录Service实例
+func NewBrowseService() *BrowseService {
+	return &BrowseService{
+		viewRepo:    repository.NewProductViewRepository(),
+		productRepo: repository.NewProductRepository(),
+	}
+}
+
+// RecordView 记录一次商品浏览，userID为0（未登录）时只计入浏览量
+func (s *BrowseService) RecordView(ctx context.Context, userID uint64, productID uint64) {
+	now := time.Now()
+
+	key := fmt.Sprintf(utils.ProductViewsKey, now.Format("2006-01-02"))
+	count, err := utils.HIncrBy(ctx, key, strconv.FormatUint(productID, 10), 1)
+	if err != nil {
+		log.Printf("Failed to count view of product %d: %v", productID, err)
+	} else if count == 1 {
+		_ = utils.Expire(ctx, key, productViewsTTL)
+	}
+
+	if userID == 0 {
+		return
+	}
+	size, days := config.GlobalConfig.Browse.HistorySize, config.GlobalConfig.Browse.HistoryDays
+	if size <= 0 {
+		size = 100
+	}
+	if days <= 0 {
+		days = 90
+	}
+	historyKey := fmt.Sprintf(utils.UserBrowseKey, userID)
+	expiration := time.Duration(days) * 24 * time.Hour
+	s.recordCoView(ctx, historyKey, productID, now, expiration)
+	if err := utils.ZAddCapped(ctx, historyKey, productID, float64(now.Unix()), int64(size), expiration); err != nil {
+		log.Printf("Failed to record browse history of user %d: %v", userID, err)
+	}
+}
+
+// recordCoView 按天累计共同浏览次数，计数保留到超出最近浏览的保留天数
+func (s *BrowseService) recordCoView(ctx context.Context, historyKey string, productID uint64, now time.Time, retention time.Duration) {
+	cfg := config.GlobalConfig.Recommend
+	window, maxBasket := cfg.CoViewWindowHours, cfg.MaxBasketSize
+	if window <= 0 {
+		window = 24
+	}
+	if maxBasket <= 0 {
+		maxBasket = 50
+	}
+	date := now.Format("2006-01-02")
+	keys := []string{historyKey, fmt.Sprintf(utils.CoViewPairsKey, date), fmt.Sprintf(utils.CoViewItemsKey, date)}
+	ttl := retention + 24*time.Hour
+	_, err := utils.RunScript(ctx, coViewScript, keys, productID, now.Unix(), window*3600, maxBasket, int64(ttl.Seconds()))
+	if err != nil {
+		log.Printf("Failed to record co-views of user history %s: %v", historyKey, err)
+	}
+}
+
+// RecentProductIDs 获取用户最近浏览的商品ID，按浏览时间倒序
+func (s *BrowseService) RecentProductIDs(ctx context.Context, userID uint64, limit int) ([]uint64, error) {
+	members, err := utils.ZRevRangeWithScores(ctx, fmt.Sprintf(utils.UserBrowseKey, userID), 0, int64(limit)-1)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, 0, len(members))
+	for _, member := range members {
+		if id, err := strconv.ParseUint(fmt.Sprint(member.Member), 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// GetHistory 分页获取用户最近浏览的商品，已删除的商品不返回并从记录中移除
+func (s *BrowseService) GetHistory(ctx context.Context, userID uint64, page, pageSize int) ([]*BrowseHistoryItem, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	key := fmt.Sprintf(utils.UserBrowseKey, userID)
+	total, err := utils.ZCard(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	start := int64((page - 1) * pageSize)
+	members, err := utils.ZRevRangeWithScores(ctx, key, start, start+int64(pageSize)-1)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]*BrowseHistoryItem, 0, len(members))
+	productIDs := make([]uint64, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseUint(fmt.Sprint(member.Member), 10, 64)
+		if err != nil {
+			continue
+		}
+		productIDs = append(productIDs, id)
+		items = append(items, &BrowseHistoryItem{ProductID: id, ViewedAt: time.Unix(int64(member.Score), 0)})
+	}
+	if len(items) == 0 {
+		return items, total, nil
+	}
+
+	products, err := s.productRepo.GetByIDs(productIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	productMap := make(map[uint64]*models.Product, len(products))
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	result := make([]*BrowseHistoryItem, 0, len(items))
+	for _, item := range items {
+		if item.Product = productMap[item.ProductID]; item.Product == nil {
+			_ = utils.ZRem(ctx, key, item.ProductID)
+			total--
+			continue
+		}
+		result = append(result, item)
+	}
+	return result, total, nil
+}
+
+// RemoveHistory 删除一条浏览记录
+func (s *BrowseService) RemoveHistory(ctx context.Context, userID uint64, productID uint64) error {
+	return utils.ZRem(ctx, fmt.Sprintf(utils.UserBrowseKey, userID), productID)
+}
+
+// ClearHistory 清空浏览记录
+func (s *BrowseService) ClearHistory(ctx context.Context, userID uint64) error {
+	return utils.Del(ctx, fmt.Sprintf(utils.UserBrowseKey, userID))
+}
+
+// FlushViews 将昨天和今天的浏览量计数写入数据库。
+// 写入的是当日累计值，重复执行不会重复计数
+func (s *BrowseService) FlushViews(ctx context.Context) (int, error) {
+	now := time.Now()
+	flushed := 0
+	for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+		date := day.Format("2006-01-02")
+		counts, err := utils.HGetAll(ctx, fmt.Sprintf(utils.ProductViewsKey, date))
+		if err != nil {
+			return flushed, err
+		}
+
+		views := make([]*models.ProductViewDaily, 0, len(counts))
+		for field, value := range counts {
+			productID, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				continue
+			}
+			count, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			views = append(views, &models.ProductViewDaily{ProductID: productID, Date: date, Views: count})
+		}
+		if err := s.viewRepo.SaveDaily(views); err != nil {
+			return flushed, err
+		}
+		flushed += len(views)
+	}
+	return flushed, nil
+}
+
+// GetPopularProducts 获取最近一段时间浏览量最高的在售商品
+func (s *BrowseService) GetPopularProducts(limit int) ([]*models.Product, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	days := config.GlobalConfig.Browse.PopularDays
+	if days <= 0 {
+		days = 7
+	}
+	since := time.Now().AddDate(0, 0, -days+1).Format("2006-01-02")
+
+	counts, err := s.viewRepo.GetTopViewed(since, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(counts) == 0 {
+		return []*models.Product{}, nil
+	}
+
+	productIDs := make([]uint64, 0, len(counts))
+	for _, count := range counts {
+		productIDs = append(productIDs, count.ProductID)
+	}
+	products, err := s.productRepo.GetByIDs(productIDs)
+	if err != nil {
+		return nil, err
+	}
+	productMap := make(map[uint64]*models.Product, len(products))
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	result := make([]*models.Product, 0, len(products))
+	for _, id := range productIDs {
+		if product := productMap[id]; product != nil {
+			result = append(result, product)
+		}
+	}
+	return result, nil
+}
+
+// RunWorker 定期将浏览量计数写入数据库
+func (s *BrowseService) RunWorker(ctx context.Context) {
+	interval := time.Duration(config.GlobalConfig.Browse.FlushIntervalMinutes) * time.Minute
+	runPeriodic(ctx, utils.BrowseWorkerLockKey, interval, func(ctx context.Context) {
+		if _, err := s.FlushViews(ctx); err != nil {
+			log.Printf("Failed to flush product views: %v", err)
+		}
+	})
+}
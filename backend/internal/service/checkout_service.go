@@ -0,0 +1,369 @@
+package service
+
+import (
+	"errors"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCheckoutEmpty 未选择商品
+	ErrCheckoutEmpty = errors.New("请选择要结算的商品")
+
+	// ErrSKUNotFound 商品规格不存在
+	ErrSKUNotFound = errors.New("商品规格不存在")
+
+	// ErrProductOffShelf 商品已下架
+	ErrProductOffShelf = errors.New("商品已下架")
+
+	// ErrStockInsufficient 库存不足
+	ErrStockInsufficient = errors.New("商品库存不足")
+
+	// ErrCouponUnusable 优惠券不可用
+	ErrCouponUnusable = errors.New("优惠券不满足使用条件或不能与当前促销同享")
+)
+
+// CheckoutItem 结算商品
+type CheckoutItem struct {
+	SKUID    uint64
+	Quantity int
+}
+
+// QuoteItem 结算商品明细
+type QuoteItem struct {
+	SKUID       uint64  `json:"sku_id"`
+	ProductID   uint64  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	SKUName     string  `json:"sku_name"`
+	Image       string  `json:"image"`
+	Quantity    int     `json:"quantity"`
+	Price       float64 `json:"price"`        // 原价
+	UnitPrice   float64 `json:"unit_price"`   // 成交单价（会员价等）
+	TotalAmount float64 `json:"total_amount"` // 成交小计
+
+	DiscountAmount float64                `json:"discount_amount"` // 分摊的促销和优惠券优惠
+	PayAmount      float64                `json:"pay_amount"`      // 优惠后小计
+	Discounts      []*models.ItemDiscount `json:"discounts"`       // 优惠分摊明细
+}
+
+// OrderItem 转换为订单商品，规格信息由下单方补充
+func (item *QuoteItem) OrderItem() *models.OrderItem {
+	orderItem := &models.OrderItem{
+		ProductID:      item.ProductID,
+		SKUID:          item.SKUID,
+		ProductName:    item.ProductName,
+		ProductImage:   item.Image,
+		Price:          item.UnitPrice,
+		Quantity:       item.Quantity,
+		TotalAmount:    item.TotalAmount,
+		DiscountAmount: item.DiscountAmount,
+		PayAmount:      item.PayAmount,
+	}
+	orderItem.SetDiscountDetail(item.Discounts)
+	return orderItem
+}
+
+// AppliedPromotion 结算使用的促销
+type AppliedPromotion struct {
+	ID       uint64  `json:"id"`
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Discount float64 `json:"discount"`
+}
+
+// AppliedCoupon 结算使用的优惠券
+type AppliedCoupon struct {
+	UserCouponID uint64  `json:"user_coupon_id"`
+	CouponID     uint64  `json:"coupon_id"`
+	Name         string  `json:"name"`
+	Discount     float64 `json:"discount"`
+}
+
+// CheckoutQuote 结算金额明细
+type CheckoutQuote struct {
+	Items          []*QuoteItem `json:"items"`
+	TotalAmount    float64      `json:"total_amount"`    // 商品原价合计
+	MemberLevel    string       `json:"member_level"`    // 会员等级名称
+	MemberDiscount float64      `json:"member_discount"` // 会员价优惠
+
+	Promotions        []*AppliedPromotion `json:"promotions"`         // 使用的促销
+	PromotionDiscount float64             `json:"promotion_discount"` // 促销优惠合计
+	Coupon            *AppliedCoupon      `json:"coupon"`             // 使用的优惠券
+	CouponDiscount    float64             `json:"coupon_discount"`    // 优惠券优惠
+
+	Freight         float64 `json:"freight"`
+	FreeShipping    bool    `json:"free_shipping"`
+	PointsAvailable int64   `json:"points_available"` // 可用积分
+	PointsMax       int64   `json:"points_max"`       // 本单最多可用积分
+	PointsUsed      int64   `json:"points_used"`      // 使用积分
+	PointsAmount    float64 `json:"points_amount"`    // 积分抵扣金额
+	PayAmount       float64 `json:"pay_amount"`       // 应付金额
+}
+
+// CheckoutService 结算计价业务逻辑层，下单时使用同一计价结果
+type CheckoutService struct {
+	productRepo      *repository.ProductRepository
+	categoryRepo     *repository.CategoryRepository
+	couponRepo       *repository.CouponRepository
+	memberService    *MemberService
+	pointsService    *PointsService
+	promotionService *PromotionService
+}
+
+// NewCheckoutService 创建结算Service实例
+func NewCheckoutService() *CheckoutService {
+	return &CheckoutService{
+		productRepo:      repository.NewProductRepository(),
+		categoryRepo:     repository.NewCategoryRepository(),
+		couponRepo:       repository.NewCouponRepository(),
+		memberService:    NewMemberService(),
+		pointsService:    NewPointsService(),
+		promotionService: NewPromotionService(),
+	}
+}
+
+// Quote 计算结算金额：自动应用会员价、促销和会员包邮门槛，usePoints大于0时使用积分抵扣商品金额；
+// userCouponID为空时自动选择最优优惠券，为0时不使用优惠券，否则使用指定优惠券
+func (s *CheckoutService) Quote(userID uint64, items []*CheckoutItem, usePoints int64, userCouponID *uint64) (*CheckoutQuote, error) {
+	// 合并相同SKU
+	quantities := make(map[uint64]int, len(items))
+	skuIDs := make([]uint64, 0, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			continue
+		}
+		if _, ok := quantities[item.SKUID]; !ok {
+			skuIDs = append(skuIDs, item.SKUID)
+		}
+		quantities[item.SKUID] += item.Quantity
+	}
+	if len(skuIDs) == 0 {
+		return nil, ErrCheckoutEmpty
+	}
+
+	skus, err := s.productRepo.GetSKUsByIDs(skuIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(skus) != len(skuIDs) {
+		return nil, ErrSKUNotFound
+	}
+	skuMap := make(map[uint64]*models.ProductSKU, len(skus))
+	for _, sku := range skus {
+		if sku.Product.ID == 0 || sku.Product.Status != 1 {
+			return nil, ErrProductOffShelf
+		}
+		if sku.Stock < quantities[sku.ID] {
+			return nil, ErrStockInsufficient
+		}
+		skuMap[sku.ID] = sku
+	}
+
+	benefits, err := s.memberService.GetBenefits(userID)
+	if err != nil {
+		return nil, err
+	}
+	memberPrices, err := s.memberService.MemberPrices(benefits, skus)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &CheckoutQuote{
+		Items:       make([]*QuoteItem, 0, len(skuIDs)),
+		MemberLevel: benefits.LevelName,
+	}
+	for _, skuID := range skuIDs {
+		sku := skuMap[skuID]
+		quantity := quantities[skuID]
+
+		unitPrice := sku.Price
+		if price, ok := memberPrices[skuID]; ok {
+			unitPrice = price
+		}
+		image := sku.Image
+		if image == "" {
+			if images := sku.Product.GetImages(); len(images) > 0 {
+				image = images[0]
+			}
+		}
+
+		item := &QuoteItem{
+			SKUID:       sku.ID,
+			ProductID:   sku.ProductID,
+			ProductName: sku.Product.Name,
+			SKUName:     sku.Name,
+			Image:       image,
+			Quantity:    quantity,
+			Price:       sku.Price,
+			UnitPrice:   unitPrice,
+			TotalAmount: roundMoney(unitPrice * float64(quantity)),
+		}
+		quote.Items = append(quote.Items, item)
+		quote.TotalAmount += sku.Price * float64(quantity)
+		quote.MemberDiscount += (sku.Price - unitPrice) * float64(quantity)
+	}
+	quote.TotalAmount = roundMoney(quote.TotalAmount)
+	quote.MemberDiscount = roundMoney(quote.MemberDiscount)
+
+	if err := s.applyPromotions(userID, quote, skuMap, userCouponID); err != nil {
+		return nil, err
+	}
+
+	goodsAmount := roundMoney(quote.TotalAmount - quote.MemberDiscount - quote.PromotionDiscount - quote.CouponDiscount)
+	quote.Freight = s.freight(goodsAmount, benefits)
+	quote.FreeShipping = quote.Freight == 0
+
+	points, err := s.pointsService.Quote(userID, goodsAmount, usePoints)
+	if err != nil {
+		return nil, err
+	}
+	quote.PointsAvailable = points.Available
+	quote.PointsMax = points.MaxUsable
+	quote.PointsUsed = points.Used
+	quote.PointsAmount = points.Deduction
+
+	quote.PayAmount = roundMoney(goodsAmount - quote.PointsAmount + quote.Freight)
+	return quote, nil
+}
+
+// applyPromotions 由促销引擎计算促销和优惠券的最优组合，并将各项优惠按比例分摊到结算商品
+func (s *CheckoutService) applyPromotions(userID uint64, quote *CheckoutQuote, skuMap map[uint64]*models.ProductSKU, userCouponID *uint64) error {
+	promotions, err := s.promotionService.GetActive()
+	if err != nil {
+		return err
+	}
+	parents, err := s.categoryParents(promotions)
+	if err != nil {
+		return err
+	}
+
+	lines := make([]*pricingLine, 0, len(quote.Items))
+	for _, item := range quote.Items {
+		lines = append(lines, &pricingLine{
+			ProductID:   item.ProductID,
+			CategoryIDs: categoryPath(skuMap[item.SKUID].Product.CategoryID, parents),
+			UnitPrice:   item.UnitPrice,
+			Quantity:    item.Quantity,
+		})
+	}
+
+	var coupons []*models.UserCoupon
+	requireCoupon := false
+	switch {
+	case userCouponID == nil:
+		if coupons, err = s.couponRepo.GetUserUnused(userID); err != nil {
+			return err
+		}
+	case *userCouponID > 0:
+		userCoupon, err := s.couponRepo.GetUserCoupon(userID, *userCouponID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCouponNotFound
+			}
+			return err
+		}
+		if userCoupon.Status != 0 {
+			return ErrCouponUnusable
+		}
+		coupons = []*models.UserCoupon{userCoupon}
+		requireCoupon = true
+	}
+
+	result := newPromotionEngine(lines, promotions).Best(coupons, requireCoupon)
+	if result == nil {
+		return ErrCouponUnusable
+	}
+
+	quote.Promotions = make([]*AppliedPromotion, 0)
+	for _, item := range quote.Items {
+		item.Discounts = make([]*models.ItemDiscount, 0)
+	}
+	for _, discount := range result.Discounts {
+		source := &models.ItemDiscount{}
+		if discount.Promotion != nil {
+			source.Source, source.SourceID, source.Name = "promotion", discount.Promotion.ID, discount.Promotion.Name
+			quote.Promotions = append(quote.Promotions, &AppliedPromotion{
+				ID:       discount.Promotion.ID,
+				Name:     discount.Promotion.Name,
+				Type:     discount.Promotion.Type,
+				Discount: discount.Amount,
+			})
+		} else {
+			source.Source, source.SourceID, source.Name = "coupon", discount.UserCoupon.ID, discount.UserCoupon.Coupon.Name
+			quote.Coupon = &AppliedCoupon{
+				UserCouponID: discount.UserCoupon.ID,
+				CouponID:     discount.UserCoupon.CouponID,
+				Name:         discount.UserCoupon.Coupon.Name,
+				Discount:     discount.Amount,
+			}
+		}
+		for i, share := range discount.Shares {
+			if share > 0 {
+				itemDiscount := *source
+				itemDiscount.Amount = share
+				quote.Items[i].Discounts = append(quote.Items[i].Discounts, &itemDiscount)
+			}
+		}
+	}
+	for i, item := range quote.Items {
+		item.PayAmount = result.Amounts[i]
+		item.DiscountAmount = roundMoney(item.TotalAmount - item.PayAmount)
+	}
+	quote.PromotionDiscount = result.PromotionAmount
+	quote.CouponDiscount = result.CouponAmount
+	return nil
+}
+
+// categoryParents 有按分类适用的促销时加载分类的上级关系
+func (s *CheckoutService) categoryParents(promotions []*models.Promotion) (map[uint64]uint64, error) {
+	parents := make(map[uint64]uint64)
+	for _, promotion := range promotions {
+		if promotion.ScopeType != models.PromotionScopeCategory {
+			continue
+		}
+		categories, err := s.categoryRepo.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, category := range categories {
+			parents[category.ID] = category.ParentID
+		}
+		break
+	}
+	return parents, nil
+}
+
+// categoryPath 获取分类及其所有上级分类
+func categoryPath(categoryID uint64, parents map[uint64]uint64) []uint64 {
+	path := []uint64{categoryID}
+	for id := parents[categoryID]; id != 0 && len(path) < 10; id = parents[id] {
+		path = append(path, id)
+	}
+	return path
+}
+
+// freight 计算运费：满足全站或会员等级任一包邮门槛即包邮（会员门槛为0表示无条件包邮）
+func (s *CheckoutService) freight(goodsAmount float64, benefits *MemberBenefits) float64 {
+	cfg := config.GlobalConfig.Checkout
+	if threshold := benefits.FreeShippingThreshold; threshold != nil && goodsAmount >= *threshold {
+		return 0
+	}
+	if cfg.FreeShippingThreshold > 0 && goodsAmount >= cfg.FreeShippingThreshold {
+		return 0
+	}
+	return cfg.Freight
+}
+
+// IsCheckoutError 判断是否为结算业务错误（可直接返回给用户）
+func IsCheckoutError(err error) bool {
+	return errors.Is(err, ErrCheckoutEmpty) ||
+		errors.Is(err, ErrSKUNotFound) ||
+		errors.Is(err, ErrProductOffShelf) ||
+		errors.Is(err, ErrStockInsufficient) ||
+		errors.Is(err, ErrCouponNotFound) ||
+		errors.Is(err, ErrCouponUnusable) ||
+		IsPointsError(err)
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrContentSlotNotFound 内容位不存在
+	ErrContentSlotNotFound = errors.New("内容位不存在")
+
+	// ErrContentPositionInvalid 内容位位置不支持
+	ErrContentPositionInvalid = errors.New("不支持的内容位位置")
+
+	// ErrContentImageRequired 轮播图和促销位需要图片
+	ErrContentImageRequired = errors.New("轮播图和促销位必须上传图片")
+
+	// ErrContentLinkInvalid 跳转目标无效
+	ErrContentLinkInvalid = errors.New("跳转目标无效")
+
+	// ErrContentScheduleInvalid 排期无效
+	ErrContentScheduleInvalid = errors.New("结束时间必须晚于开始时间")
+)
+
+// contentActiveLimit 每个位置最多展示的内容位数量
+const contentActiveLimit = 50
+
+// ContentSlotInput 创建/更新内容位参数
+type ContentSlotInput struct {
+	Position   string
+	Title      string
+	Image      string
+	Content    string
+	LinkType   string
+	LinkTarget string
+	StartAt    *time.Time
+	EndAt      *time.Time
+	Sort       int
+	Status     int
+}
+
+// ContentService 首页内容位业务逻辑层。
+// 当前生效的内容位按位置缓存，缓存在下一个排期开始或结束时过期；内容位变更后删除对应位置的缓存
+type ContentService struct {
+	slotRepo     *repository.ContentSlotRepository
+	productRepo  *repository.ProductRepository
+	categoryRepo *repository.CategoryRepository
+}
+
+// NewContentService 创建内容位Service实例
+func NewContentService() *ContentService {
+	return &ContentService{
+		slotRepo:     repository.NewContentSlotRepository(),
+		productRepo:  repository.NewProductRepository(),
+		categoryRepo: repository.NewCategoryRepository(),
+	}
+}
+
+// validateInput 校验位置、图片、排期和跳转目标
+func (s *ContentService) validateInput(input *ContentSlotInput) error {
+	switch input.Position {
+	case models.ContentPositionBanner, models.ContentPositionPromo:
+		if input.Image == "" {
+			return ErrContentImageRequired
+		}
+	case models.ContentPositionAnnouncement:
+	default:
+		return ErrContentPositionInvalid
+	}
+
+	if input.StartAt != nil && input.EndAt != nil && !input.EndAt.After(*input.StartAt) {
+		return ErrContentScheduleInvalid
+	}
+
+	input.LinkTarget = strings.TrimSpace(input.LinkTarget)
+	switch input.LinkType {
+	case "", models.ContentLinkNone:
+		input.LinkType = models.ContentLinkNone
+		input.LinkTarget = ""
+	case models.ContentLinkProduct:
+		id, err := strconv.ParseUint(input.LinkTarget, 10, 64)
+		if err != nil {
+			return ErrContentLinkInvalid
+		}
+		if _, err := s.productRepo.GetByID(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrContentLinkInvalid
+			}
+			return err
+		}
+	case models.ContentLinkCategory:
+		id, err := strconv.ParseUint(input.LinkTarget, 10, 64)
+		if err != nil {
+			return ErrContentLinkInvalid
+		}
+		if _, err := s.categoryRepo.GetByID(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrContentLinkInvalid
+			}
+			return err
+		}
+	case models.ContentLinkURL:
+		// 站内路径或http(s)链接，防止写入javascript:等脚本链接
+		target := strings.ToLower(input.LinkTarget)
+		if !strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+			return ErrContentLinkInvalid
+		}
+	default:
+		return ErrContentLinkInvalid
+	}
+	return nil
+}
+
+// GetSlot 获取内容位
+func (s *ContentService) GetSlot(id uint64) (*models.ContentSlot, error) {
+	slot, err := s.slotRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrContentSlotNotFound
+		}
+		return nil, err
+	}
+	return slot, nil
+}
+
+// GetSlots 分页获取内容位（管理员），包括停用和不在排期内的
+func (s *ContentService) GetSlots(position string, page, pageSize int) ([]*models.ContentSlot, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.slotRepo.GetSlots(position, page, pageSize)
+}
+
+// CreateSlot 创建内容位
+func (s *ContentService) CreateSlot(ctx context.Context, input *ContentSlotInput) (*models.ContentSlot, error) {
+	if err := s.validateInput(input); err != nil {
+		return nil, err
+	}
+
+	slot := &models.ContentSlot{
+		Position:   input.Position,
+		Title:      input.Title,
+		Image:      input.Image,
+		Content:    input.Content,
+		LinkType:   input.LinkType,
+		LinkTarget: input.LinkTarget,
+		StartAt:    input.StartAt,
+		EndAt:      input.EndAt,
+		Sort:       input.Sort,
+		Status:     input.Status,
+	}
+	if err := s.slotRepo.Create(slot); err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, slot.Position)
+	return slot, nil
+}
+
+// UpdateSlot 更新内容位
+func (s *ContentService) UpdateSlot(ctx context.Context, id uint64, input *ContentSlotInput) (*models.ContentSlot, error) {
+	before, err := s.GetSlot(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateInput(input); err != nil {
+		return nil, err
+	}
+
+	err = s.slotRepo.Update(id, map[string]interface{}{
+		"position":    input.Position,
+		"title":       input.Title,
+		"image":       input.Image,
+		"content":     input.Content,
+		"link_type":   input.LinkType,
+		"link_target": input.LinkTarget,
+		"start_at":    input.StartAt,
+		"end_at":      input.EndAt,
+		"sort":        input.Sort,
+		"status":      input.Status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, before.Position, input.Position)
+	return s.GetSlot(id)
+}
+
+// DeleteSlot 删除内容位
+func (s *ContentService) DeleteSlot(ctx context.Context, id uint64) error {
+	slot, err := s.GetSlot(id)
+	if err != nil {
+		return err
+	}
+	if err := s.slotRepo.Delete(id); err != nil {
+		return err
+	}
+	s.invalidate(ctx, slot.Position)
+	return nil
+}
+
+// invalidate 删除位置的缓存，失败时等待缓存过期
+func (s *ContentService) invalidate(ctx context.Context, positions ...string) {
+	keys := make([]string, 0, len(positions))
+	for _, position := range positions {
+		keys = append(keys, fmt.Sprintf(utils.ContentSlotsKey, position))
+	}
+	if err := utils.Del(ctx, keys...); err != nil {
+		log.Printf("Failed to invalidate content slots cache: %v", err)
+	}
+}
+
+// GetActive 获取某位置当前生效的内容位，优先读取缓存
+func (s *ContentService) GetActive(ctx context.Context, position string) ([]*models.ContentSlot, error) {
+	switch position {
+	case models.ContentPositionBanner, models.ContentPositionPromo, models.ContentPositionAnnouncement:
+	default:
+		return nil, ErrContentPositionInvalid
+	}
+
+	key := fmt.Sprintf(utils.ContentSlotsKey, position)
+	if cached, err := utils.Get(ctx, key); err == nil {
+		var slots []*models.ContentSlot
+		if json.Unmarshal([]byte(cached), &slots) == nil {
+			return slots, nil
+		}
+	}
+
+	now := time.Now()
+	slots, err := s.slotRepo.GetActive(position, now, contentActiveLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	// 缓存在下一个内容位开始或结束时过期，保证排期准时生效
+	ttl := time.Duration(config.GlobalConfig.Home.CacheSeconds) * time.Second
+	next, err := s.slotRepo.GetNextChange(position, now)
+	if err != nil {
+		return nil, err
+	}
+	if next != nil && next.Sub(now) < ttl {
+		ttl = next.Sub(now)
+	}
+	if ttl > 0 {
+		if data, err := json.Marshal(slots); err == nil {
+			if err := utils.Set(ctx, key, string(data), ttl); err != nil {
+				log.Printf("Failed to cache content slots: %v", err)
+			}
+		}
+	}
+	return slots, nil
+}
+
+// IsContentError 判断是否为内容位业务错误（可直接返回给用户）
+func IsContentError(err error) bool {
+	return errors.Is(err, ErrContentSlotNotFound) ||
+		errors.Is(err, ErrContentPositionInvalid) ||
+		errors.Is(err, ErrContentImageRequired) ||
+		errors.Is(err, ErrContentLinkInvalid) ||
+		errors.Is(err, ErrContentScheduleInvalid)
+}
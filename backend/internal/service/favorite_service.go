@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrFavoriteExists 已收藏
+	ErrFavoriteExists = errors.New("已收藏该商品")
+
+	// ErrFavoriteNotFound 收藏不存在
+	ErrFavoriteNotFound = errors.New("收藏不存在")
+
+	// ErrFavoriteLimit 收藏数量达到上限
+	ErrFavoriteLimit = errors.New("收藏数量已达上限")
+
+	// ErrProductNotFound 商品不存在
+	ErrProductNotFound = errors.New("商品不存在")
+)
+
+// FavoriteView 收藏及商品当前价格、库存
+type FavoriteView struct {
+	*models.Favorite
+	SKU          *models.ProductSKU `json:"sku,omitempty"`
+	CurrentPrice float64            `json:"current_price"`
+	PriceDrop    float64            `json:"price_drop"` // 相比收藏时降低的金额，未降价为0
+	InStock      bool               `json:"in_stock"`
+	OnSale       bool               `json:"on_sale"` // 商品是否在售
+}
+
+// favoriteTarget 收藏对象的当前状态
+type favoriteTarget struct {
+	name   string
+	price  float64
+	stock  int
+	onSale bool
+}
+
+// FavoriteService 商品收藏业务逻辑层，定期检查收藏商品的降价和到货并发送站内通知
+type FavoriteService struct {
+	favoriteRepo        *repository.FavoriteRepository
+	productRepo         *repository.ProductRepository
+	notificationService *NotificationService
+}
+
+// NewFavoriteService 创建收藏Service实例
+func NewFavoriteService() *FavoriteService {
+	return &FavoriteService{
+		favoriteRepo:        repository.NewFavoriteRepository(),
+		productRepo:         repository.NewProductRepository(),
+		notificationService: NewNotificationService(),
+	}
+}
+
+// AddFavorite 收藏商品或SKU，skuID为0表示收藏整个商品，记录收藏时的价格
+func (s *FavoriteService) AddFavorite(userID uint64, productID uint64, skuID uint64) (*models.Favorite, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	if product.Status != 1 {
+		return nil, ErrProductOffShelf
+	}
+
+	favorite := &models.Favorite{
+		UserID:    userID,
+		ProductID: productID,
+		Price:     product.Price,
+		InStock:   product.Stock > 0,
+	}
+	if skuID > 0 {
+		sku, err := s.productRepo.GetSKU(productID, skuID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrSKUNotFound
+			}
+			return nil, err
+		}
+		favorite.SKUID = sku.ID
+		favorite.Price = sku.Price
+		favorite.InStock = sku.Stock > 0
+	}
+
+	count, err := s.favoriteRepo.CountByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if maxItems := config.GlobalConfig.Favorite.MaxItems; maxItems > 0 && count >= int64(maxItems) {
+		return nil, ErrFavoriteLimit
+	}
+
+	created, err := s.favoriteRepo.Create(favorite)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrFavoriteExists
+	}
+	return favorite, nil
+}
+
+// RemoveFavorite 取消收藏
+func (s *FavoriteService) RemoveFavorite(userID uint64, id uint64) error {
+	deleted, err := s.favoriteRepo.Delete(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrFavoriteNotFound
+	}
+	return nil
+}
+
+// GetProductFavorites 获取用户对某商品的收藏，用于商品详情页展示收藏状态
+func (s *FavoriteService) GetProductFavorites(userID uint64, productID uint64) ([]*models.Favorite, error) {
+	return s.favoriteRepo.GetByUserProduct(userID, productID)
+}
+
+// GetFavorites 分页获取收藏列表，附带当前价格、降价金额和库存状态
+func (s *FavoriteService) GetFavorites(userID uint64, page, pageSize int) ([]*FavoriteView, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	favorites, total, err := s.favoriteRepo.GetFavorites(userID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skuIDs := make([]uint64, 0, len(favorites))
+	for _, favorite := range favorites {
+		if favorite.SKUID > 0 {
+			skuIDs = append(skuIDs, favorite.SKUID)
+		}
+	}
+	skuMap := make(map[uint64]*models.ProductSKU, len(skuIDs))
+	if len(skuIDs) > 0 {
+		skus, err := s.productRepo.GetSKUsByIDs(skuIDs)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, sku := range skus {
+			sku.Product = models.Product{}
+			skuMap[sku.ID] = sku
+		}
+	}
+
+	views := make([]*FavoriteView, 0, len(favorites))
+	for _, favorite := range favorites {
+		view := &FavoriteView{Favorite: favorite, SKU: skuMap[favorite.SKUID]}
+		if favorite.Product.ID != 0 {
+			if target := favoriteState(&favorite.Product, view.SKU, favorite.SKUID); target != nil {
+				view.CurrentPrice = target.price
+				view.InStock = target.stock > 0
+				view.OnSale = target.onSale
+				if target.price < favorite.Price {
+					view.PriceDrop = roundMoney(favorite.Price - target.price)
+				}
+			}
+		}
+		views = append(views, view)
+	}
+	return views, total, nil
+}
+
+// favoriteState 收藏对象的当前状态，商品或SKU已删除时返回nil
+func favoriteState(product *models.Product, sku *models.ProductSKU, skuID uint64) *favoriteTarget {
+	if product == nil || product.ID == 0 {
+		return nil
+	}
+	if skuID == 0 {
+		return &favoriteTarget{
+			name:   product.Name,
+			price:  product.Price,
+			stock:  product.Stock,
+			onSale: product.Status == 1,
+		}
+	}
+	if sku == nil || sku.ID == 0 {
+		return nil
+	}
+	return &favoriteTarget{
+		name:   fmt.Sprintf("%s %s", product.Name, sku.Name),
+		price:  sku.Price,
+		stock:  sku.Stock,
+		onSale: product.Status == 1,
+	}
+}
+
+// CheckChanges 扫描所有收藏，价格低于收藏价和上次提醒价时发送降价通知，缺货后重新有货时发送到货通知。
+// 返回发送的通知数
+func (s *FavoriteService) CheckChanges(ctx context.Context) (int, error) {
+	const batchSize = 500
+	sent := 0
+	var lastID uint64
+	for ctx.Err() == nil {
+		favorites, err := s.favoriteRepo.GetBatchAfter(lastID, batchSize)
+		if err != nil {
+			return sent, err
+		}
+		if len(favorites) == 0 {
+			break
+		}
+		lastID = favorites[len(favorites)-1].ID
+
+		count, err := s.checkBatch(ctx, favorites)
+		sent += count
+		if err != nil {
+			return sent, err
+		}
+		if len(favorites) < batchSize {
+			break
+		}
+	}
+	return sent, nil
+}
+
+// checkBatch 检查一批收藏
+func (s *FavoriteService) checkBatch(ctx context.Context, favorites []*models.Favorite) (int, error) {
+	productIDs := make([]uint64, 0, len(favorites))
+	skuIDs := make([]uint64, 0, len(favorites))
+	for _, favorite := range favorites {
+		productIDs = append(productIDs, favorite.ProductID)
+		if favorite.SKUID > 0 {
+			skuIDs = append(skuIDs, favorite.SKUID)
+		}
+	}
+
+	products, err := s.productRepo.GetByIDs(uniqueIDs(productIDs))
+	if err != nil {
+		return 0, err
+	}
+	productMap := make(map[uint64]*models.Product, len(products))
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+	skuMap := make(map[uint64]*models.ProductSKU, len(skuIDs))
+	if len(skuIDs) > 0 {
+		skus, err := s.productRepo.GetSKUsByIDs(uniqueIDs(skuIDs))
+		if err != nil {
+			return 0, err
+		}
+		for _, sku := range skus {
+			skuMap[sku.ID] = sku
+		}
+	}
+
+	var notifications []*models.Notification
+	pending := make(map[uint64]map[string]interface{})
+	for _, favorite := range favorites {
+		target := favoriteState(productMap[favorite.ProductID], skuMap[favorite.SKUID], favorite.SKUID)
+		// 已删除或下架的商品不提醒，恢复上架后再比较
+		if target == nil || !target.onSale {
+			continue
+		}
+
+		updates := make(map[string]interface{})
+		link := fmt.Sprintf("/products/%d", favorite.ProductID)
+
+		baseline := favorite.Price
+		if favorite.NotifiedPrice != nil && *favorite.NotifiedPrice < baseline {
+			baseline = *favorite.NotifiedPrice
+		}
+		if target.price < baseline {
+			updates["notified_price"] = target.price
+			notifications = append(notifications, &models.Notification{
+				UserID:  favorite.UserID,
+				Type:    models.NotificationPriceDrop,
+				Title:   "收藏的商品降价了",
+				Content: fmt.Sprintf("您收藏的「%s」降至¥%.2f，比收藏时便宜¥%.2f", target.name, target.price, roundMoney(favorite.Price-target.price)),
+				Link:    link,
+			})
+		}
+
+		inStock := target.stock > 0
+		if inStock != favorite.InStock {
+			updates["in_stock"] = inStock
+			if inStock {
+				notifications = append(notifications, &models.Notification{
+					UserID:  favorite.UserID,
+					Type:    models.NotificationRestock,
+					Title:   "收藏的商品到货了",
+					Content: fmt.Sprintf("您收藏的「%s」已到货", target.name),
+					Link:    link,
+				})
+			}
+		}
+
+		if len(updates) > 0 {
+			pending[favorite.ID] = updates
+		}
+	}
+
+	// 先发送通知再更新提醒状态，更新失败时下一轮可能重复提醒，但不会漏发
+	if err := s.notificationService.Send(ctx, notifications); err != nil {
+		return 0, err
+	}
+	for id, updates := range pending {
+		if err := s.favoriteRepo.Update(id, updates); err != nil {
+			return len(notifications), err
+		}
+	}
+	return len(notifications), nil
+}
+
+// RunWorker 定期检查收藏商品的降价和到货
+func (s *FavoriteService) RunWorker(ctx context.Context) {
+	interval := time.Duration(config.GlobalConfig.Favorite.WorkerIntervalMinutes) * time.Minute
+	runPeriodic(ctx, utils.FavoriteWorkerLockKey, interval, func(ctx context.Context) {
+		count, err := s.CheckChanges(ctx)
+		if err != nil {
+			log.Printf("Failed to check favorite changes: %v", err)
+		}
+		if count > 0 {
+			log.Printf("Sent %d favorite notifications", count)
+		}
+	})
+}
+
+// IsFavoriteError 判断是否为收藏业务错误（可直接返回给用户）
+func IsFavoriteError(err error) bool {
+	return errors.Is(err, ErrFavoriteExists) ||
+		errors.Is(err, ErrFavoriteNotFound) ||
+		errors.Is(err, ErrFavoriteLimit) ||
+		errors.Is(err, ErrProductNotFound) ||
+		errors.Is(err, ErrProductOffShelf) ||
+		errors.Is(err, ErrSKUNotFound)
+}
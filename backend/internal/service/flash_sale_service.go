@@ -0,0 +1,1142 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrFlashSaleNotFound 秒杀活动不存在
+	ErrFlashSaleNotFound = errors.New("秒杀活动不存在")
+
+	// ErrFlashSaleNotStarted 秒杀未开始
+	ErrFlashSaleNotStarted = errors.New("秒杀活动尚未开始")
+
+	// ErrFlashSaleEnded 秒杀已结束
+	ErrFlashSaleEnded = errors.New("秒杀活动已结束")
+
+	// ErrFlashSaleSoldOut 秒杀已售罄
+	ErrFlashSaleSoldOut = errors.New("秒杀商品已抢光")
+
+	// ErrFlashSaleLimit 超过每人限购数量
+	ErrFlashSaleLimit = errors.New("超过每人限购数量")
+
+	// ErrFlashSaleStarted 秒杀已开始，不能修改或删除
+	ErrFlashSaleStarted = errors.New("秒杀活动已开始，不能修改或删除")
+
+	// ErrFlashSaleOverlap 同一SKU的秒杀时间重叠
+	ErrFlashSaleOverlap = errors.New("该商品规格在此时间段已有秒杀活动")
+
+	// ErrFlashSalePrice 秒杀价无效
+	ErrFlashSalePrice = errors.New("秒杀价必须低于商品原价")
+
+	// ErrFlashSaleSchedule 秒杀时间无效
+	ErrFlashSaleSchedule = errors.New("结束时间必须晚于开始时间和当前时间")
+
+	// ErrFlashSaleBusy 秒杀库存未就绪
+	ErrFlashSaleBusy = errors.New("活动太火爆了，请稍后再试")
+
+	// ErrFlashSaleRequestNotFound 抢购请求不存在
+	ErrFlashSaleRequestNotFound = errors.New("抢购请求不存在或已过期")
+
+	// ErrAddressNotFound 收货地址不存在
+	ErrAddressNotFound = errors.New("收货地址不存在")
+
+	// errFlashSaleProcessed 抢购请求已被处理（重复消费）
+	errFlashSaleProcessed = errors.New("flash sale request already processed")
+)
+
+// 秒杀活动状态（展示用）
+const (
+	FlashSaleStateUpcoming = "upcoming"
+	FlashSaleStateOngoing  = "ongoing"
+	FlashSaleStateSoldOut  = "sold_out"
+	FlashSaleStateEnded    = "ended"
+)
+
+// 抢购请求处理状态
+const (
+	FlashSaleResultQueued  = "queued"
+	FlashSaleResultSuccess = "success"
+	FlashSaleResultFailed  = "failed"
+)
+
+const (
+	// flashSaleVisibleLimit 前台最多展示的秒杀活动数量
+	flashSaleVisibleLimit = 50
+	// flashSaleBatchSize 后台任务每轮处理的记录数
+	flashSaleBatchSize = 200
+	// flashSaleSettleDelay 活动结束后延迟结算，等待队列中结束前的抢购请求处理完
+	flashSaleSettleDelay = time.Minute
+	// flashSaleStockRetention 活动结束后Redis库存和限购记录的保留时间
+	flashSaleStockRetention = 24 * time.Hour
+	// flashSaleQueueGroup 下单队列的消费组
+	flashSaleQueueGroup = "order-workers"
+	// flashSaleClaimIdle 待确认消息空闲超过该时长视为消费者已崩溃，由其他消费者接管
+	flashSaleClaimIdle = time.Minute
+	// flashSaleClaimInterval 接管遗留消息的检查间隔
+	flashSaleClaimInterval = 30 * time.Second
+)
+
+// flashSaleBuyScript 原子地检查限购、扣减秒杀库存并累加用户已抢购数量。
+// KEYS[1] 剩余库存，KEYS[2] 用户已抢购数量；ARGV[1] 数量，ARGV[2] 用户ID，ARGV[3] 每人限购（0不限）。
+// 返回 -1 库存未预热，-2 超过限购，-3 库存不足，1 成功
+var flashSaleBuyScript = redis.NewScript(`
+local stock = redis.call('GET', KEYS[1])
+if not stock then
+	return -1
+end
+local qty = tonumber(ARGV[1])
+local limit = tonumber(ARGV[3])
+local bought = tonumber(redis.call('HGET', KEYS[2], ARGV[2]) or '0')
+if limit > 0 and bought + qty > limit then
+	return -2
+end
+if tonumber(stock) < qty then
+	return -3
+end
+redis.call('DECRBY', KEYS[1], qty)
+redis.call('HINCRBY', KEYS[2], ARGV[2], qty)
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[2], ttl)
+end
+return 1
+`)
+
+// flashSaleReturnScript 退回秒杀库存和用户已抢购数量，库存未预热时不处理（预热时按数据库重建）。
+// KEYS、ARGV同flashSaleBuyScript
+var flashSaleReturnScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('INCRBY', KEYS[1], ARGV[1])
+if redis.call('HINCRBY', KEYS[2], ARGV[2], -tonumber(ARGV[1])) <= 0 then
+	redis.call('HDEL', KEYS[2], ARGV[2])
+end
+return 1
+`)
+
+// FlashSaleInput 创建/更新秒杀活动参数，SKU创建后不能修改
+type FlashSaleInput struct {
+	Name         string
+	ProductID    uint64
+	SKUID        uint64
+	SalePrice    float64
+	Quantity     int
+	PerUserLimit int
+	StartAt      time.Time
+	EndAt        time.Time
+	Status       int
+}
+
+// FlashSaleView 秒杀活动及实时剩余库存
+type FlashSaleView struct {
+	*models.FlashSale
+	Remaining int    `json:"remaining"` // 剩余可抢数量
+	State     string `json:"state"`     // upcoming、ongoing、sold_out、ended
+}
+
+// FlashSaleResult 抢购请求处理结果
+type FlashSaleResult struct {
+	RequestID   string `json:"request_id"`
+	FlashSaleID uint64 `json:"flash_sale_id"`
+	UserID      uint64 `json:"user_id"`
+	Status      string `json:"status"` // queued、success、failed
+	OrderID     uint64 `json:"order_id,omitempty"`
+	OrderNo     string `json:"order_no,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// flashSaleRequest 下单队列消息
+type flashSaleRequest struct {
+	RequestID   string `json:"request_id"`
+	FlashSaleID uint64 `json:"flash_sale_id"`
+	UserID      uint64 `json:"user_id"`
+	Quantity    int    `json:"quantity"`
+	AddressID   uint64 `json:"address_id"`
+	Attempts    int    `json:"attempts"`
+}
+
+// FlashSaleService 秒杀业务逻辑层。
+// 秒杀库存创建时从SKU划出并预热到Redis，抢购时由Lua脚本原子扣减并检查限购，成功后写入下单队列，
+// 由消费协程异步创建订单；订单创建失败、超时未支付或取消时库存退回秒杀活动，活动结束后未售出的库存退回SKU
+type FlashSaleService struct {
+	saleRepo        *repository.FlashSaleRepository
+	orderRepo       *repository.OrderRepository
+	productRepo     *repository.ProductRepository
+	memberService   *MemberService
+	checkoutService *CheckoutService
+}
+
+// NewFlashSaleService 创建秒杀Service实例
+func NewFlashSaleService() *FlashSaleService {
+	return &FlashSaleService{
+		saleRepo:        repository.NewFlashSaleRepository(),
+		orderRepo:       repository.NewOrderRepository(),
+		productRepo:     repository.NewProductRepository(),
+		memberService:   NewMemberService(),
+		checkoutService: NewCheckoutService(),
+	}
+}
+
+// flashSaleKeys 秒杀活动的Redis库存和限购记录键
+func flashSaleKeys(saleID uint64) []string {
+	return []string{fmt.Sprintf(utils.FlashSaleStockKey, saleID), fmt.Sprintf(utils.FlashSaleUsersKey, saleID)}
+}
+
+// getSale 获取秒杀活动，优先读取缓存
+func (s *FlashSaleService) getSale(ctx context.Context, id uint64) (*models.FlashSale, error) {
+	key := fmt.Sprintf(utils.FlashSaleInfoKey, id)
+	if cached, err := utils.Get(ctx, key); err == nil {
+		var sale models.FlashSale
+		if json.Unmarshal([]byte(cached), &sale) == nil {
+			return &sale, nil
+		}
+	}
+
+	sale, err := s.saleRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFlashSaleNotFound
+		}
+		return nil, err
+	}
+	if data, err := json.Marshal(sale); err == nil {
+		ttl := time.Duration(config.GlobalConfig.FlashSale.InfoCacheSeconds) * time.Second
+		if err := utils.Set(ctx, key, string(data), ttl); err != nil {
+			log.Printf("Failed to cache flash sale %d: %v", id, err)
+		}
+	}
+	return sale, nil
+}
+
+// invalidate 删除活动信息和列表缓存
+func (s *FlashSaleService) invalidate(ctx context.Context, id uint64) {
+	if err := utils.Del(ctx, fmt.Sprintf(utils.FlashSaleInfoKey, id), utils.FlashSaleListKey); err != nil {
+		log.Printf("Failed to invalidate flash sale cache: %v", err)
+	}
+}
+
+// view 读取Redis剩余库存并计算活动状态，库存未预热时按数据库计算
+func (s *FlashSaleService) view(ctx context.Context, sale *models.FlashSale, now time.Time) *FlashSaleView {
+	remaining := sale.Quantity - sale.Sold
+	if stock, err := utils.Get(ctx, fmt.Sprintf(utils.FlashSaleStockKey, sale.ID)); err == nil {
+		if n, err := strconv.Atoi(stock); err == nil {
+			remaining = n
+		}
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	view := &FlashSaleView{FlashSale: sale, Remaining: remaining}
+	switch {
+	case now.Before(sale.StartAt):
+		view.State = FlashSaleStateUpcoming
+	case !now.Before(sale.EndAt):
+		view.State = FlashSaleStateEnded
+	case remaining == 0:
+		view.State = FlashSaleStateSoldOut
+	default:
+		view.State = FlashSaleStateOngoing
+	}
+	return view
+}
+
+// GetVisibleSales 获取进行中和即将开始的秒杀活动
+func (s *FlashSaleService) GetVisibleSales(ctx context.Context) ([]*FlashSaleView, error) {
+	var sales []*models.FlashSale
+	cached, err := utils.Get(ctx, utils.FlashSaleListKey)
+	if err != nil || json.Unmarshal([]byte(cached), &sales) != nil {
+		sales, err = s.saleRepo.GetVisible(time.Now(), flashSaleVisibleLimit)
+		if err != nil {
+			return nil, err
+		}
+		if data, err := json.Marshal(sales); err == nil {
+			ttl := time.Duration(config.GlobalConfig.FlashSale.InfoCacheSeconds) * time.Second
+			if err := utils.Set(ctx, utils.FlashSaleListKey, string(data), ttl); err != nil {
+				log.Printf("Failed to cache flash sale list: %v", err)
+			}
+		}
+	}
+
+	now := time.Now()
+	views := make([]*FlashSaleView, 0, len(sales))
+	for _, sale := range sales {
+		// 缓存中的活动可能已经结束
+		if !now.Before(sale.EndAt) {
+			continue
+		}
+		views = append(views, s.view(ctx, sale, now))
+	}
+	return views, nil
+}
+
+// GetSaleView 获取启用中的秒杀活动详情
+func (s *FlashSaleService) GetSaleView(ctx context.Context, id uint64) (*FlashSaleView, error) {
+	sale, err := s.getSale(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sale.Status != 1 {
+		return nil, ErrFlashSaleNotFound
+	}
+	return s.view(ctx, sale, time.Now()), nil
+}
+
+// Buy 抢购：扣减Redis秒杀库存后写入下单队列，返回排队中的请求，通过GetResult查询下单结果
+func (s *FlashSaleService) Buy(ctx context.Context, userID uint64, saleID uint64, quantity int, addressID uint64) (*FlashSaleResult, error) {
+	sale, err := s.getSale(ctx, saleID)
+	if err != nil {
+		return nil, err
+	}
+	if sale.Status != 1 {
+		return nil, ErrFlashSaleNotFound
+	}
+	now := time.Now()
+	if now.Before(sale.StartAt) {
+		return nil, ErrFlashSaleNotStarted
+	}
+	if !now.Before(sale.EndAt) {
+		return nil, ErrFlashSaleEnded
+	}
+	if sale.PerUserLimit > 0 && quantity > sale.PerUserLimit {
+		return nil, ErrFlashSaleLimit
+	}
+
+	keys := flashSaleKeys(saleID)
+	code, err := utils.RunScript(ctx, flashSaleBuyScript, keys, quantity, userID, sale.PerUserLimit)
+	if err != nil {
+		return nil, err
+	}
+	switch code {
+	case int64(-1):
+		return nil, ErrFlashSaleBusy
+	case int64(-2):
+		return nil, ErrFlashSaleLimit
+	case int64(-3):
+		return nil, ErrFlashSaleSoldOut
+	}
+
+	result, err := s.enqueue(ctx, saleID, userID, quantity, addressID)
+	if err != nil {
+		// 未能进入下单队列，退回已扣减的库存
+		if _, returnErr := utils.RunScript(ctx, flashSaleReturnScript, keys, quantity, userID); returnErr != nil {
+			log.Printf("Failed to return flash sale %d stock for user %d: %v", saleID, userID, returnErr)
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// enqueue 生成抢购请求并写入下单队列
+func (s *FlashSaleService) enqueue(ctx context.Context, saleID uint64, userID uint64, quantity int, addressID uint64) (*FlashSaleResult, error) {
+	requestID, err := utils.RandomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	result := &FlashSaleResult{RequestID: requestID, FlashSaleID: saleID, UserID: userID, Status: FlashSaleResultQueued}
+	if err := s.saveResult(ctx, result); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(&flashSaleRequest{
+		RequestID:   requestID,
+		FlashSaleID: saleID,
+		UserID:      userID,
+		Quantity:    quantity,
+		AddressID:   addressID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.XAdd(ctx, utils.FlashSaleQueueKey, map[string]interface{}{"data": string(data)}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// saveResult 保存抢购请求处理结果
+func (s *FlashSaleService) saveResult(ctx context.Context, result *FlashSaleResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	ttl := time.Duration(config.GlobalConfig.FlashSale.ResultTTLMinutes) * time.Minute
+	return utils.Set(ctx, fmt.Sprintf(utils.FlashSaleResultKey, result.RequestID), string(data), ttl)
+}
+
+// resultFromRecord 根据下单记录生成处理结果
+func (s *FlashSaleService) resultFromRecord(record *models.FlashSaleOrder) (*FlashSaleResult, error) {
+	result := &FlashSaleResult{
+		RequestID:   record.RequestID,
+		FlashSaleID: record.FlashSaleID,
+		UserID:      record.UserID,
+	}
+	if record.Status == models.FlashSaleOrderFailed {
+		result.Status = FlashSaleResultFailed
+		result.Reason = record.Reason
+		return result, nil
+	}
+
+	order, err := s.orderRepo.GetByID(models.DB, record.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	result.Status = FlashSaleResultSuccess
+	result.OrderID = order.ID
+	result.OrderNo = order.OrderNo
+	return result, nil
+}
+
+// GetResult 查询抢购请求处理结果，Redis中的结果过期后从下单记录查询
+func (s *FlashSaleService) GetResult(ctx context.Context, userID uint64, requestID string) (*FlashSaleResult, error) {
+	if cached, err := utils.Get(ctx, fmt.Sprintf(utils.FlashSaleResultKey, requestID)); err == nil {
+		var result FlashSaleResult
+		if json.Unmarshal([]byte(cached), &result) == nil {
+			if result.UserID != userID {
+				return nil, ErrFlashSaleRequestNotFound
+			}
+			return &result, nil
+		}
+	}
+
+	record, err := s.saleRepo.GetOrderByRequestID(requestID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFlashSaleRequestNotFound
+		}
+		return nil, err
+	}
+	if record.UserID != userID {
+		return nil, ErrFlashSaleRequestNotFound
+	}
+	return s.resultFromRecord(record)
+}
+
+// RunConsumer 启动下单队列消费协程，ctx取消后退出。
+// 消息以消费组方式读取，处理完成后才确认删除；消费者崩溃或重启遗留的待确认消息由接管协程重新处理
+func (s *FlashSaleService) RunConsumer(ctx context.Context) {
+	if err := utils.XGroupCreate(ctx, utils.FlashSaleQueueKey, flashSaleQueueGroup); err != nil {
+		log.Printf("Failed to create flash sale queue group: %v", err)
+		return
+	}
+
+	hostname, _ := os.Hostname()
+	consumer := fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	workers := config.GlobalConfig.FlashSale.QueueWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				messages, err := utils.XReadGroup(ctx, utils.FlashSaleQueueKey, flashSaleQueueGroup, consumer, 1, 5*time.Second)
+				if err != nil {
+					if !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+						log.Printf("Failed to read flash sale queue: %v", err)
+						time.Sleep(time.Second)
+					}
+					continue
+				}
+				s.handle(ctx, messages)
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(flashSaleClaimInterval)
+		defer ticker.Stop()
+		for {
+			messages, err := utils.XAutoClaim(ctx, utils.FlashSaleQueueKey, flashSaleQueueGroup, consumer, flashSaleClaimIdle, flashSaleBatchSize)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Failed to claim stale flash sale requests: %v", err)
+				}
+			} else {
+				s.handle(ctx, messages)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+// handle 处理并确认一批下单消息，未处理完的消息保持待确认，稍后由接管协程重试
+func (s *FlashSaleService) handle(ctx context.Context, messages []redis.XMessage) {
+	for _, message := range messages {
+		data, _ := message.Values["data"].(string)
+		if !s.process(ctx, data) {
+			continue
+		}
+		if err := utils.XAckDel(ctx, utils.FlashSaleQueueKey, flashSaleQueueGroup, message.ID); err != nil {
+			log.Printf("Failed to ack flash sale request %s: %v", message.ID, err)
+		}
+	}
+}
+
+// process 处理一条下单消息：业务原因失败时退回库存，数据库等异常时重新入队重试。
+// 返回false表示消息需保留待稍后重新处理
+func (s *FlashSaleService) process(ctx context.Context, data string) bool {
+	var req flashSaleRequest
+	if err := json.Unmarshal([]byte(data), &req); err != nil {
+		log.Printf("Invalid flash sale request %q: %v", data, err)
+		return true
+	}
+
+	result, err := s.createOrder(ctx, &req)
+	if err == nil {
+		if err := s.saveResult(ctx, result); err != nil {
+			log.Printf("Failed to save flash sale result %s: %v", req.RequestID, err)
+		}
+		return true
+	}
+
+	if errors.Is(err, errFlashSaleProcessed) {
+		s.syncResult(ctx, req.RequestID)
+		return true
+	}
+	if IsFlashSaleError(err) {
+		s.fail(ctx, &req, err.Error())
+		return true
+	}
+
+	req.Attempts++
+	if req.Attempts >= config.GlobalConfig.FlashSale.MaxAttempts {
+		log.Printf("Failed to create flash sale order for request %s after %d attempts: %v", req.RequestID, req.Attempts, err)
+		s.fail(ctx, &req, "下单失败，请稍后重试")
+		return true
+	}
+	log.Printf("Failed to create flash sale order for request %s, retrying: %v", req.RequestID, err)
+	retry, _ := json.Marshal(&req)
+	if err := utils.XAdd(ctx, utils.FlashSaleQueueKey, map[string]interface{}{"data": string(retry)}); err != nil {
+		log.Printf("Failed to requeue flash sale request %s: %v", req.RequestID, err)
+		return false
+	}
+	return true
+}
+
+// createOrder 在事务中增加已售数量、创建订单和下单记录
+func (s *FlashSaleService) createOrder(ctx context.Context, req *flashSaleRequest) (*FlashSaleResult, error) {
+	if _, err := s.saleRepo.GetOrderByRequestID(req.RequestID); err == nil {
+		return nil, errFlashSaleProcessed
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	sale, err := s.getSale(ctx, req.FlashSaleID)
+	if err != nil {
+		return nil, err
+	}
+	sku, err := s.productRepo.GetSKU(sale.ProductID, sale.SKUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSKUNotFound
+		}
+		return nil, err
+	}
+	benefits, err := s.memberService.GetBenefits(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	goodsAmount := roundMoney(sale.SalePrice * float64(req.Quantity))
+	freight := s.checkoutService.freight(goodsAmount, benefits)
+	order := &models.Order{
+		UserID:         req.UserID,
+		OrderType:      models.OrderTypeFlashSale,
+		AddressID:      req.AddressID,
+		TotalAmount:    roundMoney(sale.OriginalPrice * float64(req.Quantity)),
+		Freight:        freight,
+		DiscountAmount: roundMoney((sale.OriginalPrice - sale.SalePrice) * float64(req.Quantity)),
+		PayAmount:      roundMoney(goodsAmount + freight),
+		PayStatus:      models.PayStatusUnpaid,
+		OrderStatus:    models.OrderStatusPending,
+		Remark:         fmt.Sprintf("秒杀：%s", sale.Name),
+	}
+	items := []*models.OrderItem{{
+		ProductID:      sale.ProductID,
+		SKUID:          sale.SKUID,
+		ProductName:    sale.ProductName,
+		ProductImage:   sale.Image,
+		Specifications: sku.Specifications,
+		Price:          sale.SalePrice,
+		Quantity:       req.Quantity,
+		TotalAmount:    goodsAmount,
+		PayAmount:      goodsAmount,
+	}}
+
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		address, err := s.orderRepo.GetAddress(tx, req.UserID, req.AddressID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAddressNotFound
+			}
+			return err
+		}
+		order.SetReceiver(address)
+		ok, err := s.saleRepo.IncrSold(tx, sale.ID, req.Quantity)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrFlashSaleSoldOut
+		}
+		if err := s.orderRepo.CreateWithItems(tx, order, items); err != nil {
+			return err
+		}
+		created, err := s.saleRepo.CreateOrder(tx, &models.FlashSaleOrder{
+			RequestID:   req.RequestID,
+			FlashSaleID: sale.ID,
+			UserID:      req.UserID,
+			Quantity:    req.Quantity,
+			OrderID:     order.ID,
+			Status:      models.FlashSaleOrderCreated,
+		})
+		if err != nil {
+			return err
+		}
+		if !created {
+			return errFlashSaleProcessed
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &FlashSaleResult{
+		RequestID:   req.RequestID,
+		FlashSaleID: sale.ID,
+		UserID:      req.UserID,
+		Status:      FlashSaleResultSuccess,
+		OrderID:     order.ID,
+		OrderNo:     order.OrderNo,
+	}, nil
+}
+
+// fail 记录下单失败并退回Redis库存，同一请求只退回一次
+func (s *FlashSaleService) fail(ctx context.Context, req *flashSaleRequest, reason string) {
+	created, err := s.saleRepo.CreateOrder(models.DB, &models.FlashSaleOrder{
+		RequestID:   req.RequestID,
+		FlashSaleID: req.FlashSaleID,
+		UserID:      req.UserID,
+		Quantity:    req.Quantity,
+		Status:      models.FlashSaleOrderFailed,
+		Reason:      reason,
+	})
+	if err != nil {
+		log.Printf("Failed to record flash sale failure %s: %v", req.RequestID, err)
+		return
+	}
+	if !created {
+		s.syncResult(ctx, req.RequestID)
+		return
+	}
+
+	if _, err := utils.RunScript(ctx, flashSaleReturnScript, flashSaleKeys(req.FlashSaleID), req.Quantity, req.UserID); err != nil {
+		log.Printf("Failed to return flash sale %d stock for request %s: %v", req.FlashSaleID, req.RequestID, err)
+	}
+	err = s.saveResult(ctx, &FlashSaleResult{
+		RequestID:   req.RequestID,
+		FlashSaleID: req.FlashSaleID,
+		UserID:      req.UserID,
+		Status:      FlashSaleResultFailed,
+		Reason:      reason,
+	})
+	if err != nil {
+		log.Printf("Failed to save flash sale result %s: %v", req.RequestID, err)
+	}
+}
+
+// syncResult 按下单记录更新Redis中的处理结果
+func (s *FlashSaleService) syncResult(ctx context.Context, requestID string) {
+	record, err := s.saleRepo.GetOrderByRequestID(requestID)
+	if err != nil {
+		log.Printf("Failed to load flash sale record %s: %v", requestID, err)
+		return
+	}
+	result, err := s.resultFromRecord(record)
+	if err == nil {
+		err = s.saveResult(ctx, result)
+	}
+	if err != nil {
+		log.Printf("Failed to save flash sale result %s: %v", requestID, err)
+	}
+}
+
+// Release 秒杀订单取消时调用（与取消订单在同一事务内），非秒杀订单不处理。
+// 活动未结算时库存待后台任务退回Redis供其他用户抢购，已结算时直接退回SKU
+func (s *FlashSaleService) Release(tx *gorm.DB, order *models.Order) error {
+	record, err := s.saleRepo.GetOrderByOrderIDForUpdate(tx, order.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if record.Status != models.FlashSaleOrderCreated {
+		return nil
+	}
+
+	sale, err := s.saleRepo.GetForUpdate(tx, record.FlashSaleID)
+	if err != nil {
+		return err
+	}
+	if err := s.saleRepo.DecrSold(tx, sale.ID, record.Quantity); err != nil {
+		return err
+	}
+	if !sale.Settled {
+		_, err = s.saleRepo.UpdateOrderStatus(tx, record.ID, models.FlashSaleOrderCreated, models.FlashSaleOrderReleased)
+		return err
+	}
+
+	err = s.productRepo.UpdateSKU(tx, sale.SKUID, map[string]interface{}{"stock": gorm.Expr("stock + ?", record.Quantity)})
+	if err != nil {
+		return err
+	}
+	_, err = s.saleRepo.UpdateOrderStatus(tx, record.ID, models.FlashSaleOrderCreated, models.FlashSaleOrderReturned)
+	return err
+}
+
+// CancelExpired 取消超时未支付的秒杀订单，返回取消数
+func (s *FlashSaleService) CancelExpired() (int, error) {
+	timeout := time.Duration(config.GlobalConfig.FlashSale.PayTimeoutMinutes) * time.Minute
+	records, err := s.saleRepo.GetExpiredUnpaid(time.Now().Add(-timeout), flashSaleBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// 订单事件服务依赖秒杀服务，在此处创建以避免构造时循环依赖
+	orderEvents := NewOrderEventService()
+	cancelled := 0
+	for _, record := range records {
+		err := models.DB.Transaction(func(tx *gorm.DB) error {
+			ok, err := s.orderRepo.CancelUnpaid(tx, record.OrderID, "秒杀订单超时未支付")
+			if err != nil || !ok {
+				return err
+			}
+			order, err := s.orderRepo.GetByID(tx, record.OrderID)
+			if err != nil {
+				return err
+			}
+			if err := orderEvents.Cancelled(tx, order); err != nil {
+				return err
+			}
+			cancelled++
+			return nil
+		})
+		if err != nil {
+			return cancelled, err
+		}
+	}
+	return cancelled, nil
+}
+
+// ReturnReleased 将已取消订单的库存退回Redis，返回处理数。
+// 先标记已退回再退回Redis，异常时宁可少卖也不超卖
+func (s *FlashSaleService) ReturnReleased(ctx context.Context) (int, error) {
+	records, err := s.saleRepo.GetReleased(flashSaleBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	returned := 0
+	for _, record := range records {
+		ok, err := s.saleRepo.UpdateOrderStatus(models.DB, record.ID, models.FlashSaleOrderReleased, models.FlashSaleOrderReturned)
+		if err != nil {
+			return returned, err
+		}
+		if !ok {
+			continue
+		}
+		if _, err := utils.RunScript(ctx, flashSaleReturnScript, flashSaleKeys(record.FlashSaleID), record.Quantity, record.UserID); err != nil {
+			return returned, err
+		}
+		returned++
+	}
+	return returned, nil
+}
+
+// Settle 将已结束活动未售出的库存退回SKU，返回结算的活动数
+func (s *FlashSaleService) Settle() (int, error) {
+	sales, err := s.saleRepo.GetUnsettled(time.Now().Add(-flashSaleSettleDelay), flashSaleBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, sale := range sales {
+		err := models.DB.Transaction(func(tx *gorm.DB) error {
+			locked, err := s.saleRepo.GetForUpdate(tx, sale.ID)
+			if err != nil || locked.Settled {
+				return err
+			}
+			if left := locked.Quantity - locked.Sold; left > 0 {
+				err := s.productRepo.UpdateSKU(tx, locked.SKUID, map[string]interface{}{"stock": gorm.Expr("stock + ?", left)})
+				if err != nil {
+					return err
+				}
+			}
+			settled++
+			return s.saleRepo.Update(tx, locked.ID, map[string]interface{}{"settled": true})
+		})
+		if err != nil {
+			return settled, err
+		}
+	}
+	return settled, nil
+}
+
+// warm 活动Redis库存不存在时按数据库重建：剩余库存=秒杀库存-已售，限购记录按已创建的订单计算
+func (s *FlashSaleService) warm(ctx context.Context, sale *models.FlashSale) error {
+	keys := flashSaleKeys(sale.ID)
+	exists, err := utils.Exists(ctx, keys[0])
+	if err != nil || exists {
+		return err
+	}
+
+	// 待退回的库存已包含在数据库剩余库存中
+	if err := s.saleRepo.MarkSaleReleasedReturned(sale.ID); err != nil {
+		return err
+	}
+	fresh, err := s.saleRepo.GetByID(sale.ID)
+	if err != nil {
+		return err
+	}
+	quantities, err := s.saleRepo.GetUserQuantities(sale.ID)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Until(fresh.EndAt.Add(flashSaleStockRetention))
+	if ttl <= 0 {
+		return nil
+	}
+	if err := utils.Del(ctx, keys[1]); err != nil {
+		return err
+	}
+	for _, q := range quantities {
+		if err := utils.HSet(ctx, keys[1], strconv.FormatUint(q.UserID, 10), q.Quantity); err != nil {
+			return err
+		}
+	}
+	if len(quantities) > 0 {
+		if err := utils.Expire(ctx, keys[1], ttl); err != nil {
+			return err
+		}
+	}
+	_, err = utils.SetNx(ctx, keys[0], fresh.Quantity-fresh.Sold, ttl)
+	return err
+}
+
+// Warm 预热进行中和即将开始的活动的Redis库存，返回检查的活动数
+func (s *FlashSaleService) Warm(ctx context.Context) (int, error) {
+	sales, err := s.saleRepo.GetVisible(time.Now(), flashSaleBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, sale := range sales {
+		if err := s.warm(ctx, sale); err != nil {
+			return 0, err
+		}
+	}
+	return len(sales), nil
+}
+
+// RunWorker 定期预热库存、取消超时未支付的订单、退回已取消订单的库存并结算已结束的活动
+func (s *FlashSaleService) RunWorker(ctx context.Context) {
+	interval := time.Duration(config.GlobalConfig.FlashSale.WorkerIntervalSeconds) * time.Second
+	runPeriodic(ctx, utils.FlashSaleWorkerLockKey, interval, func(ctx context.Context) {
+		if _, err := s.Warm(ctx); err != nil {
+			log.Printf("Failed to warm flash sale stock: %v", err)
+		}
+		if count, err := s.CancelExpired(); err != nil {
+			log.Printf("Failed to cancel expired flash sale orders: %v", err)
+		} else if count > 0 {
+			log.Printf("Cancelled %d expired flash sale orders", count)
+		}
+		if _, err := s.ReturnReleased(ctx); err != nil {
+			log.Printf("Failed to return released flash sale stock: %v", err)
+		}
+		if count, err := s.Settle(); err != nil {
+			log.Printf("Failed to settle flash sales: %v", err)
+		} else if count > 0 {
+			log.Printf("Settled %d flash sales", count)
+		}
+	})
+}
+
+// GetSale 获取秒杀活动（管理员）
+func (s *FlashSaleService) GetSale(id uint64) (*models.FlashSale, error) {
+	sale, err := s.saleRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFlashSaleNotFound
+		}
+		return nil, err
+	}
+	return sale, nil
+}
+
+// GetSales 分页获取秒杀活动（管理员），包括停用和已结束的
+func (s *FlashSaleService) GetSales(page, pageSize int) ([]*models.FlashSale, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.saleRepo.GetSales(page, pageSize)
+}
+
+// validateSchedule 校验秒杀时间和每人限购
+func (s *FlashSaleService) validateSchedule(input *FlashSaleInput) error {
+	if !input.EndAt.After(input.StartAt) || !input.EndAt.After(time.Now()) {
+		return ErrFlashSaleSchedule
+	}
+	if input.PerUserLimit < 0 {
+		input.PerUserLimit = 0
+	}
+	return nil
+}
+
+// CreateSale 创建秒杀活动，从SKU库存中划出秒杀库存
+func (s *FlashSaleService) CreateSale(ctx context.Context, input *FlashSaleInput) (*models.FlashSale, error) {
+	if err := s.validateSchedule(input); err != nil {
+		return nil, err
+	}
+	product, err := s.productRepo.GetByID(input.ProductID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	if product.Status != 1 {
+		return nil, ErrProductOffShelf
+	}
+
+	sale := &models.FlashSale{
+		Name:         input.Name,
+		ProductID:    input.ProductID,
+		SKUID:        input.SKUID,
+		ProductName:  product.Name,
+		SalePrice:    input.SalePrice,
+		Quantity:     input.Quantity,
+		PerUserLimit: input.PerUserLimit,
+		StartAt:      input.StartAt,
+		EndAt:        input.EndAt,
+		Status:       input.Status,
+	}
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		skus, err := s.productRepo.GetSKUsForUpdate(tx, input.ProductID, []uint64{input.SKUID})
+		if err != nil {
+			return err
+		}
+		if len(skus) == 0 {
+			return ErrSKUNotFound
+		}
+		sku := skus[0]
+		if input.SalePrice >= sku.Price {
+			return ErrFlashSalePrice
+		}
+		if sku.Stock < input.Quantity {
+			return ErrStockInsufficient
+		}
+		overlap, err := s.saleRepo.ExistsOverlap(tx, sku.ID, input.StartAt, input.EndAt, 0)
+		if err != nil {
+			return err
+		}
+		if overlap {
+			return ErrFlashSaleOverlap
+		}
+
+		sale.SKUName = sku.Name
+		sale.OriginalPrice = sku.Price
+		sale.Image = sku.Image
+		if sale.Image == "" {
+			if images := product.GetImages(); len(images) > 0 {
+				sale.Image = images[0]
+			}
+		}
+		if err := s.productRepo.UpdateSKU(tx, sku.ID, map[string]interface{}{"stock": sku.Stock - input.Quantity}); err != nil {
+			return err
+		}
+		return s.saleRepo.Create(tx, sale)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx, sale.ID)
+	if err := s.warm(ctx, sale); err != nil {
+		log.Printf("Failed to warm flash sale %d stock: %v", sale.ID, err)
+	}
+	return sale, nil
+}
+
+// resetStock 删除未开始活动的Redis库存并按数据库重新预热
+func (s *FlashSaleService) resetStock(ctx context.Context, sale *models.FlashSale) {
+	if err := utils.Del(ctx, flashSaleKeys(sale.ID)...); err != nil {
+		log.Printf("Failed to reset flash sale %d stock: %v", sale.ID, err)
+		return
+	}
+	if err := s.warm(ctx, sale); err != nil {
+		log.Printf("Failed to warm flash sale %d stock: %v", sale.ID, err)
+	}
+}
+
+// UpdateSale 更新未开始的秒杀活动，秒杀库存变化时同步调整SKU库存
+func (s *FlashSaleService) UpdateSale(ctx context.Context, id uint64, input *FlashSaleInput) (*models.FlashSale, error) {
+	if err := s.validateSchedule(input); err != nil {
+		return nil, err
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		sale, err := s.saleRepo.GetForUpdate(tx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrFlashSaleNotFound
+			}
+			return err
+		}
+		if !time.Now().Before(sale.StartAt) {
+			return ErrFlashSaleStarted
+		}
+		if input.SalePrice >= sale.OriginalPrice {
+			return ErrFlashSalePrice
+		}
+		overlap, err := s.saleRepo.ExistsOverlap(tx, sale.SKUID, input.StartAt, input.EndAt, sale.ID)
+		if err != nil {
+			return err
+		}
+		if overlap {
+			return ErrFlashSaleOverlap
+		}
+
+		if diff := input.Quantity - sale.Quantity; diff != 0 {
+			skus, err := s.productRepo.GetSKUsForUpdate(tx, sale.ProductID, []uint64{sale.SKUID})
+			if err != nil {
+				return err
+			}
+			if len(skus) == 0 {
+				return ErrSKUNotFound
+			}
+			if skus[0].Stock < diff {
+				return ErrStockInsufficient
+			}
+			if err := s.productRepo.UpdateSKU(tx, sale.SKUID, map[string]interface{}{"stock": skus[0].Stock - diff}); err != nil {
+				return err
+			}
+		}
+
+		return s.saleRepo.Update(tx, id, map[string]interface{}{
+			"name":           input.Name,
+			"sale_price":     input.SalePrice,
+			"quantity":       input.Quantity,
+			"per_user_limit": input.PerUserLimit,
+			"start_at":       input.StartAt,
+			"end_at":         input.EndAt,
+			"status":         input.Status,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx, id)
+	sale, err := s.GetSale(id)
+	if err != nil {
+		return nil, err
+	}
+	s.resetStock(ctx, sale)
+	return sale, nil
+}
+
+// UpdateStatus 启用或停用秒杀活动，停用后不能再抢购，已创建的订单不受影响
+func (s *FlashSaleService) UpdateStatus(ctx context.Context, id uint64, status int) (*models.FlashSale, error) {
+	if _, err := s.GetSale(id); err != nil {
+		return nil, err
+	}
+	if err := s.saleRepo.Update(models.DB, id, map[string]interface{}{"status": status}); err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, id)
+	return s.GetSale(id)
+}
+
+// DeleteSale 删除未开始的秒杀活动，秒杀库存退回SKU
+func (s *FlashSaleService) DeleteSale(ctx context.Context, id uint64) error {
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		sale, err := s.saleRepo.GetForUpdate(tx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrFlashSaleNotFound
+			}
+			return err
+		}
+		if !time.Now().Before(sale.StartAt) {
+			return ErrFlashSaleStarted
+		}
+		err = s.productRepo.UpdateSKU(tx, sale.SKUID, map[string]interface{}{"stock": gorm.Expr("stock + ?", sale.Quantity)})
+		if err != nil {
+			return err
+		}
+		return s.saleRepo.Delete(tx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, id)
+	if err := utils.Del(ctx, flashSaleKeys(id)...); err != nil {
+		log.Printf("Failed to delete flash sale %d stock: %v", id, err)
+	}
+	return nil
+}
+
+// IsFlashSaleError 判断是否为秒杀业务错误（可直接返回给用户）
+func IsFlashSaleError(err error) bool {
+	return errors.Is(err, ErrFlashSaleNotFound) ||
+		errors.Is(err, ErrFlashSaleNotStarted) ||
+		errors.Is(err, ErrFlashSaleEnded) ||
+		errors.Is(err, ErrFlashSaleSoldOut) ||
+		errors.Is(err, ErrFlashSaleLimit) ||
+		errors.Is(err, ErrFlashSaleStarted) ||
+		errors.Is(err, ErrFlashSaleOverlap) ||
+		errors.Is(err, ErrFlashSalePrice) ||
+		errors.Is(err, ErrFlashSaleSchedule) ||
+		errors.Is(err, ErrFlashSaleBusy) ||
+		errors.Is(err, ErrFlashSaleRequestNotFound) ||
+		errors.Is(err, ErrAddressNotFound) ||
+		errors.Is(err, ErrSKUNotFound) ||
+		errors.Is(err, ErrProductNotFound) ||
+		errors.Is(err, ErrProductOffShelf) ||
+		errors.Is(err, ErrStockInsufficient)
+}
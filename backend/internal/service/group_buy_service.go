@@ -0,0 +1,965 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/pkg/payment"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrGroupBuyNotFound 拼团活动不存在
+	ErrGroupBuyNotFound = errors.New("拼团活动不存在")
+
+	// ErrGroupBuyNotActive 拼团活动未开始或已结束
+	ErrGroupBuyNotActive = errors.New("拼团活动未开始或已结束")
+
+	// ErrGroupBuyPrice 拼团价无效
+	ErrGroupBuyPrice = errors.New("拼团价必须低于商品原价")
+
+	// ErrGroupBuySchedule 活动时间无效
+	ErrGroupBuySchedule = errors.New("结束时间必须晚于开始时间")
+
+	// ErrGroupBuyQuantity 购买数量超过限制
+	ErrGroupBuyQuantity = errors.New("超过每人最多购买数量")
+
+	// ErrGroupNotFound 团不存在
+	ErrGroupNotFound = errors.New("团不存在")
+
+	// ErrGroupClosed 团已结束
+	ErrGroupClosed = errors.New("该团已结束，不能参团")
+
+	// ErrGroupFull 团已满
+	ErrGroupFull = errors.New("该团人数已满")
+
+	// ErrGroupJoined 已在团中
+	ErrGroupJoined = errors.New("您已参加该团")
+
+	// ErrGroupMemberNotFound 团成员不存在
+	ErrGroupMemberNotFound = errors.New("团成员不存在")
+
+	// ErrGroupRefundNotFailed 退款未失败，无需重试
+	ErrGroupRefundNotFailed = errors.New("该成员没有失败的退款")
+
+	// ErrGroupNotSucceeded 拼团尚未成功
+	ErrGroupNotSucceeded = errors.New("拼团成功后才能发货")
+)
+
+// groupBuyBatchSize 后台任务每轮处理的记录数
+const groupBuyBatchSize = 200
+
+// GroupBuyInput 创建/更新拼团活动参数，SKU创建后不能修改
+type GroupBuyInput struct {
+	Name          string
+	ProductID     uint64
+	SKUID         uint64
+	GroupPrice    float64
+	GroupSize     int
+	DurationHours int
+	MaxQuantity   int
+	StartAt       time.Time
+	EndAt         time.Time
+	Status        int
+}
+
+// GroupMemberView 团成员（公开信息）
+type GroupMemberView struct {
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+	IsLeader bool   `json:"is_leader"`
+	Paid     bool   `json:"paid"`
+}
+
+// GroupView 团及成员
+type GroupView struct {
+	*models.GroupBuyGroup
+	Missing int                `json:"missing"` // 还差几人成团
+	Members []*GroupMemberView `json:"members"`
+}
+
+// GroupBuyDetail 拼团活动及可参与的团
+type GroupBuyDetail struct {
+	*models.GroupBuy
+	Groups []*GroupView `json:"groups"`
+}
+
+// GroupOrderResult 开团/参团结果，前端使用订单信息发起支付
+type GroupOrderResult struct {
+	Group *models.GroupBuyGroup `json:"group"`
+	Order *models.Order         `json:"order"`
+}
+
+// MyGroupView 用户参与的拼团
+type MyGroupView struct {
+	*models.GroupBuyMember
+	Group    *models.GroupBuyGroup `json:"group"`
+	GroupBuy *models.GroupBuy      `json:"group_buy"`
+}
+
+// GroupBuyService 拼团业务逻辑层。
+// 团长下单支付后开团，其他用户通过分享编号参团；下单即占用名额，超时未支付的订单自动取消并释放名额。
+// 成团时限内支付人数达到成团人数即拼团成功，超时未成团的团失败，已支付的订单通过支付渠道自动退款。
+// 支付成功由订单流程通过OrderEventService.Paid通知，拼团成功前的订单不能发货
+type GroupBuyService struct {
+	groupBuyRepo        *repository.GroupBuyRepository
+	orderRepo           *repository.OrderRepository
+	productRepo         *repository.ProductRepository
+	userRepo            *repository.UserRepository
+	memberService       *MemberService
+	checkoutService     *CheckoutService
+	notificationService *NotificationService
+}
+
+// NewGroupBuyService 创建拼团Service实例
+func NewGroupBuyService() *GroupBuyService {
+	return &GroupBuyService{
+		groupBuyRepo:        repository.NewGroupBuyRepository(),
+		orderRepo:           repository.NewOrderRepository(),
+		productRepo:         repository.NewProductRepository(),
+		userRepo:            repository.NewUserRepository(),
+		memberService:       NewMemberService(),
+		checkoutService:     NewCheckoutService(),
+		notificationService: NewNotificationService(),
+	}
+}
+
+// GetGroupBuy 获取拼团活动
+func (s *GroupBuyService) GetGroupBuy(id uint64) (*models.GroupBuy, error) {
+	groupBuy, err := s.groupBuyRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupBuyNotFound
+		}
+		return nil, err
+	}
+	return groupBuy, nil
+}
+
+// GetActive 分页获取当前可开团的拼团活动
+func (s *GroupBuyService) GetActive(page, pageSize int) ([]*models.GroupBuy, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.groupBuyRepo.GetActive(time.Now(), page, pageSize)
+}
+
+// GetDetail 获取启用中的拼团活动及可参与的团
+func (s *GroupBuyService) GetDetail(id uint64) (*GroupBuyDetail, error) {
+	groupBuy, err := s.GetGroupBuy(id)
+	if err != nil {
+		return nil, err
+	}
+	if groupBuy.Status != 1 {
+		return nil, ErrGroupBuyNotFound
+	}
+
+	groups, err := s.groupBuyRepo.GetOpenGroups(id, time.Now(), config.GlobalConfig.GroupBuy.OpenGroupLimit)
+	if err != nil {
+		return nil, err
+	}
+	detail := &GroupBuyDetail{GroupBuy: groupBuy, Groups: make([]*GroupView, 0, len(groups))}
+	for _, group := range groups {
+		view, err := s.groupView(group)
+		if err != nil {
+			return nil, err
+		}
+		detail.Groups = append(detail.Groups, view)
+	}
+	return detail, nil
+}
+
+// GetGroup 根据分享编号获取团及成员
+func (s *GroupBuyService) GetGroup(code string) (*GroupView, error) {
+	group, err := s.groupBuyRepo.GetGroupByCode(code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, err
+	}
+	// 团长未支付的团不公开
+	if group.Status == models.GroupStatusPending {
+		return nil, ErrGroupNotFound
+	}
+	return s.groupView(group)
+}
+
+// groupView 加载团成员的昵称和头像
+func (s *GroupBuyService) groupView(group *models.GroupBuyGroup) (*GroupView, error) {
+	members, err := s.groupBuyRepo.GetMembers(group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &GroupView{GroupBuyGroup: group, Members: make([]*GroupMemberView, 0, len(members))}
+	if missing := group.RequiredSize - group.PaidCount; missing > 0 {
+		view.Missing = missing
+	}
+	for _, member := range members {
+		memberView := &GroupMemberView{IsLeader: member.IsLeader, Paid: member.Status == models.GroupMemberPaid}
+		if user, err := s.userRepo.GetByID(member.UserID); err == nil {
+			memberView.Nickname = user.Nickname
+			memberView.Avatar = user.Avatar
+		}
+		view.Members = append(view.Members, memberView)
+	}
+	return view, nil
+}
+
+// GetMyGroups 分页获取用户参与的拼团
+func (s *GroupBuyService) GetMyGroups(userID uint64, page, pageSize int) ([]*MyGroupView, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	members, total, err := s.groupBuyRepo.GetUserMembers(userID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	groupIDs := make([]uint64, 0, len(members))
+	for _, member := range members {
+		groupIDs = append(groupIDs, member.GroupID)
+	}
+	groupMap := make(map[uint64]*models.GroupBuyGroup, len(groupIDs))
+	if len(groupIDs) > 0 {
+		groups, err := s.groupBuyRepo.GetGroupsByIDs(uniqueIDs(groupIDs))
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, group := range groups {
+			groupMap[group.ID] = group
+		}
+	}
+
+	groupBuyMap := make(map[uint64]*models.GroupBuy)
+	views := make([]*MyGroupView, 0, len(members))
+	for _, member := range members {
+		groupBuy, ok := groupBuyMap[member.GroupBuyID]
+		if !ok {
+			groupBuy, _ = s.groupBuyRepo.GetByID(member.GroupBuyID)
+			groupBuyMap[member.GroupBuyID] = groupBuy
+		}
+		views = append(views, &MyGroupView{GroupBuyMember: member, Group: groupMap[member.GroupID], GroupBuy: groupBuy})
+	}
+	return views, total, nil
+}
+
+// createOrder 按拼团价创建待支付订单并扣减SKU库存
+func (s *GroupBuyService) createOrder(tx *gorm.DB, userID uint64, addressID uint64, groupBuy *models.GroupBuy, price float64, quantity int) (*models.Order, error) {
+	address, err := s.orderRepo.GetAddress(tx, userID, addressID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAddressNotFound
+		}
+		return nil, err
+	}
+
+	skus, err := s.productRepo.GetSKUsForUpdate(tx, groupBuy.ProductID, []uint64{groupBuy.SKUID})
+	if err != nil {
+		return nil, err
+	}
+	if len(skus) == 0 {
+		return nil, ErrSKUNotFound
+	}
+	sku := skus[0]
+	if sku.Stock < quantity {
+		return nil, ErrStockInsufficient
+	}
+	if err := s.productRepo.UpdateSKU(tx, sku.ID, map[string]interface{}{"stock": sku.Stock - quantity}); err != nil {
+		return nil, err
+	}
+
+	benefits, err := s.memberService.GetBenefits(userID)
+	if err != nil {
+		return nil, err
+	}
+	goodsAmount := roundMoney(price * float64(quantity))
+	freight := s.checkoutService.freight(goodsAmount, benefits)
+	order := &models.Order{
+		UserID:         userID,
+		OrderType:      models.OrderTypeGroupBuy,
+		AddressID:      addressID,
+		TotalAmount:    roundMoney(groupBuy.OriginalPrice * float64(quantity)),
+		Freight:        freight,
+		DiscountAmount: roundMoney((groupBuy.OriginalPrice - price) * float64(quantity)),
+		PayAmount:      roundMoney(goodsAmount + freight),
+		PayStatus:      models.PayStatusUnpaid,
+		OrderStatus:    models.OrderStatusPending,
+		Remark:         fmt.Sprintf("拼团：%s", groupBuy.Name),
+	}
+	order.SetReceiver(address)
+	items := []*models.OrderItem{{
+		ProductID:      groupBuy.ProductID,
+		SKUID:          groupBuy.SKUID,
+		ProductName:    groupBuy.ProductName,
+		ProductImage:   groupBuy.Image,
+		Specifications: sku.Specifications,
+		Price:          price,
+		Quantity:       quantity,
+		TotalAmount:    goodsAmount,
+		PayAmount:      goodsAmount,
+	}}
+	if err := s.orderRepo.CreateWithItems(tx, order, items); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// OpenGroup 开团：按拼团价下单，团长支付后团才对其他用户可见
+func (s *GroupBuyService) OpenGroup(userID uint64, groupBuyID uint64, quantity int, addressID uint64) (*GroupOrderResult, error) {
+	groupBuy, err := s.GetGroupBuy(groupBuyID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if groupBuy.Status != 1 || now.Before(groupBuy.StartAt) || !now.Before(groupBuy.EndAt) {
+		return nil, ErrGroupBuyNotActive
+	}
+	if quantity > groupBuy.MaxQuantity {
+		return nil, ErrGroupBuyQuantity
+	}
+
+	code, err := utils.RandomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	result := &GroupOrderResult{}
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := s.createOrder(tx, userID, addressID, groupBuy, groupBuy.GroupPrice, quantity)
+		if err != nil {
+			return err
+		}
+		group := &models.GroupBuyGroup{
+			GroupBuyID:   groupBuy.ID,
+			LeaderID:     userID,
+			ShareCode:    code,
+			GroupPrice:   groupBuy.GroupPrice,
+			RequiredSize: groupBuy.GroupSize,
+			Status:       models.GroupStatusPending,
+		}
+		if err := s.groupBuyRepo.CreateGroup(tx, group); err != nil {
+			return err
+		}
+		err = s.groupBuyRepo.CreateMember(tx, &models.GroupBuyMember{
+			GroupID:    group.ID,
+			GroupBuyID: groupBuy.ID,
+			UserID:     userID,
+			OrderID:    order.ID,
+			Quantity:   quantity,
+			IsLeader:   true,
+			Status:     models.GroupMemberPending,
+		})
+		if err != nil {
+			return err
+		}
+		result.Group = group
+		result.Order = order
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// JoinGroup 通过分享编号参团：按开团时的拼团价下单并占用名额
+func (s *GroupBuyService) JoinGroup(userID uint64, code string, quantity int, addressID uint64) (*GroupOrderResult, error) {
+	group, err := s.groupBuyRepo.GetGroupByCode(code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, err
+	}
+	groupBuy, err := s.GetGroupBuy(group.GroupBuyID)
+	if err != nil {
+		return nil, err
+	}
+	if groupBuy.Status != 1 {
+		return nil, ErrGroupBuyNotActive
+	}
+	if quantity > groupBuy.MaxQuantity {
+		return nil, ErrGroupBuyQuantity
+	}
+
+	result := &GroupOrderResult{}
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		locked, err := s.groupBuyRepo.GetGroupForUpdate(tx, group.ID)
+		if err != nil {
+			return err
+		}
+		if locked.Status == models.GroupStatusPending {
+			return ErrGroupNotFound
+		}
+		if locked.Status != models.GroupStatusOpen || locked.ExpireAt == nil || !time.Now().Before(*locked.ExpireAt) {
+			return ErrGroupClosed
+		}
+		joined, err := s.groupBuyRepo.ExistsActiveMember(tx, locked.ID, userID)
+		if err != nil {
+			return err
+		}
+		if joined {
+			return ErrGroupJoined
+		}
+		count, err := s.groupBuyRepo.CountActiveMembers(tx, locked.ID)
+		if err != nil {
+			return err
+		}
+		if count >= int64(locked.RequiredSize) {
+			return ErrGroupFull
+		}
+
+		order, err := s.createOrder(tx, userID, addressID, groupBuy, locked.GroupPrice, quantity)
+		if err != nil {
+			return err
+		}
+		err = s.groupBuyRepo.CreateMember(tx, &models.GroupBuyMember{
+			GroupID:    locked.ID,
+			GroupBuyID: locked.GroupBuyID,
+			UserID:     userID,
+			OrderID:    order.ID,
+			Quantity:   quantity,
+			Status:     models.GroupMemberPending,
+		})
+		if err != nil {
+			return err
+		}
+		result.Group = locked
+		result.Order = order
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// markRefunding 将成员标记为等待退款，由后台任务通过支付渠道退款
+func (s *GroupBuyService) markRefunding(tx *gorm.DB, member *models.GroupBuyMember, from string) error {
+	_, err := s.groupBuyRepo.UpdateMember(tx, member.ID, from, map[string]interface{}{
+		"status":    models.GroupMemberRefunding,
+		"refund_no": fmt.Sprintf("GB%d", member.ID),
+	})
+	return err
+}
+
+// Paid 拼团订单支付成功时调用（与更新支付状态在同一事务内），非拼团订单不处理。
+// 团长支付后开团；参团成员支付后人数达到成团人数即拼团成功；团已失败时支付的订单自动退款
+func (s *GroupBuyService) Paid(tx *gorm.DB, order *models.Order) error {
+	member, err := s.groupBuyRepo.GetMemberByOrderForUpdate(tx, order.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	// 订单超时取消后才收到支付，直接退款
+	if member.Status == models.GroupMemberCancelled {
+		return s.markRefunding(tx, member, models.GroupMemberCancelled)
+	}
+	if member.Status != models.GroupMemberPending {
+		return nil
+	}
+
+	group, err := s.groupBuyRepo.GetGroupForUpdate(tx, member.GroupID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	_, err = s.groupBuyRepo.UpdateMember(tx, member.ID, models.GroupMemberPending, map[string]interface{}{
+		"status":  models.GroupMemberPaid,
+		"paid_at": now,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case member.IsLeader && group.Status == models.GroupStatusPending:
+		groupBuy, err := s.groupBuyRepo.GetByID(group.GroupBuyID)
+		if err != nil {
+			return err
+		}
+		expireAt := now.Add(time.Duration(groupBuy.DurationHours) * time.Hour)
+		err = s.groupBuyRepo.UpdateGroup(tx, group.ID, map[string]interface{}{
+			"status":     models.GroupStatusOpen,
+			"paid_count": 1,
+			"expire_at":  expireAt,
+		})
+		if err != nil {
+			return err
+		}
+		return s.groupBuyRepo.IncrCount(tx, group.GroupBuyID, "group_count")
+
+	case group.Status == models.GroupStatusOpen && group.ExpireAt != nil && now.Before(*group.ExpireAt):
+		updates := map[string]interface{}{"paid_count": group.PaidCount + 1}
+		if group.PaidCount+1 >= group.RequiredSize {
+			updates["status"] = models.GroupStatusSucceeded
+			updates["finished_at"] = now
+		}
+		if err := s.groupBuyRepo.UpdateGroup(tx, group.ID, updates); err != nil {
+			return err
+		}
+		if _, ok := updates["status"]; ok {
+			return s.groupBuyRepo.IncrCount(tx, group.GroupBuyID, "success_count")
+		}
+		return nil
+
+	default:
+		// 团已失败或已过截止时间
+		return s.markRefunding(tx, member, models.GroupMemberPaid)
+	}
+}
+
+// restoreStock 退回拼团订单扣减的SKU库存
+func (s *GroupBuyService) restoreStock(tx *gorm.DB, member *models.GroupBuyMember) error {
+	groupBuy, err := s.groupBuyRepo.GetByID(member.GroupBuyID)
+	if err != nil {
+		return err
+	}
+	return s.productRepo.UpdateSKU(tx, groupBuy.SKUID, map[string]interface{}{"stock": gorm.Expr("stock + ?", member.Quantity)})
+}
+
+// Release 拼团订单未支付取消时调用（与取消订单在同一事务内），非拼团订单不处理。
+// 释放名额并退回库存；团长取消时团失败
+func (s *GroupBuyService) Release(tx *gorm.DB, order *models.Order) error {
+	member, err := s.groupBuyRepo.GetMemberByOrderForUpdate(tx, order.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	ok, err := s.groupBuyRepo.UpdateMember(tx, member.ID, models.GroupMemberPending, map[string]interface{}{
+		"status": models.GroupMemberCancelled,
+	})
+	if err != nil || !ok {
+		return err
+	}
+	if err := s.restoreStock(tx, member); err != nil {
+		return err
+	}
+
+	if !member.IsLeader {
+		return nil
+	}
+	group, err := s.groupBuyRepo.GetGroupForUpdate(tx, member.GroupID)
+	if err != nil {
+		return err
+	}
+	if group.Status != models.GroupStatusPending {
+		return nil
+	}
+	return s.groupBuyRepo.UpdateGroup(tx, group.ID, map[string]interface{}{
+		"status":      models.GroupStatusFailed,
+		"finished_at": time.Now(),
+	})
+}
+
+// Shippable 检查订单能否发货，拼团订单在拼团成功后才能发货，非拼团订单不检查
+func (s *GroupBuyService) Shippable(tx *gorm.DB, order *models.Order) error {
+	member, err := s.groupBuyRepo.GetMemberByOrderForUpdate(tx, order.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	group, err := s.groupBuyRepo.GetGroupForUpdate(tx, member.GroupID)
+	if err != nil {
+		return err
+	}
+	if member.Status != models.GroupMemberPaid || group.Status != models.GroupStatusSucceeded {
+		return ErrGroupNotSucceeded
+	}
+	return nil
+}
+
+// CancelExpired 取消超时未支付的拼团订单，返回取消数
+func (s *GroupBuyService) CancelExpired() (int, error) {
+	timeout := time.Duration(config.GlobalConfig.GroupBuy.PayTimeoutMinutes) * time.Minute
+	members, err := s.groupBuyRepo.GetExpiredUnpaid(time.Now().Add(-timeout), groupBuyBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// 订单事件服务依赖拼团服务，在此处创建以避免构造时循环依赖
+	orderEvents := NewOrderEventService()
+	cancelled := 0
+	for _, member := range members {
+		err := models.DB.Transaction(func(tx *gorm.DB) error {
+			ok, err := s.cancelOrder(tx, orderEvents, member.OrderID, "拼团订单超时未支付")
+			if ok {
+				cancelled++
+			}
+			return err
+		})
+		if err != nil {
+			return cancelled, err
+		}
+	}
+	return cancelled, nil
+}
+
+// cancelOrder 取消未支付的订单并触发订单取消事件，订单已支付或已取消时返回false
+func (s *GroupBuyService) cancelOrder(tx *gorm.DB, orderEvents *OrderEventService, orderID uint64, reason string) (bool, error) {
+	ok, err := s.orderRepo.CancelUnpaid(tx, orderID, reason)
+	if err != nil || !ok {
+		return false, err
+	}
+	order, err := s.orderRepo.GetByID(tx, orderID)
+	if err != nil {
+		return false, err
+	}
+	if err := orderEvents.Cancelled(tx, order); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FailExpired 将超过截止时间未成团的团标记为失败：取消未支付的订单，已支付的成员等待退款。返回失败的团数
+func (s *GroupBuyService) FailExpired() (int, error) {
+	ids, err := s.groupBuyRepo.GetExpiredGroupIDs(time.Now(), groupBuyBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	orderEvents := NewOrderEventService()
+	failed := 0
+	for _, id := range ids {
+		err := models.DB.Transaction(func(tx *gorm.DB) error {
+			group, err := s.groupBuyRepo.GetGroupForUpdate(tx, id)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			if group.Status != models.GroupStatusOpen || group.ExpireAt == nil || now.Before(*group.ExpireAt) {
+				return nil
+			}
+			err = s.groupBuyRepo.UpdateGroup(tx, group.ID, map[string]interface{}{
+				"status":      models.GroupStatusFailed,
+				"finished_at": now,
+			})
+			if err != nil {
+				return err
+			}
+
+			pending, err := s.groupBuyRepo.GetMembersByStatus(tx, group.ID, models.GroupMemberPending)
+			if err != nil {
+				return err
+			}
+			for _, member := range pending {
+				// 订单已支付但尚未通知时不取消，支付通知到达后自动退款
+				if _, err := s.cancelOrder(tx, orderEvents, member.OrderID, "拼团失败"); err != nil {
+					return err
+				}
+			}
+
+			paid, err := s.groupBuyRepo.GetMembersByStatus(tx, group.ID, models.GroupMemberPaid)
+			if err != nil {
+				return err
+			}
+			for _, member := range paid {
+				if err := s.markRefunding(tx, member, models.GroupMemberPaid); err != nil {
+					return err
+				}
+			}
+			failed++
+			return nil
+		})
+		if err != nil {
+			return failed, err
+		}
+	}
+	return failed, nil
+}
+
+// ProcessRefunds 通过支付渠道为等待退款的成员退款，退款单号固定，重试不会重复退款。返回退款成功数
+func (s *GroupBuyService) ProcessRefunds(ctx context.Context) (int, error) {
+	maxAttempts := config.GlobalConfig.GroupBuy.MaxRefundAttempts
+	members, err := s.groupBuyRepo.GetRefunding(maxAttempts, groupBuyBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	orderEvents := NewOrderEventService()
+	refunded := 0
+	var notifications []*models.Notification
+	for _, member := range members {
+		if ctx.Err() != nil {
+			break
+		}
+		order, err := s.orderRepo.GetByID(models.DB, member.OrderID)
+		if err != nil {
+			return refunded, err
+		}
+
+		result, err := payment.Refund(ctx, &payment.RefundRequest{
+			OrderNo:  order.OrderNo,
+			RefundNo: member.RefundNo,
+			Amount:   order.PayAmount,
+			Reason:   "拼团失败",
+		})
+		if err != nil {
+			log.Printf("Failed to refund group buy order %s: %v", order.OrderNo, err)
+			reason := []rune(err.Error())
+			if len(reason) > 255 {
+				reason = reason[:255]
+			}
+			if err := s.groupBuyRepo.RecordRefundFailure(member.ID, string(reason)); err != nil {
+				return refunded, err
+			}
+			continue
+		}
+
+		err = models.DB.Transaction(func(tx *gorm.DB) error {
+			ok, err := s.groupBuyRepo.UpdateMember(tx, member.ID, models.GroupMemberRefunding, map[string]interface{}{
+				"status":       models.GroupMemberRefunded,
+				"refund_id":    result.ProviderID,
+				"refund_error": "",
+				"refunded_at":  time.Now(),
+			})
+			if err != nil || !ok {
+				return err
+			}
+			cancelled, err := s.orderRepo.CancelRefunded(tx, order.ID, "拼团失败，已退款")
+			if err != nil {
+				return err
+			}
+			// 订单在标记等待退款前已取消时库存已退回
+			if cancelled {
+				if err := s.restoreStock(tx, member); err != nil {
+					return err
+				}
+			}
+			return orderEvents.Refunded(tx, order)
+		})
+		if err != nil {
+			return refunded, err
+		}
+		refunded++
+		notifications = append(notifications, &models.Notification{
+			UserID:  member.UserID,
+			Type:    models.NotificationGroupBuy,
+			Title:   "拼团失败，已为您退款",
+			Content: fmt.Sprintf("订单%s未能在时限内成团，已原路退款%.2f元", order.OrderNo, order.PayAmount),
+			Link:    fmt.Sprintf("/orders/%d", order.ID),
+		})
+	}
+
+	if len(notifications) > 0 {
+		if err := s.notificationService.Send(ctx, notifications); err != nil {
+			log.Printf("Failed to send group buy refund notifications: %v", err)
+		}
+	}
+	return refunded, nil
+}
+
+// RetryRefund 重置退款失败次数，由后台任务重新退款（管理员）
+func (s *GroupBuyService) RetryRefund(memberID uint64) (*models.GroupBuyMember, error) {
+	member, err := s.groupBuyRepo.GetMemberByID(memberID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupMemberNotFound
+		}
+		return nil, err
+	}
+	if member.Status != models.GroupMemberRefunding || member.RefundAttempts == 0 {
+		return nil, ErrGroupRefundNotFailed
+	}
+	_, err = s.groupBuyRepo.UpdateMember(models.DB, member.ID, models.GroupMemberRefunding, map[string]interface{}{
+		"refund_attempts": 0,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.groupBuyRepo.GetMemberByID(memberID)
+}
+
+// RunWorker 定期取消超时未支付的订单、处理超时未成团的团并退款
+func (s *GroupBuyService) RunWorker(ctx context.Context) {
+	interval := time.Duration(config.GlobalConfig.GroupBuy.WorkerIntervalSeconds) * time.Second
+	runPeriodic(ctx, utils.GroupBuyWorkerLockKey, interval, func(ctx context.Context) {
+		if count, err := s.CancelExpired(); err != nil {
+			log.Printf("Failed to cancel expired group buy orders: %v", err)
+		} else if count > 0 {
+			log.Printf("Cancelled %d expired group buy orders", count)
+		}
+		if count, err := s.FailExpired(); err != nil {
+			log.Printf("Failed to close expired groups: %v", err)
+		} else if count > 0 {
+			log.Printf("Closed %d expired groups", count)
+		}
+		if count, err := s.ProcessRefunds(ctx); err != nil {
+			log.Printf("Failed to refund failed groups: %v", err)
+		} else if count > 0 {
+			log.Printf("Refunded %d group buy orders", count)
+		}
+	})
+}
+
+// GetGroupBuys 分页获取拼团活动（管理员），包括停用和已结束的
+func (s *GroupBuyService) GetGroupBuys(page, pageSize int) ([]*models.GroupBuy, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.groupBuyRepo.GetGroupBuys(page, pageSize)
+}
+
+// GetGroups 分页获取活动的团及成员（管理员）
+func (s *GroupBuyService) GetGroups(groupBuyID uint64, status string, page, pageSize int) ([]*models.GroupBuyGroup, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	if _, err := s.GetGroupBuy(groupBuyID); err != nil {
+		return nil, 0, err
+	}
+	return s.groupBuyRepo.GetGroups(groupBuyID, status, page, pageSize)
+}
+
+// validateInput 校验活动时间、成团人数和购买数量
+func (s *GroupBuyService) validateInput(input *GroupBuyInput) error {
+	if !input.EndAt.After(input.StartAt) {
+		return ErrGroupBuySchedule
+	}
+	if input.MaxQuantity <= 0 {
+		input.MaxQuantity = 1
+	}
+	return nil
+}
+
+// CreateGroupBuy 创建拼团活动
+func (s *GroupBuyService) CreateGroupBuy(input *GroupBuyInput) (*models.GroupBuy, error) {
+	if err := s.validateInput(input); err != nil {
+		return nil, err
+	}
+	product, err := s.productRepo.GetByID(input.ProductID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	if product.Status != 1 {
+		return nil, ErrProductOffShelf
+	}
+	sku, err := s.productRepo.GetSKU(input.ProductID, input.SKUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSKUNotFound
+		}
+		return nil, err
+	}
+	if input.GroupPrice >= sku.Price {
+		return nil, ErrGroupBuyPrice
+	}
+
+	image := sku.Image
+	if image == "" {
+		if images := product.GetImages(); len(images) > 0 {
+			image = images[0]
+		}
+	}
+	groupBuy := &models.GroupBuy{
+		Name:          input.Name,
+		ProductID:     product.ID,
+		SKUID:         sku.ID,
+		ProductName:   product.Name,
+		SKUName:       sku.Name,
+		Image:         image,
+		OriginalPrice: sku.Price,
+		GroupPrice:    input.GroupPrice,
+		GroupSize:     input.GroupSize,
+		DurationHours: input.DurationHours,
+		MaxQuantity:   input.MaxQuantity,
+		StartAt:       input.StartAt,
+		EndAt:         input.EndAt,
+		Status:        input.Status,
+	}
+	if err := s.groupBuyRepo.Create(groupBuy); err != nil {
+		return nil, err
+	}
+	return groupBuy, nil
+}
+
+// UpdateGroupBuy 更新拼团活动，拼团价和成团人数只对之后开的团生效
+func (s *GroupBuyService) UpdateGroupBuy(id uint64, input *GroupBuyInput) (*models.GroupBuy, error) {
+	groupBuy, err := s.GetGroupBuy(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateInput(input); err != nil {
+		return nil, err
+	}
+	if input.GroupPrice >= groupBuy.OriginalPrice {
+		return nil, ErrGroupBuyPrice
+	}
+
+	err = s.groupBuyRepo.Update(models.DB, id, map[string]interface{}{
+		"name":           input.Name,
+		"group_price":    input.GroupPrice,
+		"group_size":     input.GroupSize,
+		"duration_hours": input.DurationHours,
+		"max_quantity":   input.MaxQuantity,
+		"start_at":       input.StartAt,
+		"end_at":         input.EndAt,
+		"status":         input.Status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetGroupBuy(id)
+}
+
+// UpdateStatus 启用或停用拼团活动，停用后不能开团和参团，已开的团到期后按规则成团或失败
+func (s *GroupBuyService) UpdateStatus(id uint64, status int) (*models.GroupBuy, error) {
+	if _, err := s.GetGroupBuy(id); err != nil {
+		return nil, err
+	}
+	if err := s.groupBuyRepo.Update(models.DB, id, map[string]interface{}{"status": status}); err != nil {
+		return nil, err
+	}
+	return s.GetGroupBuy(id)
+}
+
+// IsGroupBuyError 判断是否为拼团业务错误（可直接返回给用户）
+func IsGroupBuyError(err error) bool {
+	return errors.Is(err, ErrGroupBuyNotFound) ||
+		errors.Is(err, ErrGroupBuyNotActive) ||
+		errors.Is(err, ErrGroupBuyPrice) ||
+		errors.Is(err, ErrGroupBuySchedule) ||
+		errors.Is(err, ErrGroupBuyQuantity) ||
+		errors.Is(err, ErrGroupNotFound) ||
+		errors.Is(err, ErrGroupClosed) ||
+		errors.Is(err, ErrGroupFull) ||
+		errors.Is(err, ErrGroupJoined) ||
+		errors.Is(err, ErrGroupMemberNotFound) ||
+		errors.Is(err, ErrGroupRefundNotFailed) ||
+		errors.Is(err, ErrGroupNotSucceeded) ||
+		errors.Is(err, ErrAddressNotFound) ||
+		errors.Is(err, ErrSKUNotFound) ||
+		errors.Is(err, ErrProductNotFound) ||
+		errors.Is(err, ErrProductOffShelf) ||
+		errors.Is(err, ErrStockInsufficient)
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"time"
+)
+
+// 首页公共区块
+const (
+	HomeSectionBanners    = "banners" // 未配置轮播图内容位时使用的热门商品轮播图
+	HomeSectionCategories = "categories"
+	HomeSectionPopular    = "popular"
+	HomeSectionHot        = "hot"
+	HomeSectionNew        = "new"
+)
+
+// HomeSlot 首页内容位（轮播图、促销位、公告）
+type HomeSlot struct {
+	Title      string `json:"title"`
+	Image      string `json:"image,omitempty"`
+	Content    string `json:"content,omitempty"`
+	LinkType   string `json:"link_type"`   // 跳转类型：none、product、category、url
+	LinkTarget string `json:"link_target"` // 商品ID、分类ID或URL
+}
+
+// HomeCategory 首页分类导航，附带子分类
+type HomeCategory struct {
+	*models.Category
+	Children []*models.Category `json:"children"`
+}
+
+// HomeFeed 首页数据
+type HomeFeed struct {
+	Banners         []*HomeSlot       `json:"banners"`
+	Promos          []*HomeSlot       `json:"promos"`
+	Announcements   []*HomeSlot       `json:"announcements"`
+	Categories      []*HomeCategory   `json:"categories"`
+	Recommendations []*models.Product `json:"recommendations"`
+	Personalized    bool              `json:"personalized"` // 推荐商品是否根据用户浏览和购买记录生成
+	Hot             []*models.Product `json:"hot"`
+	New             []*models.Product `json:"new"`
+}
+
+// HomeService 首页业务逻辑层。
+// 各区块分别缓存：公共区块所有用户共享，个性化推荐按用户缓存；单个区块加载失败时返回空列表，不影响其他区块
+type HomeService struct {
+	productRepo      *repository.ProductRepository
+	categoryRepo     *repository.CategoryRepository
+	browseService    *BrowseService
+	recommendService *RecommendService
+	contentService   *ContentService
+}
+
+// NewHomeService 创建首页Service实例
+func NewHomeService() *HomeService {
+	return &HomeService{
+		productRepo:      repository.NewProductRepository(),
+		categoryRepo:     repository.NewCategoryRepository(),
+		browseService:    NewBrowseService(),
+		recommendService: NewRecommendService(),
+		contentService:   NewContentService(),
+	}
+}
+
+// cached 优先从缓存读取区块数据到dest，未命中时调用load填充dest并写入缓存
+func (s *HomeService) cached(ctx context.Context, key string, ttl time.Duration, dest interface{}, load func() error) error {
+	if cached, err := utils.Get(ctx, key); err == nil {
+		if json.Unmarshal([]byte(cached), dest) == nil {
+			return nil
+		}
+	}
+
+	if err := load(); err != nil {
+		return err
+	}
+	if data, err := json.Marshal(dest); err == nil {
+		if err := utils.Set(ctx, key, string(data), ttl); err != nil {
+			log.Printf("Failed to cache home section %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// section 加载公共区块，失败时记录日志并保持dest为空
+func (s *HomeService) section(ctx context.Context, name string, dest interface{}, load func() error) {
+	ttl := time.Duration(config.GlobalConfig.Home.CacheSeconds) * time.Second
+	if err := s.cached(ctx, fmt.Sprintf(utils.HomeSectionKey, name), ttl, dest, load); err != nil {
+		log.Printf("Failed to load home section %s: %v", name, err)
+	}
+}
+
+// GetFeed 获取首页数据，userID为0（未登录）时推荐商品为近期人气商品
+func (s *HomeService) GetFeed(ctx context.Context, userID uint64) *HomeFeed {
+	cfg := config.GlobalConfig.Home
+	feed := &HomeFeed{}
+
+	feed.Banners = s.loadSlots(ctx, models.ContentPositionBanner, cfg.BannerSize)
+	if len(feed.Banners) == 0 {
+		s.section(ctx, HomeSectionBanners, &feed.Banners, func() error {
+			banners, err := s.loadProductBanners(cfg.BannerSize)
+			feed.Banners = banners
+			return err
+		})
+	}
+	feed.Promos = s.loadSlots(ctx, models.ContentPositionPromo, contentActiveLimit)
+	feed.Announcements = s.loadSlots(ctx, models.ContentPositionAnnouncement, contentActiveLimit)
+	s.section(ctx, HomeSectionCategories, &feed.Categories, func() error {
+		categories, err := s.loadCategories(cfg.CategorySize)
+		feed.Categories = categories
+		return err
+	})
+	s.section(ctx, HomeSectionHot, &feed.Hot, func() error {
+		products, err := s.productRepo.GetHotProducts(cfg.ListSize)
+		feed.Hot = products
+		return err
+	})
+	s.section(ctx, HomeSectionNew, &feed.New, func() error {
+		products, err := s.productRepo.GetNewProducts(cfg.ListSize)
+		feed.New = products
+		return err
+	})
+
+	if userID > 0 {
+		key := fmt.Sprintf(utils.HomeRecommendKey, userID)
+		ttl := time.Duration(cfg.PersonalCacheSeconds) * time.Second
+		err := s.cached(ctx, key, ttl, &feed.Recommendations, func() error {
+			products, err := s.recommendService.GetForUser(ctx, userID, cfg.RecommendSize)
+			feed.Recommendations = products
+			return err
+		})
+		if err != nil {
+			log.Printf("Failed to load home recommendations for user %d: %v", userID, err)
+		}
+		feed.Personalized = len(feed.Recommendations) > 0
+	}
+	// 未登录或没有浏览和购买记录的用户展示人气商品
+	if !feed.Personalized {
+		s.section(ctx, HomeSectionPopular, &feed.Recommendations, func() error {
+			products, err := s.loadPopular(cfg.RecommendSize)
+			feed.Recommendations = products
+			return err
+		})
+	}
+
+	if feed.Banners == nil {
+		feed.Banners = []*HomeSlot{}
+	}
+	if feed.Categories == nil {
+		feed.Categories = []*HomeCategory{}
+	}
+	if feed.Recommendations == nil {
+		feed.Recommendations = []*models.Product{}
+	}
+	if feed.Hot == nil {
+		feed.Hot = []*models.Product{}
+	}
+	if feed.New == nil {
+		feed.New = []*models.Product{}
+	}
+	return feed
+}
+
+// loadSlots 获取某位置当前生效的内容位，失败时记录日志并返回空列表
+func (s *HomeService) loadSlots(ctx context.Context, position string, limit int) []*HomeSlot {
+	slots, err := s.contentService.GetActive(ctx, position)
+	if err != nil {
+		log.Printf("Failed to load content slots %s: %v", position, err)
+		return []*HomeSlot{}
+	}
+	result := make([]*HomeSlot, 0, len(slots))
+	for _, slot := range slots {
+		if len(result) >= limit {
+			break
+		}
+		result = append(result, &HomeSlot{
+			Title:      slot.Title,
+			Image:      slot.Image,
+			Content:    slot.Content,
+			LinkType:   slot.LinkType,
+			LinkTarget: slot.LinkTarget,
+		})
+	}
+	return result
+}
+
+// loadProductBanners 用热门商品的主图生成轮播图
+func (s *HomeService) loadProductBanners(limit int) ([]*HomeSlot, error) {
+	products, err := s.productRepo.GetHotProducts(limit)
+	if err != nil {
+		return nil, err
+	}
+	banners := make([]*HomeSlot, 0, len(products))
+	for _, product := range products {
+		images := product.GetImages()
+		if len(images) == 0 {
+			continue
+		}
+		banners = append(banners, &HomeSlot{
+			Title:      product.Name,
+			Image:      images[0],
+			LinkType:   models.ContentLinkProduct,
+			LinkTarget: fmt.Sprintf("%d", product.ID),
+		})
+	}
+	return banners, nil
+}
+
+// loadCategories 获取显示中的顶级分类及其子分类
+func (s *HomeService) loadCategories(limit int) ([]*HomeCategory, error) {
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint64][]*models.Category)
+	var result []*HomeCategory
+	for _, category := range categories {
+		if category.Status != 1 {
+			continue
+		}
+		if category.ParentID == 0 {
+			if len(result) < limit {
+				result = append(result, &HomeCategory{Category: category})
+			}
+			continue
+		}
+		children[category.ParentID] = append(children[category.ParentID], category)
+	}
+	for _, category := range result {
+		category.Children = children[category.ID]
+		if category.Children == nil {
+			category.Children = []*models.Category{}
+		}
+	}
+	return result, nil
+}
+
+// loadPopular 获取人气商品，还没有浏览数据时使用热门商品
+func (s *HomeService) loadPopular(limit int) ([]*models.Product, error) {
+	products, err := s.browseService.GetPopularProducts(limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) > 0 {
+		return products, nil
+	}
+	return s.productRepo.GetHotProducts(limit)
+}
+
+// InvalidateSection 删除公共区块缓存，区块数据变更后调用
+func (s *HomeService) InvalidateSection(ctx context.Context, name string) error {
+	return utils.Del(ctx, fmt.Sprintf(utils.HomeSectionKey, name))
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"online-mall/internal/config"
+	"online-mall/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LoginLimitStatus 登录限制状态
+type LoginLimitStatus struct {
+	Locked          bool          // 账号或IP已被锁定
+	RetryAfter      time.Duration // 需要等待的时间
+	Failures        int64         // 当前账号失败次数
+	CaptchaRequired bool          // 是否需要验证码
+}
+
+// LoginLimitService 登录失败次数限制
+type LoginLimitService struct{}
+
+// NewLoginLimitService 创建登录限制Service实例
+func NewLoginLimitService() *LoginLimitService {
+	return &LoginLimitService{}
+}
+
+// normalizeAccount 规范化登录账号
+func normalizeAccount(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
+
+// captchaRequired 判断失败次数是否达到验证码阈值
+func captchaRequired(failures int64) bool {
+	threshold := config.GlobalConfig.Login.CaptchaThreshold
+	return threshold > 0 && failures >= int64(threshold)
+}
+
+// getCount 读取计数，key不存在时返回0
+func getCount(ctx context.Context, key string) (int64, error) {
+	value, err := utils.Get(ctx, key)
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// remaining 获取key剩余有效期，不存在时返回0
+func remaining(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := utils.TTL(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// Check 登录前检查账号和IP是否允许尝试
+func (s *LoginLimitService) Check(ctx context.Context, account string, ip string) (*LoginLimitStatus, error) {
+	account = normalizeAccount(account)
+	status := &LoginLimitStatus{}
+
+	// 锁定检查（IP优先）
+	for _, key := range []string{
+		fmt.Sprintf(utils.LoginLockIPKey, ip),
+		fmt.Sprintf(utils.LoginLockAccountKey, account),
+	} {
+		ttl, err := remaining(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if ttl > 0 {
+			status.Locked = true
+			status.RetryAfter = ttl
+			return status, nil
+		}
+	}
+
+	// 递增延迟检查
+	ttl, err := remaining(ctx, fmt.Sprintf(utils.LoginDelayAccountKey, account))
+	if err != nil {
+		return nil, err
+	}
+	status.RetryAfter = ttl
+
+	failures, err := getCount(ctx, fmt.Sprintf(utils.LoginFailAccountKey, account))
+	if err != nil {
+		return nil, err
+	}
+	status.Failures = failures
+	status.CaptchaRequired = captchaRequired(failures)
+
+	return status, nil
+}
+
+// incrWithWindow 计数加一，首次计数时设置统计窗口
+func incrWithWindow(ctx context.Context, key string, window time.Duration) (int64, error) {
+	count, err := utils.Incr(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := utils.Expire(ctx, key, window); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// RecordFailure 记录一次登录失败，达到上限时锁定账号或IP
+func (s *LoginLimitService) RecordFailure(ctx context.Context, account string, ip string) (*LoginLimitStatus, error) {
+	cfg := config.GlobalConfig.Login
+	account = normalizeAccount(account)
+	window := time.Duration(cfg.FailureWindowMinutes) * time.Minute
+	lockTTL := time.Duration(cfg.LockMinutes) * time.Minute
+	status := &LoginLimitStatus{}
+
+	ipKey := fmt.Sprintf(utils.LoginFailIPKey, ip)
+	ipFailures, err := incrWithWindow(ctx, ipKey, window)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxIPFailures > 0 && ipFailures >= int64(cfg.MaxIPFailures) {
+		if err := utils.Set(ctx, fmt.Sprintf(utils.LoginLockIPKey, ip), 1, lockTTL); err != nil {
+			return nil, err
+		}
+		if err := utils.Del(ctx, ipKey); err != nil {
+			return nil, err
+		}
+		status.Locked = true
+		status.RetryAfter = lockTTL
+	}
+
+	accountKey := fmt.Sprintf(utils.LoginFailAccountKey, account)
+	failures, err := incrWithWindow(ctx, accountKey, window)
+	if err != nil {
+		return nil, err
+	}
+	status.Failures = failures
+	status.CaptchaRequired = captchaRequired(failures)
+
+	if cfg.MaxAccountFailures > 0 && failures >= int64(cfg.MaxAccountFailures) {
+		if err := utils.Set(ctx, fmt.Sprintf(utils.LoginLockAccountKey, account), 1, lockTTL); err != nil {
+			return nil, err
+		}
+		if err := utils.Del(ctx, accountKey); err != nil {
+			return nil, err
+		}
+		status.Locked = true
+		status.RetryAfter = lockTTL
+		return status, nil
+	}
+
+	// 超过阈值后每次失败延迟翻倍
+	if failures > int64(cfg.DelayAfterFailures) && cfg.BaseDelaySeconds > 0 {
+		exp := failures - int64(cfg.DelayAfterFailures) - 1
+		if exp > 16 {
+			exp = 16
+		}
+		delay := time.Duration(cfg.BaseDelaySeconds) * time.Second << uint(exp)
+		if maxDelay := time.Duration(cfg.MaxDelaySeconds) * time.Second; maxDelay > 0 && delay > maxDelay {
+			delay = maxDelay
+		}
+		if err := utils.Set(ctx, fmt.Sprintf(utils.LoginDelayAccountKey, account), 1, delay); err != nil {
+			return nil, err
+		}
+		if !status.Locked {
+			status.RetryAfter = delay
+		}
+	}
+
+	return status, nil
+}
+
+// Reset 登录成功后清除账号的失败记录
+func (s *LoginLimitService) Reset(ctx context.Context, account string) error {
+	account = normalizeAccount(account)
+	return utils.Del(ctx,
+		fmt.Sprintf(utils.LoginFailAccountKey, account),
+		fmt.Sprintf(utils.LoginDelayAccountKey, account),
+	)
+}
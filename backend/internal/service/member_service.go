@@ -0,0 +1,480 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrMemberLevelNotFound 会员等级不存在
+	ErrMemberLevelNotFound = errors.New("会员等级不存在")
+
+	// ErrMemberLevelExists 成长值门槛重复
+	ErrMemberLevelExists = errors.New("已存在相同成长值门槛的等级")
+
+	// ErrMemberLevelInUse 等级下还有会员
+	ErrMemberLevelInUse = errors.New("该等级下还有会员，无法删除")
+
+	// ErrCouponNotFound 优惠券不存在
+	ErrCouponNotFound = errors.New("优惠券不存在")
+
+	// ErrMemberPriceInvalid 会员价不合法
+	ErrMemberPriceInvalid = errors.New("会员价不能高于SKU原价")
+)
+
+// MemberLevelInput 创建/更新会员等级参数（更新时整体替换）
+type MemberLevelInput struct {
+	Name                  string
+	MinGrowth             int64
+	Discount              float64
+	FreeShippingThreshold *float64
+	Icon                  string
+	Description           string
+	Coupons               []*models.MemberLevelCoupon
+}
+
+// MemberInfo 用户会员信息
+type MemberInfo struct {
+	GrowthValue  int64               `json:"growth_value"`
+	Level        *models.MemberLevel `json:"level"`
+	NextLevel    *models.MemberLevel `json:"next_level"`
+	GrowthToNext int64               `json:"growth_to_next"` // 距下一等级还需的成长值，已是最高等级时为0
+}
+
+// MemberBenefits 用户当前等级的权益
+type MemberBenefits struct {
+	LevelID               uint64
+	LevelName             string
+	Discount              float64
+	FreeShippingThreshold *float64
+}
+
+// SKUPriceView SKU及当前用户可享的会员价
+type SKUPriceView struct {
+	*models.ProductSKU
+	MemberPrice *float64 `json:"member_price,omitempty"`
+}
+
+// MemberService 会员等级和成长值业务逻辑层
+type MemberService struct {
+	memberRepo *repository.MemberRepository
+	couponRepo *repository.CouponRepository
+}
+
+// NewMemberService 创建会员Service实例
+func NewMemberService() *MemberService {
+	return &MemberService{
+		memberRepo: repository.NewMemberRepository(),
+		couponRepo: repository.NewCouponRepository(),
+	}
+}
+
+// roundMoney 金额保留两位小数
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// levelFor 根据成长值确定等级，levels 需按成长值升序，未达到任何等级时返回nil
+func levelFor(growth int64, levels []*models.MemberLevel) *models.MemberLevel {
+	var matched *models.MemberLevel
+	for _, level := range levels {
+		if growth >= level.MinGrowth {
+			matched = level
+		}
+	}
+	return matched
+}
+
+// GetLevels 获取所有会员等级
+func (s *MemberService) GetLevels() ([]*models.MemberLevel, error) {
+	return s.memberRepo.GetLevels()
+}
+
+// GetMemberInfo 获取用户的成长值、当前等级和下一等级
+func (s *MemberService) GetMemberInfo(userID uint64) (*MemberInfo, error) {
+	levels, err := s.memberRepo.GetLevels()
+	if err != nil {
+		return nil, err
+	}
+
+	var growth int64
+	member, err := s.memberRepo.GetMember(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if member != nil {
+		growth = member.GrowthValue
+	}
+
+	info := &MemberInfo{GrowthValue: growth, Level: levelFor(growth, levels)}
+	for _, level := range levels {
+		if level.MinGrowth > growth {
+			info.NextLevel = level
+			info.GrowthToNext = level.MinGrowth - growth
+			break
+		}
+	}
+	return info, nil
+}
+
+// GetGrowthLogs 分页获取成长值流水
+func (s *MemberService) GetGrowthLogs(userID uint64, page, pageSize int) ([]*models.GrowthLog, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.memberRepo.GetGrowthLogs(userID, page, pageSize)
+}
+
+// GetBenefits 获取用户当前等级的权益，未达到任何等级时返回不打折的默认权益
+func (s *MemberService) GetBenefits(userID uint64) (*MemberBenefits, error) {
+	info, err := s.GetMemberInfo(userID)
+	if err != nil {
+		return nil, err
+	}
+	if info.Level == nil {
+		return &MemberBenefits{Discount: 1}, nil
+	}
+	return &MemberBenefits{
+		LevelID:               info.Level.ID,
+		LevelName:             info.Level.Name,
+		Discount:              info.Level.Discount,
+		FreeShippingThreshold: info.Level.FreeShippingThreshold,
+	}, nil
+}
+
+// MemberPrices 计算SKU的会员价：优先使用单独设置的会员价，否则按等级折扣。
+// 只返回低于原价的SKU
+func (s *MemberService) MemberPrices(benefits *MemberBenefits, skus []*models.ProductSKU) (map[uint64]float64, error) {
+	skuIDs := make([]uint64, 0, len(skus))
+	for _, sku := range skus {
+		skuIDs = append(skuIDs, sku.ID)
+	}
+	fixed, err := s.memberRepo.GetSKUMemberPrices(skuIDs, benefits.LevelID)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[uint64]float64, len(skus))
+	for _, sku := range skus {
+		price, ok := fixed[sku.ID]
+		if !ok {
+			price = roundMoney(sku.Price * benefits.Discount)
+		}
+		if price < sku.Price {
+			prices[sku.ID] = price
+		}
+	}
+	return prices, nil
+}
+
+// PriceSKUs 为SKU列表附加当前用户的会员价，userID为0时原样返回
+func (s *MemberService) PriceSKUs(userID uint64, skus []models.ProductSKU) ([]*SKUPriceView, error) {
+	views := make([]*SKUPriceView, 0, len(skus))
+	list := make([]*models.ProductSKU, 0, len(skus))
+	for i := range skus {
+		views = append(views, &SKUPriceView{ProductSKU: &skus[i]})
+		list = append(list, &skus[i])
+	}
+	if userID == 0 {
+		return views, nil
+	}
+
+	benefits, err := s.GetBenefits(userID)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := s.MemberPrices(benefits, list)
+	if err != nil {
+		return nil, err
+	}
+	for _, view := range views {
+		if price, ok := prices[view.ID]; ok {
+			view.MemberPrice = &price
+		}
+	}
+	return views, nil
+}
+
+// AddGrowth 变更成长值并重新计算等级，需在业务事务内调用。
+// 同一来源（source + sourceID）只生效一次；升级时发放途经各等级的升级礼包
+func (s *MemberService) AddGrowth(tx *gorm.DB, userID uint64, change int64, source string, sourceID uint64, remark string) error {
+	exists, err := s.memberRepo.ExistsGrowthLog(tx, userID, source, sourceID)
+	if err != nil || exists {
+		return err
+	}
+
+	member, err := s.memberRepo.GetOrCreateMemberForUpdate(tx, userID)
+	if err != nil {
+		return err
+	}
+	levels, err := s.memberRepo.GetLevels()
+	if err != nil {
+		return err
+	}
+
+	// 成长值不为负
+	balance := member.GrowthValue + change
+	if balance < 0 {
+		balance = 0
+	}
+
+	updates := map[string]interface{}{"growth_value": balance}
+	newLevel := levelFor(balance, levels)
+	var newLevelID uint64
+	if newLevel != nil {
+		newLevelID = newLevel.ID
+	}
+	if newLevelID != member.LevelID {
+		updates["level_id"] = newLevelID
+		updates["level_changed_at"] = time.Now()
+	}
+	if err := s.memberRepo.UpdateMember(tx, member.ID, updates); err != nil {
+		return err
+	}
+
+	err = s.memberRepo.CreateGrowthLog(tx, &models.GrowthLog{
+		UserID:   userID,
+		Source:   source,
+		SourceID: sourceID,
+		Change:   balance - member.GrowthValue,
+		Balance:  balance,
+		Remark:   remark,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 升级时发放礼包，降级后再次升级不重复发放
+	for _, level := range levels {
+		if level.MinGrowth > member.GrowthValue && level.MinGrowth <= balance {
+			if err := s.grantLevelCoupons(tx, userID, level); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// grantLevelCoupons 发放等级的升级礼包，已发放过或优惠券停用时跳过
+func (s *MemberService) grantLevelCoupons(tx *gorm.DB, userID uint64, level *models.MemberLevel) error {
+	if len(level.Coupons) == 0 {
+		return nil
+	}
+	created, err := s.memberRepo.CreateLevelReward(tx, userID, level.ID)
+	if err != nil || !created {
+		return err
+	}
+
+	couponIDs := make([]uint64, 0, len(level.Coupons))
+	for _, item := range level.Coupons {
+		couponIDs = append(couponIDs, item.CouponID)
+	}
+	coupons, err := s.couponRepo.GetByIDs(couponIDs)
+	if err != nil {
+		return err
+	}
+	enabled := make(map[uint64]bool, len(coupons))
+	for _, coupon := range coupons {
+		enabled[coupon.ID] = coupon.Status == 1
+	}
+
+	var userCoupons []*models.UserCoupon
+	for _, item := range level.Coupons {
+		if !enabled[item.CouponID] {
+			continue
+		}
+		for i := 0; i < item.Quantity; i++ {
+			userCoupons = append(userCoupons, &models.UserCoupon{UserID: userID, CouponID: item.CouponID})
+		}
+	}
+	return s.couponRepo.CreateUserCoupons(tx, userCoupons)
+}
+
+// validateLevelInput 校验等级参数
+func (s *MemberService) validateLevelInput(id uint64, input *MemberLevelInput) error {
+	exists, err := s.memberRepo.ExistsLevelByMinGrowth(input.MinGrowth, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrMemberLevelExists
+	}
+
+	if len(input.Coupons) == 0 {
+		return nil
+	}
+	couponIDs := make([]uint64, 0, len(input.Coupons))
+	for _, item := range input.Coupons {
+		couponIDs = append(couponIDs, item.CouponID)
+	}
+	coupons, err := s.couponRepo.GetByIDs(uniqueIDs(couponIDs))
+	if err != nil {
+		return err
+	}
+	if len(coupons) != len(uniqueIDs(couponIDs)) {
+		return ErrCouponNotFound
+	}
+	return nil
+}
+
+// uniqueIDs ID去重，保持原有顺序
+func uniqueIDs(ids []uint64) []uint64 {
+	seen := make(map[uint64]bool, len(ids))
+	result := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// CreateLevel 创建会员等级
+func (s *MemberService) CreateLevel(input *MemberLevelInput) (*models.MemberLevel, error) {
+	if err := s.validateLevelInput(0, input); err != nil {
+		return nil, err
+	}
+
+	level := &models.MemberLevel{
+		Name:                  input.Name,
+		MinGrowth:             input.MinGrowth,
+		Discount:              input.Discount,
+		FreeShippingThreshold: input.FreeShippingThreshold,
+		Icon:                  input.Icon,
+		Description:           input.Description,
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.memberRepo.CreateLevel(tx, level); err != nil {
+			return err
+		}
+		if err := s.memberRepo.ReplaceLevelCoupons(tx, level.ID, input.Coupons); err != nil {
+			return err
+		}
+		return s.recalculate(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.memberRepo.GetLevelByID(level.ID)
+}
+
+// UpdateLevel 更新会员等级，门槛变化后重新计算所有用户的等级
+func (s *MemberService) UpdateLevel(id uint64, input *MemberLevelInput) (*models.MemberLevel, error) {
+	if _, err := s.getLevel(id); err != nil {
+		return nil, err
+	}
+	if err := s.validateLevelInput(id, input); err != nil {
+		return nil, err
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.memberRepo.UpdateLevel(tx, id, map[string]interface{}{
+			"name":                    input.Name,
+			"min_growth":              input.MinGrowth,
+			"discount":                input.Discount,
+			"free_shipping_threshold": input.FreeShippingThreshold,
+			"icon":                    input.Icon,
+			"description":             input.Description,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.memberRepo.ReplaceLevelCoupons(tx, id, input.Coupons); err != nil {
+			return err
+		}
+		return s.recalculate(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.memberRepo.GetLevelByID(id)
+}
+
+// DeleteLevel 删除会员等级，等级下还有会员时不允许删除
+func (s *MemberService) DeleteLevel(id uint64) error {
+	if _, err := s.getLevel(id); err != nil {
+		return err
+	}
+	count, err := s.memberRepo.CountMembersByLevel(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrMemberLevelInUse
+	}
+
+	return models.DB.Transaction(func(tx *gorm.DB) error {
+		return s.memberRepo.DeleteLevel(tx, id)
+	})
+}
+
+// GetLevel 获取会员等级
+func (s *MemberService) GetLevel(id uint64) (*models.MemberLevel, error) {
+	return s.getLevel(id)
+}
+
+// getLevel 获取等级，不存在时返回ErrMemberLevelNotFound
+func (s *MemberService) getLevel(id uint64) (*models.MemberLevel, error) {
+	level, err := s.memberRepo.GetLevelByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMemberLevelNotFound
+		}
+		return nil, err
+	}
+	return level, nil
+}
+
+// recalculate 按最新门槛重新计算用户等级（批量调整不发放升级礼包）
+func (s *MemberService) recalculate(tx *gorm.DB) error {
+	var levels []*models.MemberLevel
+	if err := tx.Order("min_growth ASC").Find(&levels).Error; err != nil {
+		return err
+	}
+	return s.memberRepo.RecalculateLevels(tx, levels)
+}
+
+// GetSKUMemberPrices 获取SKU在各等级的会员价
+func (s *MemberService) GetSKUMemberPrices(skuID uint64) ([]*models.SKUMemberPrice, error) {
+	return s.memberRepo.GetSKUMemberPriceList(skuID)
+}
+
+// SetSKUMemberPrices 设置SKU的会员价（整体替换），prices 为 等级ID -> 价格
+func (s *MemberService) SetSKUMemberPrices(sku *models.ProductSKU, prices map[uint64]float64) ([]*models.SKUMemberPrice, error) {
+	rows := make([]*models.SKUMemberPrice, 0, len(prices))
+	for levelID, price := range prices {
+		if _, err := s.getLevel(levelID); err != nil {
+			return nil, err
+		}
+		if price < 0 || price > sku.Price {
+			return nil, ErrMemberPriceInvalid
+		}
+		rows = append(rows, &models.SKUMemberPrice{LevelID: levelID, Price: roundMoney(price)})
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		return s.memberRepo.ReplaceSKUMemberPrices(tx, sku.ID, rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.memberRepo.GetSKUMemberPriceList(sku.ID)
+}
+
+// IsMemberError 判断是否为会员业务错误（可直接返回给用户）
+func IsMemberError(err error) bool {
+	return errors.Is(err, ErrMemberLevelNotFound) ||
+		errors.Is(err, ErrMemberLevelExists) ||
+		errors.Is(err, ErrMemberLevelInUse) ||
+		errors.Is(err, ErrCouponNotFound) ||
+		errors.Is(err, ErrMemberPriceInvalid)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/pkg/notify"
+	"online-mall/internal/repository"
+)
+
+var (
+	// ErrNotificationNotFound 通知不存在
+	ErrNotificationNotFound = errors.New("通知不存在")
+)
+
+// NotificationService 站内通知业务逻辑层
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+	userRepo         *repository.UserRepository
+}
+
+// NewNotificationService 创建站内通知Service实例
+func NewNotificationService() *NotificationService {
+	return &NotificationService{
+		notificationRepo: repository.NewNotificationRepository(),
+		userRepo:         repository.NewUserRepository(),
+	}
+}
+
+// Send 批量发送站内通知；开启邮件通知时同时发送给已验证邮箱的用户，邮件发送失败只记录日志
+func (s *NotificationService) Send(ctx context.Context, notifications []*models.Notification) error {
+	if err := s.notificationRepo.CreateBatch(notifications); err != nil {
+		return err
+	}
+	if !config.GlobalConfig.Notification.Email {
+		return nil
+	}
+
+	for _, n := range notifications {
+		user, err := s.userRepo.GetByID(n.UserID)
+		if err != nil || user.Email == "" || user.EmailVerifiedAt == nil {
+			continue
+		}
+		err = notify.Send(ctx, &notify.Message{
+			Channel: notify.ChannelEmail,
+			To:      user.Email,
+			Subject: n.Title,
+			Content: n.Content,
+		})
+		if err != nil {
+			log.Printf("Failed to send notification email to user %d: %v", n.UserID, err)
+		}
+	}
+	return nil
+}
+
+// GetNotifications 分页获取通知
+func (s *NotificationService) GetNotifications(userID uint64, unreadOnly bool, page, pageSize int) ([]*models.Notification, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.notificationRepo.GetNotifications(userID, unreadOnly, page, pageSize)
+}
+
+// CountUnread 未读通知数
+func (s *NotificationService) CountUnread(userID uint64) (int64, error) {
+	return s.notificationRepo.CountUnread(userID)
+}
+
+// MarkRead 标记单条通知已读，已读的通知重复标记不报错
+func (s *NotificationService) MarkRead(userID uint64, id uint64) error {
+	updated, err := s.notificationRepo.MarkRead(userID, id)
+	if err != nil || updated {
+		return err
+	}
+	exists, err := s.notificationRepo.Exists(userID, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead 标记全部通知已读
+func (s *NotificationService) MarkAllRead(userID uint64) error {
+	_, err := s.notificationRepo.MarkRead(userID, 0)
+	return err
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// OrderEventService 订单状态变化后的联动处理（会员成长值、积分、秒杀和拼团等）。
+// 由订单流程在变更订单状态的同一事务内调用，重复调用不会重复发放
+type OrderEventService struct {
+	couponRepo       *repository.CouponRepository
+	memberService    *MemberService
+	pointsService    *PointsService
+	taskService      *TaskService
+	flashSaleService *FlashSaleService
+	groupBuyService  *GroupBuyService
+}
+
+// NewOrderEventService 创建订单事件Service实例
+func NewOrderEventService() *OrderEventService {
+	return &OrderEventService{
+		couponRepo:       repository.NewCouponRepository(),
+		memberService:    NewMemberService(),
+		pointsService:    NewPointsService(),
+		taskService:      NewTaskService(),
+		flashSaleService: NewFlashSaleService(),
+		groupBuyService:  NewGroupBuyService(),
+	}
+}
+
+// orderGrowth 按实付金额计算成长值
+func orderGrowth(amount float64) int64 {
+	return int64(math.Floor(amount * config.GlobalConfig.Member.GrowthPerYuan))
+}
+
+// orderPoints 按实付金额计算奖励积分
+func orderPoints(amount float64) int64 {
+	return int64(math.Floor(amount * config.GlobalConfig.Points.EarnPerYuan))
+}
+
+// Paid 订单支付成功，拼团订单更新成团进度
+func (s *OrderEventService) Paid(tx *gorm.DB, order *models.Order) error {
+	return s.groupBuyService.Paid(tx, order)
+}
+
+// Completed 订单完成（确认收货或自动确认）
+func (s *OrderEventService) Completed(tx *gorm.DB, order *models.Order) error {
+	remark := fmt.Sprintf("订单%s完成", order.OrderNo)
+	if growth := orderGrowth(order.PayAmount); growth > 0 {
+		err := s.memberService.AddGrowth(tx, order.UserID, growth, models.GrowthSourceOrderComplete, order.ID, remark)
+		if err != nil {
+			return err
+		}
+	}
+	if err := s.pointsService.Earn(tx, order.UserID, orderPoints(order.PayAmount), models.PointsSourceOrderComplete, order.ID, remark); err != nil {
+		return err
+	}
+	return s.taskService.Complete(tx, order.UserID, models.TaskFirstOrder)
+}
+
+// Cancelled 订单取消，退回下单时使用的优惠券、抵扣的积分、秒杀库存和拼团名额
+func (s *OrderEventService) Cancelled(tx *gorm.DB, order *models.Order) error {
+	if order.UserCouponID != nil {
+		if _, err := s.couponRepo.ReturnOrderCoupon(tx, order.ID); err != nil {
+			return err
+		}
+	}
+	if err := s.pointsService.RollbackRedemption(tx, order); err != nil {
+		return err
+	}
+	if err := s.flashSaleService.Release(tx, order); err != nil {
+		return err
+	}
+	return s.groupBuyService.Release(tx, order)
+}
+
+// Refunded 订单整单退款，退回抵扣的积分；已完成的订单同时扣回获得的积分和成长值
+func (s *OrderEventService) Refunded(tx *gorm.DB, order *models.Order) error {
+	if err := s.pointsService.RollbackRedemption(tx, order); err != nil {
+		return err
+	}
+	if err := s.pointsService.RevokeOrderPoints(tx, order); err != nil {
+		return err
+	}
+
+	earned, err := s.memberService.memberRepo.GetGrowthLog(tx, order.UserID, models.GrowthSourceOrderComplete, order.ID)
+	if err != nil {
+		// 未完成的订单没有发放成长值，无需扣回
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return s.memberService.AddGrowth(tx, order.UserID, -earned.Change, models.GrowthSourceOrderRefund, order.ID,
+		fmt.Sprintf("订单%s退款", order.OrderNo))
+}
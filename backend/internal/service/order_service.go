@@ -0,0 +1,477 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/pkg/payment"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrOrderNotFound 订单不存在
+	ErrOrderNotFound = errors.New("订单不存在")
+
+	// ErrOrderNotShippable 订单不能发货
+	ErrOrderNotShippable = errors.New("订单未支付或已发货")
+
+	// ErrOrderNotShipped 订单不能确认收货
+	ErrOrderNotShipped = errors.New("订单未发货或已完成")
+
+	// ErrOrderNotCancellable 订单不能取消
+	ErrOrderNotCancellable = errors.New("只有未支付的订单可以取消")
+
+	// ErrOrderRefundNotFailed 退款未失败，无需重试
+	ErrOrderRefundNotFailed = errors.New("该订单没有失败的退款")
+)
+
+// OrderInput 结算下单参数
+type OrderInput struct {
+	Items        []*CheckoutItem
+	UsePoints    int64
+	UserCouponID *uint64
+	AddressID    uint64
+	Remark       string
+}
+
+// orderBatchSize 后台任务每轮处理的订单数
+const orderBatchSize = 200
+
+// OrderService 订单业务逻辑层。
+// 结算下单时按结算计价结果创建订单，在同一事务内扣减库存、使用优惠券和扣减积分；
+// 订单状态变化时在同一事务内通过OrderEventService触发联动处理，确认收货和超时自动确认都会发放成长值和积分
+type OrderService struct {
+	orderRepo           *repository.OrderRepository
+	productRepo         *repository.ProductRepository
+	couponRepo          *repository.CouponRepository
+	checkoutService     *CheckoutService
+	pointsService       *PointsService
+	groupBuyService     *GroupBuyService
+	orderEvents         *OrderEventService
+	notificationService *NotificationService
+}
+
+// NewOrderService 创建订单Service实例
+func NewOrderService() *OrderService {
+	return &OrderService{
+		orderRepo:           repository.NewOrderRepository(),
+		productRepo:         repository.NewProductRepository(),
+		couponRepo:          repository.NewCouponRepository(),
+		checkoutService:     NewCheckoutService(),
+		pointsService:       NewPointsService(),
+		groupBuyService:     NewGroupBuyService(),
+		orderEvents:         NewOrderEventService(),
+		notificationService: NewNotificationService(),
+	}
+}
+
+// GetOrders 分页获取用户的订单，status为空时获取全部
+func (s *OrderService) GetOrders(userID uint64, status *int, page, pageSize int) ([]*models.Order, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.orderRepo.GetUserOrders(userID, status, page, pageSize)
+}
+
+// GetOrder 获取用户的订单详情
+func (s *OrderService) GetOrder(userID uint64, id uint64) (*models.Order, error) {
+	order, err := s.orderRepo.GetUserOrder(userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// Create 结算下单：按结算计价结果创建待支付订单，扣减库存、使用优惠券并扣减积分
+func (s *OrderService) Create(userID uint64, input *OrderInput) (*models.Order, error) {
+	quote, err := s.checkoutService.Quote(userID, input.Items, input.UsePoints, input.UserCouponID)
+	if err != nil {
+		return nil, err
+	}
+
+	order := &models.Order{
+		UserID:          userID,
+		OrderType:       models.OrderTypeNormal,
+		TotalAmount:     quote.TotalAmount,
+		Freight:         quote.Freight,
+		DiscountAmount:  roundMoney(quote.MemberDiscount + quote.PromotionDiscount + quote.CouponDiscount),
+		PromotionAmount: quote.PromotionDiscount,
+		CouponAmount:    quote.CouponDiscount,
+		PointsUsed:      quote.PointsUsed,
+		PointsAmount:    quote.PointsAmount,
+		PayAmount:       quote.PayAmount,
+		PayStatus:       models.PayStatusUnpaid,
+		OrderStatus:     models.OrderStatusPending,
+		Remark:          input.Remark,
+	}
+	if quote.Coupon != nil {
+		order.UserCouponID = &quote.Coupon.UserCouponID
+	}
+
+	skuIDs := make([]uint64, 0, len(quote.Items))
+	for _, item := range quote.Items {
+		skuIDs = append(skuIDs, item.SKUID)
+	}
+
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		address, err := s.orderRepo.GetAddress(tx, userID, input.AddressID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAddressNotFound
+			}
+			return err
+		}
+		order.SetReceiver(address)
+
+		skus, err := s.productRepo.GetSKUsByIDsForUpdate(tx, skuIDs)
+		if err != nil {
+			return err
+		}
+		skuMap := make(map[uint64]*models.ProductSKU, len(skus))
+		for _, sku := range skus {
+			skuMap[sku.ID] = sku
+		}
+		items := make([]*models.OrderItem, 0, len(quote.Items))
+		for _, item := range quote.Items {
+			sku, ok := skuMap[item.SKUID]
+			if !ok {
+				return ErrSKUNotFound
+			}
+			if sku.Stock < item.Quantity {
+				return ErrStockInsufficient
+			}
+			if err := s.productRepo.UpdateSKU(tx, sku.ID, map[string]interface{}{"stock": sku.Stock - item.Quantity}); err != nil {
+				return err
+			}
+			orderItem := item.OrderItem()
+			orderItem.Specifications = sku.Specifications
+			items = append(items, orderItem)
+		}
+
+		if err := s.orderRepo.CreateWithItems(tx, order, items); err != nil {
+			return err
+		}
+		if order.UserCouponID != nil {
+			ok, err := s.couponRepo.UseCoupon(tx, *order.UserCouponID, order.ID, time.Now())
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return ErrCouponUnusable
+			}
+		}
+		return s.pointsService.Redeem(tx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// cancel 取消未支付的订单：普通订单退回库存，并触发订单取消事件。订单已支付或已取消时返回false
+func (s *OrderService) cancel(tx *gorm.DB, id uint64, reason string) (bool, error) {
+	ok, err := s.orderRepo.CancelUnpaid(tx, id, reason)
+	if err != nil || !ok {
+		return false, err
+	}
+	order, err := s.orderRepo.GetByID(tx, id)
+	if err != nil {
+		return false, err
+	}
+	// 秒杀和拼团订单的库存由订单取消事件退回
+	if order.OrderType == models.OrderTypeNormal {
+		items, err := s.orderRepo.GetItems(tx, order.ID)
+		if err != nil {
+			return false, err
+		}
+		for _, item := range items {
+			err := s.productRepo.UpdateSKU(tx, item.SKUID, map[string]interface{}{"stock": gorm.Expr("stock + ?", item.Quantity)})
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	if err := s.orderEvents.Cancelled(tx, order); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Cancel 用户取消未支付的订单
+func (s *OrderService) Cancel(userID uint64, id uint64, reason string) (*models.Order, error) {
+	if _, err := s.GetOrder(userID, id); err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		reason = "用户取消"
+	}
+
+	var order *models.Order
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		ok, err := s.cancel(tx, id, reason)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrOrderNotCancellable
+		}
+		order, err = s.orderRepo.GetByID(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// CancelExpired 取消超时未支付的普通订单，返回取消数。秒杀和拼团订单由各自的后台任务按各自的时限取消
+func (s *OrderService) CancelExpired() (int, error) {
+	timeout := time.Duration(config.GlobalConfig.Order.PayTimeoutMinutes) * time.Minute
+	ids, err := s.orderRepo.GetExpiredUnpaid(models.OrderTypeNormal, time.Now().Add(-timeout), orderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, id := range ids {
+		err := models.DB.Transaction(func(tx *gorm.DB) error {
+			ok, err := s.cancel(tx, id, "订单超时未支付")
+			if ok {
+				cancelled++
+			}
+			return err
+		})
+		if err != nil {
+			return cancelled, err
+		}
+	}
+	return cancelled, nil
+}
+
+// Ship 订单发货（管理员），只有已支付的待发货订单可以发货，拼团订单需拼团成功
+func (s *OrderService) Ship(ctx context.Context, id uint64, company, trackingNo string) (*models.Order, error) {
+	var order *models.Order
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = s.orderRepo.GetByID(tx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+		if order.OrderStatus != models.OrderStatusToShip || order.PayStatus != models.PayStatusPaid {
+			return ErrOrderNotShippable
+		}
+		if err := s.groupBuyService.Shippable(tx, order); err != nil {
+			return err
+		}
+		ok, err := s.orderRepo.MarkShipped(tx, id, company, trackingNo, time.Now())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrOrderNotShippable
+		}
+		order, err = s.orderRepo.GetByID(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.notificationService.Send(ctx, []*models.Notification{{
+		UserID:  order.UserID,
+		Type:    models.NotificationOrder,
+		Title:   "您的订单已发货",
+		Content: fmt.Sprintf("订单%s已由%s发出，物流单号%s", order.OrderNo, company, trackingNo),
+		Link:    fmt.Sprintf("/orders/%d", order.ID),
+	}})
+	if err != nil {
+		log.Printf("Failed to send shipping notification for order %d: %v", order.ID, err)
+	}
+	return order, nil
+}
+
+// complete 完成已发货的订单并触发订单完成事件，订单状态已变化时返回false
+func (s *OrderService) complete(tx *gorm.DB, id uint64) (bool, error) {
+	ok, err := s.orderRepo.MarkCompleted(tx, id, time.Now())
+	if err != nil || !ok {
+		return false, err
+	}
+	order, err := s.orderRepo.GetByID(tx, id)
+	if err != nil {
+		return false, err
+	}
+	if err := s.orderEvents.Completed(tx, order); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Receive 用户确认收货
+func (s *OrderService) Receive(userID uint64, id uint64) (*models.Order, error) {
+	if _, err := s.GetOrder(userID, id); err != nil {
+		return nil, err
+	}
+
+	var order *models.Order
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		ok, err := s.complete(tx, id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrOrderNotShipped
+		}
+		order, err = s.orderRepo.GetByID(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// AutoConfirm 自动确认发货超过期限仍未确认收货的订单，返回确认数
+func (s *OrderService) AutoConfirm() (int, error) {
+	days := config.GlobalConfig.Order.AutoConfirmDays
+	ids, err := s.orderRepo.GetShippedBefore(time.Now().AddDate(0, 0, -days), orderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	confirmed := 0
+	for _, id := range ids {
+		err := models.DB.Transaction(func(tx *gorm.DB) error {
+			ok, err := s.complete(tx, id)
+			if ok {
+				confirmed++
+			}
+			return err
+		})
+		if err != nil {
+			return confirmed, err
+		}
+	}
+	return confirmed, nil
+}
+
+// ProcessRefunds 通过支付渠道为取消后才收到支付的订单退款，退款单号固定，重试不会重复退款。返回退款成功数
+func (s *OrderService) ProcessRefunds(ctx context.Context) (int, error) {
+	orders, err := s.orderRepo.GetRefunding(config.GlobalConfig.Order.MaxRefundAttempts, orderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	refunded := 0
+	var notifications []*models.Notification
+	for _, order := range orders {
+		if ctx.Err() != nil {
+			break
+		}
+
+		result, err := payment.Refund(ctx, &payment.RefundRequest{
+			OrderNo:  order.OrderNo,
+			RefundNo: order.RefundNo,
+			Amount:   order.PayAmount,
+			Reason:   "订单已取消",
+		})
+		if err != nil {
+			log.Printf("Failed to refund cancelled order %s: %v", order.OrderNo, err)
+			reason := []rune(err.Error())
+			if len(reason) > 255 {
+				reason = reason[:255]
+			}
+			if err := s.orderRepo.RecordRefundFailure(order.ID, string(reason)); err != nil {
+				return refunded, err
+			}
+			continue
+		}
+
+		ok, err := s.orderRepo.MarkRefunded(models.DB, order.ID, result.ProviderID, time.Now())
+		if err != nil {
+			return refunded, err
+		}
+		if !ok {
+			continue
+		}
+		refunded++
+		notifications = append(notifications, &models.Notification{
+			UserID:  order.UserID,
+			Type:    models.NotificationOrder,
+			Title:   "订单已取消，已为您退款",
+			Content: fmt.Sprintf("订单%s在取消后才完成支付，已原路退款%.2f元", order.OrderNo, order.PayAmount),
+			Link:    fmt.Sprintf("/orders/%d", order.ID),
+		})
+	}
+
+	if len(notifications) > 0 {
+		if err := s.notificationService.Send(ctx, notifications); err != nil {
+			log.Printf("Failed to send order refund notifications: %v", err)
+		}
+	}
+	return refunded, nil
+}
+
+// RetryRefund 重置退款失败次数，由后台任务重新退款（管理员）
+func (s *OrderService) RetryRefund(id uint64) (*models.Order, error) {
+	ok, err := s.orderRepo.ResetRefundAttempts(id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if _, err := s.orderRepo.GetByID(models.DB, id); errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, ErrOrderRefundNotFailed
+	}
+	return s.orderRepo.GetByID(models.DB, id)
+}
+
+// RunWorker 定期取消超时未支付的订单、自动确认收货，并为取消后才收到支付的订单退款
+func (s *OrderService) RunWorker(ctx context.Context) {
+	interval := time.Duration(config.GlobalConfig.Order.WorkerIntervalSeconds) * time.Second
+	runPeriodic(ctx, utils.OrderWorkerLockKey, interval, func(ctx context.Context) {
+		if count, err := s.CancelExpired(); err != nil {
+			log.Printf("Failed to cancel expired orders: %v", err)
+		} else if count > 0 {
+			log.Printf("Cancelled %d expired orders", count)
+		}
+		if count, err := s.AutoConfirm(); err != nil {
+			log.Printf("Failed to auto confirm orders: %v", err)
+		} else if count > 0 {
+			log.Printf("Auto confirmed %d orders", count)
+		}
+		if count, err := s.ProcessRefunds(ctx); err != nil {
+			log.Printf("Failed to refund cancelled orders: %v", err)
+		} else if count > 0 {
+			log.Printf("Refunded %d cancelled orders", count)
+		}
+	})
+}
+
+// IsOrderError 判断是否为订单业务错误（可直接返回给用户）
+func IsOrderError(err error) bool {
+	return errors.Is(err, ErrOrderNotFound) ||
+		errors.Is(err, ErrOrderNotShippable) ||
+		errors.Is(err, ErrOrderNotShipped) ||
+		errors.Is(err, ErrOrderNotCancellable) ||
+		errors.Is(err, ErrOrderRefundNotFailed) ||
+		errors.Is(err, ErrGroupNotSucceeded) ||
+		errors.Is(err, ErrAddressNotFound) ||
+		IsCheckoutError(err)
+}
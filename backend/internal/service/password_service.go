@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/pkg/notify"
+	"online-mall/internal/pkg/password"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ErrResetTokenInvalid 重置链接无效
+var ErrResetTokenInvalid = errors.New("重置链接无效或已过期")
+
+// PasswordService 密码业务逻辑层
+type PasswordService struct {
+	tokenRepo   *repository.TokenRepository
+	authService *AuthService
+}
+
+// NewPasswordService 创建密码Service实例
+func NewPasswordService() *PasswordService {
+	return &PasswordService{
+		tokenRepo:   repository.NewTokenRepository(),
+		authService: NewAuthService(),
+	}
+}
+
+// buildResetLink 生成密码重置链接
+func buildResetLink(token string) string {
+	base := config.GlobalConfig.Verify.ResetURL
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	return base + sep + "token=" + url.QueryEscape(token)
+}
+
+// RequestReset 申请密码重置，账号为手机号或邮箱
+// 账号不存在时静默返回，避免泄露账号是否注册
+func (s *PasswordService) RequestReset(ctx context.Context, account string, ip string) error {
+	account = strings.TrimSpace(account)
+	channel := notify.ChannelSMS
+	column := "phone"
+	if strings.Contains(account, "@") {
+		channel = notify.ChannelEmail
+		column = "email"
+	}
+
+	user := &models.User{}
+	if err := models.DB.Where(column+" = ?", account).First(user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.Status != 1 {
+		return nil
+	}
+
+	rawToken, err := utils.RandomHex(32)
+	if err != nil {
+		return err
+	}
+	expireMinutes := config.GlobalConfig.Verify.ResetExpireMinutes
+
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		// 新链接生成后旧链接全部作废
+		if err := s.tokenRepo.InvalidatePasswordResets(tx, user.ID); err != nil {
+			return err
+		}
+		return s.tokenRepo.CreatePasswordReset(tx, &models.PasswordReset{
+			UserID:    user.ID,
+			TokenHash: hashRefreshToken(rawToken),
+			Channel:   channel,
+			IP:        ip,
+			ExpiresAt: time.Now().Add(time.Duration(expireMinutes) * time.Minute),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return notify.Send(ctx, &notify.Message{
+		Channel: channel,
+		To:      account,
+		Subject: "在线商城密码重置",
+		Content: fmt.Sprintf("您正在重置在线商城账号%s的密码，请在%d分钟内打开以下链接完成操作：%s 如非本人操作请忽略。",
+			user.Username, expireMinutes, buildResetLink(rawToken)),
+	})
+}
+
+// ResetPassword 使用重置令牌设置新密码，成功后吊销该用户所有登录会话
+func (s *PasswordService) ResetPassword(ctx context.Context, rawToken string, newPassword string) error {
+	var userID uint64
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		reset, err := s.tokenRepo.GetPasswordResetForUpdate(tx, hashRefreshToken(rawToken))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrResetTokenInvalid
+			}
+			return err
+		}
+		if !reset.IsActive() {
+			return ErrResetTokenInvalid
+		}
+
+		user := &models.User{}
+		if err := tx.First(user, reset.UserID).Error; err != nil {
+			return err
+		}
+		if err := password.Validate(newPassword, password.Identities(user.Username, user.Phone, user.Email)...); err != nil {
+			return err
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(user).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+
+		userID = reset.UserID
+		return s.tokenRepo.InvalidatePasswordResets(tx, reset.UserID)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.authService.RevokeAllTokens(ctx, userID)
+}
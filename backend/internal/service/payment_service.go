@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPaymentOrderNotFound 支付通知的订单不存在
+	ErrPaymentOrderNotFound = errors.New("订单不存在")
+
+	// ErrPaymentAmountMismatch 支付金额与订单应付金额不一致
+	ErrPaymentAmountMismatch = errors.New("支付金额与订单金额不一致")
+)
+
+// PaymentService 支付结果处理业务逻辑层
+type PaymentService struct {
+	orderRepo   *repository.OrderRepository
+	orderEvents *OrderEventService
+}
+
+// NewPaymentService 创建支付Service实例
+func NewPaymentService() *PaymentService {
+	return &PaymentService{
+		orderRepo:   repository.NewOrderRepository(),
+		orderEvents: NewOrderEventService(),
+	}
+}
+
+// ConfirmPaid 处理支付成功通知：在同一事务内将订单标记为已支付、记录支付渠道交易号并触发支付成功事件，重复通知不会重复处理。
+// 订单取消后才收到的支付仍记为已支付并自动退款：拼团订单由支付成功事件处理，其他订单标记为等待退款，由订单后台任务通过支付渠道退款
+func (s *PaymentService) ConfirmPaid(orderNo string, tradeNo string, amount float64, method string) error {
+	return models.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := s.orderRepo.GetByOrderNoForUpdate(tx, orderNo)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentOrderNotFound
+			}
+			return err
+		}
+		if order.PayStatus == models.PayStatusPaid {
+			return nil
+		}
+		if roundMoney(amount) != roundMoney(order.PayAmount) {
+			return ErrPaymentAmountMismatch
+		}
+
+		ok, err := s.orderRepo.MarkPaid(tx, order.ID, method, tradeNo, time.Now())
+		if err != nil || !ok {
+			return err
+		}
+		order, err = s.orderRepo.GetByID(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		if order.OrderStatus == models.OrderStatusCancelled && order.OrderType != models.OrderTypeGroupBuy {
+			log.Printf("Order %s was paid after cancellation (trade %s), queued for refund", order.OrderNo, tradeNo)
+			if err := s.orderRepo.MarkRefunding(tx, order.ID, fmt.Sprintf("PO%d", order.ID)); err != nil {
+				return err
+			}
+		}
+		return s.orderEvents.Paid(tx, order)
+	})
+}
+
+// IsPaymentError 判断是否为支付业务错误（可直接返回给支付网关）
+func IsPaymentError(err error) bool {
+	return errors.Is(err, ErrPaymentOrderNotFound) ||
+		errors.Is(err, ErrPaymentAmountMismatch)
+}
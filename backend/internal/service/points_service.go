@@ -0,0 +1,430 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPointsInsufficient 可用积分不足
+	ErrPointsInsufficient = errors.New("可用积分不足")
+
+	// ErrPointsBelowMinimum 未达到最低使用数量
+	ErrPointsBelowMinimum = errors.New("使用积分未达到最低使用数量")
+
+	// ErrPointsExceedLimit 超过本单可抵扣上限
+	ErrPointsExceedLimit = errors.New("使用积分超过本单可抵扣上限")
+)
+
+// pointsExpiringDays 积分概览中提示即将过期的天数
+const pointsExpiringDays = 30
+
+// PointsSummary 用户积分概览
+type PointsSummary struct {
+	Balance        int64     `json:"balance"`
+	ExpiringPoints int64     `json:"expiring_points"` // 即将过期的积分
+	ExpiringBefore time.Time `json:"expiring_before"`
+	PointsPerYuan  int64     `json:"points_per_yuan"` // 抵扣比例，多少积分抵1元
+}
+
+// PointsQuote 结算时的积分抵扣计算结果
+type PointsQuote struct {
+	Available int64   // 可用积分
+	MaxUsable int64   // 本单最多可用积分，未达到最低使用数量时为0
+	Used      int64   // 实际使用积分
+	Deduction float64 // 抵扣金额
+}
+
+// PointsService 积分业务逻辑层。
+// 积分按获得批次记录有效期，消耗时优先使用先过期的批次；所有变动追加到流水
+type PointsService struct {
+	pointsRepo *repository.PointsRepository
+}
+
+// NewPointsService 创建积分Service实例
+func NewPointsService() *PointsService {
+	return &PointsService{
+		pointsRepo: repository.NewPointsRepository(),
+	}
+}
+
+// pointsExpireAt 新获得积分的过期时间，有效期未配置时视为长期有效
+func pointsExpireAt(now time.Time) time.Time {
+	days := config.GlobalConfig.Points.ExpireDays
+	if days <= 0 {
+		return now.AddDate(100, 0, 0)
+	}
+	return now.AddDate(0, 0, days)
+}
+
+// pointsToCents 积分可抵扣的金额（分），不足1分的部分舍去
+func pointsToCents(points int64) int64 {
+	perYuan := config.GlobalConfig.Points.PointsPerYuan
+	if perYuan <= 0 {
+		return 0
+	}
+	return points * 100 / perYuan
+}
+
+// centsToPoints 抵扣指定金额（分）所需的积分
+func centsToPoints(cents int64) int64 {
+	perYuan := config.GlobalConfig.Points.PointsPerYuan
+	return (cents*perYuan + 99) / 100
+}
+
+// addLedger 变更积分余额并追加流水，需已锁定积分账户
+func (s *PointsService) addLedger(tx *gorm.DB, account *models.UserPoints, change int64, source string, sourceID uint64, remark string) (*models.PointsLedger, error) {
+	balance := account.Balance + change
+	if balance < 0 {
+		balance = 0
+	}
+	if err := s.pointsRepo.UpdateBalance(tx, account.ID, balance); err != nil {
+		return nil, err
+	}
+
+	ledger := &models.PointsLedger{
+		UserID:   account.UserID,
+		Source:   source,
+		SourceID: sourceID,
+		Change:   balance - account.Balance,
+		Balance:  balance,
+		Remark:   remark,
+	}
+	if err := s.pointsRepo.CreateLedger(tx, ledger); err != nil {
+		return nil, err
+	}
+	account.Balance = balance
+	return ledger, nil
+}
+
+// consume 按过期时间先后从可用批次扣减积分，最多扣减可用部分，返回各批次的扣减明细和实际扣减数
+func (s *PointsService) consume(tx *gorm.DB, userID uint64, points int64, preferBatchID uint64) ([]*models.PointsRedemptionItem, int64, error) {
+	batches, err := s.pointsRepo.GetAvailableBatchesForUpdate(tx, userID, preferBatchID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var items []*models.PointsRedemptionItem
+	var consumed int64
+	for _, batch := range batches {
+		if consumed >= points {
+			break
+		}
+		take := batch.Remaining
+		if take > points-consumed {
+			take = points - consumed
+		}
+		if err := s.pointsRepo.AdjustBatchRemaining(tx, batch.ID, -take); err != nil {
+			return nil, 0, err
+		}
+		items = append(items, &models.PointsRedemptionItem{BatchID: batch.ID, Points: take})
+		consumed += take
+	}
+	return items, consumed, nil
+}
+
+// Earn 发放积分，需在业务事务内调用。同一来源（source + sourceID）只发放一次
+func (s *PointsService) Earn(tx *gorm.DB, userID uint64, points int64, source string, sourceID uint64, remark string) error {
+	if points <= 0 {
+		return nil
+	}
+	exists, err := s.pointsRepo.ExistsLedger(tx, userID, source, sourceID)
+	if err != nil || exists {
+		return err
+	}
+
+	account, err := s.pointsRepo.GetOrCreateAccountForUpdate(tx, userID)
+	if err != nil {
+		return err
+	}
+	ledger, err := s.addLedger(tx, account, points, source, sourceID, remark)
+	if err != nil {
+		return err
+	}
+
+	return s.pointsRepo.CreateBatch(tx, &models.PointsBatch{
+		UserID:    userID,
+		LedgerID:  ledger.ID,
+		Amount:    points,
+		Remaining: points,
+		ExpiresAt: pointsExpireAt(ledger.CreatedAt),
+	})
+}
+
+// EarnForReview 发放评价奖励积分，由评价流程在保存评价的事务内调用
+func (s *PointsService) EarnForReview(tx *gorm.DB, userID uint64, reviewID uint64) error {
+	return s.Earn(tx, userID, config.GlobalConfig.Points.ReviewPoints, models.PointsSourceReview, reviewID, "商品评价奖励")
+}
+
+// Quote 计算本单积分抵扣。goodsAmount为会员价后的商品金额（不含运费），usePoints为0表示不使用积分
+func (s *PointsService) Quote(userID uint64, goodsAmount float64, usePoints int64) (*PointsQuote, error) {
+	cfg := config.GlobalConfig.Points
+	quote := &PointsQuote{}
+	if userID == 0 || cfg.PointsPerYuan <= 0 {
+		if usePoints > 0 {
+			return nil, ErrPointsExceedLimit
+		}
+		return quote, nil
+	}
+
+	available, err := s.pointsRepo.SumAvailable(userID)
+	if err != nil {
+		return nil, err
+	}
+	quote.Available = available
+
+	// 按比例计算本单最多可抵扣的积分
+	maxCents := int64(math.Floor(goodsAmount * cfg.MaxRedeemRatio * 100))
+	maxUsable := maxCents * cfg.PointsPerYuan / 100
+	if maxUsable > available {
+		maxUsable = available
+	}
+	if maxUsable >= cfg.MinRedeemPoints {
+		quote.MaxUsable = maxUsable
+	}
+
+	if usePoints <= 0 {
+		return quote, nil
+	}
+	if usePoints < cfg.MinRedeemPoints {
+		return nil, ErrPointsBelowMinimum
+	}
+	if usePoints > available {
+		return nil, ErrPointsInsufficient
+	}
+	if usePoints > quote.MaxUsable {
+		return nil, ErrPointsExceedLimit
+	}
+
+	// 只扣除实际抵扣金额所需的积分
+	cents := pointsToCents(usePoints)
+	quote.Used = centsToPoints(cents)
+	quote.Deduction = float64(cents) / 100
+	return quote, nil
+}
+
+// Redeem 按订单的PointsUsed扣减抵扣积分，需在创建订单的事务内调用，积分不足时返回错误以回滚订单
+func (s *PointsService) Redeem(tx *gorm.DB, order *models.Order) error {
+	if order.PointsUsed <= 0 {
+		return nil
+	}
+
+	account, err := s.pointsRepo.GetOrCreateAccountForUpdate(tx, order.UserID)
+	if err != nil {
+		return err
+	}
+	items, consumed, err := s.consume(tx, order.UserID, order.PointsUsed, 0)
+	if err != nil {
+		return err
+	}
+	if consumed < order.PointsUsed {
+		return ErrPointsInsufficient
+	}
+
+	if _, err := s.addLedger(tx, account, -consumed, models.PointsSourceOrderRedeem, order.ID,
+		fmt.Sprintf("订单%s抵扣", order.OrderNo)); err != nil {
+		return err
+	}
+	return s.pointsRepo.CreateRedemption(tx, &models.PointsRedemption{
+		UserID:  order.UserID,
+		OrderID: order.ID,
+		Points:  consumed,
+		Amount:  order.PointsAmount,
+	}, items)
+}
+
+// RollbackRedemption 订单取消或退款时退回抵扣的积分，恢复到原批次；原批次已过期的部分不再退回
+func (s *PointsService) RollbackRedemption(tx *gorm.DB, order *models.Order) error {
+	redemption, err := s.pointsRepo.GetRedemptionForUpdate(tx, order.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if redemption.RolledBackAt != nil {
+		return nil
+	}
+
+	account, err := s.pointsRepo.GetOrCreateAccountForUpdate(tx, redemption.UserID)
+	if err != nil {
+		return err
+	}
+	items, err := s.pointsRepo.GetRedemptionItems(tx, redemption.ID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var restored int64
+	for _, item := range items {
+		batch, err := s.pointsRepo.GetBatchForUpdate(tx, item.BatchID)
+		if err != nil {
+			return err
+		}
+		if !batch.ExpiresAt.After(now) {
+			continue
+		}
+		if err := s.pointsRepo.AdjustBatchRemaining(tx, batch.ID, item.Points); err != nil {
+			return err
+		}
+		restored += item.Points
+	}
+
+	if restored > 0 {
+		remark := fmt.Sprintf("订单%s退回抵扣积分", order.OrderNo)
+		if restored < redemption.Points {
+			remark = fmt.Sprintf("订单%s退回抵扣积分（已过期%d积分不退回）", order.OrderNo, redemption.Points-restored)
+		}
+		if _, err := s.addLedger(tx, account, restored, models.PointsSourceRedeemRollback, order.ID, remark); err != nil {
+			return err
+		}
+	}
+	return s.pointsRepo.MarkRedemptionRolledBack(tx, redemption.ID)
+}
+
+// RevokeOrderPoints 订单退款时扣回该订单完成时发放的积分，优先从该笔奖励的批次扣除，余额不足时扣至0
+func (s *PointsService) RevokeOrderPoints(tx *gorm.DB, order *models.Order) error {
+	earned, err := s.pointsRepo.GetLedger(tx, order.UserID, models.PointsSourceOrderComplete, order.ID)
+	if err != nil {
+		// 未完成的订单没有发放积分，无需扣回
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	exists, err := s.pointsRepo.ExistsLedger(tx, order.UserID, models.PointsSourceOrderRefund, order.ID)
+	if err != nil || exists {
+		return err
+	}
+
+	account, err := s.pointsRepo.GetOrCreateAccountForUpdate(tx, order.UserID)
+	if err != nil {
+		return err
+	}
+	var preferBatchID uint64
+	if batch, err := s.pointsRepo.GetBatchByLedger(tx, earned.ID); err == nil {
+		preferBatchID = batch.ID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	_, consumed, err := s.consume(tx, order.UserID, earned.Change, preferBatchID)
+	if err != nil {
+		return err
+	}
+	// 扣回数为0时也记录流水，保证同一订单只扣回一次
+	_, err = s.addLedger(tx, account, -consumed, models.PointsSourceOrderRefund, order.ID,
+		fmt.Sprintf("订单%s退款，扣回奖励积分", order.OrderNo))
+	return err
+}
+
+// expireBatch 过期单个批次的剩余积分
+func (s *PointsService) expireBatch(batch *models.PointsBatch) error {
+	return models.DB.Transaction(func(tx *gorm.DB) error {
+		account, err := s.pointsRepo.GetOrCreateAccountForUpdate(tx, batch.UserID)
+		if err != nil {
+			return err
+		}
+		// 加锁后重新检查，批次可能已被消耗
+		locked, err := s.pointsRepo.GetBatchForUpdate(tx, batch.ID)
+		if err != nil {
+			return err
+		}
+		if locked.Remaining <= 0 || locked.ExpiresAt.After(time.Now()) {
+			return nil
+		}
+
+		if err := s.pointsRepo.AdjustBatchRemaining(tx, locked.ID, -locked.Remaining); err != nil {
+			return err
+		}
+		_, err = s.addLedger(tx, account, -locked.Remaining, models.PointsSourceExpire, locked.ID,
+			fmt.Sprintf("%s获得的积分已过期", locked.CreatedAt.Format("2006-01-02")))
+		return err
+	})
+}
+
+// ExpireDue 处理已过期批次的剩余积分，返回处理的批次数
+func (s *PointsService) ExpireDue(ctx context.Context) (int, error) {
+	const batchSize = 200
+	expired := 0
+	for ctx.Err() == nil {
+		batches, err := s.pointsRepo.GetExpiredBatches(batchSize)
+		if err != nil {
+			return expired, err
+		}
+		for _, batch := range batches {
+			if err := s.expireBatch(batch); err != nil {
+				return expired, err
+			}
+			expired++
+		}
+		if len(batches) < batchSize {
+			break
+		}
+	}
+	return expired, nil
+}
+
+// RunWorker 定期处理过期积分
+func (s *PointsService) RunWorker(ctx context.Context) {
+	interval := time.Duration(config.GlobalConfig.Points.WorkerIntervalMinutes) * time.Minute
+	runPeriodic(ctx, utils.PointsWorkerLockKey, interval, func(ctx context.Context) {
+		count, err := s.ExpireDue(ctx)
+		if err != nil {
+			log.Printf("Failed to expire points: %v", err)
+		}
+		if count > 0 {
+			log.Printf("Expired %d points batches", count)
+		}
+	})
+}
+
+// GetSummary 获取用户积分概览
+func (s *PointsService) GetSummary(userID uint64) (*PointsSummary, error) {
+	account, err := s.pointsRepo.GetAccount(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := time.Now().AddDate(0, 0, pointsExpiringDays)
+	expiring, err := s.pointsRepo.SumExpiring(userID, before)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PointsSummary{
+		Balance:        account.Balance,
+		ExpiringPoints: expiring,
+		ExpiringBefore: before,
+		PointsPerYuan:  config.GlobalConfig.Points.PointsPerYuan,
+	}, nil
+}
+
+// GetLogs 分页获取积分流水，direction为earn只查收入，spend只查支出，为空查全部
+func (s *PointsService) GetLogs(userID uint64, direction string, page, pageSize int) ([]*models.PointsLedger, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.pointsRepo.GetLedgers(userID, direction, page, pageSize)
+}
+
+// IsPointsError 判断是否为积分业务错误（可直接返回给用户）
+func IsPointsError(err error) bool {
+	return errors.Is(err, ErrPointsInsufficient) ||
+		errors.Is(err, ErrPointsBelowMinimum) ||
+		errors.Is(err, ErrPointsExceedLimit)
+}
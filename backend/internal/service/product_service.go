@@ -4,8 +4,25 @@ import (
 	"errors"
 	"online-mall/internal/models"
 	"online-mall/internal/repository"
+
+	"gorm.io/gorm"
 )
 
+var (
+	// ErrSKUStockNegative 调整后库存为负
+	ErrSKUStockNegative = errors.New("调整后库存不能小于0")
+
+	// ErrCategoryNotFound 分类不存在
+	ErrCategoryNotFound = errors.New("分类不存在")
+)
+
+// SKUUpdate 更新SKU价格和库存，为nil的字段不修改
+type SKUUpdate struct {
+	ID    uint64
+	Price *float64
+	Stock *int
+}
+
 // ProductService 商品业务逻辑层
 type ProductService struct {
 	productRepo *repository.ProductRepository
@@ -41,7 +58,7 @@ func (s *ProductService) CreateProduct(product *models.Product) error {
 	// 验证分类是否存在
 	var category models.Category
 	if err := models.DB.First(&category, product.CategoryID).Error; err != nil {
-		return errors.New("分类不存在")
+		return ErrCategoryNotFound
 	}
 
 	// 设置默认值
@@ -52,23 +69,36 @@ func (s *ProductService) CreateProduct(product *models.Product) error {
 	return s.productRepo.Create(product)
 }
 
-// UpdateProduct 更新商品
-func (s *ProductService) UpdateProduct(product *models.Product) error {
+// UpdateProduct 更新商品，skuUpdates不为空时在同一事务中更新SKU价格和库存，
+// 任一SKU无效时整体回滚；返回库存由0补充为正数的SKU，由调用方在提交后发送到货提醒
+func (s *ProductService) UpdateProduct(product *models.Product, skuUpdates []*SKUUpdate) ([]uint64, error) {
 	// 检查商品是否存在
 	_, err := s.productRepo.GetByID(product.ID)
 	if err != nil {
-		return errors.New("商品不存在")
+		return nil, ErrProductNotFound
 	}
 
 	// 验证分类是否存在（如果修改了分类）
 	if product.CategoryID > 0 {
 		var category models.Category
 		if err := models.DB.First(&category, product.CategoryID).Error; err != nil {
-			return errors.New("分类不存在")
+			return nil, ErrCategoryNotFound
 		}
 	}
 
-	return s.productRepo.Update(product)
+	var replenished []uint64
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.productRepo.Update(tx, product); err != nil {
+			return err
+		}
+		var err error
+		replenished, err = s.updateSKUs(tx, product.ID, skuUpdates)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return replenished, nil
 }
 
 // DeleteProduct 删除商品
@@ -144,3 +174,89 @@ func (s *ProductService) SearchProducts(keyword string, page, pageSize int) ([]*
 	}
 	return s.productRepo.GetProducts(query)
 }
+
+// GetSKU 获取商品下的SKU
+func (s *ProductService) GetSKU(productID uint64, skuID uint64) (*models.ProductSKU, error) {
+	return s.productRepo.GetSKU(productID, skuID)
+}
+
+// updateSKUs 在事务中批量更新商品下SKU的价格和库存，返回库存由0补充为正数的SKU
+func (s *ProductService) updateSKUs(tx *gorm.DB, productID uint64, updates []*SKUUpdate) ([]uint64, error) {
+	if len(updates) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint64, 0, len(updates))
+	for _, update := range updates {
+		ids = append(ids, update.ID)
+	}
+	ids = uniqueIDs(ids)
+
+	skus, err := s.productRepo.GetSKUsForUpdate(tx, productID, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(skus) != len(ids) {
+		return nil, ErrSKUNotFound
+	}
+	skuMap := make(map[uint64]*models.ProductSKU, len(skus))
+	for _, sku := range skus {
+		skuMap[sku.ID] = sku
+	}
+
+	var replenished []uint64
+	for _, update := range updates {
+		sku := skuMap[update.ID]
+		changes := make(map[string]interface{})
+		if update.Price != nil {
+			changes["price"] = *update.Price
+		}
+		if update.Stock != nil {
+			changes["stock"] = *update.Stock
+			if sku.Stock <= 0 && *update.Stock > 0 {
+				replenished = append(replenished, sku.ID)
+			}
+			sku.Stock = *update.Stock
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		if err := s.productRepo.UpdateSKU(tx, sku.ID, changes); err != nil {
+			return nil, err
+		}
+	}
+	return uniqueIDs(replenished), nil
+}
+
+// IsProductError 判断是否为商品业务错误（可直接返回给用户）
+func IsProductError(err error) bool {
+	return errors.Is(err, ErrProductNotFound) ||
+		errors.Is(err, ErrCategoryNotFound) ||
+		errors.Is(err, ErrSKUNotFound)
+}
+
+// AdjustSKUStock 调整SKU库存：stock不为nil时设置为该值，否则按delta增减。
+// 返回调整前后的库存
+func (s *ProductService) AdjustSKUStock(productID uint64, skuID uint64, stock *int, delta int) (int, int, error) {
+	var before, after int
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		skus, err := s.productRepo.GetSKUsForUpdate(tx, productID, []uint64{skuID})
+		if err != nil {
+			return err
+		}
+		if len(skus) == 0 {
+			return ErrSKUNotFound
+		}
+
+		before = skus[0].Stock
+		after = before + delta
+		if stock != nil {
+			after = *stock
+		}
+		if after < 0 {
+			return ErrSKUStockNegative
+		}
+		return s.productRepo.UpdateSKU(tx, skuID, map[string]interface{}{"stock": after})
+	})
+	return before, after, err
+}
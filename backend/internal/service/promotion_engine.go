@@ -0,0 +1,420 @@
+package service
+
+import (
+	"online-mall/internal/models"
+	"sort"
+)
+
+// promotionSearchLimit 参与组合搜索的促销数量上限，超出时保留单独优惠最大的
+const promotionSearchLimit = 10
+
+// promotionTypeOrder 组合内促销的应用顺序：先单品促销（组合价、N件X元、第二件折扣）占用商品件数，再按优惠后金额计算满减
+var promotionTypeOrder = map[string]int{
+	models.PromotionBundle:        0,
+	models.PromotionNForX:         1,
+	models.PromotionSecondItem:    2,
+	models.PromotionFullReduction: 3,
+}
+
+// pricingLine 参与促销计价的结算商品
+type pricingLine struct {
+	ProductID   uint64
+	CategoryIDs []uint64 // 商品分类及其上级分类
+	UnitPrice   float64
+	Quantity    int
+}
+
+// pricingDiscount 一项优惠及其按商品下标分摊的金额
+type pricingDiscount struct {
+	Promotion  *models.Promotion  // 促销优惠
+	UserCoupon *models.UserCoupon // 优惠券优惠
+	Amount     float64
+	Shares     []float64
+}
+
+// pricingResult 一种促销组合（及优惠券）的计价结果
+type pricingResult struct {
+	Discounts       []*pricingDiscount
+	Amounts         []float64 // 各商品优惠后金额
+	PromotionAmount float64
+	CouponAmount    float64
+	stackCoupon     bool
+	units           []int  // 各商品未参与单品促销的件数
+	fullClaimed     []bool // 各商品是否已参与满减
+}
+
+// total 优惠总额
+func (r *pricingResult) total() float64 {
+	return roundMoney(r.PromotionAmount + r.CouponAmount)
+}
+
+// promotionEngine 促销计价引擎：枚举促销组合，按固定顺序应用后再选最优优惠券，
+// 相同输入总是得到相同结果
+type promotionEngine struct {
+	lines      []*pricingLine
+	promotions []*models.Promotion
+	rules      []*models.PromotionRules
+	eligible   [][]bool // [促销下标][商品下标]
+}
+
+// newPromotionEngine 创建促销计价引擎，只保留对结算商品有效的促销
+func newPromotionEngine(lines []*pricingLine, promotions []*models.Promotion) *promotionEngine {
+	e := &promotionEngine{lines: lines}
+	for _, promotion := range promotions {
+		rules := promotion.GetRules()
+		eligible := e.eligibleLines(promotion, rules)
+		if eligible == nil {
+			continue
+		}
+		e.promotions = append(e.promotions, promotion)
+		e.rules = append(e.rules, rules)
+		e.eligible = append(e.eligible, eligible)
+	}
+
+	if len(e.promotions) > promotionSearchLimit {
+		standalone := make([]float64, len(e.promotions))
+		for i := range e.promotions {
+			if result := e.apply(1 << uint(i)); result != nil {
+				standalone[i] = result.PromotionAmount
+			}
+		}
+		e.keep(func(i, j int) bool {
+			if standalone[i] != standalone[j] {
+				return standalone[i] > standalone[j]
+			}
+			return e.promotions[i].ID < e.promotions[j].ID
+		}, promotionSearchLimit)
+	}
+	e.keep(func(i, j int) bool {
+		ti, tj := promotionTypeOrder[e.promotions[i].Type], promotionTypeOrder[e.promotions[j].Type]
+		if ti != tj {
+			return ti < tj
+		}
+		return e.promotions[i].ID < e.promotions[j].ID
+	}, len(e.promotions))
+	return e
+}
+
+// keep 按less排序促销并保留前limit个
+func (e *promotionEngine) keep(less func(i, j int) bool, limit int) {
+	order := make([]int, len(e.promotions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return less(order[a], order[b]) })
+	if len(order) > limit {
+		order = order[:limit]
+	}
+
+	promotions := make([]*models.Promotion, 0, len(order))
+	rules := make([]*models.PromotionRules, 0, len(order))
+	eligible := make([][]bool, 0, len(order))
+	for _, i := range order {
+		promotions = append(promotions, e.promotions[i])
+		rules = append(rules, e.rules[i])
+		eligible = append(eligible, e.eligible[i])
+	}
+	e.promotions, e.rules, e.eligible = promotions, rules, eligible
+}
+
+// eligibleLines 计算促销适用的商品，没有可适用商品时返回nil
+func (e *promotionEngine) eligibleLines(promotion *models.Promotion, rules *models.PromotionRules) []bool {
+	eligible := make([]bool, len(e.lines))
+	found := false
+
+	if promotion.Type == models.PromotionBundle {
+		products := make(map[uint64]bool, len(rules.ProductIDs))
+		for _, id := range rules.ProductIDs {
+			products[id] = true
+		}
+		present := make(map[uint64]bool, len(products))
+		for i, line := range e.lines {
+			if products[line.ProductID] {
+				eligible[i] = true
+				present[line.ProductID] = true
+			}
+		}
+		// 组合商品需全部在结算商品中
+		if len(products) == 0 || len(present) != len(products) {
+			return nil
+		}
+		return eligible
+	}
+
+	scope := make(map[uint64]bool)
+	for _, id := range promotion.GetScopeIDs() {
+		scope[id] = true
+	}
+	for i, line := range e.lines {
+		switch promotion.ScopeType {
+		case models.PromotionScopeProduct:
+			eligible[i] = scope[line.ProductID]
+		case models.PromotionScopeCategory:
+			for _, categoryID := range line.CategoryIDs {
+				if scope[categoryID] {
+					eligible[i] = true
+					break
+				}
+			}
+		default:
+			eligible[i] = true
+		}
+		found = found || eligible[i]
+	}
+	if !found {
+		return nil
+	}
+	return eligible
+}
+
+// Best 计算优惠总额最大的促销组合和优惠券：coupons为候选优惠券，requireCoupon为true时只接受用上优惠券的组合；
+// 优惠相同时选参与促销更少、组合序号更小、优惠券ID更小的，没有可行组合时返回nil
+func (e *promotionEngine) Best(coupons []*models.UserCoupon, requireCoupon bool) *pricingResult {
+	var best *pricingResult
+	for mask := 0; mask < 1<<uint(len(e.promotions)); mask++ {
+		result := e.apply(mask)
+		if result == nil {
+			continue
+		}
+		if result.stackCoupon {
+			e.applyCoupon(result, coupons)
+		}
+		if requireCoupon && result.CouponAmount == 0 {
+			continue
+		}
+		if best == nil || result.total() > best.total() ||
+			(result.total() == best.total() && len(result.Discounts) < len(best.Discounts)) {
+			best = result
+		}
+	}
+	return best
+}
+
+// apply 按固定顺序应用mask中的促销，有促销不产生优惠时返回nil（与不含该促销的组合重复）
+func (e *promotionEngine) apply(mask int) *pricingResult {
+	result := &pricingResult{
+		Amounts:     make([]float64, len(e.lines)),
+		stackCoupon: true,
+		units:       make([]int, len(e.lines)),
+		fullClaimed: make([]bool, len(e.lines)),
+	}
+	for i, line := range e.lines {
+		result.Amounts[i] = roundMoney(line.UnitPrice * float64(line.Quantity))
+		result.units[i] = line.Quantity
+	}
+
+	for i, promotion := range e.promotions {
+		if mask&(1<<uint(i)) == 0 {
+			continue
+		}
+		var amount float64
+		var bases []float64
+		switch promotion.Type {
+		case models.PromotionBundle:
+			amount, bases = e.bundle(result, e.rules[i])
+		case models.PromotionNForX:
+			amount, bases = e.nForX(result, e.rules[i], e.eligible[i])
+		case models.PromotionSecondItem:
+			amount, bases = e.secondItem(result, e.rules[i], e.eligible[i])
+		case models.PromotionFullReduction:
+			amount, bases = e.fullReduction(result, e.rules[i], e.eligible[i])
+		}
+		amount = roundMoney(amount)
+		if amount <= 0 {
+			return nil
+		}
+
+		shares := allocateDiscount(amount, bases)
+		for j, share := range shares {
+			result.Amounts[j] = roundMoney(result.Amounts[j] - share)
+		}
+		result.Discounts = append(result.Discounts, &pricingDiscount{Promotion: promotion, Amount: amount, Shares: shares})
+		result.PromotionAmount = roundMoney(result.PromotionAmount + amount)
+		result.stackCoupon = result.stackCoupon && promotion.StackCoupon
+	}
+	return result
+}
+
+// bundle 组合价：每组取各组合商品中单价最高的一件，组合原价高于组合价时成组
+func (e *promotionEngine) bundle(result *pricingResult, rules *models.PromotionRules) (float64, []float64) {
+	bases := make([]float64, len(e.lines))
+	var amount float64
+	for {
+		picked := make([]int, 0, len(rules.ProductIDs))
+		var sum float64
+		for _, productID := range rules.ProductIDs {
+			pick := -1
+			for i, line := range e.lines {
+				if line.ProductID != productID || result.units[i] == 0 {
+					continue
+				}
+				if pick < 0 || line.UnitPrice > e.lines[pick].UnitPrice {
+					pick = i
+				}
+			}
+			if pick < 0 {
+				return amount, bases
+			}
+			picked = append(picked, pick)
+			sum += e.lines[pick].UnitPrice
+		}
+		// 每组都取剩余最贵的商品，后续组合原价只会更低
+		if sum <= rules.Price {
+			return amount, bases
+		}
+		for _, i := range picked {
+			result.units[i]--
+			bases[i] += e.lines[i].UnitPrice
+		}
+		amount += sum - rules.Price
+	}
+}
+
+// nForX N件X元：适用商品按单价从高到低每N件成组，组内原价高于X时成组
+func (e *promotionEngine) nForX(result *pricingResult, rules *models.PromotionRules, eligible []bool) (float64, []float64) {
+	bases := make([]float64, len(e.lines))
+	order := make([]int, 0, len(e.lines))
+	for i := range e.lines {
+		if eligible[i] && result.units[i] > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return e.lines[order[a]].UnitPrice > e.lines[order[b]].UnitPrice
+	})
+
+	units := make([]int, 0)
+	for _, i := range order {
+		for n := 0; n < result.units[i]; n++ {
+			units = append(units, i)
+		}
+	}
+
+	var amount float64
+	for start := 0; start+rules.Count <= len(units); start += rules.Count {
+		var sum float64
+		for _, i := range units[start : start+rules.Count] {
+			sum += e.lines[i].UnitPrice
+		}
+		if sum <= rules.Price {
+			break
+		}
+		for _, i := range units[start : start+rules.Count] {
+			result.units[i]--
+			bases[i] += e.lines[i].UnitPrice
+		}
+		amount += sum - rules.Price
+	}
+	return amount, bases
+}
+
+// secondItem 第二件折扣：同一SKU每两件中一件按折扣率计价
+func (e *promotionEngine) secondItem(result *pricingResult, rules *models.PromotionRules, eligible []bool) (float64, []float64) {
+	bases := make([]float64, len(e.lines))
+	var amount float64
+	for i, line := range e.lines {
+		if !eligible[i] {
+			continue
+		}
+		pairs := result.units[i] / 2
+		if pairs == 0 {
+			continue
+		}
+		result.units[i] -= pairs * 2
+		bases[i] = line.UnitPrice * float64(pairs*2)
+		amount += line.UnitPrice * float64(pairs) * (1 - rules.Rate)
+	}
+	return amount, bases
+}
+
+// fullReduction 满减：未参与其他满减的适用商品按优惠后金额合计，取达到的最高档位
+func (e *promotionEngine) fullReduction(result *pricingResult, rules *models.PromotionRules, eligible []bool) (float64, []float64) {
+	bases := make([]float64, len(e.lines))
+	var total float64
+	for i := range e.lines {
+		if eligible[i] && !result.fullClaimed[i] {
+			bases[i] = result.Amounts[i]
+			total += result.Amounts[i]
+		}
+	}
+
+	var amount float64
+	for _, tier := range rules.Tiers {
+		if total >= tier.Threshold && tier.Reduction > amount {
+			amount = tier.Reduction
+		}
+	}
+	if amount <= 0 {
+		return 0, bases
+	}
+	if amount > total {
+		amount = total
+	}
+	for i := range e.lines {
+		if bases[i] > 0 {
+			result.fullClaimed[i] = true
+		}
+	}
+	return amount, bases
+}
+
+// applyCoupon 在促销后的金额上选择优惠最大的优惠券，coupons需按ID升序，优惠相同时选ID更小的
+func (e *promotionEngine) applyCoupon(result *pricingResult, coupons []*models.UserCoupon) {
+	var total float64
+	for _, amount := range result.Amounts {
+		total += amount
+	}
+	total = roundMoney(total)
+
+	var best *models.UserCoupon
+	var bestAmount float64
+	for _, userCoupon := range coupons {
+		amount := roundMoney(userCoupon.Coupon.GetDiscountAmount(total))
+		if amount > total {
+			amount = total
+		}
+		if amount > bestAmount {
+			best, bestAmount = userCoupon, amount
+		}
+	}
+	if best == nil {
+		return
+	}
+
+	shares := allocateDiscount(bestAmount, result.Amounts)
+	for i, share := range shares {
+		result.Amounts[i] = roundMoney(result.Amounts[i] - share)
+	}
+	result.Discounts = append(result.Discounts, &pricingDiscount{UserCoupon: best, Amount: bestAmount, Shares: shares})
+	result.CouponAmount = bestAmount
+}
+
+// allocateDiscount 按基数比例将优惠分摊到各商品，精确到分，尾差计入最后一个参与分摊的商品
+func allocateDiscount(amount float64, bases []float64) []float64 {
+	shares := make([]float64, len(bases))
+	var total float64
+	last := -1
+	for i, base := range bases {
+		if base > 0 {
+			total += base
+			last = i
+		}
+	}
+	if last < 0 {
+		return shares
+	}
+
+	remaining := amount
+	for i, base := range bases {
+		if base <= 0 {
+			continue
+		}
+		if i == last {
+			shares[i] = roundMoney(remaining)
+			break
+		}
+		shares[i] = roundMoney(amount * base / total)
+		remaining -= shares[i]
+	}
+	return shares
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"online-mall/internal/models"
+	"testing"
+	"time"
+)
+
+// testPromotion 创建测试用促销，scopeIDs为空时适用全场
+func testPromotion(id uint64, promotionType string, scopeIDs []uint64, rules *models.PromotionRules, stackCoupon bool) *models.Promotion {
+	promotion := &models.Promotion{
+		Type:        promotionType,
+		ScopeType:   models.PromotionScopeAll,
+		StackCoupon: stackCoupon,
+	}
+	promotion.ID = id
+	if len(scopeIDs) > 0 {
+		promotion.ScopeType = models.PromotionScopeProduct
+		promotion.SetScopeIDs(scopeIDs)
+	}
+	promotion.SetRules(rules)
+	return promotion
+}
+
+// testCoupon 创建测试用的满减券
+func testCoupon(id uint64, value, minAmount float64) *models.UserCoupon {
+	now := time.Now()
+	userCoupon := &models.UserCoupon{Coupon: models.Coupon{
+		Type:      1,
+		Value:     value,
+		MinAmount: minAmount,
+		StartTime: now.Add(-time.Hour).Format("2006-01-02 15:04:05"),
+		EndTime:   now.Add(time.Hour).Format("2006-01-02 15:04:05"),
+		Status:    1,
+	}}
+	userCoupon.ID = id
+	return userCoupon
+}
+
+// fullReductionRules 满减规则，tiers依次为门槛和减免金额
+func fullReductionRules(tiers ...float64) *models.PromotionRules {
+	rules := &models.PromotionRules{}
+	for i := 0; i+1 < len(tiers); i += 2 {
+		rules.Tiers = append(rules.Tiers, models.PromotionTier{Threshold: tiers[i], Reduction: tiers[i+1]})
+	}
+	return rules
+}
+
+// checkAllocation 检查每项优惠的分摊合计等于优惠金额，且优惠后金额合计等于原价减优惠总额
+func checkAllocation(t *testing.T, lines []*pricingLine, result *pricingResult) {
+	t.Helper()
+	var original, amounts float64
+	for i, line := range lines {
+		original += line.UnitPrice * float64(line.Quantity)
+		amounts += result.Amounts[i]
+	}
+	for _, discount := range result.Discounts {
+		var sum float64
+		for _, share := range discount.Shares {
+			sum += share
+		}
+		if roundMoney(sum) != discount.Amount {
+			t.Errorf("shares %v sum to %.2f, want %.2f", discount.Shares, roundMoney(sum), discount.Amount)
+		}
+	}
+	if roundMoney(original-amounts) != result.total() {
+		t.Errorf("discounted amounts %v differ from original %.2f by %.2f, want %.2f",
+			result.Amounts, original, roundMoney(original-amounts), result.total())
+	}
+}
+
+func TestPromotionEngineBest(t *testing.T) {
+	tests := []struct {
+		name            string
+		lines           []*pricingLine
+		promotions      []*models.Promotion
+		coupons         []*models.UserCoupon
+		requireCoupon   bool
+		wantPromotions  []uint64
+		wantPromotion   float64
+		wantCoupon      uint64
+		wantCouponValue float64
+	}{
+		{
+			name:       "满减未达门槛",
+			lines:      []*pricingLine{{ProductID: 1, UnitPrice: 99, Quantity: 1}},
+			promotions: []*models.Promotion{testPromotion(1, models.PromotionFullReduction, nil, fullReductionRules(100, 10), true)},
+		},
+		{
+			name:           "满减刚好达到门槛",
+			lines:          []*pricingLine{{ProductID: 1, UnitPrice: 50, Quantity: 2}},
+			promotions:     []*models.Promotion{testPromotion(1, models.PromotionFullReduction, nil, fullReductionRules(100, 10), true)},
+			wantPromotions: []uint64{1},
+			wantPromotion:  10,
+		},
+		{
+			name:           "满减取达到的最高档位",
+			lines:          []*pricingLine{{ProductID: 1, UnitPrice: 250, Quantity: 1}},
+			promotions:     []*models.Promotion{testPromotion(1, models.PromotionFullReduction, nil, fullReductionRules(100, 10, 200, 25, 300, 40), true)},
+			wantPromotions: []uint64{1},
+			wantPromotion:  25,
+		},
+		{
+			name: "单品促销与满减叠加，满减按单品优惠后金额计算",
+			lines: []*pricingLine{
+				{ProductID: 1, UnitPrice: 60, Quantity: 2},
+				{ProductID: 2, UnitPrice: 30, Quantity: 1},
+			},
+			promotions: []*models.Promotion{
+				testPromotion(1, models.PromotionFullReduction, nil, fullReductionRules(100, 10), true),
+				testPromotion(2, models.PromotionSecondItem, []uint64{1}, &models.PromotionRules{Rate: 0.5}, true),
+			},
+			wantPromotions: []uint64{2, 1},
+			wantPromotion:  40,
+		},
+		{
+			name:  "单品优惠后不满门槛时不再叠加满减",
+			lines: []*pricingLine{{ProductID: 1, UnitPrice: 60, Quantity: 2}},
+			promotions: []*models.Promotion{
+				testPromotion(1, models.PromotionFullReduction, nil, fullReductionRules(100, 10), true),
+				testPromotion(2, models.PromotionSecondItem, []uint64{1}, &models.PromotionRules{Rate: 0.5}, true),
+			},
+			wantPromotions: []uint64{2},
+			wantPromotion:  30,
+		},
+		{
+			name:  "同一商品只参与一个满减",
+			lines: []*pricingLine{{ProductID: 1, UnitPrice: 200, Quantity: 1}},
+			promotions: []*models.Promotion{
+				testPromotion(1, models.PromotionFullReduction, nil, fullReductionRules(100, 10), true),
+				testPromotion(2, models.PromotionFullReduction, nil, fullReductionRules(100, 15), true),
+			},
+			wantPromotions: []uint64{2},
+			wantPromotion:  15,
+		},
+		{
+			name: "不同商品分别参与满减",
+			lines: []*pricingLine{
+				{ProductID: 1, UnitPrice: 100, Quantity: 1},
+				{ProductID: 2, UnitPrice: 100, Quantity: 1},
+			},
+			promotions: []*models.Promotion{
+				testPromotion(1, models.PromotionFullReduction, []uint64{1}, fullReductionRules(100, 10), true),
+				testPromotion(2, models.PromotionFullReduction, []uint64{2}, fullReductionRules(100, 15), true),
+			},
+			wantPromotions: []uint64{1, 2},
+			wantPromotion:  25,
+		},
+		{
+			name: "组合价占用的件数不再参与N件X元",
+			lines: []*pricingLine{
+				{ProductID: 1, UnitPrice: 50, Quantity: 1},
+				{ProductID: 2, UnitPrice: 40, Quantity: 1},
+				{ProductID: 3, UnitPrice: 30, Quantity: 1},
+			},
+			promotions: []*models.Promotion{
+				testPromotion(1, models.PromotionBundle, nil, &models.PromotionRules{ProductIDs: []uint64{1, 2}, Price: 70}, true),
+				testPromotion(2, models.PromotionNForX, nil, &models.PromotionRules{Count: 2, Price: 50}, true),
+			},
+			wantPromotions: []uint64{2},
+			wantPromotion:  40,
+		},
+		{
+			name:            "不可同享的促销优惠更小时只用优惠券",
+			lines:           []*pricingLine{{ProductID: 1, UnitPrice: 200, Quantity: 1}},
+			promotions:      []*models.Promotion{testPromotion(1, models.PromotionFullReduction, nil, fullReductionRules(200, 30), false)},
+			coupons:         []*models.UserCoupon{testCoupon(1, 50, 100)},
+			wantCoupon:      1,
+			wantCouponValue: 50,
+		},
+		{
+			name:           "不可同享的促销优惠更大时不用优惠券",
+			lines:          []*pricingLine{{ProductID: 1, UnitPrice: 200, Quantity: 1}},
+			promotions:     []*models.Promotion{testPromotion(1, models.PromotionFullReduction, nil, fullReductionRules(200, 30), false)},
+			coupons:        []*models.UserCoupon{testCoupon(1, 20, 100)},
+			wantPromotions: []uint64{1},
+			wantPromotion:  30,
+		},
+		{
+			name:            "可同享的促销后按优惠后金额选券",
+			lines:           []*pricingLine{{ProductID: 1, UnitPrice: 200, Quantity: 1}},
+			promotions:      []*models.Promotion{testPromotion(1, models.PromotionFullReduction, nil, fullReductionRules(200, 30), true)},
+			coupons:         []*models.UserCoupon{testCoupon(1, 20, 100), testCoupon(2, 40, 180)},
+			wantPromotions:  []uint64{1},
+			wantPromotion:   30,
+			wantCoupon:      1,
+			wantCouponValue: 20,
+		},
+		{
+			name:            "必须使用优惠券时排除不可同享的促销",
+			lines:           []*pricingLine{{ProductID: 1, UnitPrice: 200, Quantity: 1}},
+			promotions:      []*models.Promotion{testPromotion(1, models.PromotionFullReduction, nil, fullReductionRules(200, 60), false)},
+			coupons:         []*models.UserCoupon{testCoupon(1, 20, 100)},
+			requireCoupon:   true,
+			wantCoupon:      1,
+			wantCouponValue: 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := newPromotionEngine(tt.lines, tt.promotions).Best(tt.coupons, tt.requireCoupon)
+			if result == nil {
+				t.Fatal("Best returned nil")
+			}
+
+			var promotionIDs []uint64
+			var couponID uint64
+			for _, discount := range result.Discounts {
+				if discount.Promotion != nil {
+					promotionIDs = append(promotionIDs, discount.Promotion.ID)
+				} else {
+					couponID = discount.UserCoupon.ID
+				}
+			}
+			if len(promotionIDs) != len(tt.wantPromotions) {
+				t.Fatalf("promotions = %v, want %v", promotionIDs, tt.wantPromotions)
+			}
+			for i := range promotionIDs {
+				if promotionIDs[i] != tt.wantPromotions[i] {
+					t.Fatalf("promotions = %v, want %v", promotionIDs, tt.wantPromotions)
+				}
+			}
+			if result.PromotionAmount != tt.wantPromotion {
+				t.Errorf("PromotionAmount = %.2f, want %.2f", result.PromotionAmount, tt.wantPromotion)
+			}
+			if couponID != tt.wantCoupon || result.CouponAmount != tt.wantCouponValue {
+				t.Errorf("coupon = %d (%.2f), want %d (%.2f)", couponID, result.CouponAmount, tt.wantCoupon, tt.wantCouponValue)
+			}
+			checkAllocation(t, tt.lines, result)
+		})
+	}
+}
+
+func TestPromotionEngineBestRequireCouponWithoutCoupon(t *testing.T) {
+	lines := []*pricingLine{{ProductID: 1, UnitPrice: 50, Quantity: 1}}
+	coupons := []*models.UserCoupon{testCoupon(1, 20, 100)}
+	if result := newPromotionEngine(lines, nil).Best(coupons, true); result != nil {
+		t.Errorf("Best = %+v, want nil when no coupon is usable", result)
+	}
+}
+
+func TestPromotionEngineSearchLimit(t *testing.T) {
+	// 12个分别作用于不同商品的满减，减免金额为1到12元，只保留单独优惠最大的10个参与组合
+	var lines []*pricingLine
+	var promotions []*models.Promotion
+	for i := uint64(1); i <= 12; i++ {
+		lines = append(lines, &pricingLine{ProductID: i, UnitPrice: 100, Quantity: 1})
+		promotions = append(promotions, testPromotion(i, models.PromotionFullReduction, []uint64{i}, fullReductionRules(100, float64(i)), true))
+	}
+	// 与第3个促销单独优惠相同、ID更大的促销争夺第10个名额，相同优惠保留ID更小的
+	promotions = append(promotions, testPromotion(13, models.PromotionFullReduction, []uint64{1}, fullReductionRules(100, 3), true))
+
+	engine := newPromotionEngine(lines, promotions)
+	if len(engine.promotions) != promotionSearchLimit {
+		t.Fatalf("kept %d promotions, want %d", len(engine.promotions), promotionSearchLimit)
+	}
+	for _, promotion := range engine.promotions {
+		if promotion.ID < 3 || promotion.ID == 13 {
+			t.Errorf("kept promotion %d, want promotions 3 to 12", promotion.ID)
+		}
+	}
+
+	result := engine.Best(nil, false)
+	if result == nil {
+		t.Fatal("Best returned nil")
+	}
+	if want := 3.0 + 4 + 5 + 6 + 7 + 8 + 9 + 10 + 11 + 12; result.PromotionAmount != want {
+		t.Errorf("PromotionAmount = %.2f, want %.2f", result.PromotionAmount, want)
+	}
+	checkAllocation(t, lines, result)
+}
+
+func TestAllocateDiscount(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount float64
+		bases  []float64
+		want   []float64
+	}{
+		{name: "均分有尾差", amount: 10, bases: []float64{1, 1, 1}, want: []float64{3.33, 3.33, 3.34}},
+		{name: "跳过基数为0的商品", amount: 0.05, bases: []float64{3, 0, 7, 0}, want: []float64{0.02, 0, 0.03, 0}},
+		{name: "按金额比例分摊", amount: 10, bases: []float64{50, 30, 20}, want: []float64{5, 3, 2}},
+		{name: "尾差计入最后一个商品", amount: 1, bases: []float64{1, 1, 1, 1, 1, 1}, want: []float64{0.17, 0.17, 0.17, 0.17, 0.17, 0.15}},
+		{name: "没有可分摊的商品", amount: 7, bases: []float64{0, 0}, want: []float64{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := allocateDiscount(tt.amount, tt.bases)
+			var sum, bases float64
+			for i, share := range shares {
+				sum += share
+				bases += tt.bases[i]
+			}
+			// 有可分摊的商品时分摊合计精确等于优惠金额
+			if bases > 0 && roundMoney(sum) != tt.amount {
+				t.Errorf("shares %v sum to %.2f, want %.2f", shares, roundMoney(sum), tt.amount)
+			}
+			for i := range tt.want {
+				if shares[i] != tt.want[i] {
+					t.Fatalf("allocateDiscount(%.2f, %v) = %v, want %v", tt.amount, tt.bases, shares, tt.want)
+				}
+			}
+		})
+	}
+}
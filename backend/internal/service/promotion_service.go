@@ -0,0 +1,247 @@
+package service
+
+import (
+	"errors"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPromotionNotFound 促销活动不存在
+	ErrPromotionNotFound = errors.New("促销活动不存在")
+
+	// ErrPromotionSchedule 活动时间无效
+	ErrPromotionSchedule = errors.New("结束时间必须晚于开始时间")
+
+	// ErrPromotionType 促销类型无效
+	ErrPromotionType = errors.New("促销类型无效")
+
+	// ErrPromotionScope 适用范围无效
+	ErrPromotionScope = errors.New("指定商品或分类时至少选择一项")
+
+	// ErrPromotionTiers 满减档位无效
+	ErrPromotionTiers = errors.New("满减档位无效：门槛需大于0且不重复，减免金额需小于门槛")
+
+	// ErrPromotionNForX N件X元规则无效
+	ErrPromotionNForX = errors.New("N件X元规则无效：件数需在2到99之间，总价需大于0")
+
+	// ErrPromotionRate 第二件折扣率无效
+	ErrPromotionRate = errors.New("第二件折扣率需在0到1之间")
+
+	// ErrPromotionBundle 组合价规则无效
+	ErrPromotionBundle = errors.New("组合价规则无效：需选择2到10个不同商品，组合价需大于0")
+)
+
+// PromotionInput 创建/更新促销活动参数
+type PromotionInput struct {
+	Name        string
+	Type        string
+	ScopeType   string
+	ScopeIDs    []uint64
+	Rules       models.PromotionRules
+	StackCoupon bool
+	StartAt     time.Time
+	EndAt       time.Time
+	Status      int
+}
+
+// PromotionService 促销活动业务逻辑层
+type PromotionService struct {
+	promotionRepo *repository.PromotionRepository
+	productRepo   *repository.ProductRepository
+}
+
+// NewPromotionService 创建促销活动Service实例
+func NewPromotionService() *PromotionService {
+	return &PromotionService{
+		promotionRepo: repository.NewPromotionRepository(),
+		productRepo:   repository.NewProductRepository(),
+	}
+}
+
+// GetPromotion 获取促销活动
+func (s *PromotionService) GetPromotion(id uint64) (*models.Promotion, error) {
+	promotion, err := s.promotionRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// GetActive 获取当前生效的促销活动
+func (s *PromotionService) GetActive() ([]*models.Promotion, error) {
+	return s.promotionRepo.GetActive(time.Now())
+}
+
+// GetPromotions 分页获取促销活动（管理员），包括停用和已结束的
+func (s *PromotionService) GetPromotions(page, pageSize int) ([]*models.Promotion, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.promotionRepo.GetPromotions(page, pageSize)
+}
+
+// validateInput 校验活动时间、适用范围和促销规则，只保留促销类型用到的规则字段
+func (s *PromotionService) validateInput(input *PromotionInput) (*models.PromotionRules, error) {
+	if !input.EndAt.After(input.StartAt) {
+		return nil, ErrPromotionSchedule
+	}
+
+	rules := &models.PromotionRules{}
+	switch input.Type {
+	case models.PromotionFullReduction:
+		tiers := append([]models.PromotionTier(nil), input.Rules.Tiers...)
+		sort.Slice(tiers, func(i, j int) bool { return tiers[i].Threshold < tiers[j].Threshold })
+		if len(tiers) == 0 || len(tiers) > 10 {
+			return nil, ErrPromotionTiers
+		}
+		for i, tier := range tiers {
+			if tier.Threshold <= 0 || tier.Reduction <= 0 || tier.Reduction >= tier.Threshold {
+				return nil, ErrPromotionTiers
+			}
+			if i > 0 && tier.Threshold == tiers[i-1].Threshold {
+				return nil, ErrPromotionTiers
+			}
+			tiers[i] = models.PromotionTier{Threshold: roundMoney(tier.Threshold), Reduction: roundMoney(tier.Reduction)}
+		}
+		rules.Tiers = tiers
+	case models.PromotionNForX:
+		if input.Rules.Count < 2 || input.Rules.Count > 99 || input.Rules.Price <= 0 {
+			return nil, ErrPromotionNForX
+		}
+		rules.Count = input.Rules.Count
+		rules.Price = roundMoney(input.Rules.Price)
+	case models.PromotionSecondItem:
+		if input.Rules.Rate < 0 || input.Rules.Rate >= 1 {
+			return nil, ErrPromotionRate
+		}
+		rules.Rate = input.Rules.Rate
+	case models.PromotionBundle:
+		productIDs := uniqueIDs(input.Rules.ProductIDs)
+		if len(productIDs) != len(input.Rules.ProductIDs) || len(productIDs) < 2 || len(productIDs) > 10 || input.Rules.Price <= 0 {
+			return nil, ErrPromotionBundle
+		}
+		products, err := s.productRepo.GetByIDs(productIDs)
+		if err != nil {
+			return nil, err
+		}
+		if len(products) != len(productIDs) {
+			return nil, ErrProductNotFound
+		}
+		rules.ProductIDs = productIDs
+		rules.Price = roundMoney(input.Rules.Price)
+		// 组合价只适用于组合商品
+		input.ScopeType = models.PromotionScopeAll
+	default:
+		return nil, ErrPromotionType
+	}
+
+	switch input.ScopeType {
+	case models.PromotionScopeAll:
+		input.ScopeIDs = nil
+	case models.PromotionScopeProduct, models.PromotionScopeCategory:
+		input.ScopeIDs = uniqueIDs(input.ScopeIDs)
+		if len(input.ScopeIDs) == 0 {
+			return nil, ErrPromotionScope
+		}
+	default:
+		return nil, ErrPromotionScope
+	}
+	return rules, nil
+}
+
+// CreatePromotion 创建促销活动
+func (s *PromotionService) CreatePromotion(input *PromotionInput) (*models.Promotion, error) {
+	rules, err := s.validateInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion := &models.Promotion{
+		Name:        input.Name,
+		Type:        input.Type,
+		ScopeType:   input.ScopeType,
+		StackCoupon: input.StackCoupon,
+		StartAt:     input.StartAt,
+		EndAt:       input.EndAt,
+		Status:      input.Status,
+	}
+	promotion.SetScopeIDs(input.ScopeIDs)
+	promotion.SetRules(rules)
+	if err := s.promotionRepo.Create(promotion); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// UpdatePromotion 更新促销活动，只影响之后的结算计价
+func (s *PromotionService) UpdatePromotion(id uint64, input *PromotionInput) (*models.Promotion, error) {
+	if _, err := s.GetPromotion(id); err != nil {
+		return nil, err
+	}
+	rules, err := s.validateInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion := &models.Promotion{}
+	promotion.SetScopeIDs(input.ScopeIDs)
+	promotion.SetRules(rules)
+	err = s.promotionRepo.Update(id, map[string]interface{}{
+		"name":         input.Name,
+		"type":         input.Type,
+		"scope_type":   input.ScopeType,
+		"scope_ids":    promotion.ScopeIDs,
+		"rules":        promotion.Rules,
+		"stack_coupon": input.StackCoupon,
+		"start_at":     input.StartAt,
+		"end_at":       input.EndAt,
+		"status":       input.Status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPromotion(id)
+}
+
+// UpdateStatus 启用或停用促销活动
+func (s *PromotionService) UpdateStatus(id uint64, status int) (*models.Promotion, error) {
+	if _, err := s.GetPromotion(id); err != nil {
+		return nil, err
+	}
+	if err := s.promotionRepo.Update(id, map[string]interface{}{"status": status}); err != nil {
+		return nil, err
+	}
+	return s.GetPromotion(id)
+}
+
+// DeletePromotion 删除促销活动
+func (s *PromotionService) DeletePromotion(id uint64) error {
+	if _, err := s.GetPromotion(id); err != nil {
+		return err
+	}
+	return s.promotionRepo.Delete(id)
+}
+
+// IsPromotionError 判断是否为促销业务错误（可直接返回给用户）
+func IsPromotionError(err error) bool {
+	return errors.Is(err, ErrPromotionNotFound) ||
+		errors.Is(err, ErrPromotionSchedule) ||
+		errors.Is(err, ErrPromotionType) ||
+		errors.Is(err, ErrPromotionScope) ||
+		errors.Is(err, ErrPromotionTiers) ||
+		errors.Is(err, ErrPromotionNForX) ||
+		errors.Is(err, ErrPromotionRate) ||
+		errors.Is(err, ErrPromotionBundle) ||
+		errors.Is(err, ErrProductNotFound)
+}
@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userPermissionsTTL 用户权限缓存时间
+const userPermissionsTTL = 10 * time.Minute
+
+var (
+	// ErrRoleNotFound 角色不存在
+	ErrRoleNotFound = errors.New("角色不存在")
+
+	// ErrRoleExists 角色名已存在
+	ErrRoleExists = errors.New("角色名已存在")
+
+	// ErrRoleSystem 系统内置角色不可删除
+	ErrRoleSystem = errors.New("系统内置角色不可删除")
+
+	// ErrRoleSuperAdmin 超级管理员权限不可修改
+	ErrRoleSuperAdmin = errors.New("超级管理员拥有全部权限，不可修改")
+
+	// ErrPermissionInvalid 权限编码无效
+	ErrPermissionInvalid = errors.New("包含无效的权限编码")
+
+	// ErrLastSuperAdmin 不能移除最后一个超级管理员
+	ErrLastSuperAdmin = errors.New("至少需要保留一个可用的超级管理员")
+)
+
+// RoleInput 创建/更新角色参数
+type RoleInput struct {
+	Name        string
+	DisplayName *string
+	Description *string
+	Permissions []string // 为nil时不修改权限
+}
+
+// UserAccess 用户的角色和权限
+type UserAccess struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// RBACService 角色权限业务逻辑层
+type RBACService struct {
+	roleRepo    *repository.RoleRepository
+	authService *AuthService
+}
+
+// NewRBACService 创建角色权限Service实例
+func NewRBACService() *RBACService {
+	return &RBACService{
+		roleRepo:    repository.NewRoleRepository(),
+		authService: NewAuthService(),
+	}
+}
+
+// GetUserPermissions 获取用户权限编码，优先读取缓存
+func (s *RBACService) GetUserPermissions(ctx context.Context, userID uint64) ([]string, error) {
+	key := fmt.Sprintf(utils.UserPermissionsKey, userID)
+	if cached, err := utils.Get(ctx, key); err == nil {
+		var codes []string
+		if json.Unmarshal([]byte(cached), &codes) == nil {
+			return codes, nil
+		}
+	}
+
+	codes, err := s.roleRepo.GetUserPermissionCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if codes == nil {
+		codes = []string{}
+	}
+
+	if data, err := json.Marshal(codes); err == nil {
+		if err := utils.Set(ctx, key, string(data), userPermissionsTTL); err != nil {
+			log.Printf("Failed to cache user permissions: %v", err)
+		}
+	}
+	return codes, nil
+}
+
+// HasPermissions 检查用户是否拥有全部指定权限
+func (s *RBACService) HasPermissions(ctx context.Context, userID uint64, required ...string) (bool, error) {
+	codes, err := s.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	owned := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		owned[code] = true
+	}
+	for _, code := range required {
+		if !owned[code] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// GetUserAccess 获取用户的角色和权限
+func (s *RBACService) GetUserAccess(ctx context.Context, userID uint64) (*UserAccess, error) {
+	roles, err := s.roleRepo.GetUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := s.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &UserAccess{Roles: make([]string, 0, len(roles)), Permissions: permissions}
+	for _, role := range roles {
+		access.Roles = append(access.Roles, role.Name)
+	}
+	return access, nil
+}
+
+// invalidateUsers 清除用户权限缓存
+func (s *RBACService) invalidateUsers(ctx context.Context, userIDs ...uint64) {
+	if len(userIDs) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		keys = append(keys, fmt.Sprintf(utils.UserPermissionsKey, userID))
+	}
+	if err := utils.Del(ctx, keys...); err != nil {
+		log.Printf("Failed to invalidate user permissions: %v", err)
+	}
+}
+
+// invalidateRole 清除拥有该角色的用户的权限缓存
+func (s *RBACService) invalidateRole(ctx context.Context, roleID uint64) {
+	userIDs, err := s.roleRepo.GetUserIDsByRole(roleID)
+	if err != nil {
+		log.Printf("Failed to load role users: %v", err)
+		return
+	}
+	s.invalidateUsers(ctx, userIDs...)
+}
+
+// resolvePermissions 将权限编码转换为权限记录
+func (s *RBACService) resolvePermissions(codes []string) ([]*models.Permission, error) {
+	if len(codes) == 0 {
+		return []*models.Permission{}, nil
+	}
+	permissions, err := s.roleRepo.GetPermissionsByCodes(codes)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(permissions))
+	for _, perm := range permissions {
+		found[perm.Code] = true
+	}
+	for _, code := range codes {
+		if !found[code] {
+			return nil, ErrPermissionInvalid
+		}
+	}
+	return permissions, nil
+}
+
+// ListPermissions 获取所有权限
+func (s *RBACService) ListPermissions() ([]*models.Permission, error) {
+	return s.roleRepo.GetAllPermissions()
+}
+
+// ListRoles 获取所有角色
+func (s *RBACService) ListRoles() ([]*models.Role, error) {
+	return s.roleRepo.GetAll()
+}
+
+// GetRole 获取角色详情
+func (s *RBACService) GetRole(id uint64) (*models.Role, error) {
+	role, err := s.roleRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+// CreateRole 创建角色
+func (s *RBACService) CreateRole(input *RoleInput) (*models.Role, error) {
+	exists, err := s.roleRepo.ExistsByName(input.Name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrRoleExists
+	}
+
+	permissions, err := s.resolvePermissions(input.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{Name: input.Name}
+	if input.DisplayName != nil {
+		role.DisplayName = *input.DisplayName
+	}
+	if input.Description != nil {
+		role.Description = *input.Description
+	}
+
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.roleRepo.Create(tx, role); err != nil {
+			return err
+		}
+		return s.roleRepo.ReplacePermissions(tx, role, permissions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRole(role.ID)
+}
+
+// UpdateRole 更新角色信息和权限
+func (s *RBACService) UpdateRole(ctx context.Context, id uint64, input *RoleInput) (*models.Role, error) {
+	role, err := s.GetRole(id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Permissions != nil && role.Name == models.RoleSuperAdmin {
+		return nil, ErrRoleSuperAdmin
+	}
+
+	var permissions []*models.Permission
+	if input.Permissions != nil {
+		if permissions, err = s.resolvePermissions(input.Permissions); err != nil {
+			return nil, err
+		}
+	}
+
+	updates := map[string]interface{}{}
+	if input.DisplayName != nil {
+		updates["display_name"] = *input.DisplayName
+	}
+	if input.Description != nil {
+		updates["description"] = *input.Description
+	}
+
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := s.roleRepo.Update(tx, role.ID, updates); err != nil {
+				return err
+			}
+		}
+		if input.Permissions != nil {
+			return s.roleRepo.ReplacePermissions(tx, role, permissions)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Permissions != nil {
+		s.invalidateRole(ctx, role.ID)
+	}
+	return s.GetRole(role.ID)
+}
+
+// DeleteRole 删除角色
+func (s *RBACService) DeleteRole(ctx context.Context, id uint64) error {
+	role, err := s.GetRole(id)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return ErrRoleSystem
+	}
+
+	userIDs, err := s.roleRepo.GetUserIDsByRole(role.ID)
+	if err != nil {
+		return err
+	}
+	if err := models.DB.Transaction(func(tx *gorm.DB) error {
+		return s.roleRepo.Delete(tx, role)
+	}); err != nil {
+		return err
+	}
+
+	s.invalidateUsers(ctx, userIDs...)
+	return nil
+}
+
+// GetUserRoles 获取用户的角色
+func (s *RBACService) GetUserRoles(userID uint64) ([]*models.Role, error) {
+	return s.roleRepo.GetUserRoles(userID)
+}
+
+// SetUserRoles 设置用户的角色（覆盖原有角色），新获得角色时吊销用户已签发的令牌
+func (s *RBACService) SetUserRoles(ctx context.Context, userID uint64, roleNames []string) ([]*models.Role, error) {
+	roles := []*models.Role{}
+	if len(roleNames) > 0 {
+		var err error
+		if roles, err = s.roleRepo.GetByNames(roleNames); err != nil {
+			return nil, err
+		}
+		if len(roles) != len(uniqueStrings(roleNames)) {
+			return nil, ErrRoleNotFound
+		}
+	}
+
+	currentRoles, err := s.roleRepo.GetUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	wasSuperAdmin := false
+	held := make(map[uint64]bool, len(currentRoles))
+	for _, role := range currentRoles {
+		held[role.ID] = true
+		if role.Name == models.RoleSuperAdmin {
+			wasSuperAdmin = true
+		}
+	}
+
+	roleIDs := make([]uint64, 0, len(roles))
+	keepsSuperAdmin := false
+	gained := false
+	for _, role := range roles {
+		roleIDs = append(roleIDs, role.ID)
+		if role.Name == models.RoleSuperAdmin {
+			keepsSuperAdmin = true
+		}
+		if !held[role.ID] {
+			gained = true
+		}
+	}
+
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.roleRepo.SetUserRoles(tx, userID, roleIDs); err != nil {
+			return err
+		}
+		if !wasSuperAdmin || keepsSuperAdmin {
+			return nil
+		}
+
+		// 移除超级管理员角色后必须仍有可用的超级管理员
+		count, err := s.roleRepo.CountUsersByRoleName(tx, models.RoleSuperAdmin)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrLastSuperAdmin
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateUsers(ctx, userID)
+	// 获得新角色后吊销已签发的令牌：原令牌签发时未经过管理员必需的双因素认证，需重新登录
+	if gained {
+		if err := s.authService.RevokeAllTokens(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+// AssignRole 为用户添加角色，新获得角色时吊销用户已签发的令牌
+func (s *RBACService) AssignRole(ctx context.Context, userID uint64, roleName string) error {
+	roles, err := s.roleRepo.GetByNames([]string{roleName})
+	if err != nil {
+		return err
+	}
+	if len(roles) == 0 {
+		return ErrRoleNotFound
+	}
+	currentRoles, err := s.roleRepo.GetUserRoles(userID)
+	if err != nil {
+		return err
+	}
+	for _, role := range currentRoles {
+		if role.ID == roles[0].ID {
+			return nil
+		}
+	}
+	if err := s.roleRepo.AddUserRole(models.DB, userID, roles[0].ID); err != nil {
+		return err
+	}
+	s.invalidateUsers(ctx, userID)
+	return s.authService.RevokeAllTokens(ctx, userID)
+}
+
+// uniqueStrings 去重
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// IsRBACError 判断是否为角色权限业务错误（可直接返回给用户）
+func IsRBACError(err error) bool {
+	return errors.Is(err, ErrRoleNotFound) ||
+		errors.Is(err, ErrRoleExists) ||
+		errors.Is(err, ErrRoleSystem) ||
+		errors.Is(err, ErrRoleSuperAdmin) ||
+		errors.Is(err, ErrPermissionInvalid) ||
+		errors.Is(err, ErrLastSuperAdmin)
+}
@@ -0,0 +1,434 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// productPair 商品对，a < b
+type productPair struct {
+	a, b uint64
+}
+
+// coCounter 共现计数：每个商品出现的次数和每对商品共同出现的次数
+type coCounter struct {
+	items map[uint64]int
+	pairs map[productPair]int
+}
+
+// newCoCounter 创建共现计数
+func newCoCounter() *coCounter {
+	return &coCounter{items: make(map[uint64]int), pairs: make(map[productPair]int)}
+}
+
+// addItem 记录商品出现一次
+func (c *coCounter) addItem(id uint64) {
+	c.items[id]++
+}
+
+// addPair 记录两个商品共同出现一次
+func (c *coCounter) addPair(a, b uint64) {
+	if a == b {
+		return
+	}
+	if a > b {
+		a, b = b, a
+	}
+	c.pairs[productPair{a, b}]++
+}
+
+// similarities 计算余弦相似度 co(a,b)/sqrt(n(a)*n(b))，过滤共现次数不足的商品对
+func (c *coCounter) similarities(minSupport int, fn func(pair productPair, count int, score float64)) {
+	for pair, count := range c.pairs {
+		if count < minSupport {
+			continue
+		}
+		fn(pair, count, float64(count)/math.Sqrt(float64(c.items[pair.a])*float64(c.items[pair.b])))
+	}
+}
+
+// RecommendService 商品推荐业务逻辑层。
+// 离线任务根据订单中的共同购买和浏览记录中的共同浏览计算商品间相似度，每个商品保存得分最高的若干关联商品；
+// 关联商品不足时按同分类销量补足
+type RecommendService struct {
+	recommendRepo *repository.RecommendRepository
+	productRepo   *repository.ProductRepository
+	browseService *BrowseService
+}
+
+// NewRecommendService 创建商品推荐Service实例
+func NewRecommendService() *RecommendService {
+	return &RecommendService{
+		recommendRepo: repository.NewRecommendRepository(),
+		productRepo:   repository.NewProductRepository(),
+		browseService: NewBrowseService(),
+	}
+}
+
+// countCoPurchases 统计最近订单中的共同购买
+func (s *RecommendService) countCoPurchases(ctx context.Context, since time.Time, maxBasket int) (*coCounter, error) {
+	const batchSize = 1000
+	counter := newCoCounter()
+	var lastID uint64
+	for ctx.Err() == nil {
+		orderIDs, err := s.recommendRepo.GetPaidOrderIDs(since, lastID, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(orderIDs) == 0 {
+			break
+		}
+		lastID = orderIDs[len(orderIDs)-1]
+
+		rows, err := s.recommendRepo.GetOrderProducts(orderIDs)
+		if err != nil {
+			return nil, err
+		}
+		baskets := make(map[uint64][]uint64, len(orderIDs))
+		for _, row := range rows {
+			if len(baskets[row.OrderID]) < maxBasket {
+				baskets[row.OrderID] = append(baskets[row.OrderID], row.ProductID)
+			}
+		}
+		for _, basket := range baskets {
+			for i, a := range basket {
+				counter.addItem(a)
+				for _, b := range basket[i+1:] {
+					counter.addPair(a, b)
+				}
+			}
+		}
+
+		if len(orderIDs) < batchSize {
+			break
+		}
+	}
+	return counter, ctx.Err()
+}
+
+// countCoViews 汇总最近days天浏览时累计的共同浏览次数，同一用户在时间窗口内浏览的两个商品记一次
+func (s *RecommendService) countCoViews(ctx context.Context, now time.Time, days int) (*coCounter, error) {
+	counter := newCoCounter()
+	for i := 0; i < days && ctx.Err() == nil; i++ {
+		date := now.AddDate(0, 0, -i).Format("2006-01-02")
+
+		items, err := utils.HGetAll(ctx, fmt.Sprintf(utils.CoViewItemsKey, date))
+		if err != nil {
+			return nil, err
+		}
+		for field, value := range items {
+			id, err := strconv.ParseUint(field, 10, 64)
+			count, countErr := strconv.Atoi(value)
+			if err != nil || countErr != nil {
+				continue
+			}
+			counter.items[id] += count
+		}
+
+		pairs, err := utils.HGetAll(ctx, fmt.Sprintf(utils.CoViewPairsKey, date))
+		if err != nil {
+			return nil, err
+		}
+		for field, value := range pairs {
+			ids := strings.SplitN(field, ":", 2)
+			if len(ids) != 2 {
+				continue
+			}
+			a, errA := strconv.ParseUint(ids[0], 10, 64)
+			b, errB := strconv.ParseUint(ids[1], 10, 64)
+			count, countErr := strconv.Atoi(value)
+			if errA != nil || errB != nil || countErr != nil || a == b {
+				continue
+			}
+			if a > b {
+				a, b = b, a
+			}
+			counter.pairs[productPair{a, b}] += count
+		}
+	}
+	return counter, ctx.Err()
+}
+
+// Compute 计算商品关联并写入数据库，返回写入的关联数
+func (s *RecommendService) Compute(ctx context.Context) (int, error) {
+	cfg := config.GlobalConfig.Recommend
+	topN, minSupport, maxBasket := cfg.TopN, cfg.MinSupport, cfg.MaxBasketSize
+	if topN <= 0 {
+		topN = 20
+	}
+	if minSupport <= 0 {
+		minSupport = 1
+	}
+	if maxBasket <= 0 {
+		maxBasket = 50
+	}
+	// 精确到秒，避免数据库时间精度导致本轮写入的记录被当作过期删除
+	generatedAt := time.Now().Truncate(time.Second)
+
+	bought, err := s.countCoPurchases(ctx, generatedAt.AddDate(0, 0, -cfg.LookbackDays), maxBasket)
+	if err != nil {
+		return 0, err
+	}
+	historyDays := config.GlobalConfig.Browse.HistoryDays
+	if historyDays <= 0 {
+		historyDays = 90
+	}
+	viewed, err := s.countCoViews(ctx, generatedAt, historyDays)
+	if err != nil {
+		return 0, err
+	}
+
+	candidates := make(map[uint64]map[uint64]*models.ProductRelation)
+	relation := func(productID, relatedID uint64) *models.ProductRelation {
+		related, ok := candidates[productID]
+		if !ok {
+			related = make(map[uint64]*models.ProductRelation)
+			candidates[productID] = related
+		}
+		r, ok := related[relatedID]
+		if !ok {
+			r = &models.ProductRelation{ProductID: productID, RelatedID: relatedID, UpdatedAt: generatedAt}
+			related[relatedID] = r
+		}
+		return r
+	}
+	bought.similarities(minSupport, func(pair productPair, count int, score float64) {
+		for _, r := range []*models.ProductRelation{relation(pair.a, pair.b), relation(pair.b, pair.a)} {
+			r.Score += cfg.PurchaseWeight * score
+			r.CoBought = count
+		}
+	})
+	viewed.similarities(minSupport, func(pair productPair, count int, score float64) {
+		for _, r := range []*models.ProductRelation{relation(pair.a, pair.b), relation(pair.b, pair.a)} {
+			r.Score += cfg.ViewWeight * score
+			r.CoViewed = count
+		}
+	})
+
+	relations := make([]*models.ProductRelation, 0, len(candidates)*topN)
+	for _, related := range candidates {
+		list := make([]*models.ProductRelation, 0, len(related))
+		for _, r := range related {
+			r.Score = math.Round(r.Score*1e6) / 1e6
+			list = append(list, r)
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Score != list[j].Score {
+				return list[i].Score > list[j].Score
+			}
+			return list[i].RelatedID < list[j].RelatedID
+		})
+		if len(list) > topN {
+			list = list[:topN]
+		}
+		relations = append(relations, list...)
+	}
+
+	if err := s.recommendRepo.SaveRelations(relations); err != nil {
+		return 0, err
+	}
+	if _, err := s.recommendRepo.DeleteStaleRelations(generatedAt); err != nil {
+		return len(relations), err
+	}
+	return len(relations), nil
+}
+
+// loadProducts 按ID顺序加载商品，跳过不存在的商品
+func (s *RecommendService) loadProducts(ids []uint64) ([]*models.Product, error) {
+	if len(ids) == 0 {
+		return []*models.Product{}, nil
+	}
+	products, err := s.productRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	productMap := make(map[uint64]*models.Product, len(products))
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+	result := make([]*models.Product, 0, len(ids))
+	for _, id := range ids {
+		if product := productMap[id]; product != nil {
+			result = append(result, product)
+		}
+	}
+	return result, nil
+}
+
+// fillByCategory 推荐不足limit个时，用这些分类下销量最高的在售商品补足
+func (s *RecommendService) fillByCategory(ids []uint64, categoryIDs []uint64, exclude []uint64, limit int) ([]uint64, error) {
+	if len(ids) >= limit || len(categoryIDs) == 0 {
+		return ids, nil
+	}
+	excludeIDs := append(append([]uint64{}, exclude...), ids...)
+	fallback, err := s.productRepo.GetTopSellingInCategories(categoryIDs, excludeIDs, limit-len(ids))
+	if err != nil {
+		return nil, err
+	}
+	for _, product := range fallback {
+		ids = append(ids, product.ID)
+	}
+	return ids, nil
+}
+
+// GetRelated 获取商品详情页的相关推荐
+func (s *RecommendService) GetRelated(productID uint64, limit int) ([]*models.Product, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	relations, err := s.recommendRepo.GetRelations([]uint64{productID}, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, 0, limit)
+	for _, relation := range relations {
+		ids = append(ids, relation.RelatedID)
+	}
+
+	ids, err = s.fillByCategory(ids, []uint64{product.CategoryID}, []uint64{productID}, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadProducts(ids)
+}
+
+// GetCartRecommendations 获取购物车页的推荐：合并购物车各商品的关联商品得分，排除已在购物车中的商品。
+// productIDs为空时使用用户购物车中的商品
+func (s *RecommendService) GetCartRecommendations(userID uint64, productIDs []uint64, limit int) ([]*models.Product, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	if len(productIDs) == 0 && userID > 0 {
+		cartIDs, err := s.recommendRepo.GetCartProductIDs(userID)
+		if err != nil {
+			return nil, err
+		}
+		productIDs = cartIDs
+	}
+	return s.recommendFromSeeds(uniqueIDs(productIDs), limit)
+}
+
+// GetForUser 根据用户最近浏览和购买的商品推荐，排除这些商品本身。
+// 用户没有浏览和购买记录时返回空列表
+func (s *RecommendService) GetForUser(ctx context.Context, userID uint64, limit int) ([]*models.Product, error) {
+	const seedSize = 20
+	viewed, err := s.browseService.RecentProductIDs(ctx, userID, seedSize)
+	if err != nil {
+		// 浏览记录不可用时仅根据购买记录推荐
+		log.Printf("Failed to load browse history of user %d: %v", userID, err)
+	}
+	bought, err := s.recommendRepo.GetPurchasedProductIDs(userID, seedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	seeds := uniqueIDs(append(viewed, bought...))
+	if len(seeds) == 0 {
+		return []*models.Product{}, nil
+	}
+	return s.recommendFromSeeds(seeds, limit)
+}
+
+// recommendFromSeeds 合并种子商品的关联商品得分，排除种子商品本身；
+// 不足limit个时按种子商品所在分类的销量补足，仍不足时用热门商品补足
+func (s *RecommendService) recommendFromSeeds(seeds []uint64, limit int) ([]*models.Product, error) {
+	excluded := make(map[uint64]bool, len(seeds))
+	for _, id := range seeds {
+		excluded[id] = true
+	}
+
+	ids := make([]uint64, 0, limit)
+	var categoryIDs []uint64
+	if len(seeds) > 0 {
+		relations, err := s.recommendRepo.GetRelations(seeds, 500)
+		if err != nil {
+			return nil, err
+		}
+		scores := make(map[uint64]float64)
+		for _, relation := range relations {
+			if !excluded[relation.RelatedID] {
+				scores[relation.RelatedID] += relation.Score
+			}
+		}
+		for id := range scores {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			if scores[ids[i]] != scores[ids[j]] {
+				return scores[ids[i]] > scores[ids[j]]
+			}
+			return ids[i] < ids[j]
+		})
+		if len(ids) > limit {
+			ids = ids[:limit]
+		}
+
+		products, err := s.productRepo.GetByIDs(seeds)
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range products {
+			categoryIDs = append(categoryIDs, product.CategoryID)
+		}
+	}
+
+	ids, err := s.fillByCategory(ids, uniqueIDs(categoryIDs), seeds, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) < limit {
+		hot, err := s.productRepo.GetHotProducts(limit * 2)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[uint64]bool, len(ids))
+		for _, id := range ids {
+			seen[id] = true
+		}
+		for _, product := range hot {
+			if len(ids) >= limit {
+				break
+			}
+			if !excluded[product.ID] && !seen[product.ID] {
+				ids = append(ids, product.ID)
+			}
+		}
+	}
+	return s.loadProducts(ids)
+}
+
+// RunWorker 定期计算商品关联
+func (s *RecommendService) RunWorker(ctx context.Context) {
+	interval := time.Duration(config.GlobalConfig.Recommend.IntervalHours) * time.Hour
+	runPeriodic(ctx, utils.RecommendWorkerLockKey, interval, func(ctx context.Context) {
+		start := time.Now()
+		count, err := s.Compute(ctx)
+		if err != nil {
+			log.Printf("Failed to compute product relations: %v", err)
+			return
+		}
+		log.Printf("Computed %d product relations in %s", count, time.Since(start).Round(time.Millisecond))
+	})
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSKUInStock SKU有货，无需订阅
+	ErrSKUInStock = errors.New("商品有货，无需订阅到货提醒")
+
+	// ErrSubscriptionNotFound 订阅不存在
+	ErrSubscriptionNotFound = errors.New("订阅不存在")
+)
+
+// restockFanoutLockTTL 单个SKU发送任务锁的有效期，防止任务异常退出后锁不释放
+const restockFanoutLockTTL = 10 * time.Minute
+
+// StockSubscriptionView 订阅及SKU信息
+type StockSubscriptionView struct {
+	*models.StockSubscription
+	SKU *models.ProductSKU `json:"sku"`
+}
+
+// RestockService 到货提醒业务逻辑层。
+// SKU库存由0补充后按批次发送提醒，每个订阅只提醒一次；超过每用户频率限制的提醒延后由后台任务补发
+type RestockService struct {
+	subscriptionRepo    *repository.StockSubscriptionRepository
+	productRepo         *repository.ProductRepository
+	notificationService *NotificationService
+}
+
+// NewRestockService 创建到货提醒Service实例
+func NewRestockService() *RestockService {
+	return &RestockService{
+		subscriptionRepo:    repository.NewStockSubscriptionRepository(),
+		productRepo:         repository.NewProductRepository(),
+		notificationService: NewNotificationService(),
+	}
+}
+
+// Subscribe 订阅缺货SKU的到货提醒，已提醒过的订阅重新生效
+func (s *RestockService) Subscribe(userID uint64, productID uint64, skuID uint64) (*models.StockSubscription, error) {
+	sku, err := s.productRepo.GetSKU(productID, skuID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSKUNotFound
+		}
+		return nil, err
+	}
+	if sku.Stock > 0 {
+		return nil, ErrSKUInStock
+	}
+
+	err = s.subscriptionRepo.Upsert(&models.StockSubscription{
+		UserID:    userID,
+		SKUID:     skuID,
+		ProductID: productID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.subscriptionRepo.GetByUserSKU(userID, skuID)
+}
+
+// Unsubscribe 取消订阅
+func (s *RestockService) Unsubscribe(userID uint64, id uint64) error {
+	deleted, err := s.subscriptionRepo.Delete(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// GetSubscriptions 分页获取用户的到货提醒订阅
+func (s *RestockService) GetSubscriptions(userID uint64, page, pageSize int) ([]*StockSubscriptionView, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	subscriptions, total, err := s.subscriptionRepo.GetSubscriptions(userID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skuIDs := make([]uint64, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		skuIDs = append(skuIDs, subscription.SKUID)
+	}
+	skuMap := make(map[uint64]*models.ProductSKU, len(skuIDs))
+	if len(skuIDs) > 0 {
+		skus, err := s.productRepo.GetSKUsByIDs(skuIDs)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, sku := range skus {
+			skuMap[sku.ID] = sku
+		}
+	}
+
+	views := make([]*StockSubscriptionView, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		views = append(views, &StockSubscriptionView{StockSubscription: subscription, SKU: skuMap[subscription.SKUID]})
+	}
+	return views, total, nil
+}
+
+// Replenished SKU库存由0补充后调用，异步发送到货提醒
+func (s *RestockService) Replenished(skuIDs []uint64) {
+	if len(skuIDs) == 0 {
+		return
+	}
+	go func() {
+		ctx := context.Background()
+		for _, skuID := range skuIDs {
+			if _, err := s.FanOut(ctx, skuID); err != nil {
+				log.Printf("Failed to send restock notifications for sku %d: %v", skuID, err)
+			}
+		}
+	}()
+}
+
+// allowUser 检查用户本小时收到的到货提醒是否未超过限制，Redis不可用时不限制
+func (s *RestockService) allowUser(ctx context.Context, userID uint64) bool {
+	limit := config.GlobalConfig.Restock.MaxPerUserHour
+	if limit <= 0 {
+		return true
+	}
+
+	key := fmt.Sprintf(utils.RestockUserRateKey, userID, time.Now().Format("2006010215"))
+	count, err := utils.Incr(ctx, key)
+	if err != nil {
+		log.Printf("Failed to check restock rate limit for user %d: %v", userID, err)
+		return true
+	}
+	if count == 1 {
+		_ = utils.Expire(ctx, key, time.Hour)
+	}
+	return count <= int64(limit)
+}
+
+// FanOut 向某SKU等待到货的订阅者分批发送提醒，返回发送数。
+// 同一SKU同时只有一个发送任务；SKU缺货或商品下架时不发送
+func (s *RestockService) FanOut(ctx context.Context, skuID uint64) (int, error) {
+	lockKey := fmt.Sprintf(utils.RestockFanoutLockKey, skuID)
+	acquired, err := utils.SetNx(ctx, lockKey, 1, restockFanoutLockTTL)
+	if err != nil || !acquired {
+		return 0, err
+	}
+	defer func() { _ = utils.Del(ctx, lockKey) }()
+
+	skus, err := s.productRepo.GetSKUsByIDs([]uint64{skuID})
+	if err != nil || len(skus) == 0 {
+		return 0, err
+	}
+	sku := skus[0]
+	if sku.Stock <= 0 || sku.Product.ID == 0 || sku.Product.Status != 1 {
+		return 0, nil
+	}
+
+	batchSize := config.GlobalConfig.Restock.BatchSize
+	if batchSize <= 0 {
+		batchSize = 200
+	}
+
+	sent := 0
+	var lastID uint64
+	for ctx.Err() == nil {
+		subscriptions, err := s.subscriptionRepo.GetPendingBatch(skuID, lastID, batchSize)
+		if err != nil {
+			return sent, err
+		}
+		if len(subscriptions) == 0 {
+			break
+		}
+		lastID = subscriptions[len(subscriptions)-1].ID
+
+		notifications := make([]*models.Notification, 0, len(subscriptions))
+		notifiedIDs := make([]uint64, 0, len(subscriptions))
+		for _, subscription := range subscriptions {
+			// 超过频率限制的订阅保持等待状态，由后台任务稍后补发
+			if !s.allowUser(ctx, subscription.UserID) {
+				continue
+			}
+			notifications = append(notifications, &models.Notification{
+				UserID:  subscription.UserID,
+				Type:    models.NotificationRestock,
+				Title:   "订阅的商品到货了",
+				Content: fmt.Sprintf("您订阅的「%s %s」已到货，库存有限，先到先得", sku.Product.Name, sku.Name),
+				Link:    fmt.Sprintf("/products/%d", sku.ProductID),
+			})
+			notifiedIDs = append(notifiedIDs, subscription.ID)
+		}
+
+		if err := s.notificationService.Send(ctx, notifications); err != nil {
+			return sent, err
+		}
+		if err := s.subscriptionRepo.MarkNotified(notifiedIDs); err != nil {
+			return sent, err
+		}
+		sent += len(notifications)
+
+		if len(subscriptions) < batchSize {
+			break
+		}
+	}
+	return sent, nil
+}
+
+// RunWorker 定期补发因频率限制延后或发送失败的到货提醒
+func (s *RestockService) RunWorker(ctx context.Context) {
+	interval := time.Duration(config.GlobalConfig.Restock.WorkerIntervalMinutes) * time.Minute
+	runPeriodic(ctx, utils.RestockWorkerLockKey, interval, func(ctx context.Context) {
+		skuIDs, err := s.subscriptionRepo.GetPendingInStockSKUIDs(500)
+		if err != nil {
+			log.Printf("Failed to load pending restock subscriptions: %v", err)
+			return
+		}
+
+		sent := 0
+		for _, skuID := range skuIDs {
+			count, err := s.FanOut(ctx, skuID)
+			if err != nil {
+				log.Printf("Failed to send restock notifications for sku %d: %v", skuID, err)
+			}
+			sent += count
+		}
+		if sent > 0 {
+			log.Printf("Sent %d restock notifications", sent)
+		}
+	})
+}
+
+// IsRestockError 判断是否为到货提醒业务错误（可直接返回给用户）
+func IsRestockError(err error) bool {
+	return errors.Is(err, ErrSKUInStock) ||
+		errors.Is(err, ErrSubscriptionNotFound) ||
+		errors.Is(err, ErrSKUNotFound)
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"errors"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrReviewNotAllowed 订单未完成
+	ErrReviewNotAllowed = errors.New("订单完成后才能评价")
+
+	// ErrReviewItemNotFound 订单商品不存在
+	ErrReviewItemNotFound = errors.New("订单中没有该商品")
+
+	// ErrReviewExists 已评价
+	ErrReviewExists = errors.New("该商品已评价")
+)
+
+// ReviewInput 发表评价参数
+type ReviewInput struct {
+	OrderItemID uint64
+	Rating      int
+	Content     string
+	Images      []string
+}
+
+// ReviewView 商品评价（公开信息）
+type ReviewView struct {
+	ID             uint64            `json:"id"`
+	Nickname       string            `json:"nickname"`
+	Avatar         string            `json:"avatar"`
+	Specifications map[string]string `json:"specifications"` // 购买的商品规格
+	Rating         int               `json:"rating"`
+	Content        string            `json:"content"`
+	Images         []string          `json:"images"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// ReviewService 商品评价业务逻辑层，评价在同一事务内发放评价奖励积分并完成首次评价任务
+type ReviewService struct {
+	reviewRepo    *repository.ReviewRepository
+	orderRepo     *repository.OrderRepository
+	userRepo      *repository.UserRepository
+	orderService  *OrderService
+	pointsService *PointsService
+	taskService   *TaskService
+}
+
+// NewReviewService 创建商品评价Service实例
+func NewReviewService() *ReviewService {
+	return &ReviewService{
+		reviewRepo:    repository.NewReviewRepository(),
+		orderRepo:     repository.NewOrderRepository(),
+		userRepo:      repository.NewUserRepository(),
+		orderService:  NewOrderService(),
+		pointsService: NewPointsService(),
+		taskService:   NewTaskService(),
+	}
+}
+
+// Create 评价已完成订单中的商品，每件订单商品只能评价一次
+func (s *ReviewService) Create(userID uint64, orderID uint64, input *ReviewInput) (*models.ProductReview, error) {
+	order, err := s.orderService.GetOrder(userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.OrderStatus != models.OrderStatusCompleted {
+		return nil, ErrReviewNotAllowed
+	}
+	var item *models.OrderItem
+	for i := range order.OrderItems {
+		if order.OrderItems[i].ID == input.OrderItemID {
+			item = &order.OrderItems[i]
+			break
+		}
+	}
+	if item == nil {
+		return nil, ErrReviewItemNotFound
+	}
+
+	review := &models.ProductReview{
+		UserID:      userID,
+		OrderID:     order.ID,
+		OrderItemID: item.ID,
+		ProductID:   item.ProductID,
+		SKUID:       item.SKUID,
+		Rating:      input.Rating,
+		Content:     input.Content,
+	}
+	review.SetImages(input.Images)
+
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.reviewRepo.Create(tx, review); err != nil {
+			if models.IsDuplicateKey(err) {
+				return ErrReviewExists
+			}
+			return err
+		}
+		if err := s.pointsService.EarnForReview(tx, userID, review.ID); err != nil {
+			return err
+		}
+		return s.taskService.Complete(tx, userID, models.TaskFirstReview)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// GetProductReviews 分页获取商品评价，附带评价人的昵称、头像和购买的规格
+func (s *ReviewService) GetProductReviews(productID uint64, page, pageSize int) ([]*ReviewView, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	reviews, total, err := s.reviewRepo.GetProductReviews(productID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(reviews) == 0 {
+		return []*ReviewView{}, total, nil
+	}
+
+	userIDs := make([]uint64, 0, len(reviews))
+	itemIDs := make([]uint64, 0, len(reviews))
+	for _, review := range reviews {
+		userIDs = append(userIDs, review.UserID)
+		itemIDs = append(itemIDs, review.OrderItemID)
+	}
+	users, err := s.userRepo.GetByIDs(userIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	userMap := make(map[uint64]*models.User, len(users))
+	for _, user := range users {
+		userMap[user.ID] = user
+	}
+	items, err := s.orderRepo.GetItemsByIDs(itemIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	specMap := make(map[uint64]map[string]string, len(items))
+	for _, item := range items {
+		specMap[item.ID] = item.GetSpecifications()
+	}
+
+	views := make([]*ReviewView, 0, len(reviews))
+	for _, review := range reviews {
+		view := &ReviewView{
+			ID:             review.ID,
+			Specifications: specMap[review.OrderItemID],
+			Rating:         review.Rating,
+			Content:        review.Content,
+			Images:         review.GetImages(),
+			CreatedAt:      review.CreatedAt,
+		}
+		if user, ok := userMap[review.UserID]; ok {
+			view.Nickname = user.Nickname
+			view.Avatar = user.Avatar
+		}
+		views = append(views, view)
+	}
+	return views, total, nil
+}
+
+// IsReviewError 判断是否为评价业务错误（可直接返回给用户）
+func IsReviewError(err error) bool {
+	return errors.Is(err, ErrReviewNotAllowed) ||
+		errors.Is(err, ErrReviewItemNotFound) ||
+		errors.Is(err, ErrReviewExists) ||
+		errors.Is(err, ErrOrderNotFound)
+}
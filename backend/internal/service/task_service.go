@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrAlreadyCheckedIn 今天已签到
+	ErrAlreadyCheckedIn = errors.New("今天已经签到过了")
+
+	// ErrCheckinMonthInvalid 签到月份不合法
+	ErrCheckinMonthInvalid = errors.New("月份格式错误")
+
+	// ErrTaskNotFound 任务不存在
+	ErrTaskNotFound = errors.New("任务不存在")
+
+	// ErrTaskRewardInvalid 任务奖励设置不合法
+	ErrTaskRewardInvalid = errors.New("积分奖励需大于0，优惠券奖励需指定优惠券和数量")
+)
+
+const (
+	checkinDateLayout  = "2006-01-02"
+	checkinMonthLayout = "2006-01"
+
+	// checkinCalendarTTL 签到日历位图保留时间，过期后按需从数据库重建
+	checkinCalendarTTL = 400 * 24 * time.Hour
+)
+
+// CheckinResult 签到结果
+type CheckinResult struct {
+	Date   string `json:"date"`
+	Streak int    `json:"streak"` // 连续签到天数
+	Points int64  `json:"points"` // 本次获得积分
+}
+
+// CheckinCalendar 签到日历
+type CheckinCalendar struct {
+	Month          string `json:"month"`
+	Days           []int  `json:"days"` // 已签到的日期
+	CheckedInToday bool   `json:"checked_in_today"`
+	Streak         int    `json:"streak"`      // 当前连续签到天数
+	NextReward     int64  `json:"next_reward"` // 下次签到可获得的积分
+}
+
+// TaskView 任务及用户完成情况
+type TaskView struct {
+	*models.Task
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// TaskInput 更新任务参数，为nil的字段不修改
+type TaskInput struct {
+	Name           *string
+	Description    *string
+	RewardType     *string
+	RewardPoints   *int64
+	CouponID       *uint64
+	CouponQuantity *int
+	Sort           *int
+	Status         *int
+}
+
+// TaskService 签到和任务中心业务逻辑层。
+// 签到以数据库记录保证每天只签到一次，Redis位图用于日历展示；任务奖励在完成时自动发放且只发放一次
+type TaskService struct {
+	taskRepo      *repository.TaskRepository
+	userRepo      *repository.UserRepository
+	couponRepo    *repository.CouponRepository
+	pointsService *PointsService
+}
+
+// NewTaskService 创建任务Service实例
+func NewTaskService() *TaskService {
+	return &TaskService{
+		taskRepo:      repository.NewTaskRepository(),
+		userRepo:      repository.NewUserRepository(),
+		couponRepo:    repository.NewCouponRepository(),
+		pointsService: NewPointsService(),
+	}
+}
+
+// checkinReward 连续签到第streak天的奖励积分
+func checkinReward(streak int) int64 {
+	rewards := config.GlobalConfig.Checkin.Rewards
+	if len(rewards) == 0 || streak <= 0 {
+		return 0
+	}
+	if streak > len(rewards) {
+		streak = len(rewards)
+	}
+	return rewards[streak-1]
+}
+
+// checkinCalendarKey 用户某月的签到日历key
+func checkinCalendarKey(userID uint64, month string) string {
+	return fmt.Sprintf(utils.CheckinCalendarKey, userID, month)
+}
+
+// currentStreak 截至今天的连续签到天数，今天未签到时按昨天计算
+func (s *TaskService) currentStreak(tx *gorm.DB, userID uint64, now time.Time) (streak int, checkedInToday bool, err error) {
+	for i, date := range []string{now.Format(checkinDateLayout), now.AddDate(0, 0, -1).Format(checkinDateLayout)} {
+		record, err := s.taskRepo.GetCheckin(tx, userID, date)
+		if err == nil {
+			return record.Streak, i == 0, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, false, err
+		}
+	}
+	return 0, false, nil
+}
+
+// Checkin 每日签到，按连续签到天数发放递增的积分
+func (s *TaskService) Checkin(ctx context.Context, userID uint64) (*CheckinResult, error) {
+	now := time.Now()
+	record := &models.CheckinRecord{
+		UserID: userID,
+		Date:   now.Format(checkinDateLayout),
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		streak, checkedInToday, err := s.currentStreak(tx, userID, now)
+		if err != nil {
+			return err
+		}
+		if checkedInToday {
+			return ErrAlreadyCheckedIn
+		}
+
+		record.Streak = streak + 1
+		record.Points = checkinReward(record.Streak)
+		// 并发签到时由唯一索引保证只有一次成功
+		created, err := s.taskRepo.CreateCheckin(tx, record)
+		if err != nil {
+			return err
+		}
+		if !created {
+			return ErrAlreadyCheckedIn
+		}
+
+		return s.pointsService.Earn(tx, userID, record.Points, models.PointsSourceCheckin, record.ID,
+			fmt.Sprintf("连续签到第%d天", record.Streak))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 位图已被淘汰时从数据库重建（包含今天），否则只置今天的位；写入失败时删除该月位图，下次查询重建
+	key := checkinCalendarKey(userID, now.Format(checkinMonthLayout))
+	exists, err := utils.Exists(ctx, key)
+	switch {
+	case err != nil:
+		log.Printf("Failed to check checkin calendar for user %d: %v", userID, err)
+		_ = utils.Del(ctx, key)
+	case !exists:
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		if _, err := s.rebuildCalendar(ctx, userID, monthStart); err != nil {
+			log.Printf("Failed to rebuild checkin calendar for user %d: %v", userID, err)
+		}
+	default:
+		if err := utils.SetBit(ctx, key, int64(now.Day()-1), 1); err != nil {
+			log.Printf("Failed to update checkin calendar for user %d: %v", userID, err)
+			_ = utils.Del(ctx, key)
+		} else {
+			_ = utils.Expire(ctx, key, checkinCalendarTTL)
+		}
+	}
+
+	return &CheckinResult{
+		Date:   record.Date,
+		Streak: record.Streak,
+		Points: record.Points,
+	}, nil
+}
+
+// GetCheckinCalendar 获取签到日历，month格式为2006-01，为空时为本月
+func (s *TaskService) GetCheckinCalendar(ctx context.Context, userID uint64, month string) (*CheckinCalendar, error) {
+	now := time.Now()
+	if month == "" {
+		month = now.Format(checkinMonthLayout)
+	}
+	start, err := time.ParseInLocation(checkinMonthLayout, month, now.Location())
+	if err != nil || start.After(now) {
+		return nil, ErrCheckinMonthInvalid
+	}
+
+	days, err := s.calendarDays(ctx, userID, start)
+	if err != nil {
+		return nil, err
+	}
+
+	streak, checkedInToday, err := s.currentStreak(models.DB, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckinCalendar{
+		Month:          month,
+		Days:           days,
+		CheckedInToday: checkedInToday,
+		Streak:         streak,
+		NextReward:     checkinReward(streak + 1),
+	}, nil
+}
+
+// calendarDays 从位图读取某月已签到的日期，位图不存在时从数据库重建
+func (s *TaskService) calendarDays(ctx context.Context, userID uint64, start time.Time) ([]int, error) {
+	key := checkinCalendarKey(userID, start.Format(checkinMonthLayout))
+	bitmap, err := utils.Get(ctx, key)
+	if err == nil {
+		days := make([]int, 0)
+		for i := 0; i < len(bitmap)*8; i++ {
+			if bitmap[i/8]&(0x80>>(i%8)) != 0 {
+				days = append(days, i+1)
+			}
+		}
+		return days, nil
+	}
+	if err != redis.Nil {
+		log.Printf("Failed to read checkin calendar for user %d: %v", userID, err)
+	}
+	return s.rebuildCalendar(ctx, userID, start)
+}
+
+// rebuildCalendar 从签到记录重建某月的签到位图，返回已签到的日期
+func (s *TaskService) rebuildCalendar(ctx context.Context, userID uint64, start time.Time) ([]int, error) {
+	key := checkinCalendarKey(userID, start.Format(checkinMonthLayout))
+	end := start.AddDate(0, 1, -1)
+	dates, err := s.taskRepo.GetCheckinDates(userID, start.Format(checkinDateLayout), end.Format(checkinDateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]int, 0, len(dates))
+	for _, date := range dates {
+		day, err := strconv.Atoi(date[len(date)-2:])
+		if err != nil {
+			continue
+		}
+		days = append(days, day)
+		if err := utils.SetBit(ctx, key, int64(day-1), 1); err != nil {
+			log.Printf("Failed to rebuild checkin calendar for user %d: %v", userID, err)
+		}
+	}
+	if len(days) > 0 {
+		_ = utils.Expire(ctx, key, checkinCalendarTTL)
+	}
+	return days, nil
+}
+
+// GetTasks 获取启用的任务及当前用户的完成情况
+func (s *TaskService) GetTasks(userID uint64) ([]*TaskView, error) {
+	// 补发功能上线前已完善资料的用户
+	if err := s.CheckProfile(userID); err != nil {
+		log.Printf("Failed to check profile task for user %d: %v", userID, err)
+	}
+
+	tasks, err := s.taskRepo.GetTasks(true)
+	if err != nil {
+		return nil, err
+	}
+	userTasks, err := s.taskRepo.GetUserTasks(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]*TaskView, 0, len(tasks))
+	for _, task := range tasks {
+		view := &TaskView{Task: task}
+		if userTask, ok := userTasks[task.ID]; ok {
+			view.Completed = true
+			view.CompletedAt = &userTask.CompletedAt
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// Complete 标记用户完成任务并发放奖励，需在业务事务内调用。
+// 任务不存在或已停用时忽略；同一任务只发放一次
+func (s *TaskService) Complete(tx *gorm.DB, userID uint64, code string) error {
+	task, err := s.taskRepo.GetTaskByCode(tx, code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if task.Status != 1 {
+		return nil
+	}
+
+	created, err := s.taskRepo.CreateUserTask(tx, &models.UserTask{
+		UserID:      userID,
+		TaskID:      task.ID,
+		CompletedAt: time.Now(),
+	})
+	if err != nil || !created {
+		return err
+	}
+
+	switch task.RewardType {
+	case models.TaskRewardPoints:
+		return s.pointsService.Earn(tx, userID, task.RewardPoints, models.PointsSourceTask, task.ID,
+			fmt.Sprintf("完成任务：%s", task.Name))
+	case models.TaskRewardCoupon:
+		return s.grantCoupon(tx, userID, task)
+	}
+	return nil
+}
+
+// grantCoupon 发放任务奖励的优惠券，优惠券停用时跳过
+func (s *TaskService) grantCoupon(tx *gorm.DB, userID uint64, task *models.Task) error {
+	coupons, err := s.couponRepo.GetByIDs([]uint64{task.CouponID})
+	if err != nil {
+		return err
+	}
+	if len(coupons) == 0 || coupons[0].Status != 1 {
+		return nil
+	}
+
+	userCoupons := make([]*models.UserCoupon, 0, task.CouponQuantity)
+	for i := 0; i < task.CouponQuantity; i++ {
+		userCoupons = append(userCoupons, &models.UserCoupon{UserID: userID, CouponID: task.CouponID})
+	}
+	return s.couponRepo.CreateUserCoupons(tx, userCoupons)
+}
+
+// CheckProfile 资料完善（昵称、头像及手机号或邮箱）时完成对应任务
+func (s *TaskService) CheckProfile(userID uint64) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.Nickname == "" || user.Avatar == "" || (user.Phone == "" && user.Email == "") {
+		return nil
+	}
+
+	return models.DB.Transaction(func(tx *gorm.DB) error {
+		return s.Complete(tx, userID, models.TaskCompleteProfile)
+	})
+}
+
+// GetAllTasks 获取全部任务（管理员）
+func (s *TaskService) GetAllTasks() ([]*models.Task, error) {
+	return s.taskRepo.GetTasks(false)
+}
+
+// GetTask 获取任务详情
+func (s *TaskService) GetTask(id uint64) (*models.Task, error) {
+	task, err := s.taskRepo.GetTaskByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+	return task, nil
+}
+
+// UpdateTask 更新任务名称、奖励和状态（管理员）
+func (s *TaskService) UpdateTask(id uint64, input *TaskInput) (*models.Task, error) {
+	task, err := s.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if input.Name != nil {
+		updates["name"] = *input.Name
+	}
+	if input.Description != nil {
+		updates["description"] = *input.Description
+	}
+	if input.RewardType != nil {
+		updates["reward_type"] = *input.RewardType
+		task.RewardType = *input.RewardType
+	}
+	if input.RewardPoints != nil {
+		updates["reward_points"] = *input.RewardPoints
+		task.RewardPoints = *input.RewardPoints
+	}
+	if input.CouponID != nil {
+		updates["coupon_id"] = *input.CouponID
+		task.CouponID = *input.CouponID
+	}
+	if input.CouponQuantity != nil {
+		updates["coupon_quantity"] = *input.CouponQuantity
+		task.CouponQuantity = *input.CouponQuantity
+	}
+	if input.Sort != nil {
+		updates["sort"] = *input.Sort
+	}
+	if input.Status != nil {
+		updates["status"] = *input.Status
+	}
+
+	// 校验更新后的奖励
+	switch task.RewardType {
+	case models.TaskRewardPoints:
+		if task.RewardPoints <= 0 {
+			return nil, ErrTaskRewardInvalid
+		}
+	case models.TaskRewardCoupon:
+		if task.CouponID == 0 || task.CouponQuantity <= 0 {
+			return nil, ErrTaskRewardInvalid
+		}
+		coupons, err := s.couponRepo.GetByIDs([]uint64{task.CouponID})
+		if err != nil {
+			return nil, err
+		}
+		if len(coupons) == 0 {
+			return nil, ErrCouponNotFound
+		}
+	default:
+		return nil, ErrTaskRewardInvalid
+	}
+
+	if len(updates) > 0 {
+		if err := s.taskRepo.UpdateTask(id, updates); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetTask(id)
+}
+
+// IsTaskError 判断是否为签到/任务业务错误（可直接返回给用户）
+func IsTaskError(err error) bool {
+	return errors.Is(err, ErrAlreadyCheckedIn) ||
+		errors.Is(err, ErrCheckinMonthInvalid) ||
+		errors.Is(err, ErrTaskNotFound) ||
+		errors.Is(err, ErrTaskRewardInvalid) ||
+		errors.Is(err, ErrCouponNotFound)
+}
@@ -0,0 +1,434 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"online-mall/internal/config"
+	"online-mall/internal/models"
+	"online-mall/internal/pkg/totp"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTwoFactorNotSetup 未开始绑定
+	ErrTwoFactorNotSetup = errors.New("请先获取双因素认证密钥")
+
+	// ErrTwoFactorNotEnabled 未启用
+	ErrTwoFactorNotEnabled = errors.New("未启用双因素认证")
+
+	// ErrTwoFactorAlreadyEnabled 已启用
+	ErrTwoFactorAlreadyEnabled = errors.New("已启用双因素认证")
+
+	// ErrTwoFactorMandatory 管理员不能关闭
+	ErrTwoFactorMandatory = errors.New("管理员账号必须启用双因素认证")
+
+	// ErrTwoFactorCodeInvalid 验证码错误
+	ErrTwoFactorCodeInvalid = errors.New("动态验证码或恢复码错误")
+
+	// ErrTwoFactorChallengeInvalid 登录第二步已失效
+	ErrTwoFactorChallengeInvalid = errors.New("登录验证已过期，请重新登录")
+
+	// ErrTwoFactorTooManyAttempts 登录第二步尝试次数过多
+	ErrTwoFactorTooManyAttempts = errors.New("验证失败次数过多，请重新登录")
+)
+
+// TwoFactorSetup 绑定验证器App所需信息
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"` // 前端据此生成二维码
+}
+
+// TwoFactorStatus 双因素认证状态
+type TwoFactorStatus struct {
+	Enabled            bool       `json:"enabled"`
+	Required           bool       `json:"required"` // 是否为强制启用（管理员）
+	EnabledAt          *time.Time `json:"enabled_at"`
+	RecoveryCodesCount int64      `json:"recovery_codes_count"` // 剩余可用恢复码
+}
+
+// TwoFactorLogin 登录第二步通过后的结果
+type TwoFactorLogin struct {
+	UserID        uint64
+	Device        string
+	RecoveryCodes []string // 首次绑定时生成的恢复码
+}
+
+// TwoFactorService 双因素认证业务逻辑层
+type TwoFactorService struct {
+	repo        *repository.TwoFactorRepository
+	authService *AuthService
+}
+
+// NewTwoFactorService 创建双因素认证Service实例
+func NewTwoFactorService() *TwoFactorService {
+	return &TwoFactorService{
+		repo:        repository.NewTwoFactorRepository(),
+		authService: NewAuthService(),
+	}
+}
+
+// secretCipher 根据配置创建密钥加密器
+func secretCipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(config.GlobalConfig.TwoFactor.EncryptKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptSecret 加密TOTP密钥
+func encryptSecret(secret string) (string, error) {
+	aead, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret 解密TOTP密钥
+func decryptSecret(encrypted string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	aead, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("invalid encrypted secret")
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// normalizeRecoveryCode 规范化恢复码，忽略大小写、空格和连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// generateRecoveryCodes 生成恢复码，返回明文和哈希
+func generateRecoveryCodes() ([]string, []string, error) {
+	count := config.GlobalConfig.TwoFactor.RecoveryCodeCount
+	if count <= 0 {
+		count = 10
+	}
+	codes := make([]string, 0, count)
+	hashes := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		raw, err := utils.RandomHex(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashRefreshToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// Required 判断用户登录是否需要第二步验证
+func (s *TwoFactorService) Required(user *models.User) (bool, error) {
+	if s.authService.UserRole(user) == "admin" {
+		return true, nil
+	}
+	record, err := s.repo.GetByUserID(user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return record.IsEnabled(), nil
+}
+
+// GetStatus 获取双因素认证状态
+func (s *TwoFactorService) GetStatus(user *models.User) (*TwoFactorStatus, error) {
+	status := &TwoFactorStatus{Required: s.authService.UserRole(user) == "admin"}
+
+	record, err := s.repo.GetByUserID(user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return status, nil
+		}
+		return nil, err
+	}
+	if !record.IsEnabled() {
+		return status, nil
+	}
+
+	status.Enabled = true
+	status.EnabledAt = record.EnabledAt
+	status.RecoveryCodesCount, err = s.repo.CountUnusedRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// BeginSetup 生成新的TOTP密钥，验证通过后才会启用
+func (s *TwoFactorService) BeginSetup(user *models.User) (*TwoFactorSetup, error) {
+	record, err := s.repo.GetByUserID(user.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if record != nil && record.IsEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := encryptSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Save(models.DB, user.ID, encrypted); err != nil {
+		return nil, err
+	}
+
+	issuer := config.GlobalConfig.TwoFactor.Issuer
+	return &TwoFactorSetup{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(issuer, user.Username, secret),
+	}, nil
+}
+
+// validateTOTP 校验动态验证码，不早于上次使用的时间步，保证同一验证码只能使用一次
+func (s *TwoFactorService) validateTOTP(record *models.UserTOTP, code string) (int64, error) {
+	secret, err := decryptSecret(record.Secret)
+	if err != nil {
+		return 0, err
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), config.GlobalConfig.TwoFactor.Skew)
+	if !ok || step <= record.LastUsedStep {
+		return 0, ErrTwoFactorCodeInvalid
+	}
+	return step, nil
+}
+
+// Enable 校验动态验证码并启用双因素认证，返回恢复码
+func (s *TwoFactorService) Enable(userID uint64, code string) ([]string, error) {
+	var recoveryCodes []string
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		record, err := s.repo.GetByUserIDForUpdate(tx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTwoFactorNotSetup
+			}
+			return err
+		}
+		if record.IsEnabled() {
+			return ErrTwoFactorAlreadyEnabled
+		}
+
+		step, err := s.validateTOTP(record, code)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Enable(tx, record.ID, step); err != nil {
+			return err
+		}
+
+		codes, hashes, err := generateRecoveryCodes()
+		if err != nil {
+			return err
+		}
+		recoveryCodes = codes
+		return s.repo.ReplaceRecoveryCodes(tx, userID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// verifyInTx 在事务中校验动态验证码或恢复码
+func (s *TwoFactorService) verifyInTx(tx *gorm.DB, userID uint64, code string, allowRecovery bool) error {
+	record, err := s.repo.GetByUserIDForUpdate(tx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTwoFactorNotEnabled
+		}
+		return err
+	}
+	if !record.IsEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if _, err := strconv.Atoi(code); err == nil && len(code) == totp.Digits {
+		step, err := s.validateTOTP(record, code)
+		if err != nil {
+			return err
+		}
+		return s.repo.UpdateLastUsedStep(tx, record.ID, step)
+	}
+
+	if !allowRecovery {
+		return ErrTwoFactorCodeInvalid
+	}
+	used, err := s.repo.UseRecoveryCode(tx, userID, hashRefreshToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrTwoFactorCodeInvalid
+	}
+	return nil
+}
+
+// Verify 校验动态验证码或恢复码
+func (s *TwoFactorService) Verify(userID uint64, code string) error {
+	return models.DB.Transaction(func(tx *gorm.DB) error {
+		return s.verifyInTx(tx, userID, code, true)
+	})
+}
+
+// Disable 关闭双因素认证，管理员不允许关闭
+func (s *TwoFactorService) Disable(user *models.User, code string) error {
+	if s.authService.UserRole(user) == "admin" {
+		return ErrTwoFactorMandatory
+	}
+	return models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.verifyInTx(tx, user.ID, code, true); err != nil {
+			return err
+		}
+		return s.repo.Delete(tx, user.ID)
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，需要动态验证码
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint64, code string) ([]string, error) {
+	var recoveryCodes []string
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.verifyInTx(tx, userID, code, false); err != nil {
+			return err
+		}
+		codes, hashes, err := generateRecoveryCodes()
+		if err != nil {
+			return err
+		}
+		recoveryCodes = codes
+		return s.repo.ReplaceRecoveryCodes(tx, userID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// CreateChallenge 密码校验通过后创建登录第二步凭证
+func (s *TwoFactorService) CreateChallenge(ctx context.Context, userID uint64, device string) (string, error) {
+	token, err := utils.RandomHex(32)
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf(utils.TwoFactorChallengeKey, hashRefreshToken(token))
+	if err := utils.HSet(ctx, key, "user_id", userID); err != nil {
+		return "", err
+	}
+	if err := utils.HSet(ctx, key, "device", device); err != nil {
+		return "", err
+	}
+	expire := time.Duration(config.GlobalConfig.TwoFactor.ChallengeMinutes) * time.Minute
+	if err := utils.Expire(ctx, key, expire); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// getChallenge 读取登录第二步凭证
+func (s *TwoFactorService) getChallenge(ctx context.Context, token string) (string, uint64, string, error) {
+	key := fmt.Sprintf(utils.TwoFactorChallengeKey, hashRefreshToken(token))
+	values, err := utils.HGetAll(ctx, key)
+	if err != nil {
+		return "", 0, "", err
+	}
+	userID, err := strconv.ParseUint(values["user_id"], 10, 64)
+	if err != nil || userID == 0 {
+		return "", 0, "", ErrTwoFactorChallengeInvalid
+	}
+	return key, userID, values["device"], nil
+}
+
+// ChallengeSetup 强制启用的账号在登录过程中获取绑定信息
+func (s *TwoFactorService) ChallengeSetup(ctx context.Context, token string) (*TwoFactorSetup, error) {
+	_, userID, _, err := s.getChallenge(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{}
+	if err := models.DB.First(user, userID).Error; err != nil {
+		return nil, err
+	}
+	return s.BeginSetup(user)
+}
+
+// VerifyChallenge 完成登录第二步，未绑定的账号在此完成绑定
+func (s *TwoFactorService) VerifyChallenge(ctx context.Context, token string, code string) (*TwoFactorLogin, error) {
+	key, userID, device, err := s.getChallenge(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	// 挑战已过期时不累加，避免重新创建没有过期时间的键
+	attempts, ok, err := utils.HIncrByIfExists(ctx, key, "attempts", 1)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+	if maxAttempts := config.GlobalConfig.TwoFactor.MaxAttempts; maxAttempts > 0 && attempts > int64(maxAttempts) {
+		_ = utils.Del(ctx, key)
+		return nil, ErrTwoFactorTooManyAttempts
+	}
+
+	result := &TwoFactorLogin{UserID: userID, Device: device}
+	err = s.Verify(userID, code)
+	if errors.Is(err, ErrTwoFactorNotEnabled) {
+		result.RecoveryCodes, err = s.Enable(userID, code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_ = utils.Del(ctx, key)
+	return result, nil
+}
+
+// IsTwoFactorError 判断是否为双因素认证业务错误（可直接返回给用户）
+func IsTwoFactorError(err error) bool {
+	return errors.Is(err, ErrTwoFactorNotSetup) ||
+		errors.Is(err, ErrTwoFactorNotEnabled) ||
+		errors.Is(err, ErrTwoFactorAlreadyEnabled) ||
+		errors.Is(err, ErrTwoFactorMandatory) ||
+		errors.Is(err, ErrTwoFactorCodeInvalid) ||
+		errors.Is(err, ErrTwoFactorChallengeInvalid) ||
+		errors.Is(err, ErrTwoFactorTooManyAttempts)
+}
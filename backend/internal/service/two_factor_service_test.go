@@ -0,0 +1,82 @@
+package service
+
+import (
+	"encoding/base64"
+	"online-mall/internal/config"
+	"testing"
+)
+
+// useEncryptKey 测试期间使用指定的TOTP密钥加密密钥
+func useEncryptKey(t *testing.T, key string) {
+	t.Helper()
+	previous := config.GlobalConfig
+	config.GlobalConfig = &config.Config{TwoFactor: config.TwoFactorConfig{EncryptKey: key}}
+	t.Cleanup(func() { config.GlobalConfig = previous })
+}
+
+func TestEncryptSecretRoundTrip(t *testing.T) {
+	useEncryptKey(t, "test-encrypt-key")
+
+	for _, secret := range []string{"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "JBSWY3DPEHPK3PXP", ""} {
+		encrypted, err := encryptSecret(secret)
+		if err != nil {
+			t.Fatalf("encryptSecret(%q) error: %v", secret, err)
+		}
+		if secret != "" && encrypted == secret {
+			t.Errorf("encryptSecret(%q) returned plaintext", secret)
+		}
+		decrypted, err := decryptSecret(encrypted)
+		if err != nil {
+			t.Fatalf("decryptSecret error: %v", err)
+		}
+		if decrypted != secret {
+			t.Errorf("round trip = %q, want %q", decrypted, secret)
+		}
+	}
+}
+
+func TestEncryptSecretUsesRandomNonce(t *testing.T) {
+	useEncryptKey(t, "test-encrypt-key")
+
+	first, err := encryptSecret("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("encryptSecret error: %v", err)
+	}
+	second, err := encryptSecret("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("encryptSecret error: %v", err)
+	}
+	if first == second {
+		t.Error("encrypting the same secret twice produced identical ciphertext")
+	}
+}
+
+func TestDecryptSecretRejectsInvalidInput(t *testing.T) {
+	useEncryptKey(t, "test-encrypt-key")
+	encrypted, err := encryptSecret("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("encryptSecret error: %v", err)
+	}
+	data, _ := base64.StdEncoding.DecodeString(encrypted)
+	data[len(data)-1] ^= 0x01
+	tampered := base64.StdEncoding.EncodeToString(data)
+
+	tests := []struct {
+		name      string
+		key       string
+		encrypted string
+	}{
+		{"tampered ciphertext", "test-encrypt-key", tampered},
+		{"wrong key", "other-encrypt-key", encrypted},
+		{"not base64", "test-encrypt-key", "%%%"},
+		{"shorter than nonce", "test-encrypt-key", base64.StdEncoding.EncodeToString([]byte("short"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useEncryptKey(t, tt.key)
+			if _, err := decryptSecret(tt.encrypted); err == nil {
+				t.Error("decryptSecret succeeded, want error")
+			}
+		})
+	}
+}
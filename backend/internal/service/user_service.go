@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"online-mall/internal/models"
+	"online-mall/internal/repository"
+	"online-mall/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUserNotFound 用户不存在
+	ErrUserNotFound = errors.New("用户不存在")
+
+	// ErrUserSelfOperation 不能对自己执行该操作
+	ErrUserSelfOperation = errors.New("不能禁用或删除自己的账号")
+
+	// ErrPhoneExists 手机号已被使用
+	ErrPhoneExists = errors.New("手机号已被其他用户使用")
+
+	// ErrEmailExists 邮箱已被使用
+	ErrEmailExists = errors.New("邮箱已被其他用户使用")
+)
+
+// AdminUserView 后台用户信息（含角色和订单统计）
+type AdminUserView struct {
+	*models.User
+	Roles      []string `json:"roles"`
+	OrderCount int64    `json:"order_count"` // 订单数（不含已取消）
+	TotalSpend float64  `json:"total_spend"` // 累计实付金额
+}
+
+// AdminUserUpdate 后台修改用户资料参数，为nil的字段不修改
+type AdminUserUpdate struct {
+	Nickname *string
+	Avatar   *string
+	Phone    *string
+	Email    *string
+	Status   *int
+}
+
+// UserService 用户管理业务逻辑层
+type UserService struct {
+	userRepo      *repository.UserRepository
+	roleRepo      *repository.RoleRepository
+	tokenRepo     *repository.TokenRepository
+	twoFactorRepo *repository.TwoFactorRepository
+	authService   *AuthService
+	rbacService   *RBACService
+}
+
+// NewUserService 创建用户Service实例
+func NewUserService() *UserService {
+	return &UserService{
+		userRepo:      repository.NewUserRepository(),
+		roleRepo:      repository.NewRoleRepository(),
+		tokenRepo:     repository.NewTokenRepository(),
+		twoFactorRepo: repository.NewTwoFactorRepository(),
+		authService:   NewAuthService(),
+		rbacService:   NewRBACService(),
+	}
+}
+
+// getUser 获取用户，不存在时返回ErrUserNotFound
+func (s *UserService) getUser(id uint64) (*models.User, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// buildViews 为用户列表补充角色和订单统计
+func (s *UserService) buildViews(users []*models.User) ([]*AdminUserView, error) {
+	userIDs := make([]uint64, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+
+	stats, err := s.userRepo.GetOrderStats(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := s.roleRepo.GetRoleNamesByUsers(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	roleNames := make(map[uint64][]string, len(users))
+	for _, role := range roles {
+		roleNames[role.UserID] = append(roleNames[role.UserID], role.Name)
+	}
+
+	views := make([]*AdminUserView, 0, len(users))
+	for _, user := range users {
+		names := roleNames[user.ID]
+		if names == nil {
+			names = []string{}
+		}
+		views = append(views, &AdminUserView{
+			User:       user,
+			Roles:      names,
+			OrderCount: stats[user.ID].OrderCount,
+			TotalSpend: stats[user.ID].TotalSpend,
+		})
+	}
+	return views, nil
+}
+
+// GetUsers 分页获取用户列表
+func (s *UserService) GetUsers(query *models.UserQuery) ([]*AdminUserView, int64, error) {
+	// 设置默认值
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 || query.PageSize > 100 {
+		query.PageSize = 10
+	}
+
+	users, total, err := s.userRepo.GetUsers(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	views, err := s.buildViews(users)
+	if err != nil {
+		return nil, 0, err
+	}
+	return views, total, nil
+}
+
+// GetUserDetail 获取用户详情
+func (s *UserService) GetUserDetail(id uint64) (*AdminUserView, error) {
+	user, err := s.getUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	views, err := s.buildViews([]*models.User{user})
+	if err != nil {
+		return nil, err
+	}
+	return views[0], nil
+}
+
+// isSuperAdmin 判断用户是否为超级管理员
+func (s *UserService) isSuperAdmin(userID uint64) (bool, error) {
+	roles, err := s.roleRepo.GetUserRoles(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, role := range roles {
+		if role.Name == models.RoleSuperAdmin {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ensureSuperAdminRemains 超级管理员被禁用或删除后，确认仍有可用的超级管理员
+func (s *UserService) ensureSuperAdminRemains(tx *gorm.DB) error {
+	count, err := s.roleRepo.CountUsersByRoleName(tx, models.RoleSuperAdmin)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrLastSuperAdmin
+	}
+	return nil
+}
+
+// UpdateUser 修改用户资料和状态，operatorID为操作的管理员
+func (s *UserService) UpdateUser(ctx context.Context, operatorID uint64, id uint64, input *AdminUserUpdate) (*AdminUserView, error) {
+	user, err := s.getUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if input.Nickname != nil {
+		updates["nickname"] = *input.Nickname
+	}
+	if input.Avatar != nil {
+		updates["avatar"] = *input.Avatar
+	}
+	if input.Phone != nil && *input.Phone != user.Phone {
+		if *input.Phone == "" {
+			updates["phone"] = nil
+		} else {
+			exists, err := s.userRepo.ExistsByField("phone", *input.Phone, user.ID)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				return nil, ErrPhoneExists
+			}
+			updates["phone"] = *input.Phone
+		}
+		// 管理员修改的号码未经用户验证
+		updates["phone_verified_at"] = nil
+	}
+	if input.Email != nil && *input.Email != user.Email {
+		if *input.Email == "" {
+			updates["email"] = nil
+		} else {
+			exists, err := s.userRepo.ExistsByField("email", *input.Email, user.ID)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				return nil, ErrEmailExists
+			}
+			updates["email"] = *input.Email
+		}
+		updates["email_verified_at"] = nil
+	}
+
+	disabling := input.Status != nil && *input.Status == 0 && user.Status != 0
+	if input.Status != nil {
+		if disabling && user.ID == operatorID {
+			return nil, ErrUserSelfOperation
+		}
+		updates["status"] = *input.Status
+	}
+
+	superAdmin := false
+	if disabling {
+		if superAdmin, err = s.isSuperAdmin(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(updates) > 0 {
+		err = models.DB.Transaction(func(tx *gorm.DB) error {
+			if err := s.userRepo.Update(tx, user.ID, updates); err != nil {
+				return err
+			}
+			if superAdmin {
+				return s.ensureSuperAdminRemains(tx)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 禁用账号时吊销其所有token
+	if disabling {
+		if err := s.authService.RevokeAllTokens(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetUserDetail(user.ID)
+}
+
+// UpdateStatus 启用/禁用用户
+func (s *UserService) UpdateStatus(ctx context.Context, operatorID uint64, id uint64, status int) (*AdminUserView, error) {
+	return s.UpdateUser(ctx, operatorID, id, &AdminUserUpdate{Status: &status})
+}
+
+// DeleteUser 注销用户：清除个人信息后软删除，订单等历史数据保留
+func (s *UserService) DeleteUser(ctx context.Context, operatorID uint64, id uint64) error {
+	if id == operatorID {
+		return ErrUserSelfOperation
+	}
+	user, err := s.getUser(id)
+	if err != nil {
+		return err
+	}
+	superAdmin, err := s.isSuperAdmin(user.ID)
+	if err != nil {
+		return err
+	}
+
+	// 原密码哈希替换为随机密码，账号无法再登录
+	randomPassword, err := utils.RandomHex(32)
+	if err != nil {
+		return err
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.userRepo.Anonymize(tx, user, string(passwordHash)); err != nil {
+			return err
+		}
+		if err := s.roleRepo.SetUserRoles(tx, user.ID, nil); err != nil {
+			return err
+		}
+		if err := s.twoFactorRepo.Delete(tx, user.ID); err != nil {
+			return err
+		}
+		if err := s.tokenRepo.InvalidatePasswordResets(tx, user.ID); err != nil {
+			return err
+		}
+		if superAdmin {
+			return s.ensureSuperAdminRemains(tx)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.rbacService.invalidateUsers(ctx, user.ID)
+	return s.authService.RevokeAllTokens(ctx, user.ID)
+}
+
+// IsUserManageError 判断是否为用户管理业务错误（可直接返回给用户）
+func IsUserManageError(err error) bool {
+	return errors.Is(err, ErrUserNotFound) ||
+		errors.Is(err, ErrUserSelfOperation) ||
+		errors.Is(err, ErrPhoneExists) ||
+		errors.Is(err, ErrEmailExists) ||
+		errors.Is(err, ErrLastSuperAdmin)
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"online-mall/internal/config"
+	"online-mall/internal/pkg/notify"
+	"online-mall/internal/utils"
+	"strings"
+	"time"
+)
+
+// 验证码使用场景
+const (
+	VerifySceneLogin     = "login"      // 验证码登录
+	VerifySceneRegister  = "register"   // 注册
+	VerifySceneBindPhone = "bind_phone" // 绑定手机号
+	VerifySceneBindEmail = "bind_email" // 绑定邮箱
+	VerifySceneDeletion  = "deletion"   // 注销账号
+)
+
+var (
+	// ErrVerifyCodeCooldown 发送过于频繁
+	ErrVerifyCodeCooldown = errors.New("验证码发送过于频繁，请稍后再试")
+
+	// ErrVerifyCodeDailyLimit 超过每日发送上限
+	ErrVerifyCodeDailyLimit = errors.New("今日验证码发送次数已达上限")
+
+	// ErrVerifyCodeInvalid 验证码错误
+	ErrVerifyCodeInvalid = errors.New("验证码错误或已过期")
+
+	// ErrVerifyCodeTooManyAttempts 错误次数过多
+	ErrVerifyCodeTooManyAttempts = errors.New("验证码错误次数过多，请重新获取")
+
+	// ErrVerifySceneInvalid 不支持的场景
+	ErrVerifySceneInvalid = errors.New("不支持的验证码场景")
+)
+
+// sceneNames 场景名称
+var sceneNames = map[string]string{
+	VerifySceneLogin:     "登录",
+	VerifySceneRegister:  "注册",
+	VerifySceneBindPhone: "绑定手机号",
+	VerifySceneBindEmail: "绑定邮箱",
+	VerifySceneDeletion:  "注销账号",
+}
+
+// VerifyCodeService 验证码业务逻辑层
+type VerifyCodeService struct{}
+
+// NewVerifyCodeService 创建验证码Service实例
+func NewVerifyCodeService() *VerifyCodeService {
+	return &VerifyCodeService{}
+}
+
+// normalizeTarget 规范化手机号或邮箱
+func normalizeTarget(target string) string {
+	return strings.ToLower(strings.TrimSpace(target))
+}
+
+// generateCode 生成数字验证码
+func generateCode(length int) (string, error) {
+	if length <= 0 {
+		length = 6
+	}
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(length)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", length, n), nil
+}
+
+// Send 生成并发送验证码
+func (s *VerifyCodeService) Send(ctx context.Context, scene string, channel string, target string) error {
+	cfg := config.GlobalConfig.Verify
+	sceneName, ok := sceneNames[scene]
+	if !ok {
+		return ErrVerifySceneInvalid
+	}
+	target = normalizeTarget(target)
+
+	// 重发冷却
+	cooldownKey := fmt.Sprintf(utils.VerifyCooldownKey, scene, target)
+	acquired, err := utils.SetNx(ctx, cooldownKey, 1, time.Duration(cfg.ResendSeconds)*time.Second)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return ErrVerifyCodeCooldown
+	}
+
+	// 每日上限
+	dailyKey := fmt.Sprintf(utils.VerifyDailyKey, target, time.Now().Format("20060102"))
+	count, err := utils.Incr(ctx, dailyKey)
+	if err != nil {
+		return err
+	}
+	if count == 1 {
+		_ = utils.Expire(ctx, dailyKey, 24*time.Hour)
+	}
+	if cfg.DailyLimit > 0 && count > int64(cfg.DailyLimit) {
+		return ErrVerifyCodeDailyLimit
+	}
+
+	code, err := generateCode(cfg.CodeLength)
+	if err != nil {
+		return err
+	}
+
+	// 保存验证码，重新发送会覆盖旧验证码并重置校验次数
+	codeKey := fmt.Sprintf(utils.VerifyCodeKey, scene, target)
+	if err := utils.Del(ctx, codeKey); err != nil {
+		return err
+	}
+	if err := utils.HSet(ctx, codeKey, "code", code); err != nil {
+		return err
+	}
+	if err := utils.Expire(ctx, codeKey, time.Duration(cfg.ExpireMinutes)*time.Minute); err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf("您的%s验证码为%s，%d分钟内有效，请勿泄露给他人。", sceneName, code, cfg.ExpireMinutes)
+	err = notify.Send(ctx, &notify.Message{
+		Channel: channel,
+		To:      target,
+		Subject: "在线商城" + sceneName + "验证码",
+		Content: content,
+	})
+	if err != nil {
+		// 发送失败时允许立即重试
+		_ = utils.Del(ctx, codeKey, cooldownKey)
+		return err
+	}
+
+	return nil
+}
+
+// Verify 校验验证码，校验成功后验证码立即失效
+func (s *VerifyCodeService) Verify(ctx context.Context, scene string, target string, code string) error {
+	if err := s.Check(ctx, scene, target, code); err != nil {
+		return err
+	}
+	return s.Consume(ctx, scene, target)
+}
+
+// Check 校验验证码并计入错误次数，但不使验证码失效；需同时校验多个验证码时全部通过后再调用Consume
+func (s *VerifyCodeService) Check(ctx context.Context, scene string, target string, code string) error {
+	target = normalizeTarget(target)
+	codeKey := fmt.Sprintf(utils.VerifyCodeKey, scene, target)
+	if code == "" {
+		return ErrVerifyCodeInvalid
+	}
+
+	// 先原子地累加校验次数，验证码已过期时不会重新创建键
+	attempts, ok, err := utils.HIncrByIfExists(ctx, codeKey, "attempts", 1)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVerifyCodeInvalid
+	}
+	if maxAttempts := config.GlobalConfig.Verify.MaxAttempts; maxAttempts > 0 && attempts > int64(maxAttempts) {
+		_ = utils.Del(ctx, codeKey)
+		return ErrVerifyCodeTooManyAttempts
+	}
+
+	values, err := utils.HGetAll(ctx, codeKey)
+	if err != nil {
+		return err
+	}
+	expected, ok := values["code"]
+	if !ok || subtle.ConstantTimeCompare([]byte(expected), []byte(code)) != 1 {
+		return ErrVerifyCodeInvalid
+	}
+	return nil
+}
+
+// Consume 使验证码失效
+func (s *VerifyCodeService) Consume(ctx context.Context, scene string, target string) error {
+	return utils.Del(ctx, fmt.Sprintf(utils.VerifyCodeKey, scene, normalizeTarget(target)))
+}
+
+// IsVerifyCodeError 判断是否为验证码业务错误（可直接返回给用户）
+func IsVerifyCodeError(err error) bool {
+	return errors.Is(err, ErrVerifyCodeCooldown) ||
+		errors.Is(err, ErrVerifyCodeDailyLimit) ||
+		errors.Is(err, ErrVerifyCodeInvalid) ||
+		errors.Is(err, ErrVerifyCodeTooManyAttempts) ||
+		errors.Is(err, ErrVerifySceneInvalid)
+}
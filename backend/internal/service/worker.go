@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"log"
+	"online-mall/internal/utils"
+	"time"
+)
+
+// runPeriodic 按固定间隔执行后台任务，多实例部署时通过Redis锁保证每轮只有一个实例执行
+func runPeriodic(ctx context.Context, lockKey string, interval time.Duration, task func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// 锁在下一轮开始前过期，本实例和其他实例都不会跳过下一轮
+		acquired, err := utils.SetNx(ctx, lockKey, 1, interval/2)
+		if err != nil {
+			log.Printf("Failed to acquire worker lock %s: %v", lockKey, err)
+		} else if acquired {
+			task(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
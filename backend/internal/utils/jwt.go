@@ -1,35 +1,76 @@
 package utils
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
 	"online-mall/internal/config"
 )
 
-// JWTClaims JWT声明结构
+// ErrTokenRevoked token已被吊销
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// JWTClaims JWT声明结构（token唯一标识jti保存在RegisteredClaims.ID中）
 type JWTClaims struct {
 	UserID   uint64 `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	FamilyID string `json:"fid,omitempty"` // 所属刷新令牌族ID
 	jwt.RegisteredClaims
 }
 
+// generateTokenID 生成token唯一标识
+func generateTokenID() (string, error) {
+	return RandomHex(16)
+}
+
+// RandomHex 生成指定字节数的随机十六进制字符串
+func RandomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// AccessTokenTTL 访问令牌有效期
+func AccessTokenTTL() time.Duration {
+	return time.Duration(config.GlobalConfig.JWT.AccessExpireMinutes) * time.Minute
+}
+
 // GenerateToken 生成JWT token
 func GenerateToken(userID uint64, username string, role string) (string, error) {
+	return GenerateAccessToken(userID, username, role, "")
+}
+
+// GenerateAccessToken 生成绑定刷新令牌族的访问令牌
+func GenerateAccessToken(userID uint64, username string, role string, familyID string) (string, error) {
 	cfg := config.GlobalConfig.JWT
 
 	// 设置过期时间
-	expireTime := time.Now().Add(time.Duration(cfg.ExpireHours) * time.Hour)
+	expireTime := time.Now().Add(AccessTokenTTL())
+
+	// 生成jti
+	tokenID, err := generateTokenID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token id: %v", err)
+	}
 
 	// 创建声明
 	claims := JWTClaims{
 		UserID:   userID,
 		Username: username,
 		Role:     role,
+		FamilyID: familyID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(expireTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    cfg.Issuer,
@@ -74,22 +115,95 @@ func ParseToken(tokenString string) (*JWTClaims, error) {
 	return nil, errors.New("invalid token")
 }
 
-// RefreshToken 刷新JWT token
-func RefreshToken(tokenString string) (string, error) {
-	// 解析token获取声明
+// ValidateToken 验证JWT token（包括签名、有效期及是否已被吊销）
+func ValidateToken(tokenString string) (*JWTClaims, error) {
 	claims, err := ParseToken(tokenString)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	revoked, err := IsTokenRevoked(context.Background(), claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check token status: %v", err)
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
 	}
 
-	// 生成新token
-	return GenerateToken(claims.UserID, claims.Username, claims.Role)
+	return claims, nil
 }
 
-// ValidateToken 验证JWT token
-func ValidateToken(tokenString string) (*JWTClaims, error) {
-	// 直接使用ParseToken进行验证
-	return ParseToken(tokenString)
+// RevokeToken 吊销单个token，黑名单有效期与token剩余有效期一致
+func RevokeToken(ctx context.Context, claims *JWTClaims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return errors.New("token without jti or exp cannot be revoked")
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return Set(ctx, fmt.Sprintf(TokenBlacklistKey, claims.ID), 1, ttl)
+}
+
+// RevokeUserTokens 吊销用户当前已签发的所有token（修改密码、禁用账号时使用）
+func RevokeUserTokens(ctx context.Context, userID uint64) error {
+	ttl := AccessTokenTTL()
+	return Set(ctx, fmt.Sprintf(UserTokenRevokedKey, userID), time.Now().Unix(), ttl)
+}
+
+// RevokeSessionTokens 吊销某个登录会话签发的所有访问令牌
+func RevokeSessionTokens(ctx context.Context, familyID string) error {
+	if familyID == "" {
+		return nil
+	}
+	return Set(ctx, fmt.Sprintf(SessionRevokedKey, familyID), 1, AccessTokenTTL())
+}
+
+// IsTokenRevoked 检查token是否已被吊销
+func IsTokenRevoked(ctx context.Context, claims *JWTClaims) (bool, error) {
+	// 单个token黑名单
+	if claims.ID != "" {
+		exists, err := Exists(ctx, fmt.Sprintf(TokenBlacklistKey, claims.ID))
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			return true, nil
+		}
+	}
+
+	// 会话级吊销
+	if claims.FamilyID != "" {
+		exists, err := Exists(ctx, fmt.Sprintf(SessionRevokedKey, claims.FamilyID))
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			return true, nil
+		}
+	}
+
+	// 用户级吊销：签发时间不晚于吊销时间的token全部失效。
+	// 签发时间只精确到秒，吊销同一秒内签发的token也按已吊销处理，宁可让用户重新登录也不放过吊销前的token
+	value, err := Get(ctx, fmt.Sprintf(UserTokenRevokedKey, claims.UserID))
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	revokedAt, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false, err
+	}
+	if claims.IssuedAt == nil || claims.IssuedAt.Unix() <= revokedAt {
+		return true, nil
+	}
+
+	return false, nil
 }
 
 // GetUserIDFromToken 从token中获取用户ID
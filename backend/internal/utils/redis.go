@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"online-mall/internal/config"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -56,10 +57,42 @@ func CloseRedis() error {
 // RedisKey Redis key常量
 const (
 	// 用户相关
-	UserInfoKey    = "user:info:%d"    // 用户信息
-	UserTokenKey   = "user:token:%s"   // 用户token
-	UserCartKey    = "user:cart:%d"    // 用户购物车
-	UserAddressKey = "user:address:%d" // 用户地址列表
+	UserInfoKey        = "user:info:%d"        // 用户信息
+	UserTokenKey       = "user:token:%s"       // 用户token
+	UserCartKey        = "user:cart:%d"        // 用户购物车
+	UserAddressKey     = "user:address:%d"     // 用户地址列表
+	UserPermissionsKey = "user:permissions:%d" // 用户权限编码
+	UserBrowseKey      = "user:browse:%d"      // 用户最近浏览（有序集合，成员为商品ID，分数为浏览时间）
+
+	// 账号相关
+	AccountWorkerLockKey = "account:worker:lock" // 注销和导出清理任务锁（多实例只执行一个）
+
+	// 积分相关
+	PointsWorkerLockKey = "points:worker:lock" // 积分过期任务锁
+
+	// 收藏相关
+	FavoriteWorkerLockKey = "favorite:worker:lock" // 降价/到货检查任务锁
+
+	// 到货提醒相关
+	RestockWorkerLockKey = "restock:worker:lock" // 到货提醒补发任务锁
+	RestockFanoutLockKey = "restock:fanout:%d"   // SKU到货提醒发送锁（同一SKU同时只有一个发送任务）
+	RestockUserRateKey   = "restock:rate:%d:%s"  // 用户每小时到货提醒次数（用户ID:小时）
+
+	// 签到相关
+	CheckinCalendarKey = "checkin:%d:%s" // 签到日历位图（用户ID:年月），偏移量为日期-1
+
+	// 认证相关
+	TokenBlacklistKey     = "token:blacklist:%s"    // 已吊销的token（jti）
+	UserTokenRevokedKey   = "user:token:revoked:%d" // 用户token统一吊销时间
+	SessionRevokedKey     = "session:revoked:%s"    // 已退出的登录会话（令牌族ID）
+	TwoFactorChallengeKey = "2fa:challenge:%s"      // 登录第二步凭证（凭证哈希）
+
+	// 登录防护相关
+	LoginFailAccountKey  = "login:fail:account:%s"  // 账号登录失败次数
+	LoginFailIPKey       = "login:fail:ip:%s"       // IP登录失败次数
+	LoginLockAccountKey  = "login:lock:account:%s"  // 账号锁定
+	LoginLockIPKey       = "login:lock:ip:%s"       // IP锁定
+	LoginDelayAccountKey = "login:delay:account:%s" // 账号下次允许尝试前的等待
 
 	// 商品相关
 	ProductInfoKey  = "product:info:%d" // 商品信息
@@ -67,14 +100,49 @@ const (
 	HotProductsKey  = "hot:products"    // 热门商品
 	NewProductsKey  = "new:products"    // 新品商品
 
+	// 浏览统计相关
+	ProductViewsKey     = "product:views:%s"   // 商品每日浏览量（哈希，日期 -> 商品ID:浏览次数）
+	BrowseWorkerLockKey = "browse:worker:lock" // 浏览量落库任务锁
+
+	// 推荐相关
+	RecommendWorkerLockKey = "recommend:worker:lock"     // 关联推荐计算任务锁
+	CoViewPairsKey         = "recommend:coview:pairs:%s" // 每日共同浏览次数（哈希，日期 -> "商品A:商品B":次数，A<B）
+	CoViewItemsKey         = "recommend:coview:items:%s" // 每日参与共同浏览统计的商品浏览次数（哈希，日期 -> 商品ID:次数）
+
+	// 首页相关
+	HomeSectionKey   = "home:section:%s"   // 首页公共区块缓存（区块名）
+	HomeRecommendKey = "home:recommend:%d" // 首页个性化推荐缓存（用户ID）
+	ContentSlotsKey  = "content:slots:%s"  // 当前生效的内容位缓存（位置）
+
+	// 秒杀相关
+	FlashSaleInfoKey       = "flashsale:info:%d"     // 秒杀活动信息缓存
+	FlashSaleListKey       = "flashsale:list"        // 进行中和即将开始的秒杀活动缓存
+	FlashSaleStockKey      = "flashsale:stock:%d"    // 秒杀剩余库存
+	FlashSaleUsersKey      = "flashsale:users:%d"    // 用户已抢购数量（哈希，用户ID -> 数量）
+	FlashSaleQueueKey      = "flashsale:orders"      // 秒杀下单队列（Stream，消费组确认后删除）
+	FlashSaleResultKey     = "flashsale:result:%s"   // 抢购请求处理结果（请求ID）
+	FlashSaleWorkerLockKey = "flashsale:worker:lock" // 秒杀库存预热、超时取消和结算任务锁
+
+	// 拼团相关
+	GroupBuyWorkerLockKey = "groupbuy:worker:lock" // 拼团超时取消、成团检查和退款任务锁
+
 	// 订单相关
-	OrderKey      = "order:%d"       // 订单信息
-	UserOrdersKey = "user:orders:%d" // 用户订单列表
+	OrderKey           = "order:%d"          // 订单信息
+	UserOrdersKey      = "user:orders:%d"    // 用户订单列表
+	OrderWorkerLockKey = "order:worker:lock" // 自动确认收货任务锁
 
 	// 优惠券相关
 	CouponKey      = "coupon:%d"       // 优惠券
 	UserCouponsKey = "user:coupons:%d" // 用户优惠券列表
 
+	// 验证码相关
+	VerifyCodeKey     = "verify:code:%s:%s"     // 验证码（场景:号码）
+	VerifyCooldownKey = "verify:cooldown:%s:%s" // 重发冷却（场景:号码）
+	VerifyDailyKey    = "verify:daily:%s:%s"    // 每日发送次数（号码:日期）
+
+	// 限流相关
+	RateLimitKey = "ratelimit:%s:%s" // 接口限流计数（路由:IP）
+
 	// 缓存通用
 	CachePrefix = "online-mall:" // 缓存前缀
 )
@@ -120,6 +188,31 @@ func HGetAll(ctx context.Context, key string) (map[string]string, error) {
 	return RedisClient.HGetAll(ctx, key).Result()
 }
 
+// HIncrBy 哈希字段增加指定数值
+func HIncrBy(ctx context.Context, key string, field string, value int64) (int64, error) {
+	return RedisClient.HIncrBy(ctx, key, field, value).Result()
+}
+
+// hIncrByIfExistsScript 键存在时才增加哈希字段，避免键过期后被重新创建为没有过期时间的键
+var hIncrByIfExistsScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+return redis.call('HINCRBY', KEYS[1], ARGV[1], ARGV[2])
+`)
+
+// HIncrByIfExists 哈希键存在时增加字段数值，键不存在时返回false
+func HIncrByIfExists(ctx context.Context, key string, field string, value int64) (int64, bool, error) {
+	result, err := hIncrByIfExistsScript.Run(ctx, RedisClient, []string{key}, field, value).Int64()
+	if err != nil {
+		return 0, false, err
+	}
+	if result == -1 {
+		return 0, false, nil
+	}
+	return result, true, nil
+}
+
 // HDel 删除哈希字段
 func HDel(ctx context.Context, key string, fields ...string) error {
 	return RedisClient.HDel(ctx, key, fields...).Err()
@@ -175,7 +268,113 @@ func SetNx(ctx context.Context, key string, value interface{}, expiration time.D
 	return RedisClient.SetNX(ctx, key, value, expiration).Result()
 }
 
+// TTL 获取剩余过期时间
+func TTL(ctx context.Context, key string) (time.Duration, error) {
+	return RedisClient.TTL(ctx, key).Result()
+}
+
+// SetBit 设置位图中指定偏移量的值
+func SetBit(ctx context.Context, key string, offset int64, value int) error {
+	return RedisClient.SetBit(ctx, key, offset, value).Err()
+}
+
 // GetSet 获取并设置
 func GetSet(ctx context.Context, key string, value interface{}) (string, error) {
 	return RedisClient.GetSet(ctx, key, value).Result()
 }
+
+// ZAddCapped 向有序集合添加成员（已存在时更新分数），只保留分数最高的size个成员并刷新过期时间
+func ZAddCapped(ctx context.Context, key string, member interface{}, score float64, size int64, expiration time.Duration) error {
+	_, err := RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, redis.Z{Score: score, Member: member})
+		pipe.ZRemRangeByRank(ctx, key, 0, -size-1)
+		pipe.Expire(ctx, key, expiration)
+		return nil
+	})
+	return err
+}
+
+// ZRevRangeWithScores 按分数从高到低获取有序集合成员及分数
+func ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return RedisClient.ZRevRangeWithScores(ctx, key, start, stop).Result()
+}
+
+// ZRem 删除有序集合成员
+func ZRem(ctx context.Context, key string, members ...interface{}) error {
+	return RedisClient.ZRem(ctx, key, members...).Err()
+}
+
+// ZCard 获取有序集合成员数
+func ZCard(ctx context.Context, key string) (int64, error) {
+	return RedisClient.ZCard(ctx, key).Result()
+}
+
+// RunScript 执行Lua脚本（优先使用EVALSHA）
+func RunScript(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return script.Run(ctx, RedisClient, keys, args...).Result()
+}
+
+// XAdd 向Stream追加消息
+func XAdd(ctx context.Context, stream string, values map[string]interface{}) error {
+	return RedisClient.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: values}).Err()
+}
+
+// XGroupCreate 创建Stream消费组（Stream不存在时一并创建），已存在时忽略
+func XGroupCreate(ctx context.Context, stream string, group string) error {
+	err := RedisClient.XGroupCreateMkStream(ctx, stream, group, "0").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// XReadGroup 以消费组方式阻塞读取新消息，消息在确认前保留在待确认列表中；超时返回redis.Nil
+func XReadGroup(ctx context.Context, stream string, group string, consumer string, count int64, block time.Duration) ([]redis.XMessage, error) {
+	streams, err := RedisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(streams) == 0 {
+		return nil, redis.Nil
+	}
+	return streams[0].Messages, nil
+}
+
+// XAckDel 确认并删除已处理的Stream消息
+func XAckDel(ctx context.Context, stream string, group string, ids ...string) error {
+	pipe := RedisClient.TxPipeline()
+	pipe.XAck(ctx, stream, group, ids...)
+	pipe.XDel(ctx, stream, ids...)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// XAutoClaim 将消费组中空闲超过minIdle的待确认消息（消费者崩溃或重启遗留）转给consumer
+func XAutoClaim(ctx context.Context, stream string, group string, consumer string, minIdle time.Duration, count int64) ([]redis.XMessage, error) {
+	var claimed []redis.XMessage
+	start := "0-0"
+	for {
+		messages, next, err := RedisClient.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   stream,
+			Group:    group,
+			Consumer: consumer,
+			MinIdle:  minIdle,
+			Start:    start,
+			Count:    count,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, messages...)
+		if next == "0-0" || int64(len(claimed)) >= count {
+			return claimed, nil
+		}
+		start = next
+	}
+}
@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"online-mall/internal/config"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 私有文件分类
+const (
+	PrivateDirInvoice    = "invoices"    // 发票
+	PrivateDirAfterSales = "after-sales" // 售后凭证
+	PrivateDirExport     = "exports"     // 导出文件
+)
+
+// FileDownloadPath 私有文件下载接口路径
+const FileDownloadPath = "/api/files/download"
+
+var (
+	// ErrInvalidFilePath 非法文件路径
+	ErrInvalidFilePath = errors.New("invalid file path")
+
+	// ErrSignatureExpired 签名已过期
+	ErrSignatureExpired = errors.New("signature expired")
+
+	// ErrSignatureInvalid 签名无效
+	ErrSignatureInvalid = errors.New("invalid signature")
+)
+
+// SignedFile 签名文件参数
+type SignedFile struct {
+	Path    string // 私有目录下的相对路径
+	Expires int64  // 过期时间戳
+	UserID  uint64 // 绑定的用户ID，0表示不绑定
+	Sign    string // 签名
+}
+
+// cleanPrivatePath 规范化私有文件相对路径，禁止跳出私有目录
+func cleanPrivatePath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	cleaned := path.Clean("/" + name)
+	cleaned = strings.TrimPrefix(cleaned, "/")
+	if cleaned == "" || cleaned == "." || strings.HasPrefix(cleaned, "..") {
+		return "", ErrInvalidFilePath
+	}
+	return cleaned, nil
+}
+
+// privateFullPath 获取私有文件的绝对路径
+func privateFullPath(name string) (string, error) {
+	cleaned, err := cleanPrivatePath(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(config.GlobalConfig.Upload.PrivatePath, filepath.FromSlash(cleaned)), nil
+}
+
+// SavePrivateFile 保存私有文件，返回相对路径
+func SavePrivateFile(dir string, filename string, data []byte) (string, error) {
+	name, err := cleanPrivatePath(path.Join(dir, filename))
+	if err != nil {
+		return "", err
+	}
+
+	fullPath, err := privateFullPath(name)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0700); err != nil {
+		return "", fmt.Errorf("failed to create private dir: %v", err)
+	}
+
+	if err := os.WriteFile(fullPath, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write private file: %v", err)
+	}
+
+	return name, nil
+}
+
+// ReadPrivateFile 读取私有文件
+func ReadPrivateFile(name string) ([]byte, error) {
+	fullPath, err := privateFullPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(fullPath)
+}
+
+// DeletePrivateFile 删除私有文件
+func DeletePrivateFile(name string) error {
+	fullPath, err := privateFullPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// computeFileSign 计算文件签名
+func computeFileSign(name string, expires int64, userID uint64) string {
+	mac := hmac.New(sha256.New, []byte(config.GlobalConfig.Upload.SignSecret))
+	mac.Write([]byte(fmt.Sprintf("%s|%d|%d", name, expires, userID)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignFileURL 生成私有文件的签名下载链接
+// userID 为0时链接不绑定用户，expire 为0时使用默认有效期
+func SignFileURL(name string, userID uint64, expire time.Duration) (string, error) {
+	cleaned, err := cleanPrivatePath(name)
+	if err != nil {
+		return "", err
+	}
+
+	if expire <= 0 {
+		expire = time.Duration(config.GlobalConfig.Upload.SignExpireMinutes) * time.Minute
+	}
+	expires := time.Now().Add(expire).Unix()
+
+	query := url.Values{}
+	query.Set("path", cleaned)
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	if userID > 0 {
+		query.Set("uid", strconv.FormatUint(userID, 10))
+	}
+	query.Set("sign", computeFileSign(cleaned, expires, userID))
+
+	return FileDownloadPath + "?" + query.Encode(), nil
+}
+
+// VerifyFileSign 校验私有文件签名
+func VerifyFileSign(file *SignedFile) error {
+	cleaned, err := cleanPrivatePath(file.Path)
+	if err != nil {
+		return err
+	}
+
+	if time.Now().Unix() > file.Expires {
+		return ErrSignatureExpired
+	}
+
+	expected := computeFileSign(cleaned, file.Expires, file.UserID)
+	if !hmac.Equal([]byte(expected), []byte(file.Sign)) {
+		return ErrSignatureInvalid
+	}
+
+	file.Path = cleaned
+	return nil
+}